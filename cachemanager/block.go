@@ -0,0 +1,300 @@
+package cachemanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
+	"github.com/QuantumCoinProject/qc/ethdb"
+	"github.com/QuantumCoinProject/qc/log"
+	"strings"
+	"time"
+)
+
+var BlockDetailsKey = "block-details-%d"           //%d is block number
+var BlockTransactionsKey = "block-transactions-%d" //%d is block number
+var BlockNumberKey = "block-number-%s"             //%s is block hash
+
+var BlockNotFoundErr = errors.New("block not found")
+
+type BlockVoteType string
+
+// List of BlockVoteType
+const (
+	BLOCK_VOTE_TYPE_OK  BlockVoteType = "ok"
+	BLOCK_VOTE_TYPE_NIL BlockVoteType = "nil"
+)
+
+// BlockConsensusDetails is the consensus data of a block. It is only available for blocks indexed while
+// enableExtendedApis is true.
+type BlockConsensusDetails struct {
+	Proposer string `json:"proposer"`
+
+	Round uint8 `json:"round"`
+
+	VoteType BlockVoteType `json:"voteType"`
+
+	SlashedProposers []string `json:"slashedProposers,omitempty"`
+}
+
+type BlockCompact struct {
+	BlockNumber uint64 `json:"blockNumber"`
+
+	Hash string `json:"hash"`
+
+	ParentHash string `json:"parentHash"`
+
+	CreatedAt string `json:"createdAt"`
+
+	GasUsed string `json:"gasUsed"`
+
+	TransactionCount uint64 `json:"transactionCount"`
+
+	Consensus *BlockConsensusDetails `json:"consensus,omitempty"`
+}
+
+type BlockDetails struct {
+	BlockCompact
+
+	Transactions []string `json:"transactions"` //hashes of the transactions in the block
+}
+
+type GetBlockDetailsResponse struct {
+	Result BlockDetails `json:"result"`
+}
+
+type ListBlocksResponse struct {
+	PageCount uint64         `json:"pageCount"`
+	Items     []BlockCompact `json:"items"`
+}
+
+func getBlockDetailsKey(blockNumber uint64) []byte {
+	return []byte(fmt.Sprintf(BlockDetailsKey, blockNumber))
+}
+
+func getBlockTransactionsKey(blockNumber uint64) []byte {
+	return []byte(fmt.Sprintf(BlockTransactionsKey, blockNumber))
+}
+
+func getBlockNumberKey(blockHash common.Hash) []byte {
+	return []byte(fmt.Sprintf(BlockNumberKey, strings.ToLower(blockHash.Hex())))
+}
+
+// newBlockConsensusDetails returns the consensus details of a block, or nil if its consensus data was not fetched.
+func newBlockConsensusDetails(consensusData *proofofstake.ConsensusData) *BlockConsensusDetails {
+	if consensusData == nil || consensusData.Data == nil {
+		return nil
+	}
+	data := consensusData.Data
+	consensus := &BlockConsensusDetails{
+		Proposer: strings.ToLower(data.BlockProposer.Hex()),
+		Round:    data.Round,
+	}
+	switch data.VoteType {
+	case proofofstake.VOTE_TYPE_OK:
+		consensus.VoteType = BLOCK_VOTE_TYPE_OK
+	case proofofstake.VOTE_TYPE_NIL:
+		consensus.VoteType = BLOCK_VOTE_TYPE_NIL
+	}
+	for _, proposer := range data.SlashedBlockProposers {
+		consensus.SlashedProposers = append(consensus.SlashedProposers, strings.ToLower(proposer.Hex()))
+	}
+	return consensus
+}
+
+// processBlockDetails stores the details of a block and its transactions, so that they can be listed by block number
+// and by block hash.
+func (c *CacheManager) processBlockDetails(data *blockData, transactions []AccountTransactionCompact, batch *ethdb.Batch, undo *blockUndo) error {
+	block := data.block
+	details := BlockDetails{
+		BlockCompact: BlockCompact{
+			BlockNumber:      block.NumberU64(),
+			Hash:             block.Hash().Hex(),
+			ParentHash:       block.ParentHash().Hex(),
+			CreatedAt:        time.Unix(int64(block.Time()), 0).UTC().Format(TimeLayout),
+			GasUsed:          hexutil.EncodeUint64(block.GasUsed()),
+			TransactionCount: uint64(len(transactions)),
+			Consensus:        newBlockConsensusDetails(data.consensusData),
+		},
+		Transactions: make([]string, len(transactions)),
+	}
+	for i, transaction := range transactions {
+		details.Transactions[i] = transaction.Hash
+	}
+
+	detailsBlob, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	err = c.putWithUndo(getBlockDetailsKey(block.NumberU64()), detailsBlob, batch, undo)
+	if err != nil {
+		return err
+	}
+
+	transactionsBlob, err := json.Marshal(transactions)
+	if err != nil {
+		return err
+	}
+	err = c.putWithUndo(getBlockTransactionsKey(block.NumberU64()), transactionsBlob, batch, undo)
+	if err != nil {
+		return err
+	}
+
+	return c.putWithUndo(getBlockNumberKey(block.Hash()), common.Uint64ToBytes(block.NumberU64()), batch, undo)
+}
+
+// getBlockDetailsFromDb returns the details of an indexed block, or nil if the block was not indexed, or was indexed
+// by an older version.
+func (c *CacheManager) getBlockDetailsFromDb(blockNumber uint64) (*BlockDetails, error) {
+	key := getBlockDetailsKey(blockNumber)
+	ok, err := c.cacheDb.Has(key)
+	if err != nil || ok == false {
+		return nil, err
+	}
+	detailsBlob, err := c.cacheDb.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var details BlockDetails
+	err = json.Unmarshal(detailsBlob, &details)
+	if err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// GetBlockByNumber returns the details of an indexed block. Returns BlockNotFoundErr if the block was not indexed yet.
+func (c *CacheManager) GetBlockByNumber(blockNumber uint64) (GetBlockDetailsResponse, error) {
+	details, err := c.getBlockDetailsFromDb(blockNumber)
+	if err != nil {
+		log.Error("GetBlockByNumber getBlockDetailsFromDb", "error", err, "Block number", blockNumber)
+		return GetBlockDetailsResponse{}, err
+	}
+	if details == nil {
+		return GetBlockDetailsResponse{}, BlockNotFoundErr
+	}
+
+	return GetBlockDetailsResponse{Result: *details}, nil
+}
+
+// GetBlockByHash returns the details of an indexed block. Returns BlockNotFoundErr if the block was not indexed yet,
+// or is not part of the indexed chain.
+func (c *CacheManager) GetBlockByHash(blockHash common.Hash) (GetBlockDetailsResponse, error) {
+	key := getBlockNumberKey(blockHash)
+	ok, err := c.cacheDb.Has(key)
+	if err != nil {
+		log.Error("GetBlockByHash cacheDb.Has", "error", err, "hash", blockHash)
+		return GetBlockDetailsResponse{}, err
+	}
+	if ok == false {
+		return GetBlockDetailsResponse{}, BlockNotFoundErr
+	}
+	blockNumberBlob, err := c.cacheDb.Get(key)
+	if err != nil {
+		log.Error("GetBlockByHash cacheDb.Get", "error", err, "hash", blockHash)
+		return GetBlockDetailsResponse{}, err
+	}
+
+	return c.GetBlockByNumber(common.BytesToUint64(blockNumberBlob))
+}
+
+// ListBlocks lists the indexed blocks, in pages of PageSize blocks in ascending order of block number. Page 1 has the
+// blocks 1 to PageSize. The last page, with the most recent blocks, is returned if pageNumberInput is less than 1.
+// Blocks indexed by older versions are skipped.
+func (c *CacheManager) ListBlocks(pageNumberInput int64) (ListBlocksResponse, error) {
+	lastBlockNumber, err := c.getLastBlockNumberByDb(LastBlockKey)
+	if err != nil {
+		if err.Error() != "leveldb: not found" {
+			log.Error("ListBlocks getLastBlockNumberByDb", "error", err)
+			return ListBlocksResponse{}, err
+		}
+		lastBlockNumber = 0
+	}
+
+	pageCount := getPageCount(lastBlockNumber)
+	if pageCount == 0 {
+		return ListBlocksResponse{PageCount: 0, Items: make([]BlockCompact, 0)}, nil
+	}
+
+	var pageNumber uint64
+	if pageNumberInput < 1 {
+		pageNumber = pageCount
+	} else {
+		pageNumber = uint64(pageNumberInput)
+	}
+	if pageNumber > pageCount {
+		return ListBlocksResponse{PageCount: pageCount, Items: make([]BlockCompact, 0)}, nil
+	}
+
+	listResponse := ListBlocksResponse{
+		PageCount: pageCount,
+		Items:     make([]BlockCompact, 0, PageSize),
+	}
+	for blockNumber := (pageNumber-1)*PageSize + 1; blockNumber <= pageNumber*PageSize && blockNumber <= lastBlockNumber; blockNumber++ {
+		details, err := c.getBlockDetailsFromDb(blockNumber)
+		if err != nil {
+			log.Error("ListBlocks getBlockDetailsFromDb", "error", err, "Block number", blockNumber)
+			return ListBlocksResponse{}, err
+		}
+		if details == nil {
+			continue
+		}
+		listResponse.Items = append(listResponse.Items, details.BlockCompact)
+	}
+
+	return listResponse, nil
+}
+
+// ListBlockTransactions lists the transactions in an indexed block, in pages of PageSize transactions in the order of
+// the block. The last page is returned if pageNumberInput is less than 1. Returns BlockNotFoundErr if the block was not
+// indexed yet.
+func (c *CacheManager) ListBlockTransactions(blockNumber uint64, pageNumberInput int64) (ListAccountTransactionsResponse, error) {
+	key := getBlockTransactionsKey(blockNumber)
+	ok, err := c.cacheDb.Has(key)
+	if err != nil {
+		log.Error("ListBlockTransactions cacheDb.Has", "error", err, "Block number", blockNumber)
+		return ListAccountTransactionsResponse{}, err
+	}
+	if ok == false {
+		return ListAccountTransactionsResponse{}, BlockNotFoundErr
+	}
+	transactionsBlob, err := c.cacheDb.Get(key)
+	if err != nil {
+		log.Error("ListBlockTransactions cacheDb.Get", "error", err, "Block number", blockNumber)
+		return ListAccountTransactionsResponse{}, err
+	}
+	var transactions []AccountTransactionCompact
+	err = json.Unmarshal(transactionsBlob, &transactions)
+	if err != nil {
+		log.Error("ListBlockTransactions json.Unmarshal", "error", err, "Block number", blockNumber)
+		return ListAccountTransactionsResponse{}, err
+	}
+
+	pageCount := getPageCount(uint64(len(transactions)))
+	if pageCount == 0 {
+		return ListAccountTransactionsResponse{PageCount: 0}, nil
+	}
+
+	var pageNumber uint64
+	if pageNumberInput < 1 {
+		pageNumber = pageCount
+	} else {
+		pageNumber = uint64(pageNumberInput)
+	}
+	if pageNumber > pageCount {
+		return ListAccountTransactionsResponse{PageCount: pageCount}, nil
+	}
+
+	start := (pageNumber - 1) * PageSize
+	end := start + PageSize
+	if end > uint64(len(transactions)) {
+		end = uint64(len(transactions))
+	}
+
+	return ListAccountTransactionsResponse{
+		PageCount: pageCount,
+		Items:     transactions[start:end],
+	}, nil
+}
@@ -5,15 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/QuantumCoinProject/qc"
 	"github.com/QuantumCoinProject/qc/common"
 	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
 	"github.com/QuantumCoinProject/qc/core"
 	"github.com/QuantumCoinProject/qc/core/rawdb"
 	"github.com/QuantumCoinProject/qc/core/types"
 	"github.com/QuantumCoinProject/qc/ethclient"
 	"github.com/QuantumCoinProject/qc/ethdb"
+	"github.com/QuantumCoinProject/qc/event"
 	"github.com/QuantumCoinProject/qc/log"
 	"github.com/QuantumCoinProject/qc/params"
+	"github.com/QuantumCoinProject/qc/relay"
 	"io/ioutil"
 	"math/big"
 	"os"
@@ -27,25 +31,78 @@ import (
 
 type CacheManager struct {
 	cacheDir                 string
-	nodeUrl                  string
+	nodePool                 *relay.NodePool
 	cacheLock                sync.Mutex
 	cacheDb                  ethdb.Database
-	client                   *ethclient.Client
+	client                   chainClient
+	signer                   types.Signer
+	prefetcher               *blockPrefetcher
+	blockReceiptsUnsupported int32 //set when the node does not support eth_getBlockReceipts
+	traceUnsupported         int32 //set when the node does not support debug_traceTransaction
+	indexFeed                event.Feed
 	enableExtendedApis       bool
 	genesisCirculatingSupply string
 	maxSupply                string
 }
 
+// chainClient is the subset of the node api used by the cache manager.
+type chainClient interface {
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	BlockReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
+	TransactionReceipts(ctx context.Context, txHashes []common.Hash) (types.Receipts, error)
+	TraceTransactions(ctx context.Context, txHashes []common.Hash) ([]*ethclient.CallFrame, error)
+	GetBlockConsensusData(ctx context.Context, number *big.Int) (*proofofstake.ConsensusData, error)
+	GetStakingDetailsByValidatorAddress(ctx context.Context, validator common.Address, number *big.Int) (*proofofstake.ValidatorDetails, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	CallContract(ctx context.Context, msg dp.CallMsg, blockNumber *big.Int) ([]byte, error)
+	Close()
+}
+
 var SummaryKey = "summary"
 var LastBlockKey = "last-block"
 var AccountTxnCountKey = "account-txn-count-%s"                  //%s is account address
 var AccountTransactionPageKey = "account-transaction-list-%s-%d" //%s is account address, %d is page number
+var TokenTransferCountKey = "token-transfer-count-%s"            //%s is token contract address
+var TokenTransferPageKey = "token-transfer-list-%s-%d"           //%s is token contract address, %d is page number
+var BlockHashKey = "block-hash-%d"                               //%d is block number
+var BlockUndoKey = "block-undo-%d"                               //%d is block number
 var chainID *big.Int
 
+var ReorgDetectedErr = errors.New("chain reorganisation detected")
+
 const TimeLayout = "2006-01-02T15:04:05Z"
 
+// transactionListKeys are the keys of a paged list of transactions per address.
+type transactionListKeys struct {
+	Name     string
+	CountKey string
+	PageKey  string
+}
+
+var accountTransactionKeys = &transactionListKeys{Name: "", CountKey: AccountTxnCountKey, PageKey: AccountTransactionPageKey}
+var tokenTransferKeys = &transactionListKeys{Name: "token-transfers", CountKey: TokenTransferCountKey, PageKey: TokenTransferPageKey}
+
+func getTransactionListKeys(name string) (*transactionListKeys, error) {
+	switch name {
+	case accountTransactionKeys.Name:
+		return accountTransactionKeys, nil
+	case tokenTransferKeys.Name:
+		return tokenTransferKeys, nil
+	case stakingHistoryKeys.Name:
+		return stakingHistoryKeys, nil
+	case remarksKeys.Name:
+		return remarksKeys, nil
+	}
+	return nil, fmt.Errorf("unknown transaction list %s", name)
+}
+
 const PageSize uint64 = 20
 
+// MaxReorgDepth is the number of most recent indexed blocks that can be rolled back on a chain reorganisation.
+const MaxReorgDepth uint64 = 128
+
 type AccountTransactionList struct {
 	Address      string                      `json:"address"`
 	Transactions []AccountTransactionCompact `json:"transactions"`
@@ -60,6 +117,8 @@ const (
 	TOKEN_TRANSFER     TransactionType = "TokenTransfer"
 	NEW_SMART_CONTRACT TransactionType = "NewSmartContract"
 	SMART_CONTRACT     TransactionType = "SmartContract"
+	BLOCK_REWARD       TransactionType = "BlockReward" //staking history only
+	SLASHING           TransactionType = "Slashing"    //staking history only
 )
 
 type AccountTransactionCompact struct {
@@ -80,6 +139,20 @@ type AccountTransactionCompact struct {
 	Status string `json:"status,omitempty"`
 
 	TransactionType string `json:"transactionType,omitempty"`
+
+	//For TokenTransfer transactions, From and To are the sender and recipient of the tokens
+	TokenAddress string `json:"tokenAddress,omitempty"`
+
+	TokenSymbol string `json:"tokenSymbol,omitempty"`
+
+	TokenDecimals *uint8 `json:"tokenDecimals,omitempty"`
+
+	TokenAmount string `json:"tokenAmount,omitempty"`
+
+	//Set for coins transferred by a contract called by the transaction, in which case From is the contract
+	Internal bool `json:"internal,omitempty"`
+
+	Remarks string `json:"remarks,omitempty"` //hex encoded
 }
 
 type ListAccountTransactionsResponse struct {
@@ -104,9 +177,28 @@ type GetBlockchainDetailsResponse struct {
 	BlockchainDetails
 }
 
-func NewCacheManager(cacheDir string, nodeUrl string, enableExtendedApis bool, genesisFilePath string, maxSupply string) (*CacheManager, error) {
+// blockUndo is the cache state overwritten when indexing a block, used to roll back the block on a chain reorganisation.
+type blockUndo struct {
+	Summary  *BlockchainDetails `json:"summary,omitempty"` //summary before the block, nil if enableExtendedApis is false
+	Accounts []accountUndo      `json:"accounts"`
+	Keys     []keyUndo          `json:"keys,omitempty"`
+}
+
+type accountUndo struct {
+	List     string `json:"list,omitempty"` //name of the transaction list, empty for account transactions
+	Address  string `json:"address"`
+	TxnCount uint64 `json:"txnCount"`           //account txn count before the block
+	LastPage []byte `json:"lastPage,omitempty"` //last transaction page before the block, if it was not full
+}
+
+type keyUndo struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value,omitempty"` //value before the block, nil if the key did not exist
+}
+
+func NewCacheManager(cacheDir string, nodePool *relay.NodePool, enableExtendedApis bool, genesisFilePath string, maxSupply string) (*CacheManager, error) {
 	cManager := &CacheManager{
-		nodeUrl:            nodeUrl,
+		nodePool:           nodePool,
 		cacheDir:           cacheDir,
 		enableExtendedApis: enableExtendedApis,
 	}
@@ -161,7 +253,7 @@ func NewCacheManager(cacheDir string, nodeUrl string, enableExtendedApis bool, g
 }
 
 func (c *CacheManager) initialize() error {
-	log.Info("Quantum Coin initialize cache manager", "cacheDir", c.cacheDir, "nodeUrl", c.nodeUrl)
+	log.Info("Quantum Coin initialize cache manager", "cacheDir", c.cacheDir)
 
 	catchManagerFilePath := filepath.Join(c.cacheDir, "cacheManager.db")
 	catchManager, err := rawdb.NewLevelDBDatabase(catchManagerFilePath, 64, 0, "", false)
@@ -170,11 +262,8 @@ func (c *CacheManager) initialize() error {
 	}
 	c.cacheDb = catchManager
 
-	client, err := ethclient.Dial(c.nodeUrl)
-	if err != nil {
-		return err
-	}
-
+	//The calls go through the pool, which fails over to the next node when a node cannot be reached
+	client := ethclient.NewCallerClient(c.nodePool)
 	chainID, err = client.NetworkID(context.Background())
 	if err != nil {
 		log.Error("initialize NetworkID", "error", err)
@@ -182,6 +271,8 @@ func (c *CacheManager) initialize() error {
 	}
 
 	c.client = client
+	c.signer = types.NewLondonSigner(chainID)
+	c.prefetcher = newBlockPrefetcher(c, PrefetchDepth)
 
 	return nil
 }
@@ -201,18 +292,7 @@ func (c *CacheManager) start() error {
 			log.Warn("First time start")
 			blockNumber = 0
 			if c.enableExtendedApis {
-				runningSummary = &BlockchainDetails{
-					BlockNumber:           0,
-					MaxSupply:             c.maxSupply,
-					TotalSupply:           c.genesisCirculatingSupply,
-					CirculatingSupply:     c.genesisCirculatingSupply,
-					BurntCoins:            "0x0",
-					BlockRewardsCoins:     "0x0",
-					BaseBlockRewardsCoins: "0x0",
-					TxnFeeRewardsCoins:    "0x0",
-					TxnFeeBurntCoins:      "0x0",
-					SlashedCoins:          "0x0",
-				}
+				runningSummary = c.newGenesisSummary()
 			}
 		} else {
 			log.Error("GetLastBlockByDb", "err", err.Error())
@@ -237,10 +317,10 @@ func (c *CacheManager) start() error {
 			case <-cacheTimer.C:
 				blockNumberToGet := blockNumber + 1
 				log.Info("Batch Start ", "Block Number ", blockNumberToGet)
-				err := c.processByCacheManager(blockNumberToGet, runningSummary)
+				lastBlockNumber, err := c.processNextBlock(blockNumber, runningSummary)
+				blockNumber = lastBlockNumber
 				if err == nil {
-					blockNumber = blockNumberToGet
-					log.Info("Batch Complete", "Block number", blockNumberToGet)
+					log.Info("Batch Complete", "Block number", blockNumber)
 					delayNumber = 0
 				} else {
 					if err.Error() == "not found" {
@@ -268,44 +348,117 @@ func (c *CacheManager) start() error {
 	return nil
 }
 
-func (c *CacheManager) processByCacheManager(blockNumber uint64, runningSummary *BlockchainDetails) error {
-	blockNum := new(big.Int).SetUint64(blockNumber)
-	block, err := c.client.BlockByNumber(context.Background(), blockNum)
+func (c *CacheManager) newGenesisSummary() *BlockchainDetails {
+	return &BlockchainDetails{
+		BlockNumber:           0,
+		MaxSupply:             c.maxSupply,
+		TotalSupply:           c.genesisCirculatingSupply,
+		CirculatingSupply:     c.genesisCirculatingSupply,
+		BurntCoins:            "0x0",
+		BlockRewardsCoins:     "0x0",
+		BaseBlockRewardsCoins: "0x0",
+		TxnFeeRewardsCoins:    "0x0",
+		TxnFeeBurntCoins:      "0x0",
+		SlashedCoins:          "0x0",
+	}
+}
+
+// processNextBlock indexes the block after blockNumber, the last indexed block. If that block does not extend the
+// indexed chain, the indexed blocks after the fork point with the canonical chain of the node are rolled back
+// instead, so that the blocks of the new chain are indexed next. Returns the number of the last indexed block.
+func (c *CacheManager) processNextBlock(blockNumber uint64, runningSummary *BlockchainDetails) (uint64, error) {
+	data, err := c.prefetcher.get(blockNumber + 1)
+	if err == nil {
+		err = c.processByCacheManager(data, runningSummary)
+	}
+	if err == nil {
+		c.updateIndexMetrics(blockNumber + 1)
+		return blockNumber + 1, nil
+	}
+	//the prefetched blocks can be outdated
+	c.prefetcher.reset()
+
+	if errors.Is(err, ReorgDetectedErr) == false {
+		if err.Error() != "not found" || blockNumber == 0 {
+			return blockNumber, err
+		}
+		//the next block is not available yet, unless the indexed chain was replaced by a shorter chain
+		canonical, checkErr := c.isCanonical(blockNumber)
+		if checkErr != nil {
+			log.Error("isCanonical", "error", checkErr, "Block number", blockNumber)
+			return blockNumber, err
+		}
+		if canonical {
+			return blockNumber, err
+		}
+	}
+
+	log.Warn("Chain reorganisation detected", "Block number", blockNumber+1, "error", err)
+	reorgMeter.Mark(1)
+	forkBlockNumber, err := c.rollbackToForkPoint(blockNumber, runningSummary)
 	if err != nil {
-		if err.Error() != "not found" {
-			log.Error("BlockByNumber", "error", err)
+		log.Error("rollbackToForkPoint", "error", err, "Block number", forkBlockNumber)
+		return forkBlockNumber, err
+	}
+	log.Info("Rolled back to fork point", "Block number", forkBlockNumber, "rolled back blocks", blockNumber-forkBlockNumber)
+
+	return forkBlockNumber, nil
+}
+
+func (c *CacheManager) processByCacheManager(data *blockData, runningSummary *BlockchainDetails) error {
+	defer blockIndexTimer.UpdateSince(time.Now())
+
+	block := data.block
+	blockNumber := block.NumberU64()
+	blockNum := new(big.Int).SetUint64(blockNumber)
+
+	if blockNumber > 1 {
+		parentHash, ok, err := c.getBlockHash(blockNumber - 1)
+		if err != nil {
+			log.Error("processByCacheManager getBlockHash", "error", err)
+			return err
+		}
+		//the hash is not available for blocks indexed by older versions
+		if ok && parentHash != block.ParentHash() {
+			return fmt.Errorf("%w: parentHash %s of block %d does not match indexed block hash %s", ReorgDetectedErr,
+				block.ParentHash().Hex(), blockNumber, parentHash.Hex())
 		}
-		return err
 	}
 
 	txnBatch := c.cacheDb.NewBatch()
 	blockKey := []byte(LastBlockKey)
-	err = txnBatch.Put(blockKey, common.Uint64ToBytes(blockNumber))
+	err := txnBatch.Put(blockKey, common.Uint64ToBytes(blockNumber))
 	if err != nil {
 		log.Error("processByCacheManager txnBatch.Put", "error", err)
 		return err
 	}
 
+	err = txnBatch.Put(getBlockHashKey(blockNumber), block.Hash().Bytes())
+	if err != nil {
+		log.Error("processByCacheManager txnBatch.Put blockHash", "error", err)
+		return err
+	}
+
+	var undo blockUndo
+	undo.Accounts = make([]accountUndo, 0)
+
 	var liveAccountMap map[string][]AccountTransactionCompact //address to transactions in block mapping
 	liveAccountMap = make(map[string][]AccountTransactionCompact)
+	newTokens := make(map[string]*TokenInfo)
+	tokenTransferMap := make(map[string][]AccountTransactionCompact) //token contract to transfers in block mapping
+	indexed := make([]IndexedTransaction, 0, len(block.Transactions()))
+	blockTransactions := make([]AccountTransactionCompact, 0, len(block.Transactions()))
 
-	var receipts types.Receipts
-	receipts = make(types.Receipts, len(block.Transactions()))
 	for i, tx := range block.Transactions() {
-		receipt, err := c.client.TransactionReceipt(context.Background(), tx.Hash())
-		if err != nil {
-			log.Error("processByCacheManager TransactionReceipt", "error", err)
-			return err
-		}
-		receipts[i] = receipt
+		receipt := data.receipts[i]
 
-		msg, err := tx.AsMessage(types.NewLondonSigner(chainID))
+		from, err := types.Sender(c.signer, tx)
 		if err != nil {
-			log.Error("processByCacheManager AsMessage", "error", err)
+			log.Error("processByCacheManager Sender", "error", err)
 			return err
 		}
 
-		fromAddress := strings.ToLower(msg.From().Hex())
+		fromAddress := strings.ToLower(from.Hex())
 		var toAddress string
 		if tx.To() != nil {
 			toAddress = strings.ToLower(tx.To().Hex())
@@ -323,9 +476,17 @@ func (c *CacheManager) processByCacheManager(blockNumber uint64, runningSummary
 		transaction.From = fromAddress
 		transaction.To = toAddress
 		transaction.Value = common.BigIntToHexString(tx.Value())
+		if len(tx.Remarks()) > 0 {
+			transaction.Remarks = hexutil.Encode(tx.Remarks())
+		}
 
 		gasUsed := big.NewInt(1).SetUint64(receipt.GasUsed)
-		txnFee := common.SafeMulBigInt(gasUsed, tx.GasPrice())
+		//The node sets the gas price charged for the transaction, which depends on the gas tier fork
+		gasPrice := receipt.EffectiveGasPrice
+		if gasPrice == nil {
+			gasPrice = tx.GasPrice()
+		}
+		txnFee := common.SafeMulBigInt(gasUsed, gasPrice)
 		transaction.TxnFee = common.BigIntToHexString(txnFee)
 
 		if receipt.Status == 1 {
@@ -334,71 +495,380 @@ func (c *CacheManager) processByCacheManager(blockNumber uint64, runningSummary
 			transaction.Status = "0x0"
 		}
 
-		//todo: fix
-		txType, err := getTransactionType(tx)
+		var contractInfo *TokenInfo
+		if tx.To() == nil && receipt.Status == types.ReceiptStatusSuccessful {
+			contractInfo, err = c.getTokenInfo(receipt.ContractAddress, blockNum, newTokens)
+			if err != nil {
+				log.Error("processByCacheManager getTokenInfo", "error", err, "tx", tx.Hash())
+				return err
+			}
+		}
+
+		tokenTransfers, err := c.getTokenTransfers(receipt, blockNum, newTokens)
 		if err != nil {
-			log.Error("getTransactionType", "error", err, "tx", tx.Hash())
+			log.Error("processByCacheManager getTokenTransfers", "error", err, "tx", tx.Hash())
+			return err
 		}
+
+		txType, directTransfer := getTransactionType(tx, fromAddress, contractInfo, tokenTransfers)
 		transaction.TransactionType = string(txType)
 
-		_, ok := liveAccountMap[fromAddress]
-		if ok == false {
-			liveAccountMap[fromAddress] = make([]AccountTransactionCompact, 0)
+		accounts := []string{fromAddress}
+		if tx.To() != nil {
+			accounts = append(accounts, toAddress)
+		}
+		if contractInfo != nil && contractInfo.IsToken {
+			setTokenDetails(&transaction, contractInfo)
+		}
+		if directTransfer != nil {
+			setTokenDetails(&transaction, directTransfer.Token)
+			transaction.To = directTransfer.To
+			transaction.TokenAmount = common.BigIntToHexString(directTransfer.Amount)
+			accounts = append(accounts, directTransfer.To)
+			tokenTransferMap[directTransfer.Token.Address] = append(tokenTransferMap[directTransfer.Token.Address], transaction)
+		}
+		indexed = append(indexed, IndexedTransaction{
+			Transaction: transaction,
+			Accounts:    addAccountTransaction(liveAccountMap, accounts, transaction),
+		})
+		blockTransactions = append(blockTransactions, transaction)
+
+		//transfers made by the called contracts, or other transfers by the called token contract
+		for _, tokenTransfer := range tokenTransfers {
+			if tokenTransfer == directTransfer {
+				continue
+			}
+			var transfer AccountTransactionCompact
+			transfer.Hash = transaction.Hash
+			transfer.BlockNumber = blockNumber
+			transfer.CreatedAt = transaction.CreatedAt
+			transfer.From = tokenTransfer.From
+			transfer.To = tokenTransfer.To
+			transfer.Status = transaction.Status
+			transfer.TransactionType = string(TOKEN_TRANSFER)
+			setTokenDetails(&transfer, tokenTransfer.Token)
+			transfer.TokenAmount = common.BigIntToHexString(tokenTransfer.Amount)
+			indexed = append(indexed, IndexedTransaction{
+				Transaction: transfer,
+				Accounts:    addAccountTransaction(liveAccountMap, []string{tokenTransfer.From, tokenTransfer.To}, transfer),
+			})
+			tokenTransferMap[tokenTransfer.Token.Address] = append(tokenTransferMap[tokenTransfer.Token.Address], transfer)
 		}
-		liveAccountMap[fromAddress] = append(liveAccountMap[fromAddress], transaction)
 
-		if tx.To() != nil {
-			if fromAddress != toAddress {
-				_, ok := liveAccountMap[toAddress]
-				if ok == false {
-					liveAccountMap[toAddress] = make([]AccountTransactionCompact, 0)
-				}
-				liveAccountMap[toAddress] = append(liveAccountMap[toAddress], transaction)
+		//coins transferred by the called contracts
+		if data.traces != nil {
+			for _, call := range getInternalTransfers(data.traces[i]) {
+				transfer := newInternalTransfer(transaction, call)
+				indexed = append(indexed, IndexedTransaction{
+					Transaction: transfer,
+					Accounts:    addAccountTransaction(liveAccountMap, []string{transfer.From, transfer.To}, transfer),
+				})
 			}
 		}
 	}
 
 	for k, v := range liveAccountMap {
-		err = c.processAccountTransactions(k, &v, &txnBatch)
+		err = c.processAccountTransactions(accountTransactionKeys, k, &v, &txnBatch, &undo)
 		if err != nil {
 			log.Error("processAccountTransaction", "error", err, "address", k)
 			return err
 		}
 	}
 
+	err = c.processBlockTokens(blockNum, tokenTransferMap, newTokens, &txnBatch, &undo)
+	if err != nil {
+		log.Error("processBlockTokens", "error", err)
+		return err
+	}
+
+	err = c.processBlockDetails(data, blockTransactions, &txnBatch, &undo)
+	if err != nil {
+		log.Error("processBlockDetails", "error", err)
+		return err
+	}
+
+	err = c.processRemarks(blockTransactions, &txnBatch, &undo)
+	if err != nil {
+		log.Error("processRemarks", "error", err)
+		return err
+	}
+
+	err = c.processStakingHistory(data, &txnBatch, &undo)
+	if err != nil {
+		log.Error("processStakingHistory", "error", err)
+		return err
+	}
+
 	if c.enableExtendedApis {
-		err = c.updateSummary(blockNum, runningSummary, &txnBatch)
+		summary := *runningSummary
+		undo.Summary = &summary
+		err = c.updateSummary(data, runningSummary, &txnBatch)
 		if err != nil {
+			*runningSummary = summary
 			log.Error("updateSummary", "error", err)
 			return err
 		}
 	}
 
+	undoBlob, err := json.Marshal(undo)
+	if err != nil {
+		log.Error("processByCacheManager json.Marshal undo", "error", err)
+		return err
+	}
+	err = txnBatch.Put(getBlockUndoKey(blockNumber), undoBlob)
+	if err != nil {
+		log.Error("processByCacheManager txnBatch.Put undo", "error", err)
+		return err
+	}
+	if blockNumber > MaxReorgDepth {
+		err = txnBatch.Delete(getBlockUndoKey(blockNumber - MaxReorgDepth))
+		if err != nil {
+			log.Error("processByCacheManager txnBatch.Delete undo", "error", err)
+			return err
+		}
+	}
+
 	err = txnBatch.Write()
 	if err != nil {
+		if undo.Summary != nil {
+			*runningSummary = *undo.Summary
+		}
 		log.Error("processByCacheManager txnBatch Write", "error", err)
 		return err
 	}
 
+	c.indexFeed.Send(IndexEvent{
+		Block: BlockEvent{
+			BlockNumber:      blockNumber,
+			Hash:             block.Hash().Hex(),
+			ParentHash:       block.ParentHash().Hex(),
+			CreatedAt:        time.Unix(int64(block.Time()), 0).UTC().Format(TimeLayout),
+			TransactionCount: len(block.Transactions()),
+		},
+		Transactions: indexed,
+	})
+
 	return nil
 }
 
-func (c *CacheManager) updateSummary(blockNumber *big.Int, runningSummary *BlockchainDetails, batch *ethdb.Batch) error {
+// rollbackToForkPoint rolls back the indexed blocks, starting at blockNumber, until the last indexed block is part
+// of the canonical chain of the node. Returns the number of the last indexed block.
+func (c *CacheManager) rollbackToForkPoint(blockNumber uint64, runningSummary *BlockchainDetails) (uint64, error) {
+	for blockNumber > 0 {
+		canonical, err := c.isCanonical(blockNumber)
+		if err != nil {
+			return blockNumber, err
+		}
+		if canonical {
+			return blockNumber, nil
+		}
 
-	leftBlock := blockNumber.Uint64()
-	rightBlock := runningSummary.BlockNumber + 1
-	if leftBlock != rightBlock {
-		log.Error("updateSummary", "leftBlock", leftBlock, "rightBlock", rightBlock)
-		return errors.New("updateSummary unexpected blockNumber")
+		err = c.rollbackBlock(blockNumber, runningSummary)
+		if err != nil {
+			return blockNumber, err
+		}
+		blockNumber = blockNumber - 1
+	}
+
+	return blockNumber, nil
+}
+
+// isCanonical returns whether the indexed block is part of the canonical chain of the node. Blocks indexed by older
+// versions, whose hash was not stored, are assumed to be canonical.
+func (c *CacheManager) isCanonical(blockNumber uint64) (bool, error) {
+	blockHash, ok, err := c.getBlockHash(blockNumber)
+	if err != nil {
+		return false, err
+	}
+	if ok == false {
+		return true, nil
+	}
+
+	header, err := c.client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		//the canonical chain can be shorter than the indexed chain
+		if err.Error() == "not found" {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return header.Hash() == blockHash, nil
+}
+
+// rollbackBlock reverts the account transaction pages, account transaction counts and summary to the state before
+// blockNumber, the last indexed block, was indexed.
+func (c *CacheManager) rollbackBlock(blockNumber uint64, runningSummary *BlockchainDetails) error {
+	undoKey := getBlockUndoKey(blockNumber)
+	ok, err := c.cacheDb.Has(undoKey)
+	if err != nil {
+		return err
+	}
+	if ok == false {
+		return fmt.Errorf("cannot roll back block %d, reorganisations deeper than %d blocks are not supported", blockNumber, MaxReorgDepth)
+	}
+	undoBlob, err := c.cacheDb.Get(undoKey)
+	if err != nil {
+		return err
+	}
+	var undo blockUndo
+	err = json.Unmarshal(undoBlob, &undo)
+	if err != nil {
+		return err
+	}
+	blockHash, _, err := c.getBlockHash(blockNumber)
+	if err != nil {
+		return err
+	}
+
+	txnBatch := c.cacheDb.NewBatch()
+	for _, account := range undo.Accounts {
+		keys, err := getTransactionListKeys(account.List)
+		if err != nil {
+			return err
+		}
+		txnCount, err := c.getAccountTxnCount(keys, account.Address)
+		if err != nil {
+			return err
+		}
+		if txnCount < account.TxnCount {
+			return fmt.Errorf("unexpected txnCount %d of address %s, expected at least %d", txnCount, account.Address, account.TxnCount)
+		}
+
+		for page := getPageCount(account.TxnCount) + 1; page <= getPageCount(txnCount); page++ {
+			err = txnBatch.Delete(getAccountPageKey(keys, account.Address, page))
+			if err != nil {
+				return err
+			}
+		}
+		if account.TxnCount%PageSize != 0 {
+			err = txnBatch.Put(getAccountPageKey(keys, account.Address, getPageCount(account.TxnCount)), account.LastPage)
+			if err != nil {
+				return err
+			}
+		}
+
+		if account.TxnCount == 0 {
+			_, keyBlob := getAccountTxnCountKey(keys, account.Address)
+			err = txnBatch.Delete(keyBlob)
+		} else {
+			err = c.putAccountTxnCount(keys, account.Address, account.TxnCount, &txnBatch)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := len(undo.Keys) - 1; i >= 0; i-- {
+		if undo.Keys[i].Value == nil {
+			err = txnBatch.Delete([]byte(undo.Keys[i].Key))
+		} else {
+			err = txnBatch.Put([]byte(undo.Keys[i].Key), undo.Keys[i].Value)
+		}
+		if err != nil {
+			return err
+		}
 	}
 
-	consensusData, err := c.client.GetBlockConsensusData(context.Background(), blockNumber)
+	if c.enableExtendedApis {
+		if undo.Summary == nil {
+			return fmt.Errorf("summary of block %d not found", blockNumber-1)
+		}
+		err = c.putSummary(undo.Summary, &txnBatch)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = txnBatch.Put([]byte(LastBlockKey), common.Uint64ToBytes(blockNumber-1))
+	if err != nil {
+		return err
+	}
+	err = txnBatch.Delete(getBlockHashKey(blockNumber))
+	if err != nil {
+		return err
+	}
+	err = txnBatch.Delete(undoKey)
 	if err != nil {
 		return err
 	}
 
+	err = txnBatch.Write()
+	if err != nil {
+		return err
+	}
+
+	if c.enableExtendedApis {
+		*runningSummary = *undo.Summary
+	}
+
+	log.Info("Rolled back block", "Block number", blockNumber, "accounts", len(undo.Accounts))
+
+	c.indexFeed.Send(IndexEvent{
+		Block: BlockEvent{
+			BlockNumber: blockNumber,
+			Hash:        blockHash.Hex(),
+		},
+		RolledBack: true,
+	})
+
+	return nil
+}
+
+// putWithUndo adds a key to the batch of a block, recording its current value in the undo record of the block. A key
+// must be written at most once per block.
+func (c *CacheManager) putWithUndo(key []byte, value []byte, batch *ethdb.Batch, undo *blockUndo) error {
 	txnBatch := *batch
-	blockRewardsInfo := consensusData.BlockRewardsInfo
+	ok, err := c.cacheDb.Has(key)
+	if err != nil {
+		return err
+	}
+	var previous []byte
+	if ok {
+		previous, err = c.cacheDb.Get(key)
+		if err != nil {
+			return err
+		}
+	}
+	undo.Keys = append(undo.Keys, keyUndo{Key: string(key), Value: previous})
+
+	return txnBatch.Put(key, value)
+}
+
+func getBlockHashKey(blockNumber uint64) []byte {
+	return []byte(fmt.Sprintf(BlockHashKey, blockNumber))
+}
+
+func getBlockUndoKey(blockNumber uint64) []byte {
+	return []byte(fmt.Sprintf(BlockUndoKey, blockNumber))
+}
+
+// getBlockHash returns the hash of an indexed block, and false if it was not stored.
+func (c *CacheManager) getBlockHash(blockNumber uint64) (common.Hash, bool, error) {
+	key := getBlockHashKey(blockNumber)
+	ok, err := c.cacheDb.Has(key)
+	if err != nil || ok == false {
+		return common.Hash{}, false, err
+	}
+	blob, err := c.cacheDb.Get(key)
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	return common.BytesToHash(blob), true, nil
+}
+
+func (c *CacheManager) updateSummary(data *blockData, runningSummary *BlockchainDetails, batch *ethdb.Batch) error {
+
+	leftBlock := data.block.NumberU64()
+	rightBlock := runningSummary.BlockNumber + 1
+	if leftBlock != rightBlock {
+		log.Error("updateSummary", "leftBlock", leftBlock, "rightBlock", rightBlock)
+		return errors.New("updateSummary unexpected blockNumber")
+	}
+
+	var err error
+	txnBatch := *batch
+	blockRewardsInfo := data.consensusData.BlockRewardsInfo
 
 	var baseBlockProposerRewards *big.Int
 	var blockProposerRewards *big.Int
@@ -407,7 +877,7 @@ func (c *CacheManager) updateSummary(blockNumber *big.Int, runningSummary *Block
 	var slashAmount *big.Int
 
 	//Update running summary
-	runningSummary.BlockNumber = blockNumber.Uint64()
+	runningSummary.BlockNumber = data.block.NumberU64()
 
 	if len(blockRewardsInfo.BaseBlockProposerRewards) > 0 {
 		baseBlockProposerRewards, err = hexutil.DecodeBig(blockRewardsInfo.BaseBlockProposerRewards)
@@ -479,13 +949,8 @@ func (c *CacheManager) updateSummary(blockNumber *big.Int, runningSummary *Block
 		runningSummary.SlashedCoins = hexutil.EncodeBig(common.SafeAddBigInt(slashedCoinsBig, slashAmount))
 	}
 
-	//Get latest burnt coins info
-	burntCoinsWei, err := c.client.BalanceAt(context.Background(), common.ZERO_ADDRESS, blockNumber)
-	if err != nil {
-		log.Error("updateSummary BalanceAt", "error", err)
-		return err
-	}
-
+	//Latest burnt coins info
+	burntCoinsWei := data.burntCoins
 	runningSummary.BurntCoins = hexutil.EncodeBig(burntCoinsWei)
 	genesisCirculatingSupplyBig, _ := hexutil.DecodeBig(c.genesisCirculatingSupply)
 	blockRewardsCoinsBig, _ := hexutil.DecodeBig(runningSummary.BlockRewardsCoins)
@@ -504,12 +969,7 @@ func (c *CacheManager) updateSummary(blockNumber *big.Int, runningSummary *Block
 
 func (c *CacheManager) latestBlockByNode() (uint64, error) {
 
-	client, err := ethclient.Dial(c.nodeUrl)
-	if err != nil {
-		return 0, err
-	}
-
-	latestBlock, err := client.BlockNumber(context.Background())
+	latestBlock, err := c.client.BlockNumber(context.Background())
 	if err != nil {
 		return 0, err
 	}
@@ -578,19 +1038,24 @@ func (c *CacheManager) close() error {
 	return nil
 }
 
-func (c *CacheManager) processAccountTransactions(address string, txnList *[]AccountTransactionCompact, batch *ethdb.Batch) error {
+func (c *CacheManager) processAccountTransactions(keys *transactionListKeys, address string, txnList *[]AccountTransactionCompact, batch *ethdb.Batch, undo *blockUndo) error {
 	txnBatch := *batch
 	var txnCount uint64
 	var err error
 
 	address = strings.ToLower(address)
 
-	txnCount, err = c.getAccountTxnCount(address)
+	txnCount, err = c.getAccountTxnCount(keys, address)
 	if err != nil {
 		return err
 	}
 	newTxnCount := txnCount + 1
 	var accountTransactionList AccountTransactionList
+	accountUndoEntry := accountUndo{
+		List:     keys.Name,
+		Address:  address,
+		TxnCount: txnCount,
+	}
 
 	log.Info("processAccountTransactions", "address", address, "txnCount", txnCount, "transaction count in block", len(*txnList))
 
@@ -601,7 +1066,7 @@ func (c *CacheManager) processAccountTransactions(address string, txnList *[]Acc
 	} else {
 		//Load current state form the cache
 		txnPageCount := getPageCount(newTxnCount)
-		txnPageKey := getAccountPageKey(address, txnPageCount)
+		txnPageKey := getAccountPageKey(keys, address, txnPageCount)
 
 		log.Info("processAccountTransactions loading from cache", "address", address, "newTxnCount", newTxnCount, "txnPageCount", txnPageCount)
 
@@ -610,6 +1075,7 @@ func (c *CacheManager) processAccountTransactions(address string, txnList *[]Acc
 			log.Error("cacheDb.Get accountTxnPageKey", "error", err)
 			return err
 		}
+		accountUndoEntry.LastPage = accountTransactionListBlob
 		err = json.Unmarshal(accountTransactionListBlob, &accountTransactionList)
 		if err != nil {
 			log.Error("json.Unmarshal accountTransactionListBlob", "error", err)
@@ -642,7 +1108,7 @@ func (c *CacheManager) processAccountTransactions(address string, txnList *[]Acc
 
 			runningTxnCount := txnCount + uint64(i) + 1
 			txnPageCount := getPageCount(runningTxnCount)
-			txnPageKey := getAccountPageKey(address, txnPageCount)
+			txnPageKey := getAccountPageKey(keys, address, txnPageCount)
 			err = txnBatch.Put(txnPageKey, accountTransactionListBlob)
 			if err != nil {
 				log.Error("txnBatch.Put accountTransactionListBlob", "error", err)
@@ -654,10 +1120,11 @@ func (c *CacheManager) processAccountTransactions(address string, txnList *[]Acc
 	}
 
 	txnCount = txnCount + uint64(len(*txnList))
-	err = c.putAccountTxnCount(address, txnCount, batch)
+	err = c.putAccountTxnCount(keys, address, txnCount, batch)
 	if err != nil {
 		return err
 	}
+	undo.Accounts = append(undo.Accounts, accountUndoEntry)
 
 	log.Info("inserted account txn list", "txnCount", txnCount, "txnPageCount", getPageCount(txnCount), "txnCountInBlock", len(*txnList), "address", address)
 
@@ -672,14 +1139,14 @@ func getPageCount(txnCount uint64) uint64 {
 	}
 }
 
-func getAccountTxnCountKey(address string) (key string, blob []byte) {
-	key = fmt.Sprintf(AccountTxnCountKey, address)
+func getAccountTxnCountKey(keys *transactionListKeys, address string) (key string, blob []byte) {
+	key = fmt.Sprintf(keys.CountKey, address)
 	blob = []byte(key)
 	return key, blob
 }
 
-func (c *CacheManager) getAccountTxnCount(address string) (uint64, error) {
-	accountTxnCountKey, keyBlob := getAccountTxnCountKey(address)
+func (c *CacheManager) getAccountTxnCount(keys *transactionListKeys, address string) (uint64, error) {
+	accountTxnCountKey, keyBlob := getAccountTxnCountKey(keys, address)
 	accountTxnCountBlob, err := c.cacheDb.Get(keyBlob)
 	if err != nil {
 		if err.Error() == "leveldb: not found" {
@@ -696,10 +1163,10 @@ func (c *CacheManager) getAccountTxnCount(address string) (uint64, error) {
 	}
 }
 
-func (c *CacheManager) putAccountTxnCount(address string, txnCount uint64, batch *ethdb.Batch) error {
+func (c *CacheManager) putAccountTxnCount(keys *transactionListKeys, address string, txnCount uint64, batch *ethdb.Batch) error {
 	txnBatch := *batch
 	address = strings.ToLower(address)
-	accountTxnCountKey, keyBlob := getAccountTxnCountKey(address)
+	accountTxnCountKey, keyBlob := getAccountTxnCountKey(keys, address)
 	log.Info("putAccountTxnCount", "address", address, "accountTxnCountKey", accountTxnCountKey, "txnCount", txnCount)
 
 	blob := common.Uint64ToBytes(txnCount)
@@ -728,11 +1195,25 @@ func (c *CacheManager) GetBlockchainDetails() (GetBlockchainDetailsResponse, err
 }
 
 func (c *CacheManager) ListTransactionByAccount(accountAddress common.Address, pageNumberInput int64) (ListAccountTransactionsResponse, error) {
+	return c.listTransactions(accountTransactionKeys, accountAddress, pageNumberInput)
+}
+
+// ListTokenTransfers lists the transfers of a token contract, in pages of PageSize transfers. The last page is returned
+// if pageNumberInput is less than 1.
+func (c *CacheManager) ListTokenTransfers(tokenAddress common.Address, pageNumberInput int64) (ListAccountTransactionsResponse, error) {
+	return c.listTransactions(tokenTransferKeys, tokenAddress, pageNumberInput)
+}
+
+func (c *CacheManager) listTransactions(keys *transactionListKeys, accountAddress common.Address, pageNumberInput int64) (ListAccountTransactionsResponse, error) {
+	return c.listTransactionPage(keys, strings.ToLower(accountAddress.Hex()), pageNumberInput)
+}
+
+// listTransactionPage returns a page of a transaction list. address is the address the list is keyed by.
+func (c *CacheManager) listTransactionPage(keys *transactionListKeys, address string, pageNumberInput int64) (ListAccountTransactionsResponse, error) {
 	listResponse := ListAccountTransactionsResponse{}
-	address := strings.ToLower(accountAddress.Hex())
 
 	var pageCount uint64
-	accountTxnCount, err := c.getAccountTxnCount(address)
+	accountTxnCount, err := c.getAccountTxnCount(keys, address)
 	if err != nil {
 		return ListAccountTransactionsResponse{}, err
 	}
@@ -757,7 +1238,7 @@ func (c *CacheManager) ListTransactionByAccount(accountAddress common.Address, p
 		return ListAccountTransactionsResponse{PageCount: pageCount}, nil
 	}
 
-	pageKey := fmt.Sprintf(AccountTransactionPageKey, address, pageNumber)
+	pageKey := fmt.Sprintf(keys.PageKey, address, pageNumber)
 	accountTxnPageKey := []byte(pageKey)
 	log.Info("cache get", "key", pageKey)
 
@@ -792,18 +1273,47 @@ func (c *CacheManager) ListTransactionByAccount(accountAddress common.Address, p
 	return listResponse, nil
 }
 
-func getAccountPageKey(address string, pageCount uint64) []byte {
-	pageKey := fmt.Sprintf(AccountTransactionPageKey, strings.ToLower(address), pageCount)
+func getAccountPageKey(keys *transactionListKeys, address string, pageCount uint64) []byte {
+	pageKey := fmt.Sprintf(keys.PageKey, strings.ToLower(address), pageCount)
 	return []byte(pageKey)
 }
 
-// todo: handle TokenTransfer, NewToken
-func getTransactionType(txn *types.Transaction) (TransactionType, error) {
+// addAccountTransaction adds a transaction to the transactions in the block of each of the accounts, once per account,
+// and returns the accounts it was added to. The zero address is skipped, since it is the sender of every token mint
+// and the recipient of every token burn.
+func addAccountTransaction(liveAccountMap map[string][]AccountTransactionCompact, accounts []string, transaction AccountTransactionCompact) []string {
+	zeroAddress := strings.ToLower(common.ZERO_ADDRESS.Hex())
+	added := make(map[string]bool)
+	addedAccounts := make([]string, 0, len(accounts))
+	for _, address := range accounts {
+		if address == zeroAddress || added[address] {
+			continue
+		}
+		added[address] = true
+		addedAccounts = append(addedAccounts, address)
+		liveAccountMap[address] = append(liveAccountMap[address], transaction)
+	}
+	return addedAccounts
+}
+
+// getTransactionType classifies a transaction. contractInfo is the token info of the contract created by the
+// transaction, if any. A call to a token contract that transfers tokens of the sender is a TokenTransfer; the
+// transfer is returned too.
+func getTransactionType(txn *types.Transaction, fromAddress string, contractInfo *TokenInfo, tokenTransfers []*tokenTransfer) (TransactionType, *tokenTransfer) {
 	if txn.To() == nil {
+		if contractInfo != nil && contractInfo.IsToken {
+			return NEW_TOKEN, nil
+		}
 		return NEW_SMART_CONTRACT, nil
 	}
 	if txn.Data() == nil || len(txn.Data()) == 0 {
 		return COIN_TRANSFER, nil
 	}
+	toAddress := strings.ToLower(txn.To().Hex())
+	for _, tokenTransfer := range tokenTransfers {
+		if tokenTransfer.Token.Address == toAddress && tokenTransfer.From == fromAddress {
+			return TOKEN_TRANSFER, tokenTransfer
+		}
+	}
 	return SMART_CONTRACT, nil
 }
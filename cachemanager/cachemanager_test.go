@@ -0,0 +1,1168 @@
+package cachemanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/QuantumCoinProject/qc"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
+	"github.com/QuantumCoinProject/qc/core/rawdb"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/ethclient"
+	"github.com/QuantumCoinProject/qc/params"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testAlice = common.BytesToAddress([]byte{0xa1})
+	testBob   = common.BytesToAddress([]byte{0xb0})
+	testCarol = common.BytesToAddress([]byte{0xc0})
+	testDave  = common.BytesToAddress([]byte{0xd0})
+)
+
+// testChain is a simulated node. It also acts as the transaction signer, since transactions in the chain are not signed.
+type testChain struct {
+	blocks    []*types.Block //canonical chain, blocks[0] is the genesis block
+	receipts  map[common.Hash]*types.Receipt
+	senders   map[common.Hash]common.Address
+	contracts map[common.Address]map[string][]byte //contract address to method id to call result
+	nonce     uint64
+	nilBlocks map[uint64]bool //blocks with a nil vote, in which the proposer testCarol is slashed
+	traces    map[common.Hash]*ethclient.CallFrame
+	callErr   error //error returned by every contract call, if set
+
+	blockReceiptsUnsupported bool //simulates a node without eth_getBlockReceipts
+	traceUnsupported         bool //simulates a node without debug_traceTransaction
+}
+
+type testTransaction struct {
+	from            common.Address
+	to              *common.Address
+	data            []byte
+	remarks         []byte
+	contractAddress common.Address
+	logs            []*types.Log
+	calls           []*ethclient.CallFrame //calls made by the called contract
+}
+
+type testCallError struct{}
+
+func (e *testCallError) Error() string  { return "execution reverted" }
+func (e *testCallError) ErrorCode() int { return 3 }
+
+type testMethodNotFoundError struct{}
+
+func (e *testMethodNotFoundError) Error() string  { return "the method does not exist/is not available" }
+func (e *testMethodNotFoundError) ErrorCode() int { return -32601 }
+
+type testNodeError struct{}
+
+func (e *testNodeError) Error() string  { return "missing trie node" }
+func (e *testNodeError) ErrorCode() int { return -32000 }
+
+func newTestChain() *testChain {
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0)})
+	return &testChain{
+		blocks:    []*types.Block{genesis},
+		receipts:  make(map[common.Hash]*types.Receipt),
+		senders:   make(map[common.Hash]common.Address),
+		contracts: make(map[common.Address]map[string][]byte),
+		nilBlocks: make(map[uint64]bool),
+		traces:    make(map[common.Hash]*ethclient.CallFrame),
+	}
+}
+
+// appendBlock adds a block with the transactions to the chain.
+func (chain *testChain) appendBlock(transactions ...*testTransaction) {
+	parentBlock := chain.blocks[len(chain.blocks)-1]
+	txs := make([]*types.Transaction, len(transactions))
+	for i, transaction := range transactions {
+		chain.nonce = chain.nonce + 1
+		txs[i] = types.NewTx(&types.DefaultFeeTx{
+			ChainID:    big.NewInt(1),
+			Nonce:      chain.nonce,
+			Gas:        21000,
+			MaxGasTier: types.GAS_TIER_DEFAULT,
+			To:         transaction.to,
+			Value:      big.NewInt(int64(chain.nonce)),
+			Data:       transaction.data,
+			Remarks:    transaction.remarks,
+		})
+		chain.senders[txs[i].Hash()] = transaction.from
+	}
+
+	header := &types.Header{
+		ParentHash: parentBlock.Hash(),
+		Number:     new(big.Int).Add(parentBlock.Number(), common.Big1),
+		Time:       1700000000 + chain.nonce,
+		Extra:      common.Uint64ToBytes(chain.nonce),
+	}
+	block := types.NewBlockWithHeader(header).WithBody(txs)
+	for i, tx := range txs {
+		gasUsed := params.TxGas
+		if transactions[i].calls != nil {
+			gasUsed = 50000
+			chain.traces[tx.Hash()] = &ethclient.CallFrame{
+				Type:  "CALL",
+				From:  transactions[i].from,
+				To:    *tx.To(),
+				Value: (*hexutil.Big)(tx.Value()),
+				Calls: transactions[i].calls,
+			}
+		}
+		chain.receipts[tx.Hash()] = &types.Receipt{
+			Status:          types.ReceiptStatusSuccessful,
+			GasUsed:         gasUsed,
+			TxHash:          tx.Hash(),
+			ContractAddress: transactions[i].contractAddress,
+			Logs:            transactions[i].logs,
+			BlockHash:       block.Hash(),
+			BlockNumber:     block.Number(),
+		}
+	}
+	chain.blocks = append(chain.blocks, block)
+}
+
+// extend replaces the blocks after parent with count new blocks, each with a transfer from -> to.
+func (chain *testChain) extend(parent uint64, count int, from common.Address, to common.Address) {
+	chain.blocks = chain.blocks[:parent+1]
+	for i := 0; i < count; i++ {
+		recipient := to
+		chain.appendBlock(&testTransaction{from: from, to: &recipient})
+	}
+}
+
+func (chain *testChain) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if number.Uint64() >= uint64(len(chain.blocks)) {
+		return nil, dp.NotFound
+	}
+	return chain.blocks[number.Uint64()], nil
+}
+
+func (chain *testChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	block, err := chain.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (chain *testChain) BlockNumber(ctx context.Context) (uint64, error) {
+	return uint64(len(chain.blocks) - 1), nil
+}
+
+func (chain *testChain) BlockReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error) {
+	if chain.blockReceiptsUnsupported {
+		return nil, &testMethodNotFoundError{}
+	}
+	for _, block := range chain.blocks {
+		if block.Hash() == blockHash {
+			txHashes := make([]common.Hash, len(block.Transactions()))
+			for i, tx := range block.Transactions() {
+				txHashes[i] = tx.Hash()
+			}
+			return chain.TransactionReceipts(ctx, txHashes)
+		}
+	}
+	return nil, dp.NotFound
+}
+
+func (chain *testChain) TransactionReceipts(ctx context.Context, txHashes []common.Hash) (types.Receipts, error) {
+	receipts := make(types.Receipts, len(txHashes))
+	for i, txHash := range txHashes {
+		receipt, ok := chain.receipts[txHash]
+		if ok == false {
+			return nil, dp.NotFound
+		}
+		receipts[i] = receipt
+	}
+	return receipts, nil
+}
+
+func (chain *testChain) TraceTransactions(ctx context.Context, txHashes []common.Hash) ([]*ethclient.CallFrame, error) {
+	if chain.traceUnsupported {
+		return nil, &testMethodNotFoundError{}
+	}
+	traces := make([]*ethclient.CallFrame, len(txHashes))
+	for i, txHash := range txHashes {
+		if _, ok := chain.receipts[txHash]; ok == false {
+			return nil, dp.NotFound
+		}
+		//transactions without calls, such as contract creations, have an empty trace
+		trace, ok := chain.traces[txHash]
+		if ok == false {
+			trace = &ethclient.CallFrame{Type: "CALL"}
+		}
+		traces[i] = trace
+	}
+	return traces, nil
+}
+
+func (chain *testChain) GetBlockConsensusData(ctx context.Context, number *big.Int) (*proofofstake.ConsensusData, error) {
+	if chain.nilBlocks[number.Uint64()] {
+		return &proofofstake.ConsensusData{
+			Data: &proofofstake.BlockConsensusData{
+				BlockProposer:         testBob,
+				VoteType:              proofofstake.VOTE_TYPE_NIL,
+				Round:                 1,
+				SlashedBlockProposers: []common.Address{testCarol},
+			},
+			BlockRewardsInfo: &proofofstake.BlockRewardsInfo{
+				BlockProposerRewards: "0x0",
+				SlashedValidators:    []*proofofstake.Slashing{{SlashedValidator: testCarol, SlashedAmount: "0x5"}},
+				SlashAmount:          "0x5",
+			},
+		}, nil
+	}
+	return &proofofstake.ConsensusData{
+		Data: &proofofstake.BlockConsensusData{
+			BlockProposer: testCarol,
+			VoteType:      proofofstake.VOTE_TYPE_OK,
+			Round:         byte(number.Uint64()%2 + 1),
+		},
+		BlockRewardsInfo: &proofofstake.BlockRewardsInfo{
+			BlockProposerRewards:     "0x64",
+			BaseBlockProposerRewards: "0x5a",
+			TxnFeeRewards:            "0xa",
+			BurntTxnFee:              "0x1",
+		},
+	}, nil
+}
+
+// GetStakingDetailsByValidatorAddress returns testDave as the depositor of testCarol.
+func (chain *testChain) GetStakingDetailsByValidatorAddress(ctx context.Context, validator common.Address, number *big.Int) (*proofofstake.ValidatorDetails, error) {
+	details := &proofofstake.ValidatorDetails{Validator: validator}
+	if validator == testCarol {
+		details.Depositor = testDave
+	}
+	return details, nil
+}
+
+func (chain *testChain) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return new(big.Int).Set(blockNumber), nil
+}
+
+func (chain *testChain) CallContract(ctx context.Context, msg dp.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if chain.callErr != nil {
+		return nil, chain.callErr
+	}
+	methods, ok := chain.contracts[*msg.To]
+	if ok == false {
+		return nil, nil
+	}
+	result, ok := methods[hexutil.Encode(msg.Data)]
+	if ok == false {
+		return nil, &testCallError{}
+	}
+	return result, nil
+}
+
+func (chain *testChain) Close() {
+}
+
+func (chain *testChain) Sender(tx *types.Transaction) (common.Address, error) {
+	from, ok := chain.senders[tx.Hash()]
+	if ok == false {
+		return common.Address{}, errors.New("unknown transaction")
+	}
+	return from, nil
+}
+
+func (chain *testChain) SignatureValues(tx *types.Transaction, sig []byte) (r, s, v *big.Int, err error) {
+	return nil, nil, nil, errors.New("not supported")
+}
+
+func (chain *testChain) ChainID() *big.Int {
+	return big.NewInt(1)
+}
+
+func (chain *testChain) Hash(tx *types.Transaction) (common.Hash, error) {
+	return tx.Hash(), nil
+}
+
+func (chain *testChain) Equal(signer types.Signer) bool {
+	return signer == types.Signer(chain)
+}
+
+func newTestCacheManager(t *testing.T, chain *testChain) *CacheManager {
+	db, err := rawdb.NewLevelDBDatabase(filepath.Join(t.TempDir(), "cacheManager.db"), 64, 0, "", false)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	c := &CacheManager{
+		cacheDb:                  db,
+		client:                   chain,
+		signer:                   chain,
+		enableExtendedApis:       true,
+		genesisCirculatingSupply: "0x3e8",
+		maxSupply:                "0x2710",
+	}
+	c.prefetcher = newBlockPrefetcher(c, PrefetchDepth)
+	return c
+}
+
+// syncTestCacheManager indexes blocks until the cache manager has caught up with the chain.
+func syncTestCacheManager(t *testing.T, c *CacheManager, blockNumber uint64, runningSummary *BlockchainDetails) uint64 {
+	for {
+		lastBlockNumber, err := c.processNextBlock(blockNumber, runningSummary)
+		if err != nil {
+			if err.Error() == "not found" {
+				return blockNumber
+			}
+			t.Fatalf("failed %v", err)
+		}
+		blockNumber = lastBlockNumber
+	}
+}
+
+// dumpTestCache returns the contents of the cache, excluding the rollback records.
+func dumpTestCache(t *testing.T, c *CacheManager) map[string][]byte {
+	dump := make(map[string][]byte)
+	it := c.cacheDb.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		if strings.HasPrefix(string(it.Key()), "block-undo-") {
+			continue
+		}
+		dump[string(it.Key())] = common.CopyBytes(it.Value())
+	}
+	if it.Error() != nil {
+		t.Fatalf("failed %v", it.Error())
+	}
+	return dump
+}
+
+func compareTestCache(t *testing.T, actual *CacheManager, expected *CacheManager) {
+	actualDump := dumpTestCache(t, actual)
+	expectedDump := dumpTestCache(t, expected)
+	if len(actualDump) != len(expectedDump) {
+		t.Fatalf("failed, key count %d, expected %d", len(actualDump), len(expectedDump))
+	}
+	for key, value := range expectedDump {
+		if bytes.Equal(actualDump[key], value) == false {
+			t.Fatalf("failed, key %s value %s, expected %s", key, actualDump[key], value)
+		}
+	}
+}
+
+func TestCacheManager_reorg(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 30, testAlice, testBob)
+
+	c := newTestCacheManager(t, chain)
+	runningSummary := c.newGenesisSummary()
+	blockNumber := syncTestCacheManager(t, c, 0, runningSummary)
+	if blockNumber != 30 {
+		t.Fatalf("failed %d", blockNumber)
+	}
+
+	//Switch to a longer fork after block 25; the new blocks go to a different account and start a new page for alice
+	chain.extend(25, 10, testAlice, testCarol)
+
+	blockNumber = syncTestCacheManager(t, c, blockNumber, runningSummary)
+	if blockNumber != 35 {
+		t.Fatalf("failed %d", blockNumber)
+	}
+
+	response, err := c.ListTransactionByAccount(testBob, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if response.PageCount != 2 || len(response.Items) != 5 {
+		t.Fatalf("failed %d %d", response.PageCount, len(response.Items))
+	}
+	response, err = c.ListTransactionByAccount(testCarol, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if response.PageCount != 1 || len(response.Items) != 10 {
+		t.Fatalf("failed %d %d", response.PageCount, len(response.Items))
+	}
+	if response.Items[0].BlockNumber != 35 {
+		t.Fatalf("failed %d", response.Items[0].BlockNumber)
+	}
+	if runningSummary.BlockNumber != 35 {
+		t.Fatalf("failed %d", runningSummary.BlockNumber)
+	}
+
+	//The cache must be identical to a cache that only ever indexed the new chain
+	expected := newTestCacheManager(t, chain)
+	syncTestCacheManager(t, expected, 0, expected.newGenesisSummary())
+	compareTestCache(t, c, expected)
+
+	//Switch to a shorter fork, so that the parent of the next block to index does not exist yet
+	chain.extend(20, 12, testCarol, testBob)
+	blockNumber = syncTestCacheManager(t, c, blockNumber, runningSummary)
+	if blockNumber != 32 {
+		t.Fatalf("failed %d", blockNumber)
+	}
+
+	expected = newTestCacheManager(t, chain)
+	syncTestCacheManager(t, expected, 0, expected.newGenesisSummary())
+	compareTestCache(t, c, expected)
+
+	summary, err := c.getSummaryFromDb()
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if *summary != *runningSummary {
+		t.Fatalf("failed")
+	}
+}
+
+func TestCacheManager_reorgTooDeep(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, int(MaxReorgDepth)+10, testAlice, testBob)
+
+	c := newTestCacheManager(t, chain)
+	runningSummary := c.newGenesisSummary()
+	blockNumber := syncTestCacheManager(t, c, 0, runningSummary)
+
+	chain.extend(5, int(MaxReorgDepth)+20, testAlice, testCarol)
+
+	lastBlockNumber, err := c.processNextBlock(blockNumber, runningSummary)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if lastBlockNumber != blockNumber-MaxReorgDepth {
+		t.Fatalf("failed %d", lastBlockNumber)
+	}
+
+	lastBlockDb, err := c.getLastBlockNumberByDb(LastBlockKey)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if lastBlockDb != lastBlockNumber || runningSummary.BlockNumber != lastBlockNumber {
+		t.Fatalf("failed %d %d", lastBlockDb, runningSummary.BlockNumber)
+	}
+}
+
+func TestCacheManager_transactionReceiptsFallback(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 3*int(PrefetchDepth), testAlice, testBob)
+
+	expected := newTestCacheManager(t, chain)
+	syncTestCacheManager(t, expected, 0, expected.newGenesisSummary())
+	if expected.blockReceiptsUnsupported != 0 {
+		t.Fatalf("failed")
+	}
+
+	chain.blockReceiptsUnsupported = true
+	c := newTestCacheManager(t, chain)
+	blockNumber := syncTestCacheManager(t, c, 0, c.newGenesisSummary())
+	if blockNumber != 3*PrefetchDepth {
+		t.Fatalf("failed %d", blockNumber)
+	}
+	if c.blockReceiptsUnsupported != 1 {
+		t.Fatalf("failed")
+	}
+	compareTestCache(t, c, expected)
+}
+
+func TestCacheManager_receiptMismatch(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 2, testAlice, testBob)
+	chain.blockReceiptsUnsupported = true
+
+	//The receipt belongs to another block with the same transaction
+	tx := chain.blocks[2].Transactions()[0]
+	receipt := *chain.receipts[tx.Hash()]
+	receipt.BlockHash = common.BytesToHash([]byte{1})
+	chain.receipts[tx.Hash()] = &receipt
+
+	c := newTestCacheManager(t, chain)
+	runningSummary := c.newGenesisSummary()
+	blockNumber, err := c.processNextBlock(0, runningSummary)
+	if err != nil || blockNumber != 1 {
+		t.Fatalf("failed %d %v", blockNumber, err)
+	}
+	blockNumber, err = c.processNextBlock(blockNumber, runningSummary)
+	if errors.Is(err, ReceiptMismatchErr) == false || blockNumber != 1 {
+		t.Fatalf("failed %d %v", blockNumber, err)
+	}
+	if len(c.prefetcher.pending) != 0 {
+		t.Fatalf("failed %d", len(c.prefetcher.pending))
+	}
+}
+
+func TestCacheManager_indexEvents(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 3, testAlice, testBob)
+
+	c := newTestCacheManager(t, chain)
+	events := make(chan IndexEvent, 100)
+	sub := c.SubscribeIndexEvents(events)
+	defer sub.Unsubscribe()
+
+	runningSummary := c.newGenesisSummary()
+	blockNumber := syncTestCacheManager(t, c, 0, runningSummary)
+	for i := uint64(1); i <= blockNumber; i++ {
+		indexEvent := <-events
+		if indexEvent.RolledBack || indexEvent.Block.BlockNumber != i || indexEvent.Block.Hash != chain.blocks[i].Hash().Hex() {
+			t.Fatalf("failed %v", indexEvent.Block)
+		}
+		if len(indexEvent.Transactions) != 1 || indexEvent.Block.TransactionCount != 1 {
+			t.Fatalf("failed %d", len(indexEvent.Transactions))
+		}
+		indexed := indexEvent.Transactions[0]
+		if indexed.Transaction.Hash != chain.blocks[i].Transactions()[0].Hash().Hex() {
+			t.Fatalf("failed %s", indexed.Transaction.Hash)
+		}
+		alice := strings.ToLower(testAlice.Hex())
+		bob := strings.ToLower(testBob.Hex())
+		if len(indexed.Accounts) != 2 || indexed.Accounts[0] != alice || indexed.Accounts[1] != bob {
+			t.Fatalf("failed %v", indexed.Accounts)
+		}
+	}
+
+	//Replace the last two blocks
+	rolledBack := []*types.Block{chain.blocks[3], chain.blocks[2]}
+	chain.extend(1, 3, testAlice, testCarol)
+	blockNumber = syncTestCacheManager(t, c, blockNumber, runningSummary)
+	for _, block := range rolledBack {
+		indexEvent := <-events
+		if indexEvent.RolledBack == false || indexEvent.Block.BlockNumber != block.NumberU64() || indexEvent.Block.Hash != block.Hash().Hex() {
+			t.Fatalf("failed %v", indexEvent.Block)
+		}
+	}
+	for i := uint64(2); i <= blockNumber; i++ {
+		indexEvent := <-events
+		if indexEvent.RolledBack || indexEvent.Block.Hash != chain.blocks[i].Hash().Hex() {
+			t.Fatalf("failed %v", indexEvent.Block)
+		}
+	}
+	if len(events) != 0 {
+		t.Fatalf("failed %d", len(events))
+	}
+}
+
+func TestCacheManager_blocks(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, int(PageSize)+5, testAlice, testBob)
+	chain.appendBlock(&testTransaction{from: testAlice, to: &testBob}, &testTransaction{from: testBob, to: &testCarol})
+
+	c := newTestCacheManager(t, chain)
+	runningSummary := c.newGenesisSummary()
+	blockNumber := syncTestCacheManager(t, c, 0, runningSummary)
+
+	block := chain.blocks[blockNumber]
+	response, err := c.GetBlockByNumber(blockNumber)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	details := response.Result
+	if details.Hash != block.Hash().Hex() || details.ParentHash != block.ParentHash().Hex() || details.TransactionCount != 2 {
+		t.Fatalf("failed %v", details)
+	}
+	if len(details.Transactions) != 2 || details.Transactions[1] != block.Transactions()[1].Hash().Hex() {
+		t.Fatalf("failed %v", details.Transactions)
+	}
+	consensus := details.Consensus
+	if consensus == nil || consensus.Proposer != strings.ToLower(testCarol.Hex()) || consensus.Round != 1 || consensus.VoteType != BLOCK_VOTE_TYPE_OK {
+		t.Fatalf("failed %v", consensus)
+	}
+
+	response, err = c.GetBlockByHash(block.Hash())
+	if err != nil || response.Result.BlockNumber != blockNumber {
+		t.Fatalf("failed %v %d", err, response.Result.BlockNumber)
+	}
+	if _, err = c.GetBlockByNumber(blockNumber + 1); err != BlockNotFoundErr {
+		t.Fatalf("failed %v", err)
+	}
+
+	transactions, err := c.ListBlockTransactions(blockNumber, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if transactions.PageCount != 1 || len(transactions.Items) != 2 || transactions.Items[1].From != strings.ToLower(testBob.Hex()) {
+		t.Fatalf("failed %v", transactions)
+	}
+
+	blocks, err := c.ListBlocks(0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if blocks.PageCount != 2 || len(blocks.Items) != 6 || blocks.Items[5].BlockNumber != blockNumber {
+		t.Fatalf("failed %d %d", blocks.PageCount, len(blocks.Items))
+	}
+	blocks, err = c.ListBlocks(1)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(blocks.Items) != int(PageSize) || blocks.Items[0].BlockNumber != 1 {
+		t.Fatalf("failed %d", len(blocks.Items))
+	}
+
+	//The blocks of a rolled back fork are no longer found by their hash
+	chain.extend(blockNumber-2, 3, testAlice, testCarol)
+	syncTestCacheManager(t, c, blockNumber, runningSummary)
+	if _, err = c.GetBlockByHash(block.Hash()); err != BlockNotFoundErr {
+		t.Fatalf("failed %v", err)
+	}
+	response, err = c.GetBlockByNumber(blockNumber)
+	if err != nil || response.Result.Hash != chain.blocks[blockNumber].Hash().Hex() || response.Result.TransactionCount != 1 {
+		t.Fatalf("failed %v %v", err, response.Result)
+	}
+}
+
+func TestCacheManager_stakingHistory(t *testing.T) {
+	chain := newTestChain()
+	chain.nilBlocks[3] = true
+	chain.extend(0, int(PageSize)+2, testAlice, testBob)
+
+	c := newTestCacheManager(t, chain)
+	runningSummary := c.newGenesisSummary()
+	blockNumber := syncTestCacheManager(t, c, 0, runningSummary)
+
+	//A reward for each block, except block 3 in which testCarol is slashed
+	response, err := c.ListStakingHistory(testDave, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if response.PageCount != 2 || len(response.Items) != 2 {
+		t.Fatalf("failed %d %d", response.PageCount, len(response.Items))
+	}
+	latest := response.Items[0]
+	if latest.BlockNumber != blockNumber || latest.TransactionType != string(BLOCK_REWARD) || latest.Value != "0x64" {
+		t.Fatalf("failed %v", latest)
+	}
+	if latest.From != strings.ToLower(testCarol.Hex()) || latest.To != strings.ToLower(testDave.Hex()) {
+		t.Fatalf("failed %v", latest)
+	}
+	response, err = c.ListStakingHistory(testDave, 1)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	slashing := response.Items[len(response.Items)-3]
+	if slashing.BlockNumber != 3 || slashing.TransactionType != string(SLASHING) || slashing.Value != "0x5" {
+		t.Fatalf("failed %v", slashing)
+	}
+
+	//The rewards are not part of the account transactions
+	transactions, err := c.ListTransactionByAccount(testDave, 0)
+	if err != nil || transactions.PageCount != 0 {
+		t.Fatalf("failed %v %d", err, transactions.PageCount)
+	}
+
+	//Rolled back blocks are removed from the history
+	chain.extend(blockNumber-3, 1, testAlice, testBob)
+	blockNumber = syncTestCacheManager(t, c, blockNumber, runningSummary)
+	expected := newTestCacheManager(t, chain)
+	syncTestCacheManager(t, expected, 0, expected.newGenesisSummary())
+	compareTestCache(t, c, expected)
+	response, err = c.ListStakingHistory(testDave, 0)
+	if err != nil || response.PageCount != 1 || len(response.Items) != int(PageSize) {
+		t.Fatalf("failed %v %d %d", err, response.PageCount, len(response.Items))
+	}
+}
+
+func TestCacheManager_queryAccountTransactions(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 30, testAlice, testBob)
+	for i := 0; i < 10; i++ {
+		chain.appendBlock(&testTransaction{from: testBob, to: &testAlice})
+	}
+
+	c := newTestCacheManager(t, chain)
+	runningSummary := c.newGenesisSummary()
+	blockNumber := syncTestCacheManager(t, c, 0, runningSummary)
+
+	checkBlocks := func(response QueryAccountTransactionsResponse, first uint64, last uint64) {
+		count := int(last) - int(first)
+		if count < 0 {
+			count = -count
+		}
+		if len(response.Items) != count+1 || response.Items[0].BlockNumber != first || response.Items[count].BlockNumber != last {
+			t.Fatalf("failed %d %v", len(response.Items), response.Items)
+		}
+	}
+
+	response, err := c.QueryAccountTransactions(testAlice, AccountTransactionQuery{})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	checkBlocks(response, 40, 21)
+	if len(response.NextCursor) == 0 {
+		t.Fatalf("failed")
+	}
+
+	//New transactions do not move the cursor
+	chain.extend(blockNumber, 5, testAlice, testBob)
+	blockNumber = syncTestCacheManager(t, c, blockNumber, runningSummary)
+	response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{Cursor: response.NextCursor})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	checkBlocks(response, 20, 1)
+	if len(response.NextCursor) != 0 {
+		t.Fatalf("failed %s", response.NextCursor)
+	}
+
+	response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{Order: ORDER_OLDEST_FIRST, Limit: 5})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	checkBlocks(response, 1, 5)
+	response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{Order: ORDER_OLDEST_FIRST, Limit: MaxQueryLimit, Cursor: response.NextCursor})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	checkBlocks(response, 6, blockNumber)
+
+	response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{Direction: DIRECTION_IN})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	checkBlocks(response, 40, 31)
+	for _, transaction := range response.Items {
+		if transaction.To != strings.ToLower(testAlice.Hex()) {
+			t.Fatalf("failed %v", transaction)
+		}
+	}
+
+	response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{Direction: DIRECTION_OUT, FromBlock: 28, ToBlock: 42})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(response.Items) != 5 || response.Items[0].BlockNumber != 42 || response.Items[2].BlockNumber != 30 || response.Items[4].BlockNumber != 28 {
+		t.Fatalf("failed %v", response.Items)
+	}
+
+	//The block times are 1700000000 plus the block number, since each block has one transaction
+	response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{
+		Order:    ORDER_OLDEST_FIRST,
+		FromTime: time.Unix(1700000010, 0),
+		ToTime:   time.Unix(1700000012, 0),
+	})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	checkBlocks(response, 10, 12)
+
+	response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{TransactionType: string(SMART_CONTRACT)})
+	if err != nil || len(response.Items) != 0 || len(response.NextCursor) != 0 {
+		t.Fatalf("failed %v %v", err, response)
+	}
+	response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{TransactionType: string(COIN_TRANSFER), Status: "0x1", Limit: 3})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	checkBlocks(response, blockNumber, blockNumber-2)
+
+	//A query stops after MaxQueryScan transactions, with a cursor to continue from
+	maxQueryScan := MaxQueryScan
+	MaxQueryScan = 5
+	defer func() { MaxQueryScan = maxQueryScan }()
+	response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{Direction: DIRECTION_IN, Order: ORDER_OLDEST_FIRST})
+	if err != nil || len(response.Items) != 0 || len(response.NextCursor) == 0 {
+		t.Fatalf("failed %v %v", err, response)
+	}
+	for i := 0; i < 10 && len(response.Items) == 0; i++ {
+		response, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{Direction: DIRECTION_IN, Order: ORDER_OLDEST_FIRST, Cursor: response.NextCursor})
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+	}
+	if len(response.Items) != 5 || response.Items[0].BlockNumber != 31 {
+		t.Fatalf("failed %v", response.Items)
+	}
+
+	_, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{Cursor: "0x1000"})
+	if errors.Is(err, InvalidCursorErr) == false {
+		t.Fatalf("failed %v", err)
+	}
+	_, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{Order: "random"})
+	if errors.Is(err, InvalidQueryErr) == false {
+		t.Fatalf("failed %v", err)
+	}
+	_, err = c.QueryAccountTransactions(testAlice, AccountTransactionQuery{FromBlock: 10, ToBlock: 5})
+	if errors.Is(err, InvalidQueryErr) == false {
+		t.Fatalf("failed %v", err)
+	}
+}
+
+func TestCacheManager_internalTransfers(t *testing.T) {
+	contract := common.BytesToAddress([]byte{0xcc})
+	newCall := func(from common.Address, to common.Address, value int64, calls ...*ethclient.CallFrame) *ethclient.CallFrame {
+		return &ethclient.CallFrame{Type: "CALL", From: from, To: to, Value: (*hexutil.Big)(big.NewInt(value)), Calls: calls}
+	}
+	reverted := newCall(contract, testDave, 3, newCall(testDave, testAlice, 1))
+	reverted.Error = "execution reverted"
+	delegateCall := newCall(contract, testAlice, 4)
+	delegateCall.Type = "DELEGATECALL"
+
+	chain := newTestChain()
+	chain.extend(0, 2, testAlice, testBob)
+	chain.appendBlock(&testTransaction{from: testAlice, to: &contract, data: []byte{1}, calls: []*ethclient.CallFrame{
+		newCall(contract, testBob, 7),
+		reverted,
+		delegateCall,
+		newCall(contract, testCarol, 0, newCall(testCarol, testDave, 2)),
+	}})
+	chain.extend(3, 2, testAlice, testBob)
+
+	c := newTestCacheManager(t, chain)
+	runningSummary := c.newGenesisSummary()
+	blockNumber := syncTestCacheManager(t, c, 0, runningSummary)
+
+	response, err := c.ListTransactionByAccount(testBob, 0)
+	if err != nil || len(response.Items) != 5 {
+		t.Fatalf("failed %v %v", err, response.Items)
+	}
+	transfer := response.Items[2]
+	if transfer.Internal == false || transfer.BlockNumber != 3 || transfer.Value != "0x7" || transfer.TransactionType != string(COIN_TRANSFER) {
+		t.Fatalf("failed %v", transfer)
+	}
+	if transfer.From != strings.ToLower(contract.Hex()) || transfer.Hash != chain.blocks[3].Transactions()[0].Hash().Hex() {
+		t.Fatalf("failed %v", transfer)
+	}
+
+	//Reverted calls and calls without value are skipped, calls made by other contracts are not
+	response, err = c.ListTransactionByAccount(testDave, 0)
+	if err != nil || len(response.Items) != 1 {
+		t.Fatalf("failed %v %v", err, response.Items)
+	}
+	if response.Items[0].From != strings.ToLower(testCarol.Hex()) || response.Items[0].Value != "0x2" {
+		t.Fatalf("failed %v", response.Items[0])
+	}
+	response, err = c.ListTransactionByAccount(contract, 0)
+	if err != nil || len(response.Items) != 2 || response.Items[0].Internal == response.Items[1].Internal {
+		t.Fatalf("failed %v %v", err, response.Items)
+	}
+	response, err = c.ListTransactionByAccount(testAlice, 0)
+	if err != nil || len(response.Items) != 5 {
+		t.Fatalf("failed %v %v", err, response.Items)
+	}
+
+	//Rolled back blocks are removed from the history
+	chain.extend(2, 4, testAlice, testBob)
+	syncTestCacheManager(t, c, blockNumber, runningSummary)
+	expected := newTestCacheManager(t, chain)
+	syncTestCacheManager(t, expected, 0, expected.newGenesisSummary())
+	compareTestCache(t, c, expected)
+
+	//Without debug_traceTransaction, only the transactions are indexed
+	chain.extend(2, 0, testAlice, testBob)
+	chain.appendBlock(&testTransaction{from: testAlice, to: &contract, data: []byte{1}, calls: []*ethclient.CallFrame{newCall(contract, testBob, 7)}})
+	chain.traceUnsupported = true
+	c = newTestCacheManager(t, chain)
+	syncTestCacheManager(t, c, 0, c.newGenesisSummary())
+	if c.traceUnsupported != 1 {
+		t.Fatalf("failed")
+	}
+	response, err = c.ListTransactionByAccount(testBob, 0)
+	if err != nil || len(response.Items) != 2 {
+		t.Fatalf("failed %v %v", err, response.Items)
+	}
+}
+
+func TestCacheManager_remarks(t *testing.T) {
+	chain := newTestChain()
+	chain.extend(0, 2, testAlice, testBob)
+	chain.appendBlock(
+		&testTransaction{from: testAlice, to: &testBob, remarks: []byte("alice")},
+		&testTransaction{from: testCarol, to: &testBob, remarks: []byte("carol")},
+		&testTransaction{from: testAlice, to: &testCarol, remarks: []byte("alice")},
+	)
+	chain.appendBlock(&testTransaction{from: testAlice, to: &testBob, remarks: []byte("alice")})
+
+	c := newTestCacheManager(t, chain)
+	runningSummary := c.newGenesisSummary()
+	blockNumber := syncTestCacheManager(t, c, 0, runningSummary)
+
+	response, err := c.ListTransactionsByRemarks(testBob, hexutil.Encode([]byte("alice")), 0)
+	if err != nil || response.PageCount != 1 || len(response.Items) != 2 {
+		t.Fatalf("failed %v %v", err, response.Items)
+	}
+	for _, transaction := range response.Items {
+		if transaction.From != strings.ToLower(testAlice.Hex()) || transaction.Remarks != hexutil.Encode([]byte("alice")) {
+			t.Fatalf("failed %v", transaction)
+		}
+	}
+	if response.Items[0].BlockNumber != 4 || response.Items[1].BlockNumber != 3 {
+		t.Fatalf("failed %v", response.Items)
+	}
+
+	//Only transactions received by the account are listed
+	response, err = c.ListTransactionsByRemarks(testAlice, hexutil.Encode([]byte("alice")), 0)
+	if err != nil || response.PageCount != 0 {
+		t.Fatalf("failed %v %v", err, response)
+	}
+	response, err = c.ListTransactionsByRemarks(testBob, "0x0102", 0)
+	if err != nil || response.PageCount != 0 {
+		t.Fatalf("failed %v %v", err, response)
+	}
+
+	transactions, err := c.ListTransactionByAccount(testBob, 0)
+	if err != nil || transactions.Items[0].Remarks != hexutil.Encode([]byte("alice")) || len(transactions.Items[len(transactions.Items)-1].Remarks) != 0 {
+		t.Fatalf("failed %v %v", err, transactions.Items)
+	}
+
+	for _, remarks := range []string{"", "0x", "alice", hexutil.Encode(make([]byte, types.MAX_REMARKS_LENGTH+1))} {
+		_, err = c.ListTransactionsByRemarks(testBob, remarks, 0)
+		if errors.Is(err, InvalidRemarksErr) == false {
+			t.Fatalf("failed %s %v", remarks, err)
+		}
+	}
+
+	//Rolled back blocks are removed from the lists
+	chain.extend(3, 2, testAlice, testBob)
+	syncTestCacheManager(t, c, blockNumber, runningSummary)
+	expected := newTestCacheManager(t, chain)
+	syncTestCacheManager(t, expected, 0, expected.newGenesisSummary())
+	compareTestCache(t, c, expected)
+	response, err = c.ListTransactionsByRemarks(testBob, hexutil.Encode([]byte("alice")), 0)
+	if err != nil || len(response.Items) != 1 {
+		t.Fatalf("failed %v %v", err, response.Items)
+	}
+}
+
+func newTestTransferLog(token common.Address, from common.Address, to common.Address, amount int64) *types.Log {
+	return &types.Log{
+		Address: token,
+		Topics:  []common.Hash{TRANSFER_EVENT_TOPIC, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:    common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+	}
+}
+
+func TestCacheManager_tokens(t *testing.T) {
+	token := common.BytesToAddress([]byte{0x70})
+	router := common.BytesToAddress([]byte{0x80})
+
+	symbol := make([]byte, 96)
+	symbol[31] = 32
+	symbol[63] = 3
+	copy(symbol[64:], "TKN")
+	chain := newTestChain()
+	balanceOf := func(account common.Address) string {
+		return hexutil.Encode(append(common.CopyBytes(tokenBalanceOfMethod), account.Bytes()...))
+	}
+	chain.contracts[token] = map[string][]byte{
+		hexutil.Encode(tokenSymbolMethod):      symbol,
+		hexutil.Encode(tokenDecimalsMethod):    common.LeftPadBytes([]byte{18}, 32),
+		hexutil.Encode(tokenTotalSupplyMethod): common.LeftPadBytes([]byte{100}, 32),
+		balanceOf(testAlice):                   common.LeftPadBytes([]byte{95}, 32),
+		balanceOf(testBob):                     common.LeftPadBytes([]byte{12}, 32),
+		balanceOf(testCarol):                   make([]byte, 32),
+	}
+	chain.contracts[router] = map[string][]byte{}
+
+	//Token creation, with the initial supply minted to alice
+	chain.appendBlock(&testTransaction{
+		from:            testAlice,
+		data:            []byte{1},
+		contractAddress: token,
+		logs:            []*types.Log{newTestTransferLog(token, common.ZERO_ADDRESS, testAlice, 100)},
+	})
+	//Transfer from alice to bob
+	chain.appendBlock(&testTransaction{
+		from: testAlice,
+		to:   &token,
+		data: []byte{2},
+		logs: []*types.Log{newTestTransferLog(token, testAlice, testBob, 5)},
+	})
+	//Contract call by alice that transfers the tokens of carol to bob
+	chain.appendBlock(&testTransaction{
+		from: testAlice,
+		to:   &router,
+		data: []byte{3},
+		logs: []*types.Log{
+			newTestTransferLog(token, testCarol, testBob, 7),
+			newTestTransferLog(router, testCarol, testBob, 7),
+		},
+	})
+
+	c := newTestCacheManager(t, chain)
+	syncTestCacheManager(t, c, 0, c.newGenesisSummary())
+
+	tokenAddress := strings.ToLower(token.Hex())
+	bob := strings.ToLower(testBob.Hex())
+	carol := strings.ToLower(testCarol.Hex())
+
+	response, err := c.ListTransactionByAccount(testAlice, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(response.Items) != 4 {
+		t.Fatalf("failed %d", len(response.Items))
+	}
+	//most recent first
+	if response.Items[3].TransactionType != string(NEW_TOKEN) || response.Items[3].TokenSymbol != "TKN" ||
+		response.Items[3].TokenAddress != tokenAddress || *response.Items[3].TokenDecimals != 18 {
+		t.Fatalf("failed %v", response.Items[3])
+	}
+	if response.Items[2].TransactionType != string(TOKEN_TRANSFER) || response.Items[2].TokenAmount != "0x64" {
+		t.Fatalf("failed %v", response.Items[2])
+	}
+	if response.Items[1].TransactionType != string(TOKEN_TRANSFER) || response.Items[1].To != bob ||
+		response.Items[1].TokenAmount != "0x5" || len(response.Items[1].TxnFee) == 0 {
+		t.Fatalf("failed %v", response.Items[1])
+	}
+	if response.Items[0].TransactionType != string(SMART_CONTRACT) || len(response.Items[0].TokenAddress) != 0 {
+		t.Fatalf("failed %v", response.Items[0])
+	}
+
+	response, err = c.ListTransactionByAccount(testBob, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(response.Items) != 2 {
+		t.Fatalf("failed %d", len(response.Items))
+	}
+	if response.Items[0].TransactionType != string(TOKEN_TRANSFER) || response.Items[0].From != carol ||
+		response.Items[0].To != bob || response.Items[0].TokenAmount != "0x7" {
+		t.Fatalf("failed %v", response.Items[0])
+	}
+
+	response, err = c.ListTransactionByAccount(testCarol, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(response.Items) != 1 || response.Items[0].TransactionType != string(TOKEN_TRANSFER) {
+		t.Fatalf("failed %v", response.Items)
+	}
+
+	response, err = c.ListTransactionByAccount(token, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(response.Items) != 1 {
+		t.Fatalf("failed %d", len(response.Items))
+	}
+
+	response, err = c.ListTransactionByAccount(common.ZERO_ADDRESS, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if response.PageCount != 0 {
+		t.Fatalf("failed %d", response.PageCount)
+	}
+
+	tokens, err := c.ListAccountTokens(testAlice)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(tokens.Items) != 1 || tokens.Items[0].TokenAddress != tokenAddress || tokens.Items[0].Balance != "0x5f" ||
+		tokens.Items[0].Symbol != "TKN" || tokens.Items[0].Decimals != 18 {
+		t.Fatalf("failed %v", tokens.Items)
+	}
+	tokens, err = c.ListAccountTokens(testCarol)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(tokens.Items) != 0 {
+		t.Fatalf("failed %v", tokens.Items)
+	}
+
+	details, err := c.GetTokenDetails(token)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if details.Result.Symbol != "TKN" || details.Result.TotalSupply != "0x64" {
+		t.Fatalf("failed %v", details.Result)
+	}
+	_, err = c.GetTokenDetails(router)
+	if errors.Is(err, TokenNotFoundErr) == false {
+		t.Fatalf("expected token not found, got %v", err)
+	}
+
+	transfers, err := c.ListTokenTransfers(token, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if transfers.PageCount != 1 || len(transfers.Items) != 3 {
+		t.Fatalf("failed %d %d", transfers.PageCount, len(transfers.Items))
+	}
+	if transfers.Items[0].From != carol || transfers.Items[2].TokenAmount != "0x64" {
+		t.Fatalf("failed %v", transfers.Items)
+	}
+
+	//Token details, balances and transfers are rolled back with the blocks
+	chain.blocks = chain.blocks[:1]
+	chain.appendBlock(&testTransaction{from: testAlice, to: &testBob})
+	chain.appendBlock(&testTransaction{from: testAlice, to: &testBob})
+	chain.appendBlock(&testTransaction{from: testAlice, to: &testBob})
+	chain.appendBlock(&testTransaction{from: testAlice, to: &testBob})
+	blockNumber := syncTestCacheManager(t, c, 3, c.newGenesisSummary())
+	if blockNumber != 4 {
+		t.Fatalf("failed %d", blockNumber)
+	}
+	ok, err := c.cacheDb.Has(getTokenInfoKey(tokenAddress))
+	if err != nil || ok {
+		t.Fatalf("failed %v", err)
+	}
+	tokens, err = c.ListAccountTokens(testAlice)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(tokens.Items) != 0 {
+		t.Fatalf("failed %v", tokens.Items)
+	}
+	transfers, err = c.ListTokenTransfers(token, 0)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if transfers.PageCount != 0 {
+		t.Fatalf("failed %d", transfers.PageCount)
+	}
+
+	expected := newTestCacheManager(t, chain)
+	syncTestCacheManager(t, expected, 0, expected.newGenesisSummary())
+	compareTestCache(t, c, expected)
+}
+
+func TestCacheManager_tokenCallError(t *testing.T) {
+	contract := common.BytesToAddress([]byte{0x70})
+	chain := newTestChain()
+	chain.contracts[contract] = map[string][]byte{}
+	c := newTestCacheManager(t, chain)
+
+	//A reverted call means that the contract is not a token
+	newTokens := make(map[string]*TokenInfo)
+	tokenInfo, err := c.getTokenInfo(contract, big.NewInt(0), newTokens)
+	if err != nil || tokenInfo.IsToken {
+		t.Fatalf("failed %v %v", err, tokenInfo)
+	}
+
+	//Other errors of the node are returned, and nothing is cached, so that the block is indexed again
+	chain.callErr = &testNodeError{}
+	newTokens = make(map[string]*TokenInfo)
+	_, err = c.getTokenInfo(contract, big.NewInt(0), newTokens)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	if len(newTokens) != 0 {
+		t.Fatalf("failed %v", newTokens)
+	}
+}
+
+func TestDecodeTokenString(t *testing.T) {
+	bytes32 := make([]byte, 32)
+	copy(bytes32, "MKR")
+	value, ok := decodeTokenString(bytes32)
+	if ok == false || value != "MKR" {
+		t.Fatalf("failed %s", value)
+	}
+
+	invalid := make([]byte, 64)
+	invalid[31] = 64
+	_, ok = decodeTokenString(invalid)
+	if ok {
+		t.Fatalf("failed")
+	}
+
+	invalid[31] = 32
+	invalid[63] = 1
+	_, ok = decodeTokenString(invalid)
+	if ok {
+		t.Fatalf("failed")
+	}
+}
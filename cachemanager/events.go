@@ -0,0 +1,38 @@
+package cachemanager
+
+import (
+	"github.com/QuantumCoinProject/qc/event"
+)
+
+// BlockEvent describes an indexed or rolled back block.
+type BlockEvent struct {
+	BlockNumber uint64 `json:"blockNumber"`
+
+	Hash string `json:"hash,omitempty"`
+
+	ParentHash string `json:"parentHash,omitempty"`
+
+	CreatedAt string `json:"createdAt,omitempty"`
+
+	TransactionCount int `json:"transactionCount"`
+}
+
+// IndexedTransaction is a transaction entry of an indexed block, with the addresses it was indexed under.
+type IndexedTransaction struct {
+	Transaction AccountTransactionCompact
+	Accounts    []string
+}
+
+// IndexEvent is sent to the subscribers after a block was indexed, or after it was rolled back during a chain
+// reorganisation. Rolled back blocks have no transactions.
+type IndexEvent struct {
+	Block        BlockEvent
+	RolledBack   bool
+	Transactions []IndexedTransaction
+}
+
+// SubscribeIndexEvents subscribes to the blocks indexed and rolled back by the cache manager. Indexing waits for the
+// subscribers to receive each event, so the channel should be buffered and drained promptly.
+func (c *CacheManager) SubscribeIndexEvents(ch chan<- IndexEvent) event.Subscription {
+	return c.indexFeed.Subscribe(ch)
+}
@@ -0,0 +1,102 @@
+package cachemanager
+
+import (
+	"context"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/ethclient"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/params"
+	"strings"
+	"sync/atomic"
+)
+
+// needsTrace returns whether a transaction can have made internal value transfers. A failed transaction has no
+// transfers, and a transaction that only used the intrinsic gas of a transfer did not execute any contract code.
+func needsTrace(tx *types.Transaction, receipt *types.Receipt) bool {
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return false
+	}
+	return tx.To() == nil || receipt.GasUsed > params.TxGas
+}
+
+// getBlockTraces returns the call traces of the transactions in the block, indexed like the transactions. The trace of
+// a transaction that does not need one is nil. No traces are returned if the node does not support
+// debug_traceTransaction.
+func (c *CacheManager) getBlockTraces(block *types.Block, receipts types.Receipts) ([]*ethclient.CallFrame, error) {
+	if atomic.LoadInt32(&c.traceUnsupported) == 1 {
+		return nil, nil
+	}
+
+	txs := block.Transactions()
+	txHashes := make([]common.Hash, 0)
+	txIndexes := make([]int, 0)
+	for i, tx := range txs {
+		if needsTrace(tx, receipts[i]) {
+			txHashes = append(txHashes, tx.Hash())
+			txIndexes = append(txIndexes, i)
+		}
+	}
+	if len(txHashes) == 0 {
+		return nil, nil
+	}
+
+	results, err := c.client.TraceTransactions(context.Background(), txHashes)
+	if err != nil {
+		if isMethodNotFound(err) {
+			log.Warn("debug_traceTransaction is not supported by the node, internal transfers are not indexed")
+			atomic.StoreInt32(&c.traceUnsupported, 1)
+			return nil, nil
+		}
+		log.Error("getBlockTraces TraceTransactions", "error", err, "Block number", block.NumberU64())
+		return nil, err
+	}
+	if len(results) != len(txHashes) {
+		return nil, fmt.Errorf("%d traces for %d transactions", len(results), len(txHashes))
+	}
+
+	traces := make([]*ethclient.CallFrame, len(txs))
+	for i, index := range txIndexes {
+		traces[index] = results[i]
+	}
+	return traces, nil
+}
+
+// getInternalTransfers returns the calls of a transaction trace that transferred coins, in the order of execution.
+// Calls that were reverted, along with the calls they made, are skipped. The top level call is the transaction itself
+// and is not returned.
+func getInternalTransfers(trace *ethclient.CallFrame) []*ethclient.CallFrame {
+	transfers := make([]*ethclient.CallFrame, 0)
+	if trace == nil || len(trace.Error) > 0 {
+		return transfers
+	}
+	for _, call := range trace.Calls {
+		if len(call.Error) > 0 {
+			continue
+		}
+		switch call.Type {
+		case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+			if call.Value != nil && call.Value.ToInt().Sign() > 0 && call.From != call.To {
+				transfers = append(transfers, call)
+			}
+		}
+		transfers = append(transfers, getInternalTransfers(call)...)
+	}
+	return transfers
+}
+
+// newInternalTransfer returns the coin transfer made by a call of the transaction.
+func newInternalTransfer(transaction AccountTransactionCompact, call *ethclient.CallFrame) AccountTransactionCompact {
+	var transfer AccountTransactionCompact
+	transfer.Hash = transaction.Hash
+	transfer.BlockNumber = transaction.BlockNumber
+	transfer.CreatedAt = transaction.CreatedAt
+	transfer.From = strings.ToLower(call.From.Hex())
+	transfer.To = strings.ToLower(call.To.Hex())
+	transfer.Value = common.BigIntToHexString(call.Value.ToInt())
+	transfer.Status = transaction.Status
+	transfer.TransactionType = string(COIN_TRANSFER)
+	transfer.Internal = true
+	return transfer
+}
@@ -0,0 +1,218 @@
+package cachemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/ethclient"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/metrics"
+	"github.com/QuantumCoinProject/qc/rpc"
+	"math/big"
+	"sync/atomic"
+	"time"
+)
+
+// PrefetchDepth is the number of blocks, starting at the next block to index, that are fetched from the node concurrently.
+const PrefetchDepth uint64 = 8
+
+// HeadRefreshInterval is how often the latest block number of the node is refreshed.
+const HeadRefreshInterval = 5 * time.Second
+
+var ReceiptMismatchErr = errors.New("receipts do not match block")
+
+var (
+	indexedBlockGauge = metrics.NewRegisteredGauge("relay/cache/block/indexed", nil)
+	headBlockGauge    = metrics.NewRegisteredGauge("relay/cache/block/head", nil)
+	blockLagGauge     = metrics.NewRegisteredGauge("relay/cache/block/lag", nil)
+	blockFetchTimer   = metrics.NewRegisteredTimer("relay/cache/block/fetch", nil)
+	blockIndexTimer   = metrics.NewRegisteredTimer("relay/cache/block/index", nil)
+	reorgMeter        = metrics.NewRegisteredMeter("relay/cache/reorg", nil)
+)
+
+// blockData is the node data needed to index a block.
+type blockData struct {
+	block         *types.Block
+	receipts      types.Receipts
+	consensusData *proofofstake.ConsensusData       //only fetched when the extended apis are enabled
+	burntCoins    *big.Int                          //only fetched when the extended apis are enabled
+	depositors    map[common.Address]common.Address //depositors of the rewarded and slashed validators
+	traces        []*ethclient.CallFrame            //call traces of the transactions, only fetched when the extended apis are enabled
+}
+
+type blockFetchResult struct {
+	data *blockData
+	err  error
+}
+
+// blockPrefetcher fetches the blocks after the next block to index concurrently, so that the blocks can be indexed
+// one after the other without waiting for the node. Blocks after the latest block of the node are not prefetched.
+type blockPrefetcher struct {
+	fetch       func(blockNumber uint64) (*blockData, error)
+	latestBlock func() (uint64, error)
+	depth       uint64
+	head        uint64
+	headTime    time.Time
+	pending     map[uint64]chan *blockFetchResult
+}
+
+func newBlockPrefetcher(c *CacheManager, depth uint64) *blockPrefetcher {
+	return &blockPrefetcher{
+		fetch: c.fetchBlockData,
+		latestBlock: func() (uint64, error) {
+			return c.client.BlockNumber(context.Background())
+		},
+		depth:   depth,
+		pending: make(map[uint64]chan *blockFetchResult),
+	}
+}
+
+// get returns the data of the block, and starts fetching the blocks after it.
+func (p *blockPrefetcher) get(blockNumber uint64) (*blockData, error) {
+	if blockNumber+p.depth-1 > p.head || time.Since(p.headTime) > HeadRefreshInterval {
+		head, err := p.latestBlock()
+		if err != nil {
+			log.Warn("blockPrefetcher latestBlock", "error", err)
+		} else {
+			p.head = head
+			p.headTime = time.Now()
+			headBlockGauge.Update(int64(head))
+		}
+	}
+
+	for number := blockNumber; number < blockNumber+p.depth; number++ {
+		if number > blockNumber && number > p.head {
+			break
+		}
+		if _, ok := p.pending[number]; ok {
+			continue
+		}
+		result := make(chan *blockFetchResult, 1)
+		p.pending[number] = result
+		go func(number uint64) {
+			data, err := p.fetch(number)
+			result <- &blockFetchResult{data: data, err: err}
+		}(number)
+	}
+
+	result := <-p.pending[blockNumber]
+	delete(p.pending, blockNumber)
+	return result.data, result.err
+}
+
+// reset discards the prefetched blocks, after waiting for the pending fetches to complete.
+func (p *blockPrefetcher) reset() {
+	for _, result := range p.pending {
+		<-result
+	}
+	p.pending = make(map[uint64]chan *blockFetchResult)
+}
+
+func (c *CacheManager) updateIndexMetrics(blockNumber uint64) {
+	indexedBlockGauge.Update(int64(blockNumber))
+	if c.prefetcher.head > blockNumber {
+		blockLagGauge.Update(int64(c.prefetcher.head - blockNumber))
+	} else {
+		blockLagGauge.Update(0)
+	}
+}
+
+// fetchBlockData fetches the block, its receipts and, when the extended apis are enabled, its consensus data, the
+// call traces of its transactions and the burnt coins at the block.
+func (c *CacheManager) fetchBlockData(blockNumber uint64) (*blockData, error) {
+	defer blockFetchTimer.UpdateSince(time.Now())
+
+	blockNum := new(big.Int).SetUint64(blockNumber)
+	block, err := c.client.BlockByNumber(context.Background(), blockNum)
+	if err != nil {
+		return nil, err
+	}
+
+	receipts, err := c.getBlockReceipts(block)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &blockData{
+		block:    block,
+		receipts: receipts,
+	}
+	if c.enableExtendedApis {
+		data.consensusData, err = c.client.GetBlockConsensusData(context.Background(), blockNum)
+		if err != nil {
+			log.Error("fetchBlockData GetBlockConsensusData", "error", err, "Block number", blockNumber)
+			return nil, err
+		}
+		data.depositors, err = c.getBlockDepositors(blockNumber, data.consensusData)
+		if err != nil {
+			log.Error("fetchBlockData getBlockDepositors", "error", err, "Block number", blockNumber)
+			return nil, err
+		}
+		data.traces, err = c.getBlockTraces(block, receipts)
+		if err != nil {
+			log.Error("fetchBlockData getBlockTraces", "error", err, "Block number", blockNumber)
+			return nil, err
+		}
+		data.burntCoins, err = c.client.BalanceAt(context.Background(), common.ZERO_ADDRESS, blockNum)
+		if err != nil {
+			log.Error("fetchBlockData BalanceAt", "error", err, "Block number", blockNumber)
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// getBlockReceipts returns the receipts of the transactions in the block with a single eth_getBlockReceipts call, or
+// with a batch of eth_getTransactionReceipt calls if the node does not support it.
+func (c *CacheManager) getBlockReceipts(block *types.Block) (types.Receipts, error) {
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		return types.Receipts{}, nil
+	}
+
+	var receipts types.Receipts
+	var err error
+	if atomic.LoadInt32(&c.blockReceiptsUnsupported) == 0 {
+		receipts, err = c.client.BlockReceipts(context.Background(), block.Hash())
+		if isMethodNotFound(err) {
+			log.Warn("eth_getBlockReceipts is not supported by the node, using batched eth_getTransactionReceipt calls")
+			atomic.StoreInt32(&c.blockReceiptsUnsupported, 1)
+		}
+	}
+	if atomic.LoadInt32(&c.blockReceiptsUnsupported) == 1 {
+		txHashes := make([]common.Hash, len(txs))
+		for i, tx := range txs {
+			txHashes[i] = tx.Hash()
+		}
+		receipts, err = c.client.TransactionReceipts(context.Background(), txHashes)
+	}
+	if err != nil {
+		if err.Error() != "not found" {
+			log.Error("getBlockReceipts", "error", err, "Block number", block.NumberU64())
+		}
+		return nil, err
+	}
+
+	if len(receipts) != len(txs) {
+		return nil, fmt.Errorf("%w: %d receipts for %d transactions", ReceiptMismatchErr, len(receipts), len(txs))
+	}
+	for i, receipt := range receipts {
+		if receipt.TxHash != txs[i].Hash() || receipt.BlockHash != block.Hash() {
+			//the block was replaced after it was fetched, retry
+			log.Warn("getBlockReceipts receipt mismatch", "tx", txs[i].Hash(), "receiptTx", receipt.TxHash,
+				"receiptBlockHash", receipt.BlockHash, "blockHash", block.Hash())
+			return nil, ReceiptMismatchErr
+		}
+	}
+
+	return receipts, nil
+}
+
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601
+}
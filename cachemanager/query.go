@@ -0,0 +1,243 @@
+package cachemanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxQueryLimit is the maximum number of transactions returned by a query.
+const MaxQueryLimit = 100
+
+// MaxQueryScan is the maximum number of transactions a query examines. A query with filters that match few
+// transactions can return less than its limit, with a cursor to continue from.
+var MaxQueryScan uint64 = 2000
+
+type QueryOrder string
+
+// List of QueryOrder
+const (
+	ORDER_NEWEST_FIRST QueryOrder = "desc"
+	ORDER_OLDEST_FIRST QueryOrder = "asc"
+)
+
+type QueryDirection string
+
+// List of QueryDirection
+const (
+	DIRECTION_ANY QueryDirection = ""
+	DIRECTION_IN  QueryDirection = "in"  //transactions to the account
+	DIRECTION_OUT QueryDirection = "out" //transactions from the account
+)
+
+var InvalidCursorErr = errors.New("invalid cursor")
+var InvalidQueryErr = errors.New("invalid query")
+
+// AccountTransactionQuery selects the transactions of an account. Zero values are not used as filters.
+type AccountTransactionQuery struct {
+	Cursor          string //cursor returned by the previous query, empty for the first query
+	Limit           int    //PageSize if 0
+	Order           QueryOrder
+	Direction       QueryDirection
+	TransactionType string
+	Status          string
+	FromBlock       uint64
+	ToBlock         uint64
+	FromTime        time.Time
+	ToTime          time.Time
+}
+
+type QueryAccountTransactionsResponse struct {
+	Items []AccountTransactionCompact `json:"items"`
+
+	// Cursor to get the next transactions with the same query, empty if there are no more transactions. The cursor is
+	// the position of a transaction in the history of the account, so new transactions do not move it.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// transactionListReader reads the transactions of an address by their position, oldest first, keeping the last read
+// page.
+type transactionListReader struct {
+	c        *CacheManager
+	keys     *transactionListKeys
+	address  string
+	pageNum  uint64
+	page     []AccountTransactionCompact
+	err      error
+	txnCount uint64
+}
+
+func (reader *transactionListReader) get(index uint64) (AccountTransactionCompact, error) {
+	pageNum := index/PageSize + 1
+	if pageNum != reader.pageNum {
+		blob, err := reader.c.cacheDb.Get(getAccountPageKey(reader.keys, reader.address, pageNum))
+		if err != nil {
+			return AccountTransactionCompact{}, err
+		}
+		var accountTransactionList AccountTransactionList
+		err = json.Unmarshal(blob, &accountTransactionList)
+		if err != nil {
+			return AccountTransactionCompact{}, err
+		}
+		reader.pageNum = pageNum
+		reader.page = accountTransactionList.Transactions
+	}
+	//the transactions of a page are stored newest first
+	position := int(index % PageSize)
+	if position >= len(reader.page) {
+		return AccountTransactionCompact{}, fmt.Errorf("transaction %d of %s not found", index, reader.address)
+	}
+	return reader.page[len(reader.page)-1-position], nil
+}
+
+// search returns the smallest position in [0, txnCount) for which f is true, assuming f is false and then true.
+func (reader *transactionListReader) search(f func(transaction AccountTransactionCompact) bool) uint64 {
+	return uint64(sort.Search(int(reader.txnCount), func(i int) bool {
+		if reader.err != nil {
+			return true
+		}
+		transaction, err := reader.get(uint64(i))
+		if err != nil {
+			reader.err = err
+			return true
+		}
+		return f(transaction)
+	}))
+}
+
+func parseTransactionTime(transaction AccountTransactionCompact) time.Time {
+	tm, _ := time.Parse(TimeLayout, transaction.CreatedAt)
+	return tm
+}
+
+// afterRange returns whether the transaction is after the block and time range of the query.
+func (query *AccountTransactionQuery) afterRange(transaction AccountTransactionCompact) bool {
+	if query.ToBlock > 0 && transaction.BlockNumber > query.ToBlock {
+		return true
+	}
+	return query.ToTime.IsZero() == false && parseTransactionTime(transaction).After(query.ToTime)
+}
+
+// beforeRange returns whether the transaction is before the block and time range of the query.
+func (query *AccountTransactionQuery) beforeRange(transaction AccountTransactionCompact) bool {
+	if transaction.BlockNumber < query.FromBlock {
+		return true
+	}
+	return query.FromTime.IsZero() == false && parseTransactionTime(transaction).Before(query.FromTime)
+}
+
+func (query *AccountTransactionQuery) matches(address string, transaction AccountTransactionCompact) bool {
+	switch query.Direction {
+	case DIRECTION_IN:
+		if strings.ToLower(transaction.To) != address {
+			return false
+		}
+	case DIRECTION_OUT:
+		if strings.ToLower(transaction.From) != address {
+			return false
+		}
+	}
+	if len(query.TransactionType) > 0 && transaction.TransactionType != query.TransactionType {
+		return false
+	}
+	if len(query.Status) > 0 && transaction.Status != query.Status {
+		return false
+	}
+	return true
+}
+
+func (query *AccountTransactionQuery) validate() error {
+	if query.Limit < 0 || query.Limit > MaxQueryLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", InvalidQueryErr, MaxQueryLimit)
+	}
+	if query.Order != ORDER_NEWEST_FIRST && query.Order != ORDER_OLDEST_FIRST {
+		return fmt.Errorf("%w: unknown order %s", InvalidQueryErr, query.Order)
+	}
+	if query.Direction != DIRECTION_ANY && query.Direction != DIRECTION_IN && query.Direction != DIRECTION_OUT {
+		return fmt.Errorf("%w: unknown direction %s", InvalidQueryErr, query.Direction)
+	}
+	if query.ToBlock > 0 && query.ToBlock < query.FromBlock {
+		return fmt.Errorf("%w: toBlock is less than fromBlock", InvalidQueryErr)
+	}
+	return nil
+}
+
+// QueryAccountTransactions lists the transactions of an account that match the query, starting at the cursor of the
+// query, or at the newest or oldest transaction in the block and time range if the query has no cursor. At most
+// MaxQueryScan transactions are examined per query.
+func (c *CacheManager) QueryAccountTransactions(accountAddress common.Address, query AccountTransactionQuery) (QueryAccountTransactionsResponse, error) {
+	return c.queryTransactions(accountTransactionKeys, accountAddress, query)
+}
+
+func (c *CacheManager) queryTransactions(keys *transactionListKeys, accountAddress common.Address, query AccountTransactionQuery) (QueryAccountTransactionsResponse, error) {
+	if len(query.Order) == 0 {
+		query.Order = ORDER_NEWEST_FIRST
+	}
+	err := query.validate()
+	if err != nil {
+		return QueryAccountTransactionsResponse{}, err
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = int(PageSize)
+	}
+
+	address := strings.ToLower(accountAddress.Hex())
+	txnCount, err := c.getAccountTxnCount(keys, address)
+	if err != nil {
+		return QueryAccountTransactionsResponse{}, err
+	}
+	reader := &transactionListReader{c: c, keys: keys, address: address, txnCount: txnCount}
+	response := QueryAccountTransactionsResponse{Items: make([]AccountTransactionCompact, 0)}
+
+	//positions are counted from 1, so that 0 is before the oldest transaction when listing newest first
+	var position uint64
+	if len(query.Cursor) > 0 {
+		position, err = hexutil.DecodeUint64(query.Cursor)
+		if err != nil || position > txnCount+1 {
+			return QueryAccountTransactionsResponse{}, InvalidCursorErr
+		}
+	} else if query.Order == ORDER_NEWEST_FIRST {
+		position = reader.search(query.afterRange)
+	} else {
+		position = reader.search(func(transaction AccountTransactionCompact) bool { return query.beforeRange(transaction) == false }) + 1
+	}
+	if reader.err != nil {
+		log.Error("QueryAccountTransactions search", "error", reader.err, "address", address)
+		return QueryAccountTransactionsResponse{}, reader.err
+	}
+
+	for scanned := uint64(0); position > 0 && position <= txnCount; scanned++ {
+		if scanned == MaxQueryScan || len(response.Items) == limit {
+			response.NextCursor = hexutil.EncodeUint64(position)
+			break
+		}
+		transaction, err := reader.get(position - 1)
+		if err != nil {
+			log.Error("QueryAccountTransactions get", "error", err, "address", address, "position", position)
+			return QueryAccountTransactionsResponse{}, err
+		}
+		if query.Order == ORDER_NEWEST_FIRST {
+			if query.beforeRange(transaction) {
+				break
+			}
+			position--
+		} else {
+			if query.afterRange(transaction) {
+				break
+			}
+			position++
+		}
+		if query.matches(address, transaction) {
+			response.Items = append(response.Items, transaction)
+		}
+	}
+
+	return response, nil
+}
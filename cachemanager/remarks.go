@@ -0,0 +1,58 @@
+package cachemanager
+
+import (
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/ethdb"
+	"github.com/QuantumCoinProject/qc/log"
+	"strings"
+)
+
+var RemarksTxnCountKey = "remarks-txn-count-%s" //%s is account address and remarks
+var RemarksPageKey = "remarks-txn-list-%s-%d"   //%s is account address and remarks, %d is page number
+
+var remarksKeys = &transactionListKeys{Name: "remarks", CountKey: RemarksTxnCountKey, PageKey: RemarksPageKey}
+
+var InvalidRemarksErr = fmt.Errorf("remarks must be a hex string of at most %d bytes", types.MAX_REMARKS_LENGTH)
+
+// getRemarksListId returns the id of the list of transactions received by an address with the remarks, which takes
+// the place of the address in the keys of the list.
+func getRemarksListId(address string, remarks string) string {
+	return strings.ToLower(address) + "-" + strings.ToLower(remarks)
+}
+
+// processRemarks indexes the transactions with remarks by their recipient and remarks, so that the deposits to a
+// shared address can be looked up by memo.
+func (c *CacheManager) processRemarks(transactions []AccountTransactionCompact, batch *ethdb.Batch, undo *blockUndo) error {
+	remarksMap := make(map[string][]AccountTransactionCompact) //list id to transactions in block mapping
+	for _, transaction := range transactions {
+		if len(transaction.Remarks) == 0 || len(transaction.To) == 0 {
+			continue
+		}
+		listId := getRemarksListId(transaction.To, transaction.Remarks)
+		remarksMap[listId] = append(remarksMap[listId], transaction)
+	}
+
+	for listId, list := range remarksMap {
+		err := c.processAccountTransactions(remarksKeys, listId, &list, batch, undo)
+		if err != nil {
+			log.Error("processRemarks processAccountTransactions", "error", err, "listId", listId)
+			return err
+		}
+	}
+	return nil
+}
+
+// ListTransactionsByRemarks lists the transactions received by an account with the given remarks, in pages of
+// PageSize transactions. The remarks are hex encoded. The last page is returned if pageNumberInput is less than 1.
+// Failed transactions are listed too, with a status of 0x0.
+func (c *CacheManager) ListTransactionsByRemarks(accountAddress common.Address, remarks string, pageNumberInput int64) (ListAccountTransactionsResponse, error) {
+	remarksBytes, err := hexutil.Decode(remarks)
+	if err != nil || len(remarksBytes) == 0 || len(remarksBytes) > types.MAX_REMARKS_LENGTH {
+		return ListAccountTransactionsResponse{}, InvalidRemarksErr
+	}
+	listId := getRemarksListId(strings.ToLower(accountAddress.Hex()), hexutil.Encode(remarksBytes))
+	return c.listTransactionPage(remarksKeys, listId, pageNumberInput)
+}
@@ -0,0 +1,121 @@
+package cachemanager
+
+import (
+	"context"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
+	"github.com/QuantumCoinProject/qc/ethdb"
+	"github.com/QuantumCoinProject/qc/log"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var StakingHistoryCountKey = "staking-history-count-%s"  //%s is depositor address
+var StakingHistoryPageKey = "staking-history-list-%s-%d" //%s is depositor address, %d is page number
+
+var stakingHistoryKeys = &transactionListKeys{Name: "staking-history", CountKey: StakingHistoryCountKey, PageKey: StakingHistoryPageKey}
+
+// stakingEvent is a block reward or slashing of a validator, which is credited to or debited from its depositor by the
+// staking contract when the block is finalized, without a transaction.
+type stakingEvent struct {
+	validator common.Address
+	amount    *big.Int
+	eventType TransactionType
+}
+
+// getStakingEvents returns the block reward and slashings of a block, from its consensus data.
+func getStakingEvents(consensusData *proofofstake.ConsensusData) ([]stakingEvent, error) {
+	if consensusData == nil || consensusData.Data == nil || consensusData.BlockRewardsInfo == nil {
+		return nil, nil
+	}
+	rewardsInfo := consensusData.BlockRewardsInfo
+
+	events := make([]stakingEvent, 0)
+	if consensusData.Data.VoteType == proofofstake.VOTE_TYPE_OK && len(rewardsInfo.BlockProposerRewards) > 0 {
+		reward, err := hexutil.DecodeBig(rewardsInfo.BlockProposerRewards)
+		if err != nil {
+			return nil, err
+		}
+		if reward.Sign() > 0 {
+			events = append(events, stakingEvent{validator: consensusData.Data.BlockProposer, amount: reward, eventType: BLOCK_REWARD})
+		}
+	}
+	for _, slashing := range rewardsInfo.SlashedValidators {
+		amount, err := hexutil.DecodeBig(slashing.SlashedAmount)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, stakingEvent{validator: slashing.SlashedValidator, amount: amount, eventType: SLASHING})
+	}
+	return events, nil
+}
+
+// getBlockDepositors returns the depositors of the validators rewarded or slashed in a block. The depositors are read
+// at the parent block, the state the staking contract is updated from when the block is finalized.
+func (c *CacheManager) getBlockDepositors(blockNumber uint64, consensusData *proofofstake.ConsensusData) (map[common.Address]common.Address, error) {
+	events, err := getStakingEvents(consensusData)
+	if err != nil {
+		return nil, err
+	}
+
+	depositors := make(map[common.Address]common.Address)
+	parentNumber := new(big.Int).SetUint64(blockNumber - 1)
+	for _, event := range events {
+		if _, ok := depositors[event.validator]; ok {
+			continue
+		}
+		details, err := c.client.GetStakingDetailsByValidatorAddress(context.Background(), event.validator, parentNumber)
+		if err != nil {
+			log.Error("getBlockDepositors GetStakingDetailsByValidatorAddress", "error", err, "validator", event.validator)
+			return nil, err
+		}
+		depositors[event.validator] = details.Depositor
+	}
+	return depositors, nil
+}
+
+// processStakingHistory adds the block reward and slashings of a block to the staking history of the depositors.
+func (c *CacheManager) processStakingHistory(data *blockData, batch *ethdb.Batch, undo *blockUndo) error {
+	events, err := getStakingEvents(data.consensusData)
+	if err != nil {
+		return err
+	}
+
+	block := data.block
+	history := make(map[string][]AccountTransactionCompact)
+	for _, event := range events {
+		depositor, ok := data.depositors[event.validator]
+		if ok == false || depositor.IsEqualTo(common.ZERO_ADDRESS) {
+			log.Warn("processStakingHistory depositor not found", "validator", event.validator, "Block number", block.NumberU64())
+			continue
+		}
+
+		var entry AccountTransactionCompact
+		entry.BlockNumber = block.NumberU64()
+		entry.CreatedAt = time.Unix(int64(block.Time()), 0).UTC().Format(TimeLayout)
+		entry.From = strings.ToLower(event.validator.Hex())
+		entry.To = strings.ToLower(depositor.Hex())
+		entry.Value = common.BigIntToHexString(event.amount)
+		entry.Status = "0x1"
+		entry.TransactionType = string(event.eventType)
+		history[entry.To] = append(history[entry.To], entry)
+	}
+
+	for depositor, entries := range history {
+		err = c.processAccountTransactions(stakingHistoryKeys, depositor, &entries, batch, undo)
+		if err != nil {
+			log.Error("processStakingHistory processAccountTransactions", "error", err, "depositor", depositor)
+			return err
+		}
+	}
+	return nil
+}
+
+// ListStakingHistory lists the block rewards and slashings of a depositor, in pages of PageSize entries. Each entry has
+// the validator in From, the depositor in To and the amount in Value. The last page is returned if pageNumberInput is
+// less than 1. The history is only indexed while enableExtendedApis is true.
+func (c *CacheManager) ListStakingHistory(depositorAddress common.Address, pageNumberInput int64) (ListAccountTransactionsResponse, error) {
+	return c.listTransactions(stakingHistoryKeys, depositorAddress, pageNumberInput)
+}
@@ -0,0 +1,488 @@
+package cachemanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/crypto"
+	"github.com/QuantumCoinProject/qc/ethdb"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/rpc"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+var TokenInfoKey = "token-info-%s"                         //%s is contract address
+var AccountTokensKey = "account-tokens-%s"                 //%s is account address
+var AccountTokenBalanceKey = "account-token-balance-%s-%s" //%s is account address, %s is token contract address
+
+var TokenNotFoundErr = errors.New("token not found")
+
+// TRANSFER_EVENT_TOPIC is the topic of the ERC-20 event Transfer(address indexed from, address indexed to, uint256 value).
+var TRANSFER_EVENT_TOPIC = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+var (
+	tokenNameMethod        = crypto.Keccak256([]byte("name()"))[:4]
+	tokenSymbolMethod      = crypto.Keccak256([]byte("symbol()"))[:4]
+	tokenDecimalsMethod    = crypto.Keccak256([]byte("decimals()"))[:4]
+	tokenTotalSupplyMethod = crypto.Keccak256([]byte("totalSupply()"))[:4]
+	tokenBalanceOfMethod   = crypto.Keccak256([]byte("balanceOf(address)"))[:4]
+)
+
+// TokenInfo is the token details of a contract. A contract is considered an ERC-20 style token if its symbol(),
+// decimals() and totalSupply() methods can be called. Contracts that are not tokens are stored too, so that they are
+// not queried again. TotalSupply is updated when the token is transferred.
+type TokenInfo struct {
+	Address     string `json:"address"`
+	IsToken     bool   `json:"isToken"`
+	Name        string `json:"name,omitempty"`
+	Symbol      string `json:"symbol,omitempty"`
+	Decimals    uint8  `json:"decimals,omitempty"`
+	TotalSupply string `json:"totalSupply,omitempty"`
+}
+
+type TokenDetails struct {
+	Address     string `json:"address"`
+	Name        string `json:"name,omitempty"`
+	Symbol      string `json:"symbol"`
+	Decimals    uint8  `json:"decimals"`
+	TotalSupply string `json:"totalSupply"`
+}
+
+type GetTokenDetailsResponse struct {
+	Result TokenDetails `json:"result"`
+}
+
+type AccountTokenBalance struct {
+	TokenAddress string `json:"tokenAddress"`
+	Name         string `json:"name,omitempty"`
+	Symbol       string `json:"symbol"`
+	Decimals     uint8  `json:"decimals"`
+	Balance      string `json:"balance"`
+}
+
+type ListAccountTokensResponse struct {
+	Items []AccountTokenBalance `json:"items"`
+}
+
+type tokenTransfer struct {
+	Token  *TokenInfo
+	From   string
+	To     string
+	Amount *big.Int
+}
+
+func getTokenInfoKey(address string) []byte {
+	return []byte(fmt.Sprintf(TokenInfoKey, strings.ToLower(address)))
+}
+
+func getAccountTokensKey(address string) []byte {
+	return []byte(fmt.Sprintf(AccountTokensKey, strings.ToLower(address)))
+}
+
+func getAccountTokenBalanceKey(address string, tokenAddress string) []byte {
+	return []byte(fmt.Sprintf(AccountTokenBalanceKey, strings.ToLower(address), strings.ToLower(tokenAddress)))
+}
+
+// getTokenInfo returns the token details of a contract, querying the node as of blockNumber if the contract was not
+// seen before. Token details queried or updated while indexing a block are added to newTokens, to be stored with the
+// block.
+func (c *CacheManager) getTokenInfo(address common.Address, blockNumber *big.Int, newTokens map[string]*TokenInfo) (*TokenInfo, error) {
+	key := strings.ToLower(address.Hex())
+	tokenInfo, ok := newTokens[key]
+	if ok {
+		return tokenInfo, nil
+	}
+
+	tokenInfo, err := c.getTokenInfoFromDb(key)
+	if err != nil || tokenInfo != nil {
+		return tokenInfo, err
+	}
+
+	tokenInfo, err = c.queryTokenInfo(address, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	newTokens[key] = tokenInfo
+	log.Info("getTokenInfo", "address", key, "isToken", tokenInfo.IsToken, "symbol", tokenInfo.Symbol)
+
+	return tokenInfo, nil
+}
+
+// getTokenInfoFromDb returns the stored token details of a contract, or nil if the contract was not seen yet.
+func (c *CacheManager) getTokenInfoFromDb(address string) (*TokenInfo, error) {
+	keyBlob := getTokenInfoKey(address)
+	ok, err := c.cacheDb.Has(keyBlob)
+	if err != nil || ok == false {
+		return nil, err
+	}
+	tokenInfoBlob, err := c.cacheDb.Get(keyBlob)
+	if err != nil {
+		return nil, err
+	}
+	tokenInfo := &TokenInfo{}
+	err = json.Unmarshal(tokenInfoBlob, tokenInfo)
+	if err != nil {
+		return nil, err
+	}
+	return tokenInfo, nil
+}
+
+func (c *CacheManager) queryTokenInfo(address common.Address, blockNumber *big.Int) (*TokenInfo, error) {
+	tokenInfo := &TokenInfo{
+		Address: strings.ToLower(address.Hex()),
+	}
+
+	symbolBlob, ok, err := c.callTokenMethod(address, tokenSymbolMethod, blockNumber)
+	if err != nil || ok == false {
+		return tokenInfo, err
+	}
+	symbol, ok := decodeTokenString(symbolBlob)
+	if ok == false {
+		return tokenInfo, nil
+	}
+
+	decimalsBlob, ok, err := c.callTokenMethod(address, tokenDecimalsMethod, blockNumber)
+	if err != nil || ok == false {
+		return tokenInfo, err
+	}
+	decimals := new(big.Int).SetBytes(decimalsBlob)
+	if len(decimalsBlob) != 32 || decimals.Cmp(big.NewInt(255)) > 0 {
+		return tokenInfo, nil
+	}
+
+	totalSupplyBlob, ok, err := c.callTokenMethod(address, tokenTotalSupplyMethod, blockNumber)
+	if err != nil || ok == false {
+		return tokenInfo, err
+	}
+	if len(totalSupplyBlob) != 32 {
+		return tokenInfo, nil
+	}
+
+	//name is optional in ERC-20
+	nameBlob, ok, err := c.callTokenMethod(address, tokenNameMethod, blockNumber)
+	if err != nil {
+		return tokenInfo, err
+	}
+	if ok {
+		tokenInfo.Name, _ = decodeTokenString(nameBlob)
+	}
+
+	tokenInfo.IsToken = true
+	tokenInfo.Symbol = symbol
+	tokenInfo.Decimals = uint8(decimals.Uint64())
+	tokenInfo.TotalSupply = hexutil.EncodeBig(new(big.Int).SetBytes(totalSupplyBlob))
+
+	return tokenInfo, nil
+}
+
+// callTokenMethod calls a read-only method of a contract, with the abi encoded input. Returns false if the call
+// reverted, for example if the contract does not have the method. Other errors, such as the node missing the state of
+// the block, are returned so that the block is indexed again.
+func (c *CacheManager) callTokenMethod(address common.Address, input []byte, blockNumber *big.Int) ([]byte, bool, error) {
+	msg := dp.CallMsg{
+		To:   &address,
+		Data: input,
+	}
+	result, err := c.client.CallContract(context.Background(), msg, blockNumber)
+	if err != nil {
+		if isExecutionReverted(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return result, len(result) > 0, nil
+}
+
+// isExecutionReverted returns whether the error of a call is an execution revert. The node returns code 3 for a
+// revert with a reason, and the plain "execution reverted" error otherwise.
+func isExecutionReverted(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) == false {
+		return false
+	}
+	return rpcErr.ErrorCode() == 3 || strings.HasPrefix(err.Error(), "execution reverted")
+}
+
+// decodeTokenString decodes the abi encoded string returned by the name() or symbol() methods of a token. Some older
+// tokens return a bytes32 instead.
+func decodeTokenString(data []byte) (string, bool) {
+	if len(data) == 32 {
+		return strings.ToValidUTF8(string(bytes.TrimRight(data, "\x00")), ""), true
+	}
+	if len(data) < 64 {
+		return "", false
+	}
+
+	offset := new(big.Int).SetBytes(data[:32])
+	if offset.Cmp(big.NewInt(int64(len(data)-32))) > 0 {
+		return "", false
+	}
+	start := offset.Uint64() + 32
+	length := new(big.Int).SetBytes(data[offset.Uint64():start])
+	if length.Cmp(big.NewInt(int64(uint64(len(data))-start))) > 0 {
+		return "", false
+	}
+
+	return strings.ToValidUTF8(string(data[start:start+length.Uint64()]), ""), true
+}
+
+// getTokenTransfers returns the transfers of ERC-20 style tokens in the logs of a receipt, including transfers made
+// by contracts called by the transaction.
+func (c *CacheManager) getTokenTransfers(receipt *types.Receipt, blockNumber *big.Int, newTokens map[string]*TokenInfo) ([]*tokenTransfer, error) {
+	transfers := make([]*tokenTransfer, 0)
+	for _, l := range receipt.Logs {
+		//ERC-721 Transfer events have the same topic, but an indexed tokenId
+		if len(l.Topics) != 3 || l.Topics[0] != TRANSFER_EVENT_TOPIC || len(l.Data) != 32 {
+			continue
+		}
+
+		tokenInfo, err := c.getTokenInfo(l.Address, blockNumber, newTokens)
+		if err != nil {
+			return nil, err
+		}
+		if tokenInfo.IsToken == false {
+			continue
+		}
+
+		transfers = append(transfers, &tokenTransfer{
+			Token:  tokenInfo,
+			From:   strings.ToLower(common.BytesToAddress(l.Topics[1].Bytes()).Hex()),
+			To:     strings.ToLower(common.BytesToAddress(l.Topics[2].Bytes()).Hex()),
+			Amount: new(big.Int).SetBytes(l.Data),
+		})
+	}
+
+	return transfers, nil
+}
+
+func setTokenDetails(transaction *AccountTransactionCompact, tokenInfo *TokenInfo) {
+	decimals := tokenInfo.Decimals
+	transaction.TokenAddress = tokenInfo.Address
+	transaction.TokenSymbol = tokenInfo.Symbol
+	transaction.TokenDecimals = &decimals
+}
+
+// processBlockTokens stores the token details of the contracts seen first in a block. For each token transferred in
+// the block, it also stores the transfers, the total supply, and the balances and token holdings of the senders and
+// recipients as of the block.
+func (c *CacheManager) processBlockTokens(blockNumber *big.Int, tokenTransferMap map[string][]AccountTransactionCompact, newTokens map[string]*TokenInfo, batch *ethdb.Batch, undo *blockUndo) error {
+	zeroAddress := strings.ToLower(common.ZERO_ADDRESS.Hex())
+	tokens := make([]string, 0, len(tokenTransferMap))
+	for token := range tokenTransferMap {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+
+	accountTokens := make(map[string]map[string]bool) //account to tokens transferred in block mapping
+	for _, token := range tokens {
+		transfers := tokenTransferMap[token]
+		err := c.processAccountTransactions(tokenTransferKeys, token, &transfers, batch, undo)
+		if err != nil {
+			return err
+		}
+
+		tokenAddress := common.HexToAddress(token)
+		tokenInfo, err := c.getTokenInfo(tokenAddress, blockNumber, newTokens)
+		if err != nil {
+			return err
+		}
+		totalSupplyBlob, ok, err := c.callTokenMethod(tokenAddress, tokenTotalSupplyMethod, blockNumber)
+		if err != nil {
+			return err
+		}
+		if ok && len(totalSupplyBlob) == 32 {
+			updated := *tokenInfo
+			updated.TotalSupply = hexutil.EncodeBig(new(big.Int).SetBytes(totalSupplyBlob))
+			newTokens[token] = &updated
+		}
+
+		for _, transfer := range transfers {
+			for _, account := range []string{transfer.From, transfer.To} {
+				if account == zeroAddress {
+					continue
+				}
+				if accountTokens[account] == nil {
+					accountTokens[account] = make(map[string]bool)
+				}
+				accountTokens[account][token] = true
+			}
+		}
+	}
+
+	addresses := make([]string, 0, len(newTokens))
+	for address := range newTokens {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		tokenInfoBlob, err := json.Marshal(newTokens[address])
+		if err != nil {
+			return err
+		}
+		err = c.putWithUndo(getTokenInfoKey(address), tokenInfoBlob, batch, undo)
+		if err != nil {
+			return err
+		}
+	}
+
+	accounts := make([]string, 0, len(accountTokens))
+	for account := range accountTokens {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	for _, account := range accounts {
+		err := c.updateAccountTokens(account, accountTokens[account], blockNumber, batch, undo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateAccountTokens stores the balances of an account for the tokens, and adds the tokens to the token holdings of
+// the account.
+func (c *CacheManager) updateAccountTokens(account string, tokens map[string]bool, blockNumber *big.Int, batch *ethdb.Batch, undo *blockUndo) error {
+	holdings, err := c.getAccountTokens(account)
+	if err != nil {
+		return err
+	}
+	held := make(map[string]bool)
+	for _, token := range holdings {
+		held[token] = true
+	}
+
+	sortedTokens := make([]string, 0, len(tokens))
+	for token := range tokens {
+		sortedTokens = append(sortedTokens, token)
+	}
+	sort.Strings(sortedTokens)
+
+	changed := false
+	input := append(common.CopyBytes(tokenBalanceOfMethod), common.LeftPadBytes(common.HexToAddress(account).Bytes(), 32)...)
+	for _, token := range sortedTokens {
+		balanceBlob, ok, err := c.callTokenMethod(common.HexToAddress(token), input, blockNumber)
+		if err != nil {
+			return err
+		}
+		if ok == false || len(balanceBlob) != 32 {
+			log.Warn("updateAccountTokens balanceOf failed", "account", account, "token", token)
+			continue
+		}
+		err = c.putWithUndo(getAccountTokenBalanceKey(account, token), balanceBlob, batch, undo)
+		if err != nil {
+			return err
+		}
+		if held[token] == false {
+			holdings = append(holdings, token)
+			changed = true
+		}
+	}
+
+	if changed {
+		holdingsBlob, err := json.Marshal(holdings)
+		if err != nil {
+			return err
+		}
+		err = c.putWithUndo(getAccountTokensKey(account), holdingsBlob, batch, undo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getAccountTokens returns the tokens that an account has held, in the order they were first received.
+func (c *CacheManager) getAccountTokens(account string) ([]string, error) {
+	keyBlob := getAccountTokensKey(account)
+	ok, err := c.cacheDb.Has(keyBlob)
+	if err != nil || ok == false {
+		return make([]string, 0), err
+	}
+	holdingsBlob, err := c.cacheDb.Get(keyBlob)
+	if err != nil {
+		return nil, err
+	}
+	var holdings []string
+	err = json.Unmarshal(holdingsBlob, &holdings)
+	if err != nil {
+		return nil, err
+	}
+	return holdings, nil
+}
+
+// GetTokenDetails returns the details of a token contract. Returns TokenNotFoundErr if the contract is not a token, or
+// if it was not transferred or created in the indexed blocks.
+func (c *CacheManager) GetTokenDetails(tokenAddress common.Address) (GetTokenDetailsResponse, error) {
+	tokenInfo, err := c.getTokenInfoFromDb(strings.ToLower(tokenAddress.Hex()))
+	if err != nil {
+		log.Error("GetTokenDetails getTokenInfoFromDb", "error", err)
+		return GetTokenDetailsResponse{}, err
+	}
+	if tokenInfo == nil || tokenInfo.IsToken == false {
+		return GetTokenDetailsResponse{}, TokenNotFoundErr
+	}
+
+	return GetTokenDetailsResponse{
+		Result: TokenDetails{
+			Address:     tokenInfo.Address,
+			Name:        tokenInfo.Name,
+			Symbol:      tokenInfo.Symbol,
+			Decimals:    tokenInfo.Decimals,
+			TotalSupply: tokenInfo.TotalSupply,
+		},
+	}, nil
+}
+
+// ListAccountTokens lists the tokens held by an account with a non zero balance, as of the last indexed block.
+func (c *CacheManager) ListAccountTokens(accountAddress common.Address) (ListAccountTokensResponse, error) {
+	address := strings.ToLower(accountAddress.Hex())
+	holdings, err := c.getAccountTokens(address)
+	if err != nil {
+		log.Error("ListAccountTokens getAccountTokens", "error", err)
+		return ListAccountTokensResponse{}, err
+	}
+
+	listResponse := ListAccountTokensResponse{
+		Items: make([]AccountTokenBalance, 0, len(holdings)),
+	}
+	for _, token := range holdings {
+		balanceBlob, err := c.cacheDb.Get(getAccountTokenBalanceKey(address, token))
+		if err != nil {
+			log.Error("ListAccountTokens cacheDb.Get balance", "error", err, "token", token)
+			return ListAccountTokensResponse{}, err
+		}
+		balance := new(big.Int).SetBytes(balanceBlob)
+		if balance.Sign() == 0 {
+			continue
+		}
+
+		tokenInfo, err := c.getTokenInfoFromDb(token)
+		if err != nil {
+			log.Error("ListAccountTokens getTokenInfoFromDb", "error", err, "token", token)
+			return ListAccountTokensResponse{}, err
+		}
+		if tokenInfo == nil {
+			return ListAccountTokensResponse{}, fmt.Errorf("token info of %s not found", token)
+		}
+
+		listResponse.Items = append(listResponse.Items, AccountTokenBalance{
+			TokenAddress: token,
+			Name:         tokenInfo.Name,
+			Symbol:       tokenInfo.Symbol,
+			Decimals:     tokenInfo.Decimals,
+			Balance:      hexutil.EncodeBig(balance),
+		})
+	}
+
+	return listResponse, nil
+}
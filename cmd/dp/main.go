@@ -216,6 +216,8 @@ func init() {
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
+		// See votejournalcmd.go:
+		voteJournalCommand,
 		// See consolecmd.go:
 		consoleCommand,
 		attachCommand,
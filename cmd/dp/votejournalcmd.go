@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/QuantumCoinProject/qc/cmd/utils"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
+	"github.com/QuantumCoinProject/qc/core/rawdb"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	voteJournalCommand = cli.Command{
+		Name:     "votejournal",
+		Usage:    "Manage the slashing protection vote history of validator keys",
+		Category: "ACCOUNT COMMANDS",
+		Description: `
+Every consensus packet signed by a validator key is recorded in a vote journal
+in the node's data directory, and a node refuses to sign a packet that conflicts
+with one in the journal.
+
+When moving a validator key to another machine, export the vote history on the
+old machine after stopping it, and import it on the new machine before starting
+the validator there.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the signed-vote history of a validator key to an interchange file",
+				ArgsUsage: "<address> <file>",
+				Action:    utils.MigrateFlags(voteJournalExport),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+				Description: `
+    dp votejournal export <address> <file>
+
+Writes the signed-vote history of the validator key to a JSON interchange file.
+The node using the data directory must not be running.`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Import the signed-vote history of a validator key from an interchange file",
+				ArgsUsage: "<file>",
+				Action:    utils.MigrateFlags(voteJournalImport),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+				},
+				Description: `
+    dp votejournal import <file>
+
+Merges the signed-vote history in the interchange file into the vote journal of
+the validator key in the data directory. The import is refused if the file was
+modified, belongs to a different chain, or if its history conflicts or overlaps
+with votes already signed by the key on this machine.
+The node using the data directory must not be running.`,
+			},
+		},
+	}
+)
+
+// readGenesisHash returns the genesis hash of the chain in the data directory, or the zero hash if it is not initialized.
+func readGenesisHash(ctx *cli.Context, stack *node.Node) common.Hash {
+	if common.FileExist(stack.ResolvePath("chaindata")) == false {
+		return common.Hash{}
+	}
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	return rawdb.ReadCanonicalHash(db, 0)
+}
+
+func voteJournalExport(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires two arguments.")
+	}
+	if common.IsHexAddress(ctx.Args().Get(0)) == false {
+		utils.Fatalf("Invalid address: %s", ctx.Args().Get(0))
+	}
+	validator := common.HexToAddress(ctx.Args().Get(0))
+	path := ctx.Args().Get(1)
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	genesisHash := readGenesisHash(ctx, stack)
+
+	dir := stack.ResolvePath(proofofstake.VOTE_JOURNAL_DIR)
+	if common.FileExist(proofofstake.GetVoteJournalPath(dir, validator)) == false {
+		utils.Fatalf("No vote journal found for %s in %s", validator.Hex(), dir)
+	}
+
+	journal, err := proofofstake.OpenVoteJournal(dir, validator)
+	if err != nil {
+		utils.Fatalf("Failed to open vote journal: %v", err)
+	}
+	defer journal.Close()
+
+	interchange, err := journal.ExportVoteInterchange(genesisHash)
+	if err != nil {
+		utils.Fatalf("Failed to export vote journal: %v", err)
+	}
+	if err := proofofstake.WriteVoteInterchangeFile(path, interchange); err != nil {
+		utils.Fatalf("Failed to write interchange file: %v", err)
+	}
+
+	log.Info("Exported vote journal", "validator", validator, "votes", len(interchange.Votes), "file", path)
+	fmt.Printf("Exported %d votes of %s to %s\n", len(interchange.Votes), validator.Hex(), path)
+	return nil
+}
+
+func voteJournalImport(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	path := ctx.Args().First()
+
+	interchange, err := proofofstake.ReadVoteInterchangeFile(path)
+	if err != nil {
+		utils.Fatalf("Failed to read interchange file: %v", err)
+	}
+	validator := interchange.Metadata.Validator
+
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	genesisHash := readGenesisHash(ctx, stack)
+
+	journal, err := proofofstake.OpenVoteJournal(stack.ResolvePath(proofofstake.VOTE_JOURNAL_DIR), validator)
+	if err != nil {
+		utils.Fatalf("Failed to open vote journal: %v", err)
+	}
+	defer journal.Close()
+
+	count, err := journal.ImportVoteInterchange(interchange, genesisHash)
+	if err != nil {
+		utils.Fatalf("Failed to import interchange file: %v", err)
+	}
+
+	fmt.Printf("Imported %d of %d votes of %s from %s\n", count, len(interchange.Votes), validator.Hex(), path)
+	return nil
+}
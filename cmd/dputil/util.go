@@ -11,7 +11,6 @@ import (
 	"github.com/QuantumCoinProject/qc/accounts/keystore"
 	"github.com/QuantumCoinProject/qc/common"
 	"github.com/QuantumCoinProject/qc/common/hexutil"
-	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
 	"github.com/QuantumCoinProject/qc/core/types"
 	"github.com/QuantumCoinProject/qc/crypto/cryptobase"
 	"github.com/QuantumCoinProject/qc/crypto/signaturealgorithm"
@@ -558,6 +557,17 @@ func requestConvertCoins(ethAddress string, ethSignature string, key *signaturea
 	return nil
 }
 
+// isStakingV2 returns whether the staking contract of the chain has been replaced by the v2 contract, which happens at
+// the staking v2 block configured for the chain.
+func isStakingV2(client *ethclient.Client) (bool, error) {
+	code, err := client.CodeAt(context.Background(), common.HexToAddress(staking.STAKING_CONTRACT), nil)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(code, common.FromHex(stakingv2.STAKING_RUNTIME_BIN)), nil
+}
+
 func newDeposit(validatorAddress string, depositAmount string, key *signaturealgorithm.PrivateKey) error {
 
 	client, err := ethclient.Dial(rawURL)
@@ -590,13 +600,13 @@ func newDeposit(validatorAddress string, depositAmount string, key *signaturealg
 	val, _ := ParseBigFloat(depositAmount)
 	txnOpts.Value = etherToWeiFloat(val)
 
-	blockNumber, err := client.BlockNumber(context.Background())
+	stakingV2, err := isStakingV2(client)
 	if err != nil {
 		return err
 	}
 
 	var tx *types.Transaction
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	if stakingV2 == false {
 		contract, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
 			return err
@@ -659,8 +669,11 @@ func initiateWithdrawal(key *signaturealgorithm.PrivateKey) error {
 	txnOpts.Value = etherToWeiFloat(val)
 
 	var tx *types.Transaction
-	var blockNumber uint64
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	stakingV2, err := isStakingV2(client)
+	if err != nil {
+		return err
+	}
+	if stakingV2 == false {
 		contract, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
 			return err
@@ -735,8 +748,11 @@ func completeWithdrawal(key *signaturealgorithm.PrivateKey) error {
 	txnOpts.Value = etherToWeiFloat(val)
 
 	var tx *types.Transaction
-	var blockNumber uint64
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	stakingV2, err := isStakingV2(client)
+	if err != nil {
+		return err
+	}
+	if stakingV2 == false {
 		contract, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
 			return err
@@ -776,8 +792,11 @@ func getBalanceOfDepositor(dep string) (*big.Int, error) {
 	contractAddress := common.HexToAddress(staking.STAKING_CONTRACT)
 
 	var depositorBalance *big.Int
-	var blockNumber uint64
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	stakingV2, err := isStakingV2(client)
+	if err != nil {
+		return nil, err
+	}
+	if stakingV2 == false {
 
 		instance, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
@@ -818,8 +837,11 @@ func getNetBalanceOfDepositor(dep string) (*big.Int, error) {
 	}
 
 	var depositorBalance *big.Int
-	var blockNumber uint64
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	stakingV2, err := isStakingV2(client)
+	if err != nil {
+		return nil, err
+	}
+	if stakingV2 == false {
 		contractAddress := common.HexToAddress(staking.STAKING_CONTRACT)
 		instance, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
@@ -862,8 +884,11 @@ func getDepositorOfValidator(val string) (common.Address, error) {
 
 	var depositor common.Address
 	var validator common.Address
-	var blockNumber uint64
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	stakingV2, err := isStakingV2(client)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if stakingV2 == false {
 		contractAddress := common.HexToAddress(staking.STAKING_CONTRACT)
 		instance, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
@@ -904,8 +929,11 @@ func getDepositorBlockRewards(dep string) (*big.Int, error) {
 	}
 
 	var depositorBalance *big.Int
-	var blockNumber uint64
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	stakingV2, err := isStakingV2(client)
+	if err != nil {
+		return nil, err
+	}
+	if stakingV2 == false {
 		contractAddress := common.HexToAddress(staking.STAKING_CONTRACT)
 		instance, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
@@ -946,8 +974,11 @@ func getDepositorSlashings(dep string) (*big.Int, error) {
 	}
 
 	var depositorSlashing *big.Int
-	var blockNumber uint64
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	stakingV2, err := isStakingV2(client)
+	if err != nil {
+		return nil, err
+	}
+	if stakingV2 == false {
 		contractAddress := common.HexToAddress(staking.STAKING_CONTRACT)
 		instance, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
@@ -1008,12 +1039,12 @@ func listValidators() error {
 	contractAddress := common.HexToAddress(staking.STAKING_CONTRACT)
 	var validatorList []common.Address
 
-	blockNumber, err := client.BlockNumber(context.Background())
+	stakingV2, err := isStakingV2(client)
 	if err != nil {
 		return err
 	}
 
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	if stakingV2 == false {
 		instance, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
 			return err
@@ -1322,12 +1353,12 @@ func getStakingDetails(validatorAddress common.Address) error {
 
 	contractAddress := common.HexToAddress(staking.STAKING_CONTRACT)
 
-	blockNumber, err := client.BlockNumber(context.Background())
+	stakingV2, err := isStakingV2(client)
 	if err != nil {
 		return err
 	}
 
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	if stakingV2 == false {
 		fmt.Println(nil)
 	} else {
 		instance, err := stakingv2.NewStaking(contractAddress, client)
@@ -1391,8 +1422,11 @@ func pauseValidation(key *signaturealgorithm.PrivateKey) error {
 	txnOpts.Value = etherToWeiFloat(val)
 
 	var tx *types.Transaction
-	var blockNumber uint64
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	stakingV2, err := isStakingV2(client)
+	if err != nil {
+		return err
+	}
+	if stakingV2 == false {
 		contract, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
 			return err
@@ -1456,8 +1490,11 @@ func resumeValidation(key *signaturealgorithm.PrivateKey) error {
 	txnOpts.Value = etherToWeiFloat(val)
 
 	var tx *types.Transaction
-	var blockNumber uint64
-	if blockNumber < proofofstake.STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	stakingV2, err := isStakingV2(client)
+	if err != nil {
+		return err
+	}
+	if stakingV2 == false {
 		contract, err := stakingv1.NewStaking(contractAddress, client)
 		if err != nil {
 			return err
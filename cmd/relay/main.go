@@ -15,6 +15,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/metrics"
+	"github.com/QuantumCoinProject/qc/metrics/exp"
 	"io/ioutil"
 	"net"
 	"net/http"
@@ -67,8 +69,6 @@ func main() {
 		port := config.Port
 		nodeUrl := config.NodeUrl
 		corsAllowedOrigins := config.CorsAllowedOrigins
-		enableAuth := config.EnableAuth
-		apiKeys := config.ApiKeys
 		cachePath := config.CachePath
 
 		if net.ParseIP(ip) == nil {
@@ -86,22 +86,48 @@ func main() {
 			return
 		}
 
+		nodePool, err := relay.NewNodePool(append([]string{nodeUrl}, config.NodeUrls...))
+		if err != nil {
+			fmt.Println("Check configuration node Urls", err.Error())
+			return
+		}
+
+		apiKeyManager, err := relay.NewApiKeyManager(config.EnableAuth, config.ApiKeys, config.ApiKeyConfigs, config.UsageFilePath)
+		if err != nil {
+			fmt.Println("Check configuration api keys", err.Error())
+			return
+		}
+
 		if strings.EqualFold(api ,"read") {
 			if len(strings.TrimSpace(cachePath)) == 0 {
 				fmt.Println("Check configuration cache path value", cachePath)
 				return
 			}
 
-			cacheManager, err := cachemanager.NewCacheManager(cachePath, nodeUrl, config.EnableExtendedApis, config.GenesisFilePath, config.MaxSupply)
+			cacheManager, err := cachemanager.NewCacheManager(cachePath, nodePool, config.EnableExtendedApis, config.GenesisFilePath, config.MaxSupply)
 			if err != nil {
 				log.Error("NewCacheManager failed", "error", err)
 				panic(err)
 			}
-			go qcReadApi(ip, port, nodeUrl, corsAllowedOrigins,enableAuth,apiKeys, cacheManager, config.EnableExtendedApis)
+			if len(strings.TrimSpace(config.MetricsAddr)) > 0 {
+				if metrics.Enabled == false {
+					fmt.Println("Start the relay with the --metrics flag to collect the metrics served at", config.MetricsAddr)
+				}
+				exp.Setup(config.MetricsAddr)
+			}
+			go qcReadApi(ip, port, nodeUrl, corsAllowedOrigins, nodePool, apiKeyManager, cacheManager, config.EnableExtendedApis)
 		}
 
 		if strings.EqualFold(api ,"write") {
-			go qcWriteApi(ip, port, nodeUrl, corsAllowedOrigins,enableAuth,apiKeys)
+			var tracker *qcwriteapi.TransactionTracker
+			if len(strings.TrimSpace(config.TrackerPath)) > 0 {
+				tracker, err = qcwriteapi.NewTransactionTracker(config.TrackerPath, nodePool, config.WebhookUrl, config.WebhookSecret)
+				if err != nil {
+					log.Error("NewTransactionTracker failed", "error", err)
+					panic(err)
+				}
+			}
+			go qcWriteApi(ip, port, nodeUrl, corsAllowedOrigins, nodePool, tracker, apiKeyManager)
 		}
 	}
 
@@ -109,21 +135,22 @@ func main() {
 	<-make(chan int)
 }
 
-func qcReadApi(ip string, port string, nodeUrl string, corsAllowedOrigins string, enableAuth bool, apiKeys string, cacheManager *cachemanager.CacheManager, enableExtendedApis bool) {
-	ReadApiAPIService, err := qcreadapi.NewReadApiAPIService(nodeUrl, cacheManager, enableExtendedApis)
+func qcReadApi(ip string, port string, nodeUrl string, corsAllowedOrigins string, nodePool *relay.NodePool, apiKeyManager *relay.ApiKeyManager, cacheManager *cachemanager.CacheManager, enableExtendedApis bool) {
+	ReadApiAPIService, err := qcreadapi.NewReadApiAPIService(nodePool, cacheManager, enableExtendedApis)
 	if err != nil {
 		panic(err)
 	}
-	ReadApiAPIController := qcreadapi.NewReadApiAPIController(ReadApiAPIService, corsAllowedOrigins, enableAuth, apiKeys)
+	streamHub := qcreadapi.NewStreamHub(cacheManager)
+	ReadApiAPIController := qcreadapi.NewReadApiAPIController(ReadApiAPIService, corsAllowedOrigins, apiKeyManager, qcreadapi.WithReadApiAPIStreamHub(streamHub))
 	readRouter := qcreadapi.NewRouter(ReadApiAPIController)
 
 	fmt.Println("Read api server is listening on : ", ip + ":" + port, "nodeUrl" + ":" + nodeUrl, "corsAllowedOrigins" + ":" + corsAllowedOrigins)
 	http.ListenAndServe(ip + ":" + port, readRouter)
 }
 
-func qcWriteApi(ip string, port string, nodeUrl string, corsAllowedOrigins string, enableAuth bool, apiKeys string) {
-	WriteApiAPIService := qcwriteapi.NewWriteApiAPIService(nodeUrl)
-	WriteApiAPIController := qcwriteapi.NewWriteApiAPIController(WriteApiAPIService, corsAllowedOrigins, enableAuth, apiKeys)
+func qcWriteApi(ip string, port string, nodeUrl string, corsAllowedOrigins string, nodePool *relay.NodePool, tracker *qcwriteapi.TransactionTracker, apiKeyManager *relay.ApiKeyManager) {
+	WriteApiAPIService := qcwriteapi.NewWriteApiAPIService(nodePool, tracker)
+	WriteApiAPIController := qcwriteapi.NewWriteApiAPIController(WriteApiAPIService, corsAllowedOrigins, apiKeyManager)
 	writeRouter := qcwriteapi.NewRouter(WriteApiAPIController)
 
 	fmt.Println("Write api server is listening on : ", ip + ":" + port, "nodeUrl" + ":" + nodeUrl, "corsAllowedOrigins" + ":" + corsAllowedOrigins)
@@ -159,6 +186,6 @@ func readConfigJsonDataFile(filename string)  ([]relay.RelayConfig, error) {
 
 func printHelp() {
 	fmt.Println("===========")
-	fmt.Println("relay config.json")
+	fmt.Println("relay config.json [--metrics]")
 	fmt.Println("===========")
 }
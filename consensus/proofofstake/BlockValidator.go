@@ -569,8 +569,8 @@ func ValidateBlockConsensusDataInner(txns []common.Hash, parentHash common.Hash,
 
 // In this function, absolute time cannot be validated, since this function can get called at a different time, for example when new node is created and is reading old blocks
 // Hence only basic checks are allowed
-func ValidateBlockProposalTime(blockNumber uint64, proposedTime uint64) bool {
-	if blockNumber == 1 || blockNumber%BLOCK_PERIOD_TIME_CHANGE == 0 || blockNumber >= BLOCK_TIME_ORIG_START_BLOCK {
+func ValidateBlockProposalTime(blockNumber uint64, proposedTime uint64, blockTimeOrigStart uint64) bool {
+	if blockNumber == 1 || blockNumber%BLOCK_PERIOD_TIME_CHANGE == 0 || blockNumber >= blockTimeOrigStart {
 		if proposedTime == 0 {
 			return true
 		}
@@ -589,7 +589,8 @@ func ValidateBlockProposalTime(blockNumber uint64, proposedTime uint64) bool {
 }
 
 func ValidateBlockConsensusData(block *types.Block, validatorDepositMap *map[common.Address]*big.Int,
-	valDetailsMap *map[common.Address]*ValidatorDetailsV2, getBlockConsensusContext GetBlockConsensusContextFn, getValidatorsFn GetValidatorsFn) error {
+	valDetailsMap *map[common.Address]*ValidatorDetailsV2, getBlockConsensusContext GetBlockConsensusContextFn, getValidatorsFn GetValidatorsFn,
+	contextStartBlock uint64, blockTimeOrigStart uint64) error {
 	header := block.Header()
 
 	if header.ConsensusData == nil || header.UnhashedConsensusData == nil {
@@ -627,7 +628,7 @@ func ValidateBlockConsensusData(block *types.Block, validatorDepositMap *map[com
 		txnList = make([]common.Hash, 0)
 	}
 
-	if ValidateBlockProposalTime(block.Number().Uint64(), blockConsensusData.BlockTime) == false {
+	if ValidateBlockProposalTime(block.Number().Uint64(), blockConsensusData.BlockTime, blockTimeOrigStart) == false {
 		log.Warn("ValidateBlockProposalTime failed", "blockNumber", block.Number().Uint64(), "proposedTime", blockConsensusData.BlockTime)
 		return errors.New("ValidateBlockProposalTime failed")
 	}
@@ -643,7 +644,7 @@ func ValidateBlockConsensusData(block *types.Block, validatorDepositMap *map[com
 
 		preFilterValidatorCount := len(validators)
 
-		contextKey, err := GetBlockConsensusContextKeyForBlock(blockNumber)
+		contextKey, err := GetBlockConsensusContextKeyForBlock(blockNumber, contextStartBlock)
 		if err != nil {
 			return err
 		}
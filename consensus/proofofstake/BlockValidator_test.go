@@ -101,7 +101,7 @@ func BlockNilTest(blockConsensusData *BlockConsensusData, blockAdditionalConsens
 
 	block := types.NewBlock(header, txs[:], receipts, trie.NewStackTrie(nil))
 	valMap := make(map[common.Address]*big.Int)
-	err := ValidateBlockConsensusData(block, &valMap, nil, DummyGetBlockConsensusContext, nil)
+	err := ValidateBlockConsensusData(block, &valMap, nil, DummyGetBlockConsensusContext, nil, CONSENSUS_CONTEXT_START_BLOCK, BLOCK_TIME_ORIG_START_BLOCK)
 	if err == nil || strings.Compare(err.Error(), expectedError) != 0 {
 		debug.PrintStack()
 		t.Fatalf("BlockNilTest failed")
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/QuantumCoinProject/qc/accounts"
 	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/mclock"
 	"github.com/QuantumCoinProject/qc/crypto"
 	"github.com/QuantumCoinProject/qc/crypto/cryptobase"
 	"github.com/QuantumCoinProject/qc/crypto/hybrideds"
@@ -53,6 +54,9 @@ type ConsensusHandler struct {
 	getBlockConsensusContext        GetBlockConsensusContextFn
 	doesFinalizedTransactionExistFn DoesFinalizedTransactionExistFn
 	currentParentHash               common.Hash
+	clock                           mclock.Clock      //measures the consensus timeouts
+	goSend                          func(send func()) //runs the sends to the p2p network
+	forks                           *forkBlocks       //blocks at which the consensus rules of the chain change
 
 	timeStatMap map[string]int
 
@@ -61,13 +65,13 @@ type ConsensusHandler struct {
 	totalTransactions            uint64
 	maxTransactionsInBlock       uint64
 	maxTransactionsBlockTime     int64
-	initTime                     time.Time
+	initTime                     mclock.AbsTime
 	initialized                  bool
-	packetHashLastSentMap        map[common.Hash]time.Time
-	lastRequestConsensusDataTime time.Time
+	packetHashLastSentMap        map[common.Hash]mclock.AbsTime
+	lastRequestConsensusDataTime mclock.AbsTime
 
 	lastBlockNumber           uint64
-	lastBlockNumberChangeTime time.Time
+	lastBlockNumberChangeTime mclock.AbsTime
 
 	packetStats PacketStats
 
@@ -75,6 +79,8 @@ type ConsensusHandler struct {
 	latestBlockMutex  sync.RWMutex
 
 	peerHandler *PeerHandler
+
+	voteJournal *VoteJournal
 }
 
 type PacketStats struct {
@@ -97,7 +103,6 @@ type BlockAdditionalConsensusData struct {
 	InitTime         uint64                `json:"initTime" gencodec:"required"`
 }
 
-// todo: use mono clock
 var BLOCK_TIMEOUT_MS = int64(60000)
 var FULL_BLOCK_TIMEOUT_MS = int64(90000)
 var ACK_BLOCK_TIMEOUT_MS = 300000 //relative to start of block locally
@@ -207,13 +212,13 @@ type BlockRoundDetails struct {
 	validatorPrecommits   map[common.Address]*PreCommitDetails
 	validatorCommits      map[common.Address]*CommitDetails
 	validatorsDepositMap  map[common.Address]*big.Int
-	initTime              time.Time
+	initTime              mclock.AbsTime
 
 	selfKnownTransactions map[common.Hash]bool
 
 	selfProposed       bool
 	selfProposalPacket *eth.ConsensusPacket
-	selfProposedTime   time.Time
+	selfProposedTime   mclock.AbsTime
 
 	selfAckd                bool
 	selfAckPacket           *eth.ConsensusPacket
@@ -221,7 +226,8 @@ type BlockRoundDetails struct {
 
 	selfPrecommited     bool
 	selfPrecommitPacket *eth.ConsensusPacket
-	precommitInitTime   time.Time
+	precommitStarted    bool //whether the round is waiting for precommits since precommitInitTime
+	precommitInitTime   mclock.AbsTime
 
 	selfCommited     bool
 	selfCommitPacket *eth.ConsensusPacket
@@ -236,7 +242,8 @@ type BlockStateDetails struct {
 	validatorDetailsMap               *map[common.Address]*ValidatorDetailsV2
 	totalBlockDepositValue            *big.Int
 	blockMinWeightedProposalsRequired *big.Int
-	initTime                          time.Time
+	initTime                          mclock.AbsTime
+	initWallTime                      time.Time //only for the InitTime of the block, the timeouts use initTime
 	blockRoundMap                     map[byte]*BlockRoundDetails
 	currentRound                      byte
 	parentHash                        common.Hash
@@ -298,7 +305,17 @@ func GetTimeStatBucket(state string, ms int64) string {
 	return key
 }
 
-func NewConsensusPacketHandler() *ConsensusHandler {
+// ConsensusHandlerOption for how the consensus handler is set up.
+type ConsensusHandlerOption func(*ConsensusHandler)
+
+// WithClock sets the clock that measures the consensus timeouts, instead of the system clock.
+func WithClock(clock mclock.Clock) ConsensusHandlerOption {
+	return func(cph *ConsensusHandler) {
+		cph.clock = clock
+	}
+}
+
+func NewConsensusPacketHandler(opts ...ConsensusHandlerOption) *ConsensusHandler {
 	minVal := os.Getenv("MIN_VALIDATORS")
 	if len(minVal) > 0 {
 		var err error
@@ -356,6 +373,13 @@ func NewConsensusPacketHandler() *ConsensusHandler {
 		blockStateDetailsMap: make(map[common.Hash]*BlockStateDetails),
 		outOfOrderPacketsMap: make(map[common.Hash]map[common.Hash]*OutOfOrderPacket),
 		timeStatMap:          timeStatMap,
+		clock:                mclock.System{},
+		goSend:               goSend,
+		forks:                newForkBlocks(&params.ChainConfig{}),
+	}
+
+	for _, opt := range opts {
+		opt(cph)
 	}
 
 	cph.peerHandler = NewPeerHandler(isConsensusRelay, cph.GetLatestBlockNumber)
@@ -375,6 +399,21 @@ func (cph *ConsensusHandler) SetTransactionsFunction(doesFinalizedTransactionExi
 	cph.doesFinalizedTransactionExistFn = doesFinalizedTransactionExistFn
 }
 
+func (cph *ConsensusHandler) SetVoteJournal(voteJournal *VoteJournal) {
+	cph.outerPacketLock.Lock()
+	defer cph.outerPacketLock.Unlock()
+	cph.voteJournal = voteJournal
+}
+
+// getPacketBlockNumber returns the block number being decided on top of parentHash, falling back to the latest block number
+func (cph *ConsensusHandler) getPacketBlockNumber(parentHash common.Hash) uint64 {
+	blockStateDetails, ok := cph.blockStateDetailsMap[parentHash]
+	if ok {
+		return blockStateDetails.blockNumber
+	}
+	return cph.GetLatestBlockNumber()
+}
+
 func (cph *ConsensusHandler) isValidator(parentHash common.Hash) (bool, error) {
 	cph.outerPacketLock.Lock()
 	defer cph.outerPacketLock.Unlock()
@@ -606,13 +645,14 @@ func (cph *ConsensusHandler) initializeBlockStateIfRequired(parentHash common.Ha
 	cph.blockStateDetailsMap[parentHash] = &BlockStateDetails{
 		blockRoundMap:                make(map[byte]*BlockRoundDetails),
 		filteredValidatorsDepositMap: make(map[common.Address]*big.Int),
-		initTime:                     time.Now(),
+		initTime:                     cph.clock.Now(),
+		initWallTime:                 time.Now(),
 		parentHash:                   parentHash,
 		highestProposalRoundSeen:     0,
 		blockNumber:                  blockNumber,
 	}
 	blockStateDetails := cph.blockStateDetailsMap[parentHash]
-	cph.lastRequestConsensusDataTime = time.Now()
+	cph.lastRequestConsensusDataTime = cph.clock.Now()
 
 	validators, err := cph.getValidatorsFn(parentHash)
 	if err != nil {
@@ -669,7 +709,7 @@ func (cph *ConsensusHandler) initializeBlockStateIfRequired(parentHash common.Ha
 
 	//Consensus Context
 	if blockNumber >= CONTEXT_BASED_START_BLOCK {
-		contextKey, err := GetBlockConsensusContextKeyForBlock(blockNumber)
+		contextKey, err := GetBlockConsensusContextKeyForBlock(blockNumber, cph.forks.consensusContextStart)
 		if err != nil {
 			return err
 		}
@@ -706,7 +746,7 @@ func (cph *ConsensusHandler) initializeNewBlockRound(newRoundReason NewRoundReas
 		selfProposed:          false,
 		selfAckd:              false,
 		selfPrecommited:       false,
-		initTime:              time.Now(),
+		initTime:              cph.clock.Now(),
 		proposalAckPackets:    make(map[common.Address]*eth.ConsensusPacket),
 		precommitPackets:      make(map[common.Address]*eth.ConsensusPacket),
 		commitPackets:         make(map[common.Address]*eth.ConsensusPacket),
@@ -730,6 +770,13 @@ func (cph *ConsensusHandler) initializeNewBlockRound(newRoundReason NewRoundReas
 
 	cph.blockStateDetailsMap[cph.currentParentHash] = blockStateDetails
 
+	blockNumberGauge.Update(int64(blockStateDetails.blockNumber))
+	roundGauge.Update(int64(blockRoundDetails.Round))
+	roundCounter.Inc(1)
+	if proposer.IsEqualTo(cph.account.Address) {
+		selfProposerCounter.Inc(1)
+	}
+
 	return nil
 }
 
@@ -757,13 +804,14 @@ func (cph *ConsensusHandler) HandleConsensusPacket(packet *eth.ConsensusPacket,
 		return nil
 	}
 
-	if cph.initialized == false || HasExceededTimeThreshold(cph.initTime, STARTUP_DELAY_MS) == false {
+	if cph.initialized == false || HasExceededTimeThreshold(cph.clock, cph.initTime, STARTUP_DELAY_MS) == false {
 		log.Trace("received consensus packet, but consensus is not ready yet")
 		cph.peerHandler.HandleConsensusPacket(packet, fromPeerId)
 		return nil
 	}
 
 	cph.LogIncomingPacketStats()
+	markPacket("in", packet)
 	err := cph.processPacket(packet, fromPeerId)
 	if errors.Is(err, OutOfOrderPackerErr) {
 		pkt := eth.NewConsensusPacket(packet)
@@ -782,6 +830,7 @@ func (cph *ConsensusHandler) HandleConsensusPacket(packet *eth.ConsensusPacket,
 		}
 		packetMap[packet.ParentHash] = oooPacket
 		cph.outOfOrderPacketsMap[packet.ParentHash] = packetMap
+		cph.updateOutOfOrderPacketGauge()
 
 		return nil
 	}
@@ -860,6 +909,10 @@ func (cph *ConsensusHandler) processPacket(packet *eth.ConsensusPacket, fromPeer
 	}
 
 	log.Trace("processPacket", "validator", validator, "packetType", packetType)
+	if validator.IsEqualTo(cph.account.Address) && cph.voteJournal != nil && packetType <= CONSENSUS_PACKET_TYPE_COMMIT_BLOCK {
+		cph.voteJournal.RecordObserved(packet.ParentHash, cph.getPacketBlockNumber(packet.ParentHash), packet.ConsensusData)
+	}
+
 	if packetType == CONSENSUS_PACKET_TYPE_PROPOSE_BLOCK {
 		return cph.handleProposeBlockPacket(validator, packet, false)
 	} else if packetType == CONSENSUS_PACKET_TYPE_ACK_BLOCK_PROPOSAL {
@@ -909,6 +962,7 @@ func (cph *ConsensusHandler) processOutOfOrderPackets(parentHash common.Hash) er
 			cph.outOfOrderPacketsMap[parentHash] = packetMap
 		}
 	}
+	cph.updateOutOfOrderPacketGauge()
 
 	return nil
 }
@@ -981,7 +1035,7 @@ func (cph *ConsensusHandler) getBlockConsensusData(parentHash common.Hash) (bloc
 	blockConsensusData.PrecommitHash.CopyFrom(blockRoundDetails.precommitHash)
 
 	blockAdditionalConsensusData = &BlockAdditionalConsensusData{
-		InitTime: uint64(blockStateDetails.initTime.UnixNano() / int64(time.Millisecond)),
+		InitTime: uint64(blockStateDetails.initWallTime.UnixNano() / int64(time.Millisecond)),
 	}
 
 	consensusPackets := make([]eth.ConsensusPacket, 0)
@@ -1218,7 +1272,7 @@ func (cph *ConsensusHandler) handleProposeBlockPacket(validator common.Address,
 		return errors.New("invalid proposer")
 	}
 
-	if ValidateBlockProposalTimeConsensus(blockStateDetails.blockNumber, proposalDetails.BlockTime) == false {
+	if ValidateBlockProposalTimeConsensus(blockStateDetails.blockNumber, proposalDetails.BlockTime, cph.forks.blockTimeOrigStart) == false {
 		return errors.New("block time validation failed, skipping packet")
 	}
 
@@ -1276,14 +1330,17 @@ func (cph *ConsensusHandler) handleProposeBlockPacket(validator common.Address,
 			}
 		} else {
 			blockRoundDetails.state = BLOCK_STATE_WAITING_FOR_PROPOSAL_ACKS
-			blockStateDetails.proposalTime = Elapsed(blockStateDetails.initTime)
+			blockStateDetails.proposalTime = Elapsed(cph.clock, blockStateDetails.initTime)
 		}
 	} else {
-		blockStateDetails.proposalTime = Elapsed(blockStateDetails.initTime)
+		if blockRoundDetails.selfProposed == false {
+			selfProposedCounter.Inc(1)
+		}
+		blockStateDetails.proposalTime = Elapsed(cph.clock, blockStateDetails.initTime)
 		blockRoundDetails.state = BLOCK_STATE_WAITING_FOR_PROPOSAL_ACKS
 		blockRoundDetails.selfProposed = true
 		blockRoundDetails.selfProposalPacket = packet
-		blockRoundDetails.selfProposedTime = time.Now()
+		blockRoundDetails.selfProposedTime = cph.clock.Now()
 	}
 
 	pkt := eth.NewConsensusPacket(packet)
@@ -1577,7 +1634,7 @@ func (cph *ConsensusHandler) shouldMoveToNextRoundPrecommit(parentHash common.Ha
 	blockStateDetails := cph.blockStateDetailsMap[parentHash]
 	blockRoundDetails := blockStateDetails.blockRoundMap[blockStateDetails.currentRound]
 
-	if HasExceededTimeThreshold(blockRoundDetails.precommitInitTime, int64(ACK_BLOCK_TIMEOUT_MS*int(blockRoundDetails.Round))) == false {
+	if HasExceededTimeThreshold(cph.clock, blockRoundDetails.precommitInitTime, int64(ACK_BLOCK_TIMEOUT_MS*int(blockRoundDetails.Round))) == false {
 		log.Trace("shouldMoveToNextRoundPrecommit time not met", "blockRoundDetails.precommitInitTime", blockRoundDetails.precommitInitTime)
 		return false, nil
 	}
@@ -1725,6 +1782,9 @@ func (cph *ConsensusHandler) handlePrecommitPacket(validator common.Address, pac
 
 	blockRoundDetails.validatorPrecommits[validator] = precommitDetails
 	if self {
+		if blockRoundDetails.selfPrecommited == false {
+			selfPrecommitCounter.Inc(1)
+		}
 		blockRoundDetails.selfPrecommited = true
 		blockRoundDetails.selfPrecommitPacket = packet
 		log.Trace("self precomitted")
@@ -1739,7 +1799,7 @@ func (cph *ConsensusHandler) handlePrecommitPacket(validator common.Address, pac
 
 		log.Debug("handlePrecommitPacket", "totalVotesDepositCount", totalVotesDepositCount, "blockMinWeightedProposalsRequired", blockStateDetails.blockMinWeightedProposalsRequired)
 		if totalVotesDepositCount.Cmp(blockStateDetails.blockMinWeightedProposalsRequired) >= 0 {
-			blockStateDetails.precommitTime = Elapsed(blockStateDetails.initTime)
+			blockStateDetails.precommitTime = Elapsed(cph.clock, blockStateDetails.initTime)
 			blockRoundDetails.state = BLOCK_STATE_WAITING_FOR_COMMITS
 		}
 	}
@@ -1825,6 +1885,9 @@ func (cph *ConsensusHandler) handleCommitPacket(validator common.Address, packet
 
 	blockRoundDetails.validatorCommits[validator] = commitDetails
 	if self {
+		if blockRoundDetails.selfCommited == false {
+			selfCommitCounter.Inc(1)
+		}
 		blockRoundDetails.selfCommited = true
 		blockRoundDetails.selfCommitPacket = packet
 	}
@@ -1847,10 +1910,10 @@ func (cph *ConsensusHandler) handleCommitPacket(validator common.Address, packet
 				cph.totalTransactions = cph.totalTransactions + txnCountInBlock
 				if txnCountInBlock > cph.maxTransactionsInBlock {
 					cph.maxTransactionsInBlock = txnCountInBlock
-					cph.maxTransactionsBlockTime = Elapsed(blockStateDetails.initTime)
+					cph.maxTransactionsBlockTime = Elapsed(cph.clock, blockStateDetails.initTime)
 				}
 			}
-			blockStateDetails.commitTime = Elapsed(blockStateDetails.initTime)
+			blockStateDetails.commitTime = Elapsed(cph.clock, blockStateDetails.initTime)
 
 			//stats
 			cph.timeStatMap[GetTimeStatBucket(PROPOSAL_KEY_PREFIX, blockStateDetails.proposalTime)]++
@@ -1858,6 +1921,7 @@ func (cph *ConsensusHandler) handleCommitPacket(validator common.Address, packet
 			cph.timeStatMap[GetTimeStatBucket(PRECOMMIT_KEY_PREFIX, blockStateDetails.precommitTime-blockStateDetails.ackProposalTime)]++
 			cph.timeStatMap[GetTimeStatBucket(COMMIT_KEY_PREFIX, blockStateDetails.commitTime-blockStateDetails.precommitTime)]++
 			cph.timeStatMap[GetTimeStatBucket(TOTAL_KEY_PREFIX, blockStateDetails.commitTime)]++
+			cph.updateBlockMetrics(blockStateDetails, blockRoundDetails)
 
 			log.Debug("BlockStats", "maxTxnsInBlock", cph.maxTransactionsInBlock, "totalTxns", cph.totalTransactions, "okBlocks", cph.okVoteBlocks, "nilBlocks", cph.nilVoteBlocks)
 			for statKey, statVal := range cph.timeStatMap {
@@ -1883,10 +1947,15 @@ func (cph *ConsensusHandler) handleCommitPacket(validator common.Address, packet
 	return nil
 }
 
-func HasExceededTimeThreshold(startTime time.Time, thresholdMs int64) bool {
-	end := time.Now().UnixNano() / int64(time.Millisecond)
-	start := startTime.UnixNano() / int64(time.Millisecond)
-	diff := end - start
+// goSend runs a send to the p2p network in its own goroutine, so that a slow peer does not hold the locks of the
+// handler.
+func goSend(send func()) {
+	go send()
+}
+
+// HasExceededTimeThreshold returns whether thresholdMs milliseconds have passed on the clock since startTime.
+func HasExceededTimeThreshold(clock mclock.Clock, startTime mclock.AbsTime, thresholdMs int64) bool {
+	diff := Elapsed(clock, startTime)
 	if diff >= thresholdMs {
 		return true
 	} else {
@@ -1894,15 +1963,14 @@ func HasExceededTimeThreshold(startTime time.Time, thresholdMs int64) bool {
 	}
 }
 
-func Elapsed(startTime time.Time) int64 {
-	end := time.Now().UnixNano() / int64(time.Millisecond)
-	start := startTime.UnixNano() / int64(time.Millisecond)
-	diff := end - start
-	return diff
+// Elapsed returns the milliseconds passed on the clock since startTime. The handlers use a monotonic clock, so that
+// changes to the system time do not move the consensus rounds.
+func Elapsed(clock mclock.Clock, startTime mclock.AbsTime) int64 {
+	return int64(clock.Now().Sub(startTime) / time.Millisecond)
 }
 
-func GetProposalTime(blockNumber uint64) uint64 {
-	if blockNumber == 1 || blockNumber%BLOCK_PERIOD_TIME_CHANGE == 0 || blockNumber >= BLOCK_TIME_ORIG_START_BLOCK {
+func GetProposalTime(blockNumber uint64, blockTimeOrigStart uint64) uint64 {
+	if blockNumber == 1 || blockNumber%BLOCK_PERIOD_TIME_CHANGE == 0 || blockNumber >= blockTimeOrigStart {
 		blockTime := uint64(time.Now().UTC().Unix())
 		if blockTime%60 != 0 {
 			blockTime = blockTime - (blockTime % 60)
@@ -1914,8 +1982,8 @@ func GetProposalTime(blockNumber uint64) uint64 {
 	}
 }
 
-func ValidateBlockProposalTimeConsensus(blockNumber uint64, proposedTime uint64) bool {
-	if blockNumber == 1 || blockNumber%BLOCK_PERIOD_TIME_CHANGE == 0 || blockNumber >= BLOCK_TIME_ORIG_START_BLOCK {
+func ValidateBlockProposalTimeConsensus(blockNumber uint64, proposedTime uint64, blockTimeOrigStart uint64) bool {
+	if blockNumber == 1 || blockNumber%BLOCK_PERIOD_TIME_CHANGE == 0 || blockNumber >= blockTimeOrigStart {
 		if proposedTime == 0 {
 			return false
 		}
@@ -1970,7 +2038,7 @@ func (cph *ConsensusHandler) proposeBlock(parentHash common.Hash, txns []common.
 	} else {
 		proposalDetails.Txns = make([]common.Hash, 0)
 	}
-	proposalDetails.BlockTime = GetProposalTime(blockNumber)
+	proposalDetails.BlockTime = GetProposalTime(blockNumber, cph.forks.blockTimeOrigStart)
 
 	log.Trace("ProposeBlock with txns", "count", len(proposalDetails.Txns))
 
@@ -2061,6 +2129,9 @@ func (cph *ConsensusHandler) ackBlockProposalTimeout(parentHash common.Hash) err
 		pkt := eth.NewConsensusPacket(packet)
 		blockRoundDetails.proposalAckPackets[cph.account.Address] = &pkt
 		blockRoundDetails.validatorProposalAcks[cph.account.Address] = proposalAckDetails
+		if blockRoundDetails.selfAckd == false {
+			selfAckedCounter.Inc(1)
+		}
 		blockRoundDetails.selfAckd = true
 		blockRoundDetails.selfAckPacket = packet
 		blockRoundDetails.selfAckProposalVoteType = proposalAckDetails.ProposalAckVoteType
@@ -2103,11 +2174,12 @@ func (cph *ConsensusHandler) ackBlockProposalTimeout(parentHash common.Hash) err
 		//do nothing
 	} else if nilVotesDepositCount.Cmp(blockStateDetails.blockMinWeightedProposalsRequired) >= 0 { //handle timeout differently?
 		blockRoundDetails.state = BLOCK_STATE_WAITING_FOR_PRECOMMITS
-		blockRoundDetails.precommitInitTime = time.Now()
+		blockRoundDetails.precommitStarted = true
+		blockRoundDetails.precommitInitTime = cph.clock.Now()
 		blockRoundDetails.blockVoteType = VOTE_TYPE_NIL
 		blockRoundDetails.precommitHash.CopyFrom(getNilVotePreCommitHash(parentHash, blockStateDetails.currentRound))
 	} else {
-		if HasExceededTimeThreshold(blockRoundDetails.initTime, int64(ACK_BLOCK_TIMEOUT_MS*int(blockRoundDetails.Round))) {
+		if HasExceededTimeThreshold(cph.clock, blockRoundDetails.initTime, int64(ACK_BLOCK_TIMEOUT_MS*int(blockRoundDetails.Round))) {
 			if totalVotesDepositCount.Cmp(blockStateDetails.totalBlockDepositValue) >= 0 ||
 				totalVotesDepositCount.Cmp(blockStateDetails.blockMinWeightedProposalsRequired) >= 0 {
 				blockStateDetails.blockRoundMap[blockStateDetails.currentRound] = blockRoundDetails
@@ -2244,6 +2316,9 @@ func (cph *ConsensusHandler) ackBlockProposal(parentHash common.Hash) error {
 		pkt := eth.NewConsensusPacket(packet)
 		blockRoundDetails.proposalAckPackets[cph.account.Address] = &pkt
 		blockRoundDetails.validatorProposalAcks[cph.account.Address] = proposalAckDetails
+		if blockRoundDetails.selfAckd == false {
+			selfAckedCounter.Inc(1)
+		}
 		blockRoundDetails.selfAckd = true
 		blockRoundDetails.selfAckPacket = packet
 		blockRoundDetails.selfAckProposalVoteType = proposalAckDetails.ProposalAckVoteType
@@ -2282,20 +2357,22 @@ func (cph *ConsensusHandler) ackBlockProposal(parentHash common.Hash) error {
 
 	if okVotesDepositCount.Cmp(blockStateDetails.blockMinWeightedProposalsRequired) >= 0 && blockRoundDetails.selfAckProposalVoteType == VOTE_TYPE_OK { //For ok votes, vote type should match
 		blockRoundDetails.state = BLOCK_STATE_WAITING_FOR_PRECOMMITS
-		blockRoundDetails.precommitInitTime = time.Now()
+		blockRoundDetails.precommitStarted = true
+		blockRoundDetails.precommitInitTime = cph.clock.Now()
 		blockRoundDetails.precommitHash.CopyFrom(getOkVotePreCommitHash(parentHash, blockRoundDetails.proposalHash, blockStateDetails.currentRound))
 		blockRoundDetails.blockVoteType = VOTE_TYPE_OK
 		log.Trace("blockVoteType a1", "parentHash", parentHash)
-		blockStateDetails.ackProposalTime = Elapsed(blockStateDetails.initTime)
+		blockStateDetails.ackProposalTime = Elapsed(cph.clock, blockStateDetails.initTime)
 	} else if nilVotesDepositCount.Cmp(blockStateDetails.blockMinWeightedProposalsRequired) >= 0 { //handle timeout differently? for nil votes, it is ok to accept NIL vote even if self vote is OK
 		blockRoundDetails.state = BLOCK_STATE_WAITING_FOR_PRECOMMITS
-		blockRoundDetails.precommitInitTime = time.Now()
+		blockRoundDetails.precommitStarted = true
+		blockRoundDetails.precommitInitTime = cph.clock.Now()
 		blockRoundDetails.precommitHash.CopyFrom(getNilVotePreCommitHash(parentHash, blockStateDetails.currentRound))
 		log.Trace("blockVoteType a2", "parentHash", parentHash)
 		blockRoundDetails.blockVoteType = VOTE_TYPE_NIL
 	} else {
 		if totalVotesDepositCount.Cmp(blockStateDetails.totalBlockDepositValue) >= 0 ||
-			totalVotesDepositCount.Cmp(blockStateDetails.blockMinWeightedProposalsRequired) >= 0 && HasExceededTimeThreshold(blockRoundDetails.initTime, int64(ACK_BLOCK_TIMEOUT_MS*int(blockRoundDetails.Round))) {
+			totalVotesDepositCount.Cmp(blockStateDetails.blockMinWeightedProposalsRequired) >= 0 && HasExceededTimeThreshold(cph.clock, blockRoundDetails.initTime, int64(ACK_BLOCK_TIMEOUT_MS*int(blockRoundDetails.Round))) {
 			blockStateDetails.blockRoundMap[blockStateDetails.currentRound] = blockRoundDetails
 			cph.blockStateDetailsMap[parentHash] = blockStateDetails
 			err := cph.initializeNewBlockRound(NEW_ROUND_REASON_WAIT_ACK_BLOCK_PROPOSAL_TIMEOUT)
@@ -2555,25 +2632,25 @@ func (cph *ConsensusHandler) HandleConsensus(parentHash common.Hash, txns []comm
 			return errors.New("Waiting for previous block to mine")
 		}
 
-		cph.initTime = time.Now()
+		cph.initTime = cph.clock.Now()
 		cph.initialized = true
-		cph.packetHashLastSentMap = make(map[common.Hash]time.Time)
+		cph.packetHashLastSentMap = make(map[common.Hash]mclock.AbsTime)
 		cph.packetStats = PacketStats{}
 
 		return errors.New("starting up")
 	}
 
 	if cph.lastBlockNumber == blockNumber {
-		if Elapsed(cph.lastBlockNumberChangeTime) >= STALE_BLOCK_WARN_TIME && rndVal == 1 {
+		if Elapsed(cph.clock, cph.lastBlockNumberChangeTime) >= STALE_BLOCK_WARN_TIME && rndVal == 1 {
 			log.Warn("Stale Block. Please check your connection.", "blockNumber", blockNumber, "lastBlockChangeTime", cph.lastBlockNumberChangeTime)
 		}
 	} else {
 		cph.lastBlockNumber = blockNumber
-		cph.lastBlockNumberChangeTime = time.Now()
+		cph.lastBlockNumberChangeTime = cph.clock.Now()
 	}
 
-	if HasExceededTimeThreshold(cph.initTime, STARTUP_DELAY_MS) == false && rndVal == 1 {
-		log.Info("Waiting to startup...", "elapsed ms", Elapsed(cph.initTime), "pending txn count", len(txns), "STARTUP_DELAY_MS", STARTUP_DELAY_MS)
+	if HasExceededTimeThreshold(cph.clock, cph.initTime, STARTUP_DELAY_MS) == false && rndVal == 1 {
+		log.Info("Waiting to startup...", "elapsed ms", Elapsed(cph.clock, cph.initTime), "pending txn count", len(txns), "STARTUP_DELAY_MS", STARTUP_DELAY_MS)
 		return errors.New("starting up")
 	}
 
@@ -2636,7 +2713,7 @@ func (cph *ConsensusHandler) HandleConsensus(parentHash common.Hash, txns []comm
 				} else {
 					timeoutMs = BLOCK_TIMEOUT_MS
 				}
-				if HasExceededTimeThreshold(blockRoundDetails.initTime, timeoutMs*int64(blockRoundDetails.Round)) {
+				if HasExceededTimeThreshold(cph.clock, blockRoundDetails.initTime, timeoutMs*int64(blockRoundDetails.Round)) {
 					cph.ackBlockProposalTimeout(parentHash)
 				} else {
 					cph.requestConsensusData(blockStateDetails)
@@ -2679,6 +2756,14 @@ func (cph *ConsensusHandler) createConsensusPacket(parentHash common.Hash, data
 	if cph.signFn == nil {
 		return nil, errors.New("signFn is not set")
 	}
+	if cph.voteJournal != nil {
+		err := cph.voteJournal.CheckAndRecord(parentHash, cph.getPacketBlockNumber(parentHash), data)
+		if err != nil {
+			log.Error("createConsensusPacket vote journal check failed", "parentHash", parentHash, "err", err)
+			return nil, err
+		}
+	}
+
 	dataToSign := append(parentHash.Bytes(), data...)
 	var signature []byte
 	var err error
@@ -2709,7 +2794,7 @@ func (cph *ConsensusHandler) createConsensusPacket(parentHash common.Hash, data
 
 func (cph *ConsensusHandler) cleanupBroadcast() {
 	for k, v := range cph.packetHashLastSentMap {
-		elapsed := Elapsed(v)
+		elapsed := Elapsed(cph.clock, v)
 		if elapsed >= BROADCAST_CLEANUP_DELAY {
 			delete(cph.packetHashLastSentMap, k)
 		}
@@ -2746,21 +2831,24 @@ func (cph *ConsensusHandler) broadCast(packet *eth.ConsensusPacket) error {
 	packetType := ConsensusPacketType(packet.ConsensusData[startIndex-1])
 	lastSent, ok := cph.packetHashLastSentMap[hash]
 	if ok == false {
-		cph.packetHashLastSentMap[hash] = time.Now()
+		cph.packetHashLastSentMap[hash] = cph.clock.Now()
 		log.Trace("Broadcasting packet", "hash", hash, "packetType", packetType)
+		markPacket("out", packet)
 	} else {
-		elapsed := Elapsed(lastSent)
+		elapsed := Elapsed(cph.clock, lastSent)
 		if elapsed > BROADCAST_RESEND_DELAY {
-			cph.packetHashLastSentMap[hash] = time.Now()
+			cph.packetHashLastSentMap[hash] = cph.clock.Now()
 			log.Trace("Rebroadcasting packet", "hash", hash, "packetType", packetType)
+			markPacket("rebroadcast", packet)
 		} else {
 			log.Trace("Skipping broadcasting packet", "hash", hash, "packetType", packetType)
+			markPacket("skipped", packet)
 			return nil
 		}
 	}
 
 	cph.cleanupBroadcast()
-	go cph.p2pHandler.BroadcastConsensusData(packet)
+	cph.goSend(func() { cph.p2pHandler.BroadcastConsensusData(packet) })
 
 	return nil
 }
@@ -2835,12 +2923,12 @@ func (cph *ConsensusHandler) requestConsensusData(blockStateDetails *BlockStateD
 
 	lastSent, ok := cph.packetHashLastSentMap[hash]
 	if ok == false {
-		cph.packetHashLastSentMap[hash] = time.Now()
+		cph.packetHashLastSentMap[hash] = cph.clock.Now()
 		log.Trace("requestConsensusData packet", "hash", hash)
 	} else {
-		elapsed := Elapsed(lastSent)
+		elapsed := Elapsed(cph.clock, lastSent)
 		if elapsed > BROADCAST_RESEND_DELAY*3 {
-			cph.packetHashLastSentMap[hash] = time.Now()
+			cph.packetHashLastSentMap[hash] = cph.clock.Now()
 			log.Trace("requestConsensusData packet", "hash", hash)
 		} else {
 			log.Trace("Skipping requestConsensusData packet", "hash", hash)
@@ -2848,16 +2936,16 @@ func (cph *ConsensusHandler) requestConsensusData(blockStateDetails *BlockStateD
 		}
 	}
 
-	elapsed := Elapsed(blockStateDetails.initTime)
+	elapsed := Elapsed(cph.clock, blockStateDetails.initTime)
 	if elapsed < BLOCK_TIMEOUT_MS {
 		return nil
 	}
 
-	elapsed = Elapsed(cph.lastRequestConsensusDataTime)
+	elapsed = Elapsed(cph.clock, cph.lastRequestConsensusDataTime)
 	if elapsed < CONSENSUS_DATA_REQUEST_RESEND_DELAY {
 		return nil
 	}
-	cph.lastRequestConsensusDataTime = time.Now()
+	cph.lastRequestConsensusDataTime = cph.clock.Now()
 
 	log.Trace("requestConsensusData 1")
 	requestPacketDetails, err := cph.getRequestConsensusDataPacket(blockStateDetails)
@@ -2876,7 +2964,7 @@ func (cph *ConsensusHandler) requestConsensusData(blockStateDetails *BlockStateD
 	copy(packet.RequestData, data)
 	packet.ParentHash = blockStateDetails.parentHash
 
-	go cph.p2pHandler.RequestConsensusData(&packet)
+	cph.goSend(func() { cph.p2pHandler.RequestConsensusData(&packet) })
 
 	return nil
 }
@@ -2887,7 +2975,7 @@ func (cph *ConsensusHandler) cleanupBlockState() {
 			continue
 		}
 
-		if Elapsed(blockStateDetails.initTime) >= BLOCK_CLEANUP_TIME_MS {
+		if Elapsed(cph.clock, blockStateDetails.initTime) >= BLOCK_CLEANUP_TIME_MS {
 			delete(cph.blockStateDetailsMap, key)
 		}
 	}
@@ -2905,7 +2993,7 @@ func (cph *ConsensusHandler) HandleRequestConsensusDataPacket(packet *eth.Reques
 		return nil, errors.New("invalid request consensus data packet")
 	}
 
-	if cph.initialized == false || HasExceededTimeThreshold(cph.initTime, STARTUP_DELAY_MS) == false {
+	if cph.initialized == false || HasExceededTimeThreshold(cph.clock, cph.initTime, STARTUP_DELAY_MS) == false {
 		return nil, errors.New("received request for consensus packet, but consensus is not ready yet")
 	}
 
@@ -38,6 +38,7 @@ type PeerHandler struct {
 	isConsensusRelay       bool
 	getLatestBlockNumberFn GetLatestBlockNumberFn
 	localPeerId            string
+	goSend                 func(send func())                  //runs the sends to the p2p network
 	consensusRelayMap      map[string]bool                    //List of connected ConsensusRelays
 	syncPeerMap            map[string]bool                    //List of peers who have requested for consensus sync (i.e. ConsensusRelaying consensus packets)
 	packetSyncMap          map[common.Hash]*PacketSyncDetails //packet hash is the key
@@ -83,6 +84,7 @@ func NewPeerHandler(isConsensusRelay bool, getLatestBlockNumberFn GetLatestBlock
 		consensusRelayMap:      make(map[string]bool),
 		syncPeerMap:            make(map[string]bool),
 		packetSyncMap:          make(map[common.Hash]*PacketSyncDetails),
+		goSend:                 goSend,
 	}
 }
 
@@ -169,8 +171,10 @@ func (p *PeerHandler) HandleConsensusPacket(packet *eth.ConsensusPacket, fromPee
 	} else if packetType >= CONSENSUS_PACKET_TYPE_PROPOSE_BLOCK && packetType <= CONSENSUS_PACKET_TYPE_COMMIT_BLOCK {
 		p.peerLock.Lock()
 		p.packetsReceivedTotalCurrentParentHash = p.packetsReceivedTotalCurrentParentHash + 1 //todo: check parentHash before updating these counters
+		peerPacketsInCounter.Inc(1)
 		if p.consensusRelayMap[fromPeerId] == true {
 			p.packetsReceivedFromRelayTotalCurrentParentHash = p.packetsReceivedFromRelayTotalCurrentParentHash + 1
+			peerPacketsInRelayCounter.Inc(1)
 		}
 		p.peerLock.Unlock()
 
@@ -404,11 +408,13 @@ func (p *PeerHandler) BroadcastToConsensusRelays(packet *eth.ConsensusPacket, fr
 
 	log.Debug("BroadcastToConsensusRelays", "relay count", len(p.consensusRelayMap), "send list count", len(sendList), "alreadySentCount", alreadySentCount, "packetHash", packet.Hash(), "parentHash", packet.ParentHash)
 	p.packetsSentToRelaysCurrentParentHash = p.packetsSentToRelaysCurrentParentHash + int64(len(sendList))
+	peerPacketsOutRelayCounter.Inc(int64(len(sendList)))
 	if fromPeerId == p.localPeerId {
 		p.localPacketsSentToRelaysCurrentParentHash = p.localPacketsSentToRelaysCurrentParentHash + int64(len(sendList))
+		peerLocalPacketsRelayCounter.Inc(int64(len(sendList)))
 	}
 
-	go p.p2pHandler.SendConsensusPacket(sendList, packet)
+	p.goSend(func() { p.p2pHandler.SendConsensusPacket(sendList, packet) })
 
 	return len(sendList)
 }
@@ -469,7 +475,8 @@ func (p *PeerHandler) BroadcastToSyncPeers(packet *eth.ConsensusPacket, fromPeer
 		"packetHash", packet.Hash(), "parentHash", packet.ParentHash)
 
 	p.packetsSentCurrentParentHash = p.packetsSentCurrentParentHash + int64(len(sendPeerList))
-	go p.p2pHandler.SendConsensusPacket(sendPeerList, packet)
+	peerPacketsOutCounter.Inc(int64(len(sendPeerList)))
+	p.goSend(func() { p.p2pHandler.SendConsensusPacket(sendPeerList, packet) })
 
 	return len(sendPeerList)
 }
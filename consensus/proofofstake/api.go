@@ -23,11 +23,11 @@ import (
 	"github.com/QuantumCoinProject/qc/common"
 	"github.com/QuantumCoinProject/qc/common/hexutil"
 	"github.com/QuantumCoinProject/qc/consensus"
-	"github.com/QuantumCoinProject/qc/core"
 	"github.com/QuantumCoinProject/qc/core/types"
 	"github.com/QuantumCoinProject/qc/crypto"
 	"github.com/QuantumCoinProject/qc/internal/ethapi"
 	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/params"
 	"github.com/QuantumCoinProject/qc/rlp"
 	"github.com/QuantumCoinProject/qc/rpc"
 	"github.com/QuantumCoinProject/qc/systemcontracts/conversion"
@@ -109,7 +109,7 @@ func (api *API) GetStakingDetailsByValidatorAddress(validator common.Address, bl
 		return nil, errUnknownBlock
 	}
 
-	if blockNumber < STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	if blockNumber < api.proofofstake.forks.stakingContractV2 {
 		return api.proofofstake.GetStakingDetailsByValidatorAddress(validator, header.Hash())
 	} else {
 		validatorDetailsV2, err := api.proofofstake.GetStakingDetailsByValidatorAddressV2(validator, header.Hash())
@@ -157,7 +157,7 @@ func (api *API) GetStakingDetailsByDepositorAddress(depositor common.Address, bl
 		return nil, err
 	}
 
-	if blockNumber < STAKING_CONTRACT_V2_CUTOFF_BLOCK {
+	if blockNumber < api.proofofstake.forks.stakingContractV2 {
 		return api.proofofstake.GetStakingDetailsByValidatorAddress(validator, header.Hash())
 	} else {
 		validatorDetailsV2, err := api.proofofstake.GetStakingDetailsByValidatorAddressV2(validator, header.Hash())
@@ -310,7 +310,35 @@ func (api *API) GetBlockProposalDetails(blockNumberHex string) (*ProposalExtende
 	return nil, errors.New("proposal packet not found")
 }
 
-func ParseRewardsInfo(block *types.Block, receipts []*types.Receipt) (*BlockRewardsInfo, error) {
+// GetRoundState returns the state of the consensus round of the block being created, as seen by this node.
+func (api *API) GetRoundState() (*RoundState, error) {
+	if api.proofofstake.consensusHandler == nil {
+		return nil, errors.New("consensus handler is not running")
+	}
+	return api.proofofstake.consensusHandler.getRoundState(), nil
+}
+
+// GetValidatorPerformance returns the proposals, missed proposals, slashings and votes of a validator between two
+// blocks, both included. The current block is used if toBlockHex is empty.
+func (api *API) GetValidatorPerformance(validator common.Address, fromBlockHex string, toBlockHex string) (*ValidatorPerformanceDetails, error) {
+	fromBlock, err := hexutil.DecodeUint64(fromBlockHex)
+	if err != nil {
+		return nil, err
+	}
+	var toBlock uint64
+	if len(toBlockHex) == 0 {
+		toBlock = api.chain.CurrentHeader().Number.Uint64()
+	} else {
+		toBlock, err = hexutil.DecodeUint64(toBlockHex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return api.proofofstake.GetValidatorPerformance(api.chain, validator, fromBlock, toBlock)
+}
+
+func ParseRewardsInfo(config *params.ChainConfig, block *types.Block, receipts []*types.Receipt) (*BlockRewardsInfo, error) {
 	blockRewardsInfo := &BlockRewardsInfo{}
 
 	blockConsensusData := &BlockConsensusData{}
@@ -323,18 +351,19 @@ func ParseRewardsInfo(block *types.Block, receipts []*types.Receipt) (*BlockRewa
 		return nil, err
 	}
 
+	forks := newForkBlocks(config)
 	if blockConsensusData.VoteType == VOTE_TYPE_OK {
-		blockRewards := GetReward(header.Number)
+		blockRewards := getReward(header.Number, forks.rewardStart)
 		blockRewardsInfo.BaseBlockProposerRewards = hexutil.EncodeBig(blockRewards)
 
 		if len(block.Transactions()) > 0 {
-			txnFeeTotal, rewardsAmountTxnFee, burnAmountTxnFee, err := calculateTxnFeeSplit(blockRewards, block.Transactions(), receipts)
+			txnFeeTotal, rewardsAmountTxnFee, burnAmountTxnFee, err := calculateTxnFeeSplit(blockRewards, block.Transactions(), receipts, config, header.Number)
 			if err != nil {
 				log.Error("pos calculateTxnFeeSplit", "error", err)
 				return nil, err
 			}
 
-			if header.Number.Uint64() >= core.TXN_FEE_CUTTOFF_BLOCK {
+			if header.Number.Uint64() >= forks.txnFeeCutoff {
 				blockRewards = common.SafeAddBigInt(blockRewards, rewardsAmountTxnFee)
 				blockRewardsInfo.TxnFeeRewards = hexutil.EncodeBig(rewardsAmountTxnFee)
 				blockRewardsInfo.BurntTxnFee = hexutil.EncodeBig(burnAmountTxnFee)
@@ -349,7 +378,7 @@ func ParseRewardsInfo(block *types.Block, receipts []*types.Receipt) (*BlockRewa
 		blockRewardsInfo.BlockProposerRewards = hexutil.EncodeUint64(0)
 
 		totalSlashings := big.NewInt(0)
-		if blockConsensusData.Round == 1 && blockConsensusData.SlashedBlockProposers != nil && len(blockConsensusData.SlashedBlockProposers) > 0 && header.Number.Uint64() >= slashStartBlockNumber {
+		if blockConsensusData.Round == 1 && blockConsensusData.SlashedBlockProposers != nil && len(blockConsensusData.SlashedBlockProposers) > 0 && header.Number.Uint64() >= forks.slashStart {
 			blockRewardsInfo.SlashedValidators = make([]*Slashing, len(blockConsensusData.SlashedBlockProposers))
 			for i, val := range blockConsensusData.SlashedBlockProposers {
 				slashing := &Slashing{
@@ -438,7 +467,7 @@ func (api *API) GetBlockConsensusData(blockNumberHex string) (*ConsensusData, er
 		}
 	}
 
-	consensusData.BlockRewardsInfo, err = ParseRewardsInfo(block, receipts)
+	consensusData.BlockRewardsInfo, err = ParseRewardsInfo(api.chain.Config(), block, receipts)
 	if err != nil {
 		return nil, err
 	}
@@ -654,7 +683,7 @@ func (api *API) GetBlockConsensusContext(blockNumber uint64) ([32]byte, error) {
 	currentheader := api.chain.CurrentHeader()
 
 	var context [32]byte
-	key, err := GetConsensusContextKey(blockNumber)
+	key, err := consensusContextKey(blockNumber, api.proofofstake.forks.consensusContextStart)
 	if err != nil {
 		return context, err
 	}
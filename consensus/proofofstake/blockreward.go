@@ -23,6 +23,15 @@ var (
 )
 
 func GetReward(blockNumber *big.Int) *big.Int {
+	return getReward(blockNumber, rewardStartBlock)
+}
+
+// GetReward returns the block reward of the chain of the engine, whose rewards start at its reward start block.
+func (c *ProofOfStake) GetReward(blockNumber *big.Int) *big.Int {
+	return getReward(blockNumber, c.forks.rewardStart)
+}
+
+func getReward(blockNumber *big.Int, rewardStartBlock *big.Int) *big.Int {
 
 	blockReward := big.NewInt(0)
 
@@ -135,8 +135,14 @@ func (p *ProofOfStake) GetConsensusContext(key string, blockHash common.Hash) ([
 }
 
 func GetConsensusContextKey(blockNumber uint64) (string, error) {
+	return consensusContextKey(blockNumber, CONSENSUS_CONTEXT_START_BLOCK)
+}
+
+// consensusContextKey returns the consensus context key of a block of a chain whose consensus context starts at
+// contextStartBlock.
+func consensusContextKey(blockNumber uint64, contextStartBlock uint64) (string, error) {
 	var key string
-	if blockNumber <= CONSENSUS_CONTEXT_START_BLOCK {
+	if blockNumber <= contextStartBlock {
 		return key, errors.New("GetBlockConsensusContextFn blockNumber below CONSENSUS_CONTEXT_START_BLOCK")
 	}
 
@@ -146,15 +152,17 @@ func GetConsensusContextKey(blockNumber uint64) (string, error) {
 	return key, nil
 }
 
-func GetBlockConsensusContextKeyForBlock(currrentBlockNumber uint64) (string, error) {
+// GetBlockConsensusContextKeyForBlock returns the key of the consensus context that a block uses, for a chain whose
+// consensus context starts at contextStartBlock.
+func GetBlockConsensusContextKeyForBlock(currrentBlockNumber uint64, contextStartBlock uint64) (string, error) {
 	var key string
 	if currrentBlockNumber < CONTEXT_BASED_START_BLOCK {
 		return key, errors.New("GetBlockConsensusContextFn blockNumber below CONTEXT_BASED_START_BLOCK")
 	}
 
-	if currrentBlockNumber > CONSENSUS_CONTEXT_START_BLOCK+CONSENSUS_CONTEXT_MAX_BLOCK_COUNT {
-		return GetConsensusContextKey(currrentBlockNumber - CONSENSUS_CONTEXT_MAX_BLOCK_COUNT)
+	if currrentBlockNumber > contextStartBlock+CONSENSUS_CONTEXT_MAX_BLOCK_COUNT {
+		return consensusContextKey(currrentBlockNumber-CONSENSUS_CONTEXT_MAX_BLOCK_COUNT, contextStartBlock)
 	} else {
-		return GetConsensusContextKey(currrentBlockNumber - CONTEXT_BASED_BLOCK_THRESHOLD)
+		return consensusContextKey(currrentBlockNumber-CONTEXT_BASED_BLOCK_THRESHOLD, contextStartBlock)
 	}
 }
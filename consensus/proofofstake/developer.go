@@ -0,0 +1,164 @@
+package proofofstake
+
+import (
+	"bytes"
+	"errors"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/consensus"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/crypto"
+	"github.com/QuantumCoinProject/qc/eth/protocols/eth"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/rlp"
+	"sort"
+	"time"
+)
+
+// A developer chain is sealed by the local node alone, without consensus rounds. The blocks carry consensus data
+// with a single round and no consensus packets, so that Finalize rewards the proposer like on a network. The fork
+// blocks of the developer genesis config apply the consensus rules of the network from the first block.
+
+var errNoDeveloperTransactions = errors.New("no pending transactions")
+
+// developerTransactions selects all the pending transactions. Without a block period, blocks are only sealed when
+// there are transactions to include.
+func (c *ProofOfStake) developerTransactions(txnMap map[common.Address]types.Transactions) (map[common.Address]types.Transactions, error) {
+	if c.config.Period == 0 {
+		txns, _ := flattenTxnMap(txnMap, false)
+		if len(txns) == 0 {
+			return nil, errNoDeveloperTransactions
+		}
+	}
+	return txnMap, nil
+}
+
+// developerBlockProposer returns the proposer of a developer block. The validators of the staking contract propose
+// the blocks in turn; the proposer is the zero address if there are none.
+func (c *ProofOfStake) developerBlockProposer(header *types.Header) (common.Address, error) {
+	validatorDepositMap, err := c.GetValidators(header.ParentHash)
+	if err != nil {
+		log.Error("developerBlockProposer GetValidators", "err", err)
+		return common.Address{}, err
+	}
+	if len(validatorDepositMap) == 0 {
+		return common.Address{}, nil
+	}
+	validators := make([]common.Address, 0, len(validatorDepositMap))
+	for validator := range validatorDepositMap {
+		validators = append(validators, validator)
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i].Bytes(), validators[j].Bytes()) < 0
+	})
+	return validators[header.Number.Uint64()%uint64(len(validators))], nil
+}
+
+// getDeveloperConsensusData returns the consensus data of a developer block, proposed by developerBlockProposer. A
+// nil block is created if the chain has no validators.
+func (c *ProofOfStake) getDeveloperConsensusData(chain consensus.ChainHeaderReader, header *types.Header, txs []*types.Transaction) (*BlockConsensusData, *BlockAdditionalConsensusData, error) {
+	number := header.Number.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return nil, nil, consensus.ErrUnknownAncestor
+	}
+
+	blockProposer, err := c.developerBlockProposer(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	blockTime := uint64(now.Unix())
+	if blockTime < parent.Time+c.config.Period {
+		blockTime = parent.Time + c.config.Period
+	}
+
+	selectedTxns := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		selectedTxns[i] = tx.Hash()
+	}
+
+	blockConsensusData := &BlockConsensusData{
+		VoteType:              VOTE_TYPE_NIL,
+		SlashedBlockProposers: make([]common.Address, 0),
+		Round:                 1,
+		SelectedTransactions:  selectedTxns,
+		BlockTime:             blockTime,
+	}
+	if blockProposer.IsEqualTo(ZERO_ADDRESS) == false {
+		blockConsensusData.VoteType = VOTE_TYPE_OK
+		blockConsensusData.BlockProposer = blockProposer
+	} else {
+		log.Warn("No validators in the staking contract, creating a nil block", "number", number)
+	}
+	blockConsensusData.ProposalHash = developerProposalHash(header.ParentHash, blockProposer)
+	blockConsensusData.PrecommitHash = blockConsensusData.ProposalHash
+
+	blockAdditionalConsensusData := &BlockAdditionalConsensusData{
+		ConsensusPackets: make([]eth.ConsensusPacket, 0),
+		InitTime:         uint64(now.UnixNano() / int64(time.Millisecond)),
+	}
+	return blockConsensusData, blockAdditionalConsensusData, nil
+}
+
+// verifyDeveloperBlock checks the consensus data of a developer block against the rules of getDeveloperConsensusData,
+// since there are no consensus packets to validate.
+func (c *ProofOfStake) verifyDeveloperBlock(chain consensus.ChainHeaderReader, block *types.Block) error {
+	header := block.Header()
+	number := header.Number.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	if header.ConsensusData == nil || header.UnhashedConsensusData == nil {
+		return errors.New("verifyDeveloperBlock consensus data is nil")
+	}
+
+	blockConsensusData := &BlockConsensusData{}
+	if err := rlp.DecodeBytes(header.ConsensusData, blockConsensusData); err != nil {
+		return err
+	}
+	blockAdditionalConsensusData := &BlockAdditionalConsensusData{}
+	if err := rlp.DecodeBytes(header.UnhashedConsensusData, blockAdditionalConsensusData); err != nil {
+		return err
+	}
+	if blockConsensusData.Round != 1 || len(blockConsensusData.SlashedBlockProposers) > 0 || len(blockAdditionalConsensusData.ConsensusPackets) > 0 {
+		return errors.New("verifyDeveloperBlock developer blocks have a single round without consensus packets")
+	}
+
+	blockProposer, err := c.developerBlockProposer(header)
+	if err != nil {
+		return err
+	}
+	voteType := VOTE_TYPE_OK
+	if blockProposer.IsEqualTo(ZERO_ADDRESS) {
+		voteType = VOTE_TYPE_NIL
+	}
+	if blockConsensusData.VoteType != voteType || blockConsensusData.BlockProposer.IsEqualTo(blockProposer) == false {
+		return errors.New("verifyDeveloperBlock unexpected block proposer")
+	}
+	proposalHash := developerProposalHash(header.ParentHash, blockProposer)
+	if blockConsensusData.ProposalHash.IsEqualTo(proposalHash) == false || blockConsensusData.PrecommitHash.IsEqualTo(proposalHash) == false {
+		return errors.New("verifyDeveloperBlock unexpected proposal hash")
+	}
+
+	txns := block.Transactions()
+	if len(blockConsensusData.SelectedTransactions) != len(txns) {
+		return errors.New("verifyDeveloperBlock selected transactions mismatch")
+	}
+	for i, txn := range txns {
+		if blockConsensusData.SelectedTransactions[i].IsEqualTo(txn.Hash()) == false {
+			return errors.New("verifyDeveloperBlock selected transactions mismatch")
+		}
+	}
+
+	if blockConsensusData.BlockTime < parent.Time+c.config.Period {
+		return errors.New("verifyDeveloperBlock block time is before the block period")
+	}
+	return nil
+}
+
+// developerProposalHash returns the proposal hash of a developer block, which has no proposal packet to hash.
+func developerProposalHash(parentHash common.Hash, blockProposer common.Address) common.Hash {
+	return crypto.Keccak256Hash(parentHash.Bytes(), blockProposer.Bytes())
+}
@@ -0,0 +1,189 @@
+package proofofstake_test
+
+import (
+	"errors"
+	"github.com/QuantumCoinProject/qc/accounts"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
+	"github.com/QuantumCoinProject/qc/core"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/crypto/cryptobase"
+	"github.com/QuantumCoinProject/qc/crypto/signaturealgorithm"
+	"github.com/QuantumCoinProject/qc/eth"
+	"github.com/QuantumCoinProject/qc/eth/ethconfig"
+	"github.com/QuantumCoinProject/qc/node"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// The developer chain tests are an external test package, since the engine needs the blockchain api of an eth
+// backend, and the eth package imports the engine.
+
+func newDeveloperBackend(t *testing.T, period uint64, faucet common.Address) (*eth.Ethereum, *proofofstake.ProofOfStake) {
+	stack, err := node.New(&node.Config{DataDir: t.TempDir(), UseLightweightKDF: true})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	//The stack is not closed, since the p2p handler is shared by the backends of the process (see the console tests)
+
+	genesis, err := core.DeveloperGenesisBlock(period, faucet)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	backend, err := eth.New(stack, &ethconfig.Config{Genesis: genesis})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if err = stack.Start(); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	engine, ok := backend.Engine().(*proofofstake.ProofOfStake)
+	if ok == false {
+		t.Fatalf("failed %T", backend.Engine())
+	}
+	//Developer blocks are not signed, the sign functions only mark the node as a miner
+	signFn := func(signer accounts.Account, mimeType string, message []byte) ([]byte, error) {
+		return nil, nil
+	}
+	signFnWithContext := func(signer accounts.Account, mimeType string, message []byte, context []byte) ([]byte, error) {
+		return nil, nil
+	}
+	validator := core.DeveloperValidator(faucet)
+	engine.Authorize(validator, signFn, signFnWithContext, nil, accounts.Account{Address: validator})
+	return backend, engine
+}
+
+// sealDeveloperBlock seals a block with the transactions on top of the head of the chain, like the miner does, and
+// inserts it in the chain.
+func sealDeveloperBlock(backend *eth.Ethereum, engine *proofofstake.ProofOfStake, txs types.Transactions) (*types.Block, error) {
+	chain := backend.BlockChain()
+	parent := chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+	}
+	if err := engine.Prepare(chain, header); err != nil {
+		return nil, err
+	}
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	signer := types.NewLondonSigner(chain.Config().ChainID)
+	txnMap := make(map[common.Address]types.Transactions)
+	for _, tx := range txs {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, err
+		}
+		txnMap[from] = append(txnMap[from], tx)
+	}
+	if _, err = engine.HandleTransactions(chain, header, statedb, txnMap); err != nil {
+		return nil, err
+	}
+
+	gasPool := new(core.GasPool).AddGas(header.GasLimit)
+	receipts := make([]*types.Receipt, len(txs))
+	for i, tx := range txs {
+		statedb.Prepare(tx.Hash(), i)
+		receipts[i], err = core.ApplyTransaction(chain.Config(), chain, &header.Coinbase, gasPool, statedb, header, tx, &header.GasUsed, *chain.GetVMConfig(), false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	block, err := engine.FinalizeAndAssembleWithConsensus(chain, header, statedb, txs, receipts)
+	if err != nil {
+		return nil, err
+	}
+	results := make(chan *types.Block, 1)
+	if err = engine.Seal(chain, block, results, nil); err != nil {
+		return nil, err
+	}
+	select {
+	case block = <-results:
+	case <-time.After(10 * time.Second):
+		return nil, errors.New("seal timeout")
+	}
+
+	if _, err = chain.InsertChain(types.Blocks{block}); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func TestDeveloperSeal_noPeriod(t *testing.T) {
+	key, err := cryptobase.SigAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	faucet := cryptobase.SigAlg.PublicKeyToAddressNoError(&key.PublicKey)
+	backend, engine := newDeveloperBackend(t, 0, faucet)
+
+	//Without a block period, a block is only sealed when there are transactions
+	if _, err := sealDeveloperBlock(backend, engine, nil); err == nil {
+		t.Fatalf("failed")
+	}
+
+	tx := signDeveloperTransaction(t, backend, key, 0)
+	block, err := sealDeveloperBlock(backend, engine, types.Transactions{tx})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if block.NumberU64() != 1 || len(block.Transactions()) != 1 || backend.BlockChain().CurrentBlock().Hash() != block.Hash() {
+		t.Fatalf("failed %d %d", block.NumberU64(), len(block.Transactions()))
+	}
+
+	//The proposer gets the block reward and its share of the transaction fee
+	rewards, err := engine.GetDepositorRewards(faucet, block.Hash())
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	blockReward := engine.GetReward(block.Number())
+	if blockReward.Sign() <= 0 || rewards.Cmp(blockReward) <= 0 {
+		t.Fatalf("failed %v %v", rewards, blockReward)
+	}
+}
+
+func TestDeveloperSeal_period(t *testing.T) {
+	period := uint64(1)
+	faucet := common.BytesToAddress([]byte{0x0f})
+	backend, engine := newDeveloperBackend(t, period, faucet)
+
+	var blocks []*types.Block
+	for i := 0; i < 2; i++ {
+		block, err := sealDeveloperBlock(backend, engine, nil)
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		blocks = append(blocks, block)
+	}
+	if blocks[1].NumberU64() != 2 || blocks[1].Time() < blocks[0].Time()+period {
+		t.Fatalf("failed %d %d %d", blocks[1].NumberU64(), blocks[0].Time(), blocks[1].Time())
+	}
+
+	//The proposer gets the block reward of each block
+	expected := new(big.Int)
+	for i, block := range blocks {
+		expected.Add(expected, engine.GetReward(block.Number()))
+		rewards, err := engine.GetDepositorRewards(faucet, block.Hash())
+		if err != nil || rewards.Cmp(expected) != 0 {
+			t.Fatalf("failed %d %v %v %v", i, rewards, expected, err)
+		}
+	}
+}
+
+func signDeveloperTransaction(t *testing.T, backend *eth.Ethereum, key *signaturealgorithm.PrivateKey, nonce uint64) *types.Transaction {
+	chainID := backend.BlockChain().Config().ChainID
+	to := common.BytesToAddress([]byte{0x01})
+	tx := types.NewDefaultFeeTransaction(chainID, nonce, &to, big.NewInt(1), 21000, types.GAS_TIER_DEFAULT, nil)
+	tx, err := types.SignTx(tx, types.NewLondonSigner(chainID), key)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	return tx
+}
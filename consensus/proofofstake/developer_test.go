@@ -0,0 +1,81 @@
+package proofofstake
+
+import (
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/core"
+	"github.com/QuantumCoinProject/qc/core/rawdb"
+	"github.com/QuantumCoinProject/qc/core/state"
+	"github.com/QuantumCoinProject/qc/params"
+	"github.com/QuantumCoinProject/qc/systemcontracts/consensuscontext"
+	"github.com/QuantumCoinProject/qc/systemcontracts/conversion"
+	"github.com/QuantumCoinProject/qc/systemcontracts/staking"
+	"math/big"
+	"testing"
+)
+
+func TestDeveloperGenesis(t *testing.T) {
+	faucet := common.BytesToAddress([]byte{0x0f})
+	validator := core.DeveloperValidator(faucet)
+
+	genesis, err := core.DeveloperGenesisBlock(0, faucet)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if genesis.Config.ProofOfStake.Developer == false {
+		t.Fatalf("failed")
+	}
+	db := rawdb.NewMemoryDatabase()
+	block := genesis.MustCommit(db)
+	statedb, err := state.New(block.Root(), state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	if len(statedb.GetCode(conversion.CONVERSION_CONTRACT_ADDRESS)) == 0 || len(statedb.GetCode(consensuscontext.CONSENSUS_CONTEXT_CONTRACT_ADDRESS)) == 0 {
+		t.Fatalf("failed")
+	}
+	validators, err := ListValidators(statedb)
+	if err != nil || len(validators) != 1 || validators[0] != validator {
+		t.Fatalf("failed %v %v", validators, err)
+	}
+	depositor, err := GetDepositorOfValidator(statedb, validator)
+	if err != nil || depositor != faucet {
+		t.Fatalf("failed %v %v", depositor, err)
+	}
+	balance, err := GetBalanceOfDepositor(statedb, faucet)
+	if err != nil || balance.Cmp(core.DeveloperDeposit) != 0 {
+		t.Fatalf("failed %v %v", balance, err)
+	}
+
+	//Finalize rewards the depositor of the proposer
+	reward := big.NewInt(100)
+	abiData, err := staking.GetStakingContractV2_ABI()
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	data, err := encodeCall(&abiData, staking.GetContract_Method_AddDepositorReward(), faucet, reward)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	_, err = execute(tcc, data, ZERO_ADDRESS, statedb, tcc.GetHeader(block.Hash(), 0), new(big.Int))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	rewards, err := GetDepositorRewards(statedb, faucet)
+	if err != nil || rewards.Cmp(reward) != 0 {
+		t.Fatalf("failed %v %v", rewards, err)
+	}
+}
+
+func TestDeveloperTransactions(t *testing.T) {
+	c := &ProofOfStake{config: &params.ProofOfStakeConfig{Developer: true}}
+	if _, err := c.developerTransactions(nil); err != errNoDeveloperTransactions {
+		t.Fatalf("failed %v", err)
+	}
+
+	c.config.Period = 5
+	txnMap, err := c.developerTransactions(nil)
+	if err != nil || len(txnMap) != 0 {
+		t.Fatalf("failed %v", err)
+	}
+}
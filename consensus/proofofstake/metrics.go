@@ -0,0 +1,106 @@
+package proofofstake
+
+import (
+	"github.com/QuantumCoinProject/qc/eth/protocols/eth"
+	"github.com/QuantumCoinProject/qc/metrics"
+	"time"
+)
+
+// Metrics of the consensus rounds, exported through the metrics endpoints like the other metrics of the node.
+
+const (
+	// packetMeterName is the prefix of the per packet type metrics, followed by the direction and the packet type.
+	packetMeterName = "proofofstake/packets"
+)
+
+var (
+	blockNumberGauge = metrics.NewRegisteredGauge("proofofstake/block", nil)
+	roundGauge       = metrics.NewRegisteredGauge("proofofstake/round", nil)
+	roundCounter     = metrics.NewRegisteredCounter("proofofstake/rounds", nil)
+
+	proposalTimer    = metrics.NewRegisteredTimer("proofofstake/state/proposal", nil)
+	ackProposalTimer = metrics.NewRegisteredTimer("proofofstake/state/ackproposal", nil)
+	precommitTimer   = metrics.NewRegisteredTimer("proofofstake/state/precommit", nil)
+	commitTimer      = metrics.NewRegisteredTimer("proofofstake/state/commit", nil)
+	totalTimer       = metrics.NewRegisteredTimer("proofofstake/state/total", nil)
+	latencyTimer     = metrics.NewRegisteredTimer("proofofstake/latency/proposaltocommit", nil)
+
+	okBlockCounter   = metrics.NewRegisteredCounter("proofofstake/blocks/ok", nil)
+	nilBlockCounter  = metrics.NewRegisteredCounter("proofofstake/blocks/nil", nil)
+	nilVoteRateGauge = metrics.NewRegisteredGaugeFloat64("proofofstake/blocks/nilrate", nil)
+	blockTxnsHist    = metrics.NewRegisteredHistogram("proofofstake/blocks/transactions", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+	outOfOrderPacketGauge = metrics.NewRegisteredGauge("proofofstake/packets/outoforder", nil)
+
+	peerPacketsInCounter         = metrics.NewRegisteredCounter("proofofstake/peers/packets/in", nil)
+	peerPacketsInRelayCounter    = metrics.NewRegisteredCounter("proofofstake/peers/packets/in/relay", nil)
+	peerPacketsOutCounter        = metrics.NewRegisteredCounter("proofofstake/peers/packets/out", nil)
+	peerPacketsOutRelayCounter   = metrics.NewRegisteredCounter("proofofstake/peers/packets/out/relay", nil)
+	peerLocalPacketsRelayCounter = metrics.NewRegisteredCounter("proofofstake/peers/packets/out/relay/local", nil)
+
+	selfProposerCounter  = metrics.NewRegisteredCounter("proofofstake/self/proposer", nil)
+	selfProposedCounter  = metrics.NewRegisteredCounter("proofofstake/self/proposed", nil)
+	selfAckedCounter     = metrics.NewRegisteredCounter("proofofstake/self/acked", nil)
+	selfPrecommitCounter = metrics.NewRegisteredCounter("proofofstake/self/precommitted", nil)
+	selfCommitCounter    = metrics.NewRegisteredCounter("proofofstake/self/committed", nil)
+)
+
+// getPacketTypeName returns the name of a packet type used in the metric names.
+func getPacketTypeName(packetType ConsensusPacketType) string {
+	switch packetType {
+	case CONSENSUS_PACKET_TYPE_PROPOSE_BLOCK:
+		return "propose"
+	case CONSENSUS_PACKET_TYPE_ACK_BLOCK_PROPOSAL:
+		return "ack"
+	case CONSENSUS_PACKET_TYPE_PRECOMMIT_BLOCK:
+		return "precommit"
+	case CONSENSUS_PACKET_TYPE_COMMIT_BLOCK:
+		return "commit"
+	case CONSENSUS_PACKET_TYPE_CAPABILITY:
+		return "capability"
+	case CONSENSUS_PACKET_TYPE_SYNC:
+		return "sync"
+	default:
+		return "unknown"
+	}
+}
+
+// markPacket counts a packet in the metrics of its type. The direction is one of in, out, rebroadcast and skipped.
+func markPacket(direction string, packet *eth.ConsensusPacket) {
+	if metrics.Enabled == false || packet == nil || len(packet.ConsensusData) == 0 {
+		return
+	}
+	name := packetMeterName + "/" + direction + "/" + getPacketTypeName(getPacketType(packet))
+	metrics.GetOrRegisterCounter(name, nil).Inc(1)
+}
+
+// updateOutOfOrderPacketGauge sets the number of out of order packets waiting to be processed.
+func (cph *ConsensusHandler) updateOutOfOrderPacketGauge() {
+	count := 0
+	for _, packetMap := range cph.outOfOrderPacketsMap {
+		count = count + len(packetMap)
+	}
+	outOfOrderPacketGauge.Update(int64(count))
+}
+
+// updateBlockMetrics updates the metrics of a block that received its commits, with the times of the block in
+// milliseconds since the start of its first round, as kept in the time stats.
+func (cph *ConsensusHandler) updateBlockMetrics(blockStateDetails *BlockStateDetails, blockRoundDetails *BlockRoundDetails) {
+	if blockRoundDetails.blockVoteType == VOTE_TYPE_NIL {
+		nilBlockCounter.Inc(1)
+	} else if blockRoundDetails.blockVoteType == VOTE_TYPE_OK {
+		okBlockCounter.Inc(1)
+		blockTxnsHist.Update(int64(len(blockRoundDetails.proposalTxns)))
+	}
+	totalBlocks := cph.nilVoteBlocks + cph.okVoteBlocks
+	if totalBlocks > 0 {
+		nilVoteRateGauge.Update(float64(cph.nilVoteBlocks) / float64(totalBlocks))
+	}
+
+	proposalTimer.Update(time.Duration(blockStateDetails.proposalTime) * time.Millisecond)
+	ackProposalTimer.Update(time.Duration(blockStateDetails.ackProposalTime-blockStateDetails.proposalTime) * time.Millisecond)
+	precommitTimer.Update(time.Duration(blockStateDetails.precommitTime-blockStateDetails.ackProposalTime) * time.Millisecond)
+	commitTimer.Update(time.Duration(blockStateDetails.commitTime-blockStateDetails.precommitTime) * time.Millisecond)
+	totalTimer.Update(time.Duration(blockStateDetails.commitTime) * time.Millisecond)
+	latencyTimer.Update(time.Duration(blockStateDetails.commitTime-blockStateDetails.proposalTime) * time.Millisecond)
+}
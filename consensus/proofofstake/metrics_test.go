@@ -0,0 +1,35 @@
+package proofofstake
+
+import (
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/eth/protocols/eth"
+	"github.com/QuantumCoinProject/qc/metrics"
+	"testing"
+)
+
+func TestMarkPacket(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	packet := &eth.ConsensusPacket{
+		ParentHash:    common.BytesToHash([]byte{1}),
+		ConsensusData: []byte{ConsensusNetworkProtocolVersion, byte(CONSENSUS_PACKET_TYPE_PRECOMMIT_BLOCK), 0xc0},
+	}
+	//The counters are registered once per process, so only their increase is checked
+	in := metrics.GetOrRegisterCounter(packetMeterName+"/in/precommit", nil)
+	rebroadcast := metrics.GetOrRegisterCounter(packetMeterName+"/rebroadcast/precommit", nil)
+	inCount, rebroadcastCount := in.Count(), rebroadcast.Count()
+
+	markPacket("in", packet)
+	markPacket("in", packet)
+	markPacket("rebroadcast", packet)
+
+	if in.Count()-inCount != 2 || rebroadcast.Count()-rebroadcastCount != 1 {
+		t.Fatalf("failed %d %d", in.Count()-inCount, rebroadcast.Count()-rebroadcastCount)
+	}
+
+	if getPacketTypeName(ConsensusPacketType(4)) != "unknown" {
+		t.Fatalf("failed")
+	}
+}
@@ -33,7 +33,7 @@ func TestPacketHandler_min_basic_time_hash(t *testing.T) {
 			skipList[h.validator] = true
 			continue
 		}
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
@@ -77,7 +77,7 @@ func testPacketHandler_block_proposer_timedout(t *testing.T) {
 		if h.validator.IsEqualTo(proposer) {
 			continue //proposer timeout simulation
 		}
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
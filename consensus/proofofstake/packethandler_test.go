@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/QuantumCoinProject/qc/accounts"
 	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/mclock"
 	"github.com/QuantumCoinProject/qc/crypto"
 	"github.com/QuantumCoinProject/qc/crypto/cryptobase"
 	"github.com/QuantumCoinProject/qc/crypto/signaturealgorithm"
@@ -30,6 +31,7 @@ var packetDropCount int32
 var packetSentCount int32
 var TEST_CONSENSUS_BLOCK_NUMBER = uint64(1)
 var DefaultMaxWaitCount = 30
+var lastMockP2PManager *MockP2PManager
 
 type ValidatorDetailsTest struct {
 	balance *big.Int
@@ -47,6 +49,10 @@ type MockP2PManager struct {
 	blockPacketValidatorMap         map[common.Address]bool
 	blockPacketsBetweenValidatorMap map[common.Hash]bool
 	txnFinalize                     map[common.Hash]bool
+
+	clock            *mclock.Simulated //measures the consensus timeouts of the handlers
+	blockCommitCount int32             //validators running WaitBlockCommit
+	sends            sync.WaitGroup    //sends to the mock network in flight
 }
 
 type MockNetworkDetails struct {
@@ -65,6 +71,27 @@ type MockP2PHandler struct {
 	localPeerId      string
 }
 
+// goSend runs a send of a handler in its own goroutine, like the handlers do, and counts it until it is delivered.
+func (m *MockP2PManager) goSend(send func()) {
+	m.sends.Add(1)
+	go func() {
+		defer m.sends.Done()
+		send()
+	}()
+}
+
+// Settle waits until the validators started with StartBlockCommit handled consensus and their packets were delivered.
+func (m *MockP2PManager) Settle() {
+	m.clock.WaitForTimers(int(atomic.LoadInt32(&m.blockCommitCount)))
+	m.sends.Wait()
+}
+
+// Tick settles the validators, then moves the clock by a second.
+func (m *MockP2PManager) Tick() {
+	m.Settle()
+	m.clock.Run(time.Second)
+}
+
 func (m *MockP2PManager) DoesFinalizedTransactionExistFn(txnHash common.Hash) (bool, error) {
 	m.txnMapMutex.Lock()
 	defer m.txnMapMutex.Unlock()
@@ -400,6 +427,11 @@ func (vm *ValidatorManager) ListValidatorsAsMap(blockHash common.Hash) (map[comm
 }
 
 func Initialize(numKeys int) (vm *ValidatorManager, mockp2pManager *MockP2PManager, validatorMap *map[common.Address]*big.Int, validatorDetailsMap *map[common.Address]*ValidatorDetailsV2) {
+	//The validators of the previous test wait on its clock, which is not moved anymore, once they settle
+	if lastMockP2PManager != nil {
+		lastMockP2PManager.Settle()
+	}
+
 	STARTUP_DELAY_MS = int64(2000)
 	BLOCK_TIMEOUT_MS = int64(6000)
 	ACK_BLOCK_TIMEOUT_MS = 18000 //relative to start of block locally
@@ -434,10 +466,12 @@ func Initialize(numKeys int) (vm *ValidatorManager, mockp2pManager *MockP2PManag
 		blockPacketValidatorMap:         make(map[common.Address]bool),
 		blockPacketsBetweenValidatorMap: make(map[common.Hash]bool),
 		txnFinalize:                     make(map[common.Hash]bool),
+		clock:                           &mclock.Simulated{},
 	}
 
 	for addr, _ := range valMap {
-		consensusHandler := NewConsensusPacketHandler()
+		consensusHandler := NewConsensusPacketHandler(WithClock(mockp2pManager.clock))
+		consensusHandler.goSend = mockp2pManager.goSend
 		consensusHandler.getValidatorsFn = vm.GetValidatorsFn
 		consensusHandler.doesFinalizedTransactionExistFn = mockp2pManager.DoesFinalizedTransactionExistFn
 		consensusHandler.getBlockConsensusContext = mockp2pManager.GetBlockConsensusContext
@@ -461,10 +495,18 @@ func Initialize(numKeys int) (vm *ValidatorManager, mockp2pManager *MockP2PManag
 	}
 
 	validatorMap = &valMap
+	lastMockP2PManager = mockp2pManager
 
 	return
 }
 
+// StartBlockCommit runs WaitBlockCommit for the validator in a goroutine.
+func StartBlockCommit(parentHash common.Hash, mockp2pHandler *MockP2PHandler, t *testing.T) {
+	atomic.AddInt32(&mockp2pHandler.mockP2pManager.blockCommitCount, 1)
+	go WaitBlockCommit(parentHash, mockp2pHandler, t)
+}
+
+// WaitBlockCommit handles consensus for the validator once per second of the clock.
 func WaitBlockCommit(parentHash common.Hash, mockp2pHandler *MockP2PHandler, t *testing.T) {
 	waitLock.Lock()
 
@@ -481,7 +523,7 @@ func WaitBlockCommit(parentHash common.Hash, mockp2pHandler *MockP2PHandler, t *
 		if err != nil {
 			//fmt.Println("HandleTransactions err", err)
 		}
-		time.Sleep(time.Millisecond * 1000)
+		mockp2pHandler.mockP2pManager.clock.Sleep(time.Second)
 	}
 }
 
@@ -600,7 +642,7 @@ func ValidateTest(validatorMap *map[common.Address]*big.Int, valDetailsMap *map[
 			return false
 		} else {
 			PrintState(parentHash, p2p.mockP2pHandlers, startTime)
-			time.Sleep(time.Second * 1)
+			p2p.Tick()
 			i = 0
 			count = count + 1
 		}
@@ -619,7 +661,7 @@ func testPacketHandler_basic(numKeys int, t *testing.T) {
 	startTime := time.Now().UnixNano() / int64(time.Millisecond)
 	for _, handler := range p2p.mockP2pHandlers {
 		h := handler
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 	}
 
 	if ValidateTest(valMap, valDetailsMap, startTime, parentHash, p2p, numKeys, DefaultMaxWaitCount, map[VoteType]bool{VOTE_TYPE_OK: true}, BLOCK_STATE_RECEIVED_COMMITS, t) == false {
@@ -668,7 +710,7 @@ func testPacketHandler_min_basic(t *testing.T) {
 			skipList[h.validator] = true
 			continue
 		}
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
@@ -711,7 +753,7 @@ func testPacketHandler_extended_failure(t *testing.T, numKeys int, minPass int,
 	for _, handler := range p2p.mockP2pHandlers {
 		h := handler
 		if h.validator.IsEqualTo(proposer) == true {
-			go WaitBlockCommit(parentHash, h, t)
+			StartBlockCommit(parentHash, h, t)
 			break
 		}
 	}
@@ -728,7 +770,7 @@ func testPacketHandler_extended_failure(t *testing.T, numKeys int, minPass int,
 			c = c + 1
 			continue
 		}
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
@@ -776,7 +818,7 @@ func testPacketHandler_min_negative(t *testing.T, numKeys int, minPass int, unre
 			skipList[h.validator] = true
 			continue
 		}
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
@@ -831,12 +873,12 @@ func testPacketHandler_no_round2_then_round2(t *testing.T, numKeys int, minPass
 		}
 		h.SetValidatorTransactions(txns)
 
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
 	breakLoop := false
-	checkTime := time.Now()
+	checkTime := p2p.clock.Now()
 
 	fmt.Println("Stage 2")
 	for {
@@ -854,7 +896,7 @@ func testPacketHandler_no_round2_then_round2(t *testing.T, numKeys int, minPass
 					break
 				}
 			}
-			if HasExceededTimeThreshold(checkTime, int64(BLOCK_TIMEOUT_MS*2)) {
+			if HasExceededTimeThreshold(p2p.clock, checkTime, int64(BLOCK_TIMEOUT_MS*2)) {
 				for _, v := range valSkipList {
 					vh := p2p.mockP2pHandlers[v]
 
@@ -864,7 +906,7 @@ func testPacketHandler_no_round2_then_round2(t *testing.T, numKeys int, minPass
 						txns[i] = common.BytesToHash([]byte{byte(rand.Intn(255))})
 					}
 					vh.SetValidatorTransactions(txns)
-					go WaitBlockCommit(parentHash, vh, t)
+					StartBlockCommit(parentHash, vh, t)
 				}
 				breakLoop = true
 				break
@@ -874,7 +916,7 @@ func testPacketHandler_no_round2_then_round2(t *testing.T, numKeys int, minPass
 			break
 		}
 		PrintState(parentHash, p2p.mockP2pHandlers, startTime)
-		time.Sleep(time.Second * 1)
+		p2p.Tick()
 	}
 
 	fmt.Println("ValidateTest start")
@@ -952,7 +994,7 @@ func testPacketHandler_bifurcated(t *testing.T) {
 		}
 		h.SetValidatorTransactions(txns)
 		prev = h
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
@@ -981,7 +1023,7 @@ func testPacketHandler_bifurcated(t *testing.T) {
 			t.Fatalf("failed")
 		}
 		fmt.Println("loopCount", loopCount)
-		time.Sleep(time.Second * 1)
+		p2p.Tick()
 	}
 }
 
@@ -1012,7 +1054,7 @@ func testPacketHandler_round2(t *testing.T, numKeys int, minPass int) {
 		}
 		h.SetValidatorTransactions(txns)
 		prev = h
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
@@ -1036,7 +1078,7 @@ func testPacketHandler_round2(t *testing.T, numKeys int, minPass int) {
 		if loopCount >= 60 {
 			t.Fatalf("failed")
 		}
-		time.Sleep(time.Second * 1)
+		p2p.Tick()
 	}
 
 	fmt.Println("===============Round 2 start")
@@ -1107,7 +1149,7 @@ func testPacketHandler_basic_txns(t *testing.T) {
 	for _, handler := range p2p.mockP2pHandlers {
 		h := handler
 		h.SetValidatorTransactions(txns)
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 	}
 
 	if ValidateTest(valMap, valDetailsMap, startTime, parentHash, p2p, numKeys, DefaultMaxWaitCount, map[VoteType]bool{VOTE_TYPE_OK: true}, BLOCK_STATE_RECEIVED_COMMITS, t) == false {
@@ -1170,7 +1212,7 @@ func testPacketHandler_basic_txns_finalize_fail(t *testing.T) {
 	for _, handler := range p2p.mockP2pHandlers {
 		h := handler
 		h.SetValidatorTransactions(txns)
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 	}
 
 	if ValidateTest(valMap, valDetailsMap, startTime, parentHash, p2p, numKeys-1, DefaultMaxWaitCount*2, map[VoteType]bool{VOTE_TYPE_NIL: true}, BLOCK_STATE_RECEIVED_COMMITS, t) == false {
@@ -1206,7 +1248,7 @@ func testPacketHandler_split_txns(t *testing.T) {
 
 	for _, handler := range p2p.mockP2pHandlers {
 		h := handler
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 
 	}
 
@@ -1270,7 +1312,7 @@ func testPacketHandler_split_increasing_txns(t *testing.T) {
 		}
 		h := handler
 		handler.SetValidatorTransactions(txns)
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 	}
 
 	if ValidateTest(valMap, valDetailsMap, startTime, parentHash, p2p, 7, DefaultMaxWaitCount, map[VoteType]bool{VOTE_TYPE_OK: true}, BLOCK_STATE_RECEIVED_COMMITS, t) == false {
@@ -1336,7 +1378,7 @@ func testPacketHandler_split_increasing_txns_some_unresponsive(t *testing.T, num
 				j = j + 1
 			}
 			h.SetValidatorTransactions(txns)
-			go WaitBlockCommit(parentHash, h, t)
+			StartBlockCommit(parentHash, h, t)
 			break
 		}
 	}
@@ -1357,7 +1399,7 @@ func testPacketHandler_split_increasing_txns_some_unresponsive(t *testing.T, num
 			j = j + 1
 		}
 		h.SetValidatorTransactions(txns)
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
@@ -1423,7 +1465,7 @@ func testPacketHandler_packet_loss_txns(t *testing.T) {
 		h := handler
 		handler.SetValidatorTransactions(txns)
 		h.networkDetails.packetLoss = 50
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 	}
 
 	if ValidateTest(valMap, valDetailsMap, startTime, parentHash, p2p, minPass, DefaultMaxWaitCount*10, map[VoteType]bool{VOTE_TYPE_OK: true}, BLOCK_STATE_RECEIVED_COMMITS, t) == false {
@@ -1491,7 +1533,7 @@ func testPacketHandler_packet_loss_txns_some_unresponsive(t *testing.T, numVal i
 			}
 			h.SetValidatorTransactions(txns)
 			h.networkDetails.packetLoss = 50
-			go WaitBlockCommit(parentHash, h, t)
+			StartBlockCommit(parentHash, h, t)
 			break
 		}
 	}
@@ -1512,7 +1554,7 @@ func testPacketHandler_packet_loss_txns_some_unresponsive(t *testing.T, numVal i
 			j = j + 1
 		}
 		handler.SetValidatorTransactions(txns)
-		go WaitBlockCommit(parentHash, h, t)
+		StartBlockCommit(parentHash, h, t)
 		c = c + 1
 	}
 
@@ -1899,13 +1941,13 @@ func TestFilterValidators_positive_low_balance_negative(t *testing.T) {
 
 func TestBlockProposalTime(t *testing.T) {
 	for i := uint64(0); i < 1000000000; i += 256 {
-		if GetProposalTime(i) == 0 {
+		if GetProposalTime(i, BLOCK_TIME_ORIG_START_BLOCK) == 0 {
 			fmt.Println(i)
 			t.Fatalf("failed 1")
 		}
 	}
 
-	t1 := GetProposalTime(256)
+	t1 := GetProposalTime(256, BLOCK_TIME_ORIG_START_BLOCK)
 	tm := time.Unix(int64(t1), 0)
 	fmt.Println(tm)
 
@@ -1918,51 +1960,59 @@ func TestBlockProposalTime(t *testing.T) {
 		t.Fatalf("failed 3")
 	}
 
-	if GetProposalTime(1) == 0 {
+	if GetProposalTime(1, BLOCK_TIME_ORIG_START_BLOCK) == 0 {
 		t.Fatalf("failed 4")
 	}
 }
 
 func TestValidateBlockProposalTime(t *testing.T) {
-	if ValidateBlockProposalTime(1, GetProposalTime(1)) == false {
+	if ValidateBlockProposalTime(1, GetProposalTime(1, BLOCK_TIME_ORIG_START_BLOCK), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 1")
 	}
 
-	if ValidateBlockProposalTime(256, GetProposalTime(256)) == false {
+	if ValidateBlockProposalTime(256, GetProposalTime(256, BLOCK_TIME_ORIG_START_BLOCK), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 2")
 	}
 
-	if ValidateBlockProposalTime(2, GetProposalTime(2)) == false {
+	if ValidateBlockProposalTime(2, GetProposalTime(2, BLOCK_TIME_ORIG_START_BLOCK), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 3")
 	}
 
-	if ValidateBlockProposalTime(1, GetProposalTime(1)+1) == true {
+	if ValidateBlockProposalTime(1, GetProposalTime(1, BLOCK_TIME_ORIG_START_BLOCK)+1, BLOCK_TIME_ORIG_START_BLOCK) == true {
 		t.Fatalf("failed 4")
 	}
 
-	if ValidateBlockProposalTime(BLOCK_TIME_ORIG_START_BLOCK, GetProposalTime(BLOCK_TIME_ORIG_START_BLOCK)) == false {
+	if ValidateBlockProposalTime(BLOCK_TIME_ORIG_START_BLOCK, GetProposalTime(BLOCK_TIME_ORIG_START_BLOCK, BLOCK_TIME_ORIG_START_BLOCK), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 5")
 	}
+
+	if ValidateBlockProposalTime(2, GetProposalTime(2, 2), 2) == false {
+		t.Fatalf("failed 6")
+	}
+
+	if ValidateBlockProposalTime(2, GetProposalTime(2, 2), BLOCK_TIME_ORIG_START_BLOCK) == true {
+		t.Fatalf("failed 7")
+	}
 }
 
 func TestValidateBlockProposalTimeConsensus(t *testing.T) {
-	if ValidateBlockProposalTimeConsensus(1, GetProposalTime(1)) == false {
+	if ValidateBlockProposalTimeConsensus(1, GetProposalTime(1, BLOCK_TIME_ORIG_START_BLOCK), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 1")
 	}
 
-	if ValidateBlockProposalTimeConsensus(256, GetProposalTime(256)) == false {
+	if ValidateBlockProposalTimeConsensus(256, GetProposalTime(256, BLOCK_TIME_ORIG_START_BLOCK), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 2")
 	}
 
-	if ValidateBlockProposalTimeConsensus(2, GetProposalTime(2)) == false {
+	if ValidateBlockProposalTimeConsensus(2, GetProposalTime(2, BLOCK_TIME_ORIG_START_BLOCK), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 3")
 	}
 
-	if ValidateBlockProposalTimeConsensus(1, GetProposalTime(2)) == true {
+	if ValidateBlockProposalTimeConsensus(1, GetProposalTime(2, BLOCK_TIME_ORIG_START_BLOCK), BLOCK_TIME_ORIG_START_BLOCK) == true {
 		t.Fatalf("failed 4")
 	}
 
-	if ValidateBlockProposalTimeConsensus(1, GetProposalTime(1)+1) == true {
+	if ValidateBlockProposalTimeConsensus(1, GetProposalTime(1, BLOCK_TIME_ORIG_START_BLOCK)+1, BLOCK_TIME_ORIG_START_BLOCK) == true {
 		t.Fatalf("failed 5")
 	}
 
@@ -1970,7 +2020,7 @@ func TestValidateBlockProposalTimeConsensus(t *testing.T) {
 	if tm%60 != 0 {
 		tm = tm - (tm % 60)
 	}
-	if ValidateBlockProposalTimeConsensus(1, uint64(tm)) == false {
+	if ValidateBlockProposalTimeConsensus(1, uint64(tm), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 6")
 	}
 
@@ -1978,7 +2028,7 @@ func TestValidateBlockProposalTimeConsensus(t *testing.T) {
 	if tm%60 != 0 {
 		tm = tm - (tm % 60)
 	}
-	if ValidateBlockProposalTimeConsensus(1, uint64(tm)) == false {
+	if ValidateBlockProposalTimeConsensus(1, uint64(tm), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 7")
 	}
 
@@ -1986,7 +2036,7 @@ func TestValidateBlockProposalTimeConsensus(t *testing.T) {
 	if tm%60 != 0 {
 		tm = tm - (tm % 60)
 	}
-	if ValidateBlockProposalTimeConsensus(1, uint64(tm)) == false {
+	if ValidateBlockProposalTimeConsensus(1, uint64(tm), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 8")
 	}
 
@@ -1994,7 +2044,7 @@ func TestValidateBlockProposalTimeConsensus(t *testing.T) {
 	if tm%60 != 0 {
 		tm = tm - (tm % 60)
 	}
-	if ValidateBlockProposalTimeConsensus(1, uint64(tm)) == true {
+	if ValidateBlockProposalTimeConsensus(1, uint64(tm), BLOCK_TIME_ORIG_START_BLOCK) == true {
 		t.Fatalf("failed 9")
 	}
 
@@ -2002,7 +2052,7 @@ func TestValidateBlockProposalTimeConsensus(t *testing.T) {
 	if tm%60 != 0 {
 		tm = tm - (tm % 60)
 	}
-	if ValidateBlockProposalTimeConsensus(1, uint64(tm)) == false {
+	if ValidateBlockProposalTimeConsensus(1, uint64(tm), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 10")
 	}
 
@@ -2010,7 +2060,7 @@ func TestValidateBlockProposalTimeConsensus(t *testing.T) {
 	if tm%60 != 0 {
 		tm = tm - (tm % 60)
 	}
-	if ValidateBlockProposalTimeConsensus(1, uint64(tm)) == false {
+	if ValidateBlockProposalTimeConsensus(1, uint64(tm), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 11")
 	}
 
@@ -2018,7 +2068,7 @@ func TestValidateBlockProposalTimeConsensus(t *testing.T) {
 	if tm%60 != 0 {
 		tm = tm - (tm % 60)
 	}
-	if ValidateBlockProposalTimeConsensus(1, uint64(tm)) == false {
+	if ValidateBlockProposalTimeConsensus(1, uint64(tm), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 12")
 	}
 
@@ -2026,7 +2076,7 @@ func TestValidateBlockProposalTimeConsensus(t *testing.T) {
 	if tm%60 != 0 {
 		tm = tm - (tm % 60)
 	}
-	if ValidateBlockProposalTimeConsensus(1, uint64(tm)) == true {
+	if ValidateBlockProposalTimeConsensus(1, uint64(tm), BLOCK_TIME_ORIG_START_BLOCK) == true {
 		t.Fatalf("failed 13")
 	}
 
@@ -2034,7 +2084,7 @@ func TestValidateBlockProposalTimeConsensus(t *testing.T) {
 	if tm%60 != 0 {
 		tm = tm - (tm % 60)
 	}
-	if ValidateBlockProposalTimeConsensus(BLOCK_TIME_ORIG_START_BLOCK, uint64(tm)) == false {
+	if ValidateBlockProposalTimeConsensus(BLOCK_TIME_ORIG_START_BLOCK, uint64(tm), BLOCK_TIME_ORIG_START_BLOCK) == false {
 		t.Fatalf("failed 14")
 	}
 }
@@ -0,0 +1,333 @@
+package proofofstake
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/consensus"
+	"github.com/QuantumCoinProject/qc/core"
+	"github.com/QuantumCoinProject/qc/core/rawdb"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/crypto"
+	"github.com/QuantumCoinProject/qc/crypto/cryptobase"
+	"github.com/QuantumCoinProject/qc/crypto/signaturealgorithm"
+	"github.com/QuantumCoinProject/qc/eth/protocols/eth"
+	"github.com/QuantumCoinProject/qc/ethdb"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/rlp"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// The performance indexer aggregates, for each section of PERFORMANCE_SECTION_SIZE blocks, the proposals, missed
+// proposals, slashings and votes of every validator, from the consensus data of the blocks. Queries read the
+// aggregates of the sections in the range and only go through the headers of the blocks at its edges.
+
+var PERFORMANCE_SECTION_SIZE = uint64(256)
+var PERFORMANCE_CONFIRMS = uint64(16)
+var PERFORMANCE_MAX_SCAN_BLOCKS = uint64(4096) //max blocks read from the headers for a query
+var performanceThrottling = 100 * time.Millisecond
+
+var InvalidBlockRangeErr = errors.New("invalid block range")
+var BlockRangeNotIndexedErr = errors.New("block range is not indexed yet")
+
+type ValidatorPerformance struct {
+	Validator       common.Address `json:"validator"     gencodec:"required"`
+	Proposals       hexutil.Uint64 `json:"proposals"     gencodec:"required"`       //ok blocks proposed
+	MissedProposals hexutil.Uint64 `json:"missedProposals"     gencodec:"required"` //nil blocks in which the validator was a nil-voted proposer
+	Slashings       hexutil.Uint64 `json:"slashings"     gencodec:"required"`
+	ProposalAcks    hexutil.Uint64 `json:"proposalAcks"     gencodec:"required"`
+	Precommits      hexutil.Uint64 `json:"precommits"     gencodec:"required"`
+	Commits         hexutil.Uint64 `json:"commits"     gencodec:"required"`
+	BlocksSigned    hexutil.Uint64 `json:"blocksSigned"     gencodec:"required"` //blocks with at least one packet of the validator
+}
+
+type ValidatorPerformanceDetails struct {
+	*ValidatorPerformance
+	FromBlock     hexutil.Uint64 `json:"fromBlock"     gencodec:"required"`
+	ToBlock       hexutil.Uint64 `json:"toBlock"     gencodec:"required"`
+	Blocks        hexutil.Uint64 `json:"blocks"     gencodec:"required"`
+	NilBlocks     hexutil.Uint64 `json:"nilBlocks"     gencodec:"required"`
+	SlashedAmount string         `json:"slashedAmount"     gencodec:"required"`
+}
+
+// sectionPerformance is the performance of the validators in a section of blocks, as stored by the indexer.
+type sectionPerformance struct {
+	Blocks     uint64
+	NilBlocks  uint64
+	Validators []*ValidatorPerformance
+}
+
+type performanceMap struct {
+	blocks     uint64
+	nilBlocks  uint64
+	slashStart uint64
+	validators map[common.Address]*ValidatorPerformance
+}
+
+func newPerformanceMap(slashStart uint64) *performanceMap {
+	return &performanceMap{
+		slashStart: slashStart,
+		validators: make(map[common.Address]*ValidatorPerformance),
+	}
+}
+
+func (p *performanceMap) get(validator common.Address) *ValidatorPerformance {
+	performance, ok := p.validators[validator]
+	if ok == false {
+		performance = &ValidatorPerformance{Validator: validator}
+		p.validators[validator] = performance
+	}
+	return performance
+}
+
+func (p *performanceMap) add(section *sectionPerformance) {
+	p.blocks = p.blocks + section.Blocks
+	p.nilBlocks = p.nilBlocks + section.NilBlocks
+	for _, performance := range section.Validators {
+		total := p.get(performance.Validator)
+		total.Proposals = total.Proposals + performance.Proposals
+		total.MissedProposals = total.MissedProposals + performance.MissedProposals
+		total.Slashings = total.Slashings + performance.Slashings
+		total.ProposalAcks = total.ProposalAcks + performance.ProposalAcks
+		total.Precommits = total.Precommits + performance.Precommits
+		total.Commits = total.Commits + performance.Commits
+		total.BlocksSigned = total.BlocksSigned + performance.BlocksSigned
+	}
+}
+
+func (p *performanceMap) toSection() *sectionPerformance {
+	section := &sectionPerformance{
+		Blocks:     p.blocks,
+		NilBlocks:  p.nilBlocks,
+		Validators: make([]*ValidatorPerformance, 0, len(p.validators)),
+	}
+	for _, performance := range p.validators {
+		section.Validators = append(section.Validators, performance)
+	}
+	sort.Slice(section.Validators, func(i, j int) bool {
+		return bytes.Compare(section.Validators[i].Validator.Bytes(), section.Validators[j].Validator.Bytes()) < 0
+	})
+	return section
+}
+
+// getPacketSigner returns the type and signer of a consensus packet of a block. The signature is not verified, since
+// the packets of a block in the chain were verified when it was imported.
+func getPacketSigner(packet *eth.ConsensusPacket) (ConsensusPacketType, common.Address, error) {
+	if len(packet.ConsensusData) < 2 || len(packet.Signature) == 0 {
+		return 0, ZERO_ADDRESS, InvalidPacketErr
+	}
+	packetType := getPacketType(packet)
+
+	dataToVerify := append(packet.ParentHash.Bytes(), packet.ConsensusData...)
+	digestHash := crypto.Keccak256(dataToVerify)
+
+	var pubKey *signaturealgorithm.PublicKey
+	var err error
+	if packetType == CONSENSUS_PACKET_TYPE_PROPOSE_BLOCK && len(packet.Signature) != cryptobase.SigAlg.SignatureWithPublicKeyLength() {
+		pubKey, err = cryptobase.SigAlg.PublicKeyFromSignatureWithContext(digestHash, packet.Signature, FULL_SIGN_CONTEXT)
+	} else {
+		pubKey, err = cryptobase.SigAlg.PublicKeyFromSignature(digestHash, packet.Signature)
+	}
+	if err != nil {
+		return 0, ZERO_ADDRESS, err
+	}
+
+	validator, err := cryptobase.SigAlg.PublicKeyToAddress(pubKey)
+	if err != nil {
+		return 0, ZERO_ADDRESS, err
+	}
+	return packetType, validator, nil
+}
+
+// addBlockPerformance adds the performance of the validators in a block.
+func (p *performanceMap) addBlockPerformance(header *types.Header) error {
+	if len(header.ConsensusData) == 0 { //genesis block
+		return nil
+	}
+
+	blockConsensusData := &BlockConsensusData{}
+	err := rlp.DecodeBytes(header.ConsensusData, blockConsensusData)
+	if err != nil {
+		return err
+	}
+
+	p.blocks = p.blocks + 1
+	if blockConsensusData.VoteType == VOTE_TYPE_OK {
+		p.get(blockConsensusData.BlockProposer).Proposals++
+	} else {
+		p.nilBlocks = p.nilBlocks + 1
+		slashed := blockConsensusData.Round == 1 && header.Number.Uint64() >= p.slashStart
+		for _, proposer := range blockConsensusData.SlashedBlockProposers {
+			performance := p.get(proposer)
+			performance.MissedProposals++
+			if slashed {
+				performance.Slashings++
+			}
+		}
+	}
+
+	if len(header.UnhashedConsensusData) == 0 {
+		return nil
+	}
+	blockAdditionalConsensusData := &BlockAdditionalConsensusData{}
+	err = rlp.DecodeBytes(header.UnhashedConsensusData, blockAdditionalConsensusData)
+	if err != nil {
+		return err
+	}
+
+	signers := make(map[common.Address]bool)
+	for i := 0; i < len(blockAdditionalConsensusData.ConsensusPackets); i++ {
+		packetType, validator, err := getPacketSigner(&blockAdditionalConsensusData.ConsensusPackets[i])
+		if err != nil {
+			log.Trace("addBlockPerformance getPacketSigner", "err", err, "number", header.Number)
+			continue
+		}
+		performance := p.get(validator)
+		if packetType == CONSENSUS_PACKET_TYPE_ACK_BLOCK_PROPOSAL {
+			performance.ProposalAcks++
+		} else if packetType == CONSENSUS_PACKET_TYPE_PRECOMMIT_BLOCK {
+			performance.Precommits++
+		} else if packetType == CONSENSUS_PACKET_TYPE_COMMIT_BLOCK {
+			performance.Commits++
+		}
+		if signers[validator] == false {
+			signers[validator] = true
+			performance.BlocksSigned++
+		}
+	}
+
+	return nil
+}
+
+// PerformanceIndexer implements core.ChainIndexerBackend, aggregating the performance of the validators in each
+// section of the canonical chain.
+type PerformanceIndexer struct {
+	db          ethdb.Database
+	slashStart  uint64
+	section     uint64
+	head        common.Hash
+	performance *performanceMap
+}
+
+// NewPerformanceIndexer returns a chain indexer that aggregates the performance of the validators. Block proposers
+// are counted as slashed from the slash start block of the chain.
+func NewPerformanceIndexer(db ethdb.Database, slashStart, size, confirms uint64) *core.ChainIndexer {
+	backend := &PerformanceIndexer{
+		db:         db,
+		slashStart: slashStart,
+	}
+	table := rawdb.NewTable(db, string(rawdb.ValidatorPerformanceIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, size, confirms, performanceThrottling, "validatorperformance")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new section.
+func (b *PerformanceIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	b.section, b.head, b.performance = section, common.Hash{}, newPerformanceMap(b.slashStart)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the performance of the validators in a block to the section.
+func (b *PerformanceIndexer) Process(ctx context.Context, header *types.Header) error {
+	err := b.performance.addBlockPerformance(header)
+	if err != nil {
+		log.Warn("Failed to index validator performance", "number", header.Number, "err", err)
+	}
+	b.head = header.Hash()
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the performance of the section into the database.
+func (b *PerformanceIndexer) Commit() error {
+	data, err := rlp.EncodeToBytes(b.performance.toSection())
+	if err != nil {
+		return err
+	}
+	rawdb.WriteValidatorPerformance(b.db, b.section, b.head, data)
+	return nil
+}
+
+// Prune returns an empty error since we don't support pruning here.
+func (b *PerformanceIndexer) Prune(threshold uint64) error {
+	return nil
+}
+
+// readSectionPerformance returns the stored performance of a section of the canonical chain, or nil if the section
+// is not indexed.
+func readSectionPerformance(db ethdb.Database, section uint64) *sectionPerformance {
+	head := rawdb.ReadCanonicalHash(db, (section+1)*PERFORMANCE_SECTION_SIZE-1)
+	if head == (common.Hash{}) {
+		return nil
+	}
+	data, err := rawdb.ReadValidatorPerformance(db, section, head)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	performance := &sectionPerformance{}
+	err = rlp.DecodeBytes(data, performance)
+	if err != nil {
+		log.Error("readSectionPerformance", "section", section, "err", err)
+		return nil
+	}
+	return performance
+}
+
+// GetValidatorPerformance returns the performance of a validator between two blocks, both included.
+func (c *ProofOfStake) GetValidatorPerformance(chain consensus.ChainHeaderReader, validator common.Address, fromBlock uint64, toBlock uint64) (*ValidatorPerformanceDetails, error) {
+	var sections uint64
+	if c.performanceIndexer != nil {
+		sections, _, _ = c.performanceIndexer.Sections()
+	}
+	return getValidatorPerformance(c.db, chain, sections, c.forks.slashStart, validator, fromBlock, toBlock)
+}
+
+// getValidatorPerformance adds up the performance of a validator from the indexed sections that are fully within the
+// range, and from the headers of the other blocks.
+func getValidatorPerformance(db ethdb.Database, chain consensus.ChainHeaderReader, sections uint64, slashStart uint64, validator common.Address, fromBlock uint64, toBlock uint64) (*ValidatorPerformanceDetails, error) {
+	if fromBlock > toBlock || toBlock > chain.CurrentHeader().Number.Uint64() {
+		return nil, InvalidBlockRangeErr
+	}
+
+	total := newPerformanceMap(slashStart)
+	scanned := uint64(0)
+	for number := fromBlock; number <= toBlock; {
+		section := number / PERFORMANCE_SECTION_SIZE
+		sectionEnd := (section+1)*PERFORMANCE_SECTION_SIZE - 1
+		if number%PERFORMANCE_SECTION_SIZE == 0 && sectionEnd <= toBlock && section < sections {
+			performance := readSectionPerformance(db, section)
+			if performance != nil {
+				total.add(performance)
+				number = sectionEnd + 1
+				continue
+			}
+		}
+
+		scanned++
+		if scanned > PERFORMANCE_MAX_SCAN_BLOCKS {
+			return nil, BlockRangeNotIndexedErr
+		}
+		header := chain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		err := total.addBlockPerformance(header)
+		if err != nil {
+			return nil, err
+		}
+		number++
+	}
+
+	details := &ValidatorPerformanceDetails{
+		ValidatorPerformance: total.get(validator),
+		FromBlock:            hexutil.Uint64(fromBlock),
+		ToBlock:              hexutil.Uint64(toBlock),
+		Blocks:               hexutil.Uint64(total.blocks),
+		NilBlocks:            hexutil.Uint64(total.nilBlocks),
+	}
+	details.SlashedAmount = hexutil.EncodeBig(new(big.Int).Mul(slashAmount, new(big.Int).SetUint64(uint64(details.Slashings))))
+	return details, nil
+}
@@ -0,0 +1,93 @@
+package proofofstake
+
+import (
+	"context"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/consensus"
+	"github.com/QuantumCoinProject/qc/core/rawdb"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/rlp"
+	"math/big"
+	"testing"
+)
+
+type testHeaderChain struct {
+	consensus.ChainHeaderReader
+	headers []*types.Header
+}
+
+func (c *testHeaderChain) CurrentHeader() *types.Header {
+	return c.headers[len(c.headers)-1]
+}
+
+func (c *testHeaderChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number >= uint64(len(c.headers)) {
+		return nil
+	}
+	return c.headers[number]
+}
+
+func TestValidatorPerformance(t *testing.T) {
+	sectionSize, maxScanBlocks := PERFORMANCE_SECTION_SIZE, PERFORMANCE_MAX_SCAN_BLOCKS
+	defer func() {
+		PERFORMANCE_SECTION_SIZE, PERFORMANCE_MAX_SCAN_BLOCKS = sectionSize, maxScanBlocks
+	}()
+	PERFORMANCE_SECTION_SIZE = 4
+
+	val1 := common.BytesToAddress([]byte{1})
+	val2 := common.BytesToAddress([]byte{2})
+
+	//blocks with an even number are proposed by val1, the other ones are nil blocks of val2
+	db := rawdb.NewMemoryDatabase()
+	chain := &testHeaderChain{headers: []*types.Header{{Number: big.NewInt(0)}}}
+	for i := int64(1); i < 10; i++ {
+		blockConsensusData := &BlockConsensusData{Round: 1, SlashedBlockProposers: make([]common.Address, 0)}
+		if i%2 == 0 {
+			blockConsensusData.VoteType = VOTE_TYPE_OK
+			blockConsensusData.BlockProposer = val1
+		} else {
+			blockConsensusData.VoteType = VOTE_TYPE_NIL
+			blockConsensusData.SlashedBlockProposers = append(blockConsensusData.SlashedBlockProposers, val2)
+		}
+		data, err := rlp.EncodeToBytes(blockConsensusData)
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		chain.headers = append(chain.headers, &types.Header{Number: big.NewInt(i), ConsensusData: data})
+	}
+	for _, header := range chain.headers {
+		rawdb.WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+	}
+
+	indexer := &PerformanceIndexer{db: db}
+	for section := uint64(0); section < 2; section++ {
+		indexer.Reset(context.Background(), section, common.Hash{})
+		for number := section * 4; number < (section+1)*4; number++ {
+			indexer.Process(context.Background(), chain.headers[number])
+		}
+		if err := indexer.Commit(); err != nil {
+			t.Fatalf("failed %v", err)
+		}
+	}
+
+	//blocks 4 to 7 are read from the index
+	PERFORMANCE_MAX_SCAN_BLOCKS = 5
+	details, err := getValidatorPerformance(db, chain, 2, 0, val2, 1, 9)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if details.Blocks != 9 || details.NilBlocks != 5 || details.MissedProposals != 5 || details.Slashings != 5 || details.Proposals != 0 {
+		t.Fatalf("failed %v", details)
+	}
+	details, err = getValidatorPerformance(db, chain, 2, 0, val1, 2, 8)
+	if err != nil || details.Blocks != 7 || details.Proposals != 4 || details.MissedProposals != 0 {
+		t.Fatalf("failed %v %v", details, err)
+	}
+
+	if _, err = getValidatorPerformance(db, chain, 0, 0, val1, 1, 9); err != BlockRangeNotIndexedErr {
+		t.Fatalf("failed %v", err)
+	}
+	if _, err = getValidatorPerformance(db, chain, 2, 0, val1, 5, 10); err != InvalidBlockRangeErr {
+		t.Fatalf("failed %v", err)
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/QuantumCoinProject/qc/trie"
 	"io"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
@@ -101,6 +102,53 @@ var (
 	SixtyVoteStartBlock = uint64(1386825)
 )
 
+// forkBlocks are the blocks at which the consensus rules of a chain change. They are the mainnet blocks, unless the
+// proof-of-stake config of the chain sets them.
+type forkBlocks struct {
+	rewardStart           *big.Int
+	stakingContractV2     uint64
+	consensusContextStart uint64
+	validatorNilBlock     uint64
+	blockTimeOrigStart    uint64
+	txnFeeCutoff          uint64
+	slashStart            uint64
+}
+
+func newForkBlocks(chainConfig *params.ChainConfig) *forkBlocks {
+	forks := &forkBlocks{
+		rewardStart:           rewardStartBlock,
+		stakingContractV2:     STAKING_CONTRACT_V2_CUTOFF_BLOCK,
+		consensusContextStart: CONSENSUS_CONTEXT_START_BLOCK,
+		validatorNilBlock:     VALIDATOR_NIL_BLOCK_START_BLOCK,
+		blockTimeOrigStart:    BLOCK_TIME_ORIG_START_BLOCK,
+		txnFeeCutoff:          core.TxnFeeCutoffBlock(chainConfig),
+		slashStart:            slashStartBlockNumber,
+	}
+	config := chainConfig.ProofOfStake
+	if config == nil {
+		return forks
+	}
+	if config.RewardStartBlock != nil {
+		forks.rewardStart = new(big.Int).Set(config.RewardStartBlock)
+	}
+	if config.StakingV2Block != nil {
+		forks.stakingContractV2 = config.StakingV2Block.Uint64()
+	}
+	if config.ConsensusContextBlock != nil {
+		forks.consensusContextStart = config.ConsensusContextBlock.Uint64()
+	}
+	if config.ValidatorNilBlock != nil {
+		forks.validatorNilBlock = config.ValidatorNilBlock.Uint64()
+	}
+	if config.BlockTimeOrigBlock != nil {
+		forks.blockTimeOrigStart = config.BlockTimeOrigBlock.Uint64()
+	}
+	if config.SlashStartBlock != nil {
+		forks.slashStart = config.SlashStartBlock.Uint64()
+	}
+	return forks
+}
+
 // Various error messages to mark blocks invalid. These should be private to
 // prevent engine specific errors from being referenced in the remainder of the
 // codebase, inherently breaking if the engine is swapped out. Please put common
@@ -210,6 +258,13 @@ type ProofOfStake struct {
 
 	account    *accounts.Account
 	blockchain *core.BlockChain
+
+	voteJournalDir string // Directory of the validator vote journals, empty to disable slashing protection
+	voteJournal    *VoteJournal
+
+	performanceIndexer *core.ChainIndexer // Aggregates the performance of the validators
+
+	forks *forkBlocks // Blocks at which the consensus rules of the chain change
 }
 
 // New creates a ProofOfStake proof-of-authority consensus engine with the initial
@@ -239,12 +294,18 @@ func New(chainConfig *params.ChainConfig, db ethdb.Database,
 		proposals:        make(map[common.Address]bool),
 		signer:           types.NewLondonSigner(chainConfig.ChainID),
 		consensusHandler: packetHandler,
+		forks:            newForkBlocks(chainConfig),
 	}
 
 	proofofstake.consensusHandler.getValidatorsFn = proofofstake.GetValidators
 	proofofstake.consensusHandler.listValidatorsFn = proofofstake.ListValidatorsAsMap
 	proofofstake.consensusHandler.doesFinalizedTransactionExistFn = proofofstake.DoesFinalizedTransactionExist
 	proofofstake.consensusHandler.getBlockConsensusContext = proofofstake.GetConsensusContext
+	proofofstake.consensusHandler.forks = proofofstake.forks
+
+	if conf.ProofOfStake.Developer {
+		log.Warn("Developer chain, blocks are sealed by the local node without consensus rounds")
+	}
 
 	return proofofstake
 }
@@ -258,8 +319,20 @@ func (c *ProofOfStake) SetP2PHandler(handler *handler.P2PHandler, localPeerId st
 	c.consensusHandler.SetP2PHandler(handler, localPeerId)
 }
 
+// SetBlockchain sets the chain of the node and starts indexing the performance of the validators in it.
 func (c *ProofOfStake) SetBlockchain(blockchain *core.BlockChain) {
 	c.blockchain = blockchain
+
+	c.performanceIndexer = NewPerformanceIndexer(c.db, c.forks.slashStart, PERFORMANCE_SECTION_SIZE, PERFORMANCE_CONFIRMS)
+	c.performanceIndexer.Start(blockchain)
+}
+
+// SetVoteJournalDir sets the directory in which the vote journal of the validator key is kept once Authorize is called.
+func (c *ProofOfStake) SetVoteJournalDir(dir string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.voteJournalDir = dir
 }
 
 // Author implements consensus.Engine, returning the Ethereum address recovered
@@ -280,7 +353,7 @@ func (c *ProofOfStake) VerifyHeader(chain consensus.ChainHeaderReader, header *t
 	return c.verifyHeader(chain, header, nil)
 }
 
-func flattenTxnMap(txnMap map[common.Address]types.Transactions) ([]common.Hash, map[common.Hash]common.Address) {
+func flattenTxnMap(txnMap map[common.Address]types.Transactions, isGasTier bool) ([]common.Hash, map[common.Hash]common.Address) {
 	if txnMap == nil {
 		return nil, nil
 	}
@@ -302,6 +375,20 @@ func flattenTxnMap(txnMap map[common.Address]types.Transactions) ([]common.Hash,
 		}
 	}
 
+	//Once the gas tier fork is active, transactions with a higher gas tier are proposed first
+	if isGasTier {
+		addresses := make([]common.Address, 0, len(txnMap))
+		for k := range txnMap {
+			addresses = append(addresses, k)
+		}
+		sort.Slice(addresses, func(i, j int) bool {
+			return bytes.Compare(addresses[i].Bytes(), addresses[j].Bytes()) < 0
+		})
+		for i, txn := range types.SortByGasTier(txnMap, addresses) {
+			txnList[i].CopyFrom(txn.Hash())
+		}
+	}
+
 	return txnList, txnAddressMap
 }
 
@@ -341,6 +428,9 @@ func recreateTxnMap(selectedTxns []common.Hash, txnAddressMap map[common.Hash]co
 }
 
 func (c *ProofOfStake) IsBlockReadyToSeal(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB) bool {
+	if c.config.Developer {
+		return true
+	}
 	blockState, _, err := c.consensusHandler.getBlockState(header.ParentHash)
 	if err != nil {
 		log.Trace("IsBlockReadyToSeal", "blockState", blockState, "err", err)
@@ -358,7 +448,10 @@ func (c *ProofOfStake) HandleTransactions(chain consensus.ChainHeaderReader, hea
 	if c.signFn == nil {
 		return nil, errors.New("not a miner")
 	}
-	txns, txnAddressMap := flattenTxnMap(txnMap)
+	if c.config.Developer {
+		return c.developerTransactions(txnMap)
+	}
+	txns, txnAddressMap := flattenTxnMap(txnMap, c.chainConfig.IsGasTier(header.Number))
 
 	err := c.consensusHandler.HandleConsensus(header.ParentHash, txns, header.Number.Uint64())
 	if err != nil {
@@ -594,6 +687,10 @@ func (c *ProofOfStake) Prepare(chain consensus.ChainHeaderReader, header *types.
 }
 
 func (c *ProofOfStake) VerifyBlock(chain consensus.ChainHeaderReader, block *types.Block) error {
+	//Developer blocks have no consensus packets to validate
+	if c.config.Developer {
+		return c.verifyDeveloperBlock(chain, block)
+	}
 	header := block.Header()
 	number := header.Number.Uint64()
 
@@ -626,7 +723,7 @@ func (c *ProofOfStake) VerifyBlock(chain consensus.ChainHeaderReader, block *typ
 		}
 	}
 
-	err = ValidateBlockConsensusData(block, &validatorDepositMap, &valDetailsMap, c.GetConsensusContext, c.GetValidators)
+	err = ValidateBlockConsensusData(block, &validatorDepositMap, &valDetailsMap, c.GetConsensusContext, c.GetValidators, c.forks.consensusContextStart, c.forks.blockTimeOrigStart)
 	if err != nil {
 		log.Trace("ValidateBlockConsensusData", "err", err)
 	}
@@ -635,7 +732,7 @@ func (c *ProofOfStake) VerifyBlock(chain consensus.ChainHeaderReader, block *typ
 }
 
 func (c *ProofOfStake) Convert(header *types.Header, state *state.StateDB, txn *types.Transaction) error {
-	msg, err := txn.AsMessage(c.signer)
+	msg, err := txn.AsMessage(c.signer, c.chainConfig, header.Number)
 	if err != nil {
 		return err
 	}
@@ -693,6 +790,10 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 				log.Trace("Txn VerifyFields failed", "Hash", tx.Hash())
 				return errors.New("Transaction VerifyFields failed")
 			}
+			if c.chainConfig.IsGasTier(header.Number) && tx.HasValidGasTier() == false {
+				log.Trace("Txn gas tier invalid", "Hash", tx.Hash(), "gasTier", tx.GasTier())
+				return errors.New("Transaction gas tier invalid")
+			}
 			signerHash, err := c.signer.Hash(tx)
 			if err != nil {
 				return err
@@ -732,7 +833,7 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 
 	//Block Slashing
 	//If Round = 1, then it means PROPOSER was likely offline, as opposed to Round = 2 which means validators were not able to get consensus on time
-	if blockConsensusData.Round == 1 && blockConsensusData.SlashedBlockProposers != nil && len(blockConsensusData.SlashedBlockProposers) > 0 && header.Number.Uint64() >= slashStartBlockNumber {
+	if blockConsensusData.Round == 1 && blockConsensusData.SlashedBlockProposers != nil && len(blockConsensusData.SlashedBlockProposers) > 0 && header.Number.Uint64() >= c.forks.slashStart {
 		for _, val := range blockConsensusData.SlashedBlockProposers {
 			depositor, err := c.GetDepositorOfValidator(val, header.ParentHash)
 			if err != nil {
@@ -755,7 +856,7 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 	//Validator nil block
 	//If Round = 1, then it means PROPOSER was likely offline, as opposed to Round = 2 which means validators were not able to get consensus on time
 	if blockConsensusData.VoteType == VOTE_TYPE_NIL && blockConsensusData.Round == 1 && blockConsensusData.SlashedBlockProposers != nil &&
-		len(blockConsensusData.SlashedBlockProposers) > 0 && header.Number.Uint64() >= VALIDATOR_NIL_BLOCK_START_BLOCK {
+		len(blockConsensusData.SlashedBlockProposers) > 0 && header.Number.Uint64() >= c.forks.validatorNilBlock {
 		for _, val := range blockConsensusData.SlashedBlockProposers {
 			err = c.SetNilBlock(val, state, header)
 			if err != nil {
@@ -766,8 +867,8 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 	}
 
 	//Block Rewards
-	if blockConsensusData.VoteType == VOTE_TYPE_OK && header.Number.Uint64() >= rewardStartBlockNumber {
-		blockProposerRewardAmount := GetReward(header.Number)
+	if blockConsensusData.VoteType == VOTE_TYPE_OK && header.Number.Cmp(c.forks.rewardStart) >= 0 {
+		blockProposerRewardAmount := c.GetReward(header.Number)
 
 		//Add same amount of reward to Staking Contract, so that it is available for withdrawal later on
 		err := c.accumulateBalance(state, blockProposerRewardAmount, common.HexToAddress(staking.GetStakingContract_Address_String()))
@@ -784,8 +885,8 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 		}
 
 		//If txn fee for proposer criteria is met and the block has transactions
-		if header.Number.Uint64() >= core.TXN_FEE_CUTTOFF_BLOCK && len(txs) > 0 {
-			txnFeeTotal, rewardsAmountTxnFee, burnAmountTxnFee, err := calculateTxnFeeSplit(blockProposerRewardAmount, txs, receipts)
+		if header.Number.Uint64() >= c.forks.txnFeeCutoff && len(txs) > 0 {
+			txnFeeTotal, rewardsAmountTxnFee, burnAmountTxnFee, err := calculateTxnFeeSplit(blockProposerRewardAmount, txs, receipts, c.chainConfig, header.Number)
 			if err != nil {
 				return err
 			}
@@ -817,7 +918,7 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 		}
 
 		//Validator nil block reset
-		if header.Number.Uint64() > VALIDATOR_NIL_BLOCK_START_BLOCK {
+		if header.Number.Uint64() > c.forks.validatorNilBlock {
 			err = c.ResetNilBlock(blockConsensusData.BlockProposer, state, header)
 			if err != nil {
 				log.Error("ResetNilBlock err", "err", err)
@@ -827,21 +928,21 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 	}
 
 	//Staking V2
-	if header.Number.Uint64() == STAKING_CONTRACT_V2_CUTOFF_BLOCK {
-		log.Info("Setting stakingv2 contract code", "blockNumber", STAKING_CONTRACT_V2_CUTOFF_BLOCK)
+	if header.Number.Uint64() == c.forks.stakingContractV2 {
+		log.Info("Setting stakingv2 contract code", "blockNumber", c.forks.stakingContractV2)
 		stakingContractCode := common.FromHex(stakingv2.STAKING_RUNTIME_BIN)
 		state.SetCode(staking.STAKING_CONTRACT_ADDRESS, stakingContractCode)
 	}
 
 	//Consensus Context
-	if header.Number.Uint64() == CONSENSUS_CONTEXT_START_BLOCK {
-		log.Info("Setting consensus context contract code", "blockNumber", CONSENSUS_CONTEXT_START_BLOCK)
+	if header.Number.Uint64() == c.forks.consensusContextStart {
+		log.Info("Setting consensus context contract code", "blockNumber", c.forks.consensusContextStart)
 		consensuscontextContractCode := common.FromHex(consensuscontext.CONSENSUS_CONTEXT_RUNTIME_BIN)
 		state.SetCode(consensuscontext.CONSENSUS_CONTEXT_CONTRACT_ADDRESS, consensuscontextContractCode)
 	}
 
-	if header.Number.Uint64() > CONSENSUS_CONTEXT_START_BLOCK {
-		key, err := GetConsensusContextKey(header.Number.Uint64())
+	if header.Number.Uint64() > c.forks.consensusContextStart {
+		key, err := consensusContextKey(header.Number.Uint64(), c.forks.consensusContextStart)
 		if err != nil {
 			log.Error("GetBlockConsensusContextFn err", "err", err)
 			return err
@@ -855,8 +956,8 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 		}
 
 		//Remove the oldest key
-		if header.Number.Uint64() > (CONSENSUS_CONTEXT_START_BLOCK + CONSENSUS_CONTEXT_MAX_BLOCK_COUNT) {
-			oldKey, err := GetConsensusContextKey(header.Number.Uint64() - CONSENSUS_CONTEXT_MAX_BLOCK_COUNT)
+		if header.Number.Uint64() > (c.forks.consensusContextStart + CONSENSUS_CONTEXT_MAX_BLOCK_COUNT) {
+			oldKey, err := consensusContextKey(header.Number.Uint64()-CONSENSUS_CONTEXT_MAX_BLOCK_COUNT, c.forks.consensusContextStart)
 			if err != nil {
 				log.Error("GetBlockConsensusContextKey oldKey err", "err", err)
 				return err
@@ -872,7 +973,7 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 
 	//Fix blocktime
 	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
-	if (header.Number.Uint64() == 1 || header.Number.Uint64()%BLOCK_PERIOD_TIME_CHANGE == 0 || header.Number.Uint64() >= BLOCK_TIME_ORIG_START_BLOCK) && blockConsensusData.VoteType == VOTE_TYPE_OK && parent.Time < blockConsensusData.BlockTime {
+	if (header.Number.Uint64() == 1 || header.Number.Uint64()%BLOCK_PERIOD_TIME_CHANGE == 0 || header.Number.Uint64() >= c.forks.blockTimeOrigStart) && blockConsensusData.VoteType == VOTE_TYPE_OK && parent.Time < blockConsensusData.BlockTime {
 		header.Time = blockConsensusData.BlockTime
 	} else {
 		header.Time = parent.Time + c.config.Period
@@ -885,7 +986,7 @@ func (c *ProofOfStake) Finalize(chain consensus.ChainHeaderReader, header *types
 	return nil
 }
 
-func calculateTxnFeeSplit(originalBlockRewards *big.Int, txs []*types.Transaction, receipts []*types.Receipt) (txnFeeTotal *big.Int, txnFeeRewardsAmount *big.Int, burnAmount *big.Int, err error) {
+func calculateTxnFeeSplit(originalBlockRewards *big.Int, txs []*types.Transaction, receipts []*types.Receipt, chainConfig *params.ChainConfig, number *big.Int) (txnFeeTotal *big.Int, txnFeeRewardsAmount *big.Int, burnAmount *big.Int, err error) {
 	if len(receipts) != len(txs) {
 		log.Error("Finalize receipts and txn invalid len", "receipts len", len(receipts), "txn len", len(txs))
 		return nil, nil, nil, errors.New("finalize receipts and txn invalid length")
@@ -903,9 +1004,10 @@ func calculateTxnFeeSplit(originalBlockRewards *big.Int, txs []*types.Transactio
 			log.Error("Finalize txn not found in receipts", "hash", receipt.TxHash)
 			return nil, nil, nil, errors.New("finalize txn not found in receipts")
 		}
-		gasCoinsUsed := common.SafeMulBigInt(txn.GasPrice(), new(big.Int).SetUint64(receipt.GasUsed))
+		gasPrice := types.EffectiveGasPrice(chainConfig, number, txn)
+		gasCoinsUsed := common.SafeMulBigInt(gasPrice, new(big.Int).SetUint64(receipt.GasUsed))
 		txnFeeTotal = common.SafeAddBigInt(txnFeeTotal, gasCoinsUsed)
-		log.Trace("calculateTxnFeeSplit", "gasCoinsUsed", gasCoinsUsed, "txn", txn.Hash(), "gasPrice", txn.GasPrice(), "GasUsed", receipt.GasUsed)
+		log.Trace("calculateTxnFeeSplit", "gasCoinsUsed", gasCoinsUsed, "txn", txn.Hash(), "gasPrice", gasPrice, "GasUsed", receipt.GasUsed)
 	}
 
 	burnAmount, txnFeeRewardsAmount = calculateTxnFeeSplitCoins(txnFeeTotal)
@@ -944,21 +1046,31 @@ func (c *ProofOfStake) FinalizeAndAssembleWithConsensus(chain consensus.ChainHea
 		return nil, errUnknownBlock
 	}
 
-	blockState, round, err := c.consensusHandler.getBlockState(header.ParentHash)
-	if err != nil {
-		log.Trace("getBlockState", "err", err)
-		return nil, err
-	}
+	var blockConsensusData *BlockConsensusData
+	var blockAdditionalConsensusData *BlockAdditionalConsensusData
+	if c.config.Developer {
+		var err error
+		blockConsensusData, blockAdditionalConsensusData, err = c.getDeveloperConsensusData(chain, header, txs)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		blockState, round, err := c.consensusHandler.getBlockState(header.ParentHash)
+		if err != nil {
+			log.Trace("getBlockState", "err", err)
+			return nil, err
+		}
 
-	if blockState != BLOCK_STATE_RECEIVED_COMMITS {
-		log.Trace("FinalizeAndAssembleWithConsensus BLOCK_STATE_WAITING_FOR_COMMITS", round)
-		return nil, errors.New("Block state not yet BLOCK_STATE_WAITING_FOR_COMMITS")
-	}
+		if blockState != BLOCK_STATE_RECEIVED_COMMITS {
+			log.Trace("FinalizeAndAssembleWithConsensus BLOCK_STATE_WAITING_FOR_COMMITS", round)
+			return nil, errors.New("Block state not yet BLOCK_STATE_WAITING_FOR_COMMITS")
+		}
 
-	blockConsensusData, blockAdditionalConsensusData, err := c.consensusHandler.getBlockConsensusData(header.ParentHash)
-	if err != nil {
-		log.Trace("getBlockConsensusData", "err", err)
-		return nil, err
+		blockConsensusData, blockAdditionalConsensusData, err = c.consensusHandler.getBlockConsensusData(header.ParentHash)
+		if err != nil {
+			log.Trace("getBlockConsensusData", "err", err)
+			return nil, err
+		}
 	}
 	data, err := rlp.EncodeToBytes(blockConsensusData)
 	if err != nil {
@@ -991,6 +1103,19 @@ func (c *ProofOfStake) Authorize(validator common.Address, signFn SignerFn, sign
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	if c.voteJournalDir != "" && (c.voteJournal == nil || c.voteJournal.Validator() != validator) {
+		voteJournal, err := OpenVoteJournal(c.voteJournalDir, validator)
+		if err != nil {
+			log.Error("Failed to open vote journal, validator will not sign consensus packets", "dir", c.voteJournalDir, "validator", validator, "err", err)
+			return
+		}
+		if c.voteJournal != nil {
+			c.voteJournal.Close()
+		}
+		c.voteJournal = voteJournal
+		c.consensusHandler.SetVoteJournal(voteJournal)
+	}
+
 	c.validator = validator
 	c.signFn = signFn
 	c.signTxFn = signTxFn
@@ -1010,6 +1135,10 @@ func (c *ProofOfStake) Seal(chain consensus.ChainHeaderReader, block *types.Bloc
 	log.Info("Seal Block", "Hash", block.ParentHash().String(), "Number", header.Number)
 
 	delay := time.Second * 1
+	if c.config.Developer {
+		//Developer blocks are sealed as soon as their time is reached
+		delay = time.Until(time.Unix(int64(header.Time), 0))
+	}
 	go func() {
 		select {
 		case <-stop:
@@ -1039,8 +1168,17 @@ func (c *ProofOfStake) SealHash(header *types.Header) common.Hash {
 	return SealHash(header)
 }
 
-// Close implements consensus.Engine. It's a noop for proofofstake as there are no background threads.
+// Close implements consensus.Engine, closing the vote journal if one is open.
 func (c *ProofOfStake) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.performanceIndexer != nil {
+		c.performanceIndexer.Close()
+	}
+	if c.voteJournal != nil {
+		return c.voteJournal.Close()
+	}
 	return nil
 }
 
@@ -25,6 +25,7 @@ import (
 	"github.com/QuantumCoinProject/qc/crypto/cryptobase"
 	"github.com/QuantumCoinProject/qc/crypto/signaturealgorithm"
 	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/params"
 	"github.com/QuantumCoinProject/qc/systemcontracts/conversion"
 	"github.com/QuantumCoinProject/qc/systemcontracts/staking"
 	"math/big"
@@ -100,7 +101,7 @@ func TestTxnFee_Simple(t *testing.T) {
 }
 
 func TestPos_FlattenTxnMap(t *testing.T) {
-	txnList, txnAddressMap := flattenTxnMap(nil)
+	txnList, txnAddressMap := flattenTxnMap(nil, false)
 	if txnList != nil && txnAddressMap != nil {
 		t.Fatalf("failed")
 	}
@@ -130,7 +131,7 @@ func TestPos_FlattenTxnMap(t *testing.T) {
 		}
 	}
 
-	txnList, txnAddressMap = flattenTxnMap(groups)
+	txnList, txnAddressMap = flattenTxnMap(groups, false)
 	if txnList == nil && txnAddressMap == nil {
 		t.Fatalf("failed")
 	}
@@ -222,6 +223,39 @@ func TestPos_FlattenTxnMap(t *testing.T) {
 
 }
 
+func TestPos_FlattenTxnMapGasTier(t *testing.T) {
+	signer := types.NewLondonSignerDefaultChain()
+	signTxns := func(tiers ...types.GasTier) (common.Address, types.Transactions) {
+		key, _ := cryptobase.SigAlg.GenerateKey()
+		txns := make(types.Transactions, len(tiers))
+		for i, tier := range tiers {
+			tx, err := types.SignTx(types.NewDefaultFeeTransaction(big.NewInt(types.DEFAULT_CHAIN_ID), uint64(i), &common.Address{}, big.NewInt(100), 21000, tier, nil), signer, key)
+			if err != nil {
+				t.Fatalf("failed %v", err)
+			}
+			txns[i] = tx
+		}
+		return cryptobase.SigAlg.PublicKeyToAddressNoError(&key.PublicKey), txns
+	}
+
+	addrA, txnsA := signTxns(types.GAS_TIER_DEFAULT, types.GAS_TIER_10X)
+	addrB, txnsB := signTxns(types.GAS_TIER_5X)
+	groups := map[common.Address]types.Transactions{addrA: txnsA, addrB: txnsB}
+
+	txnList, txnAddressMap := flattenTxnMap(groups, true)
+	if len(txnList) != 3 || len(txnAddressMap) != 3 {
+		t.Fatalf("failed")
+	}
+
+	//The 10x transaction of A waits for the earlier default tier transaction of A
+	expected := []common.Hash{txnsB[0].Hash(), txnsA[0].Hash(), txnsA[1].Hash()}
+	for i := range expected {
+		if txnList[i].IsEqualTo(expected[i]) == false {
+			t.Fatalf("failed %d", i)
+		}
+	}
+}
+
 func encCall(abi *abi.ABI, method string, args ...interface{}) ([]byte, error) {
 	return abi.Pack(method, args...)
 }
@@ -274,7 +308,7 @@ func testGetBlockConsensusContextForBlock(t *testing.T, blockNumber uint64, expe
 		return
 	}
 
-	key, err := GetBlockConsensusContextKeyForBlock(blockNumber)
+	key, err := GetBlockConsensusContextKeyForBlock(blockNumber, CONSENSUS_CONTEXT_START_BLOCK)
 	if err != nil {
 		fmt.Println("err", err)
 		t.Fatalf("failed 2")
@@ -297,3 +331,38 @@ func Test_GetBlockConsensusContextForBlock(t *testing.T) {
 	testGetBlockConsensusContextForBlock(t, uint64(933889), uint64(421889))
 	testGetBlockConsensusContextForBlock(t, uint64(933890), uint64(421890))
 }
+
+// TestForkBlocks_mainnet checks that the mainnet blocks used by the chain config compatibility check are those of the
+// consensus engine.
+func TestForkBlocks_mainnet(t *testing.T) {
+	forks := newForkBlocks(&params.ChainConfig{})
+	mainnet := params.MainnetProofOfStakeForks
+	if forks.rewardStart.Cmp(mainnet.RewardStartBlock) != 0 ||
+		forks.stakingContractV2 != mainnet.StakingV2Block.Uint64() ||
+		forks.consensusContextStart != mainnet.ConsensusContextBlock.Uint64() ||
+		forks.validatorNilBlock != mainnet.ValidatorNilBlock.Uint64() ||
+		forks.blockTimeOrigStart != mainnet.BlockTimeOrigBlock.Uint64() ||
+		forks.txnFeeCutoff != mainnet.TxnFeeBlock.Uint64() ||
+		forks.slashStart != mainnet.SlashStartBlock.Uint64() {
+		t.Fatalf("failed %v %v", forks, mainnet)
+	}
+}
+
+func TestForkBlocks_configured(t *testing.T) {
+	forks := newForkBlocks(&params.ChainConfig{ProofOfStake: &params.ProofOfStakeConfig{
+		ConsensusContextBlock: big.NewInt(0),
+		SlashStartBlock:       big.NewInt(10),
+	}})
+	if forks.consensusContextStart != 0 || forks.slashStart != 10 || forks.blockTimeOrigStart != BLOCK_TIME_ORIG_START_BLOCK {
+		t.Fatalf("failed %v", forks)
+	}
+
+	key, err := GetBlockConsensusContextKeyForBlock(600000, forks.consensusContextStart)
+	if err != nil || key != "bc-88000" {
+		t.Fatalf("failed %v %v", key, err)
+	}
+	key, err = GetBlockConsensusContextKeyForBlock(600000, CONSENSUS_CONTEXT_START_BLOCK)
+	if err != nil || key != "bc-536000" {
+		t.Fatalf("failed %v %v", key, err)
+	}
+}
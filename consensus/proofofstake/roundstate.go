@@ -0,0 +1,193 @@
+package proofofstake
+
+import (
+	"bytes"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"math/big"
+	"sort"
+)
+
+// The round state is what the node currently knows about the consensus round of the next block, to see why a
+// block is stalled.
+
+type RoundVote struct {
+	Validator common.Address `json:"validator"     gencodec:"required"`
+	Weight    string         `json:"weight"     gencodec:"required"` //deposit of the validator
+	VoteType  VoteType       `json:"voteType,omitempty"`             //only set for proposal acks
+}
+
+type OutOfOrderPacketState struct {
+	ParentHash   common.Hash `json:"parentHash"     gencodec:"required"`
+	PacketHash   common.Hash `json:"packetHash"     gencodec:"required"`
+	PacketType   byte        `json:"packetType"     gencodec:"required"`
+	ReceivedTime uint64      `json:"receivedTime"     gencodec:"required"`
+}
+
+type RoundState struct {
+	Validator         common.Address `json:"validator"     gencodec:"required"`
+	Initialized       bool           `json:"initialized"     gencodec:"required"`
+	ParentHash        common.Hash    `json:"parentHash"     gencodec:"required"`
+	BlockNumber       uint64         `json:"blockNumber"`
+	Round             byte           `json:"round"`
+	State             string         `json:"state"`
+	NewRoundReason    NewRoundReason `json:"newRoundReason"`
+	Proposer          common.Address `json:"proposer"`
+	VoteType          VoteType       `json:"voteType"`
+	ProposalHash      common.Hash    `json:"proposalHash"`
+	PrecommitHash     common.Hash    `json:"precommitHash"`
+	TotalWeight       string         `json:"totalWeight"`
+	RequiredWeight    string         `json:"requiredWeight"` //weight of the votes required to move to the next state
+	ProposalAcks      []*RoundVote   `json:"proposalAcks"`
+	ProposalAckWeight string         `json:"proposalAckWeight"`
+	Precommits        []*RoundVote   `json:"precommits"`
+	PrecommitWeight   string         `json:"precommitWeight"`
+	Commits           []*RoundVote   `json:"commits"`
+	CommitWeight      string         `json:"commitWeight"`
+
+	//milliseconds left before the round times out, zero when already timed out
+	ProposalTimeout  int64 `json:"proposalTimeout"`
+	AckTimeout       int64 `json:"ackTimeout"`
+	PrecommitTimeout int64 `json:"precommitTimeout"`
+
+	OutOfOrderPackets []*OutOfOrderPacketState `json:"outOfOrderPackets"     gencodec:"required"`
+}
+
+func getBlockRoundStateName(state BlockRoundState) string {
+	switch state {
+	case BLOCK_STATE_WAITING_FOR_PROPOSAL:
+		return "WAITING_FOR_PROPOSAL"
+	case BLOCK_STATE_WAITING_FOR_PROPOSAL_ACKS:
+		return "WAITING_FOR_PROPOSAL_ACKS"
+	case BLOCK_STATE_WAITING_FOR_PRECOMMITS:
+		return "WAITING_FOR_PRECOMMITS"
+	case BLOCK_STATE_WAITING_FOR_COMMITS:
+		return "WAITING_FOR_COMMITS"
+	case BLOCK_STATE_RECEIVED_COMMITS:
+		return "RECEIVED_COMMITS"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// getRemainingTime returns the milliseconds left before a timeout, given the milliseconds elapsed.
+func getRemainingTime(elapsed int64, timeoutMs int64) int64 {
+	if elapsed >= timeoutMs {
+		return 0
+	}
+	return timeoutMs - elapsed
+}
+
+// getRoundVotes returns the validators that voted with their weight, sorted by address.
+func getRoundVotes(validators []common.Address, depositMap map[common.Address]*big.Int) ([]*RoundVote, *big.Int) {
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i].Bytes(), validators[j].Bytes()) < 0
+	})
+
+	weight := big.NewInt(0)
+	votes := make([]*RoundVote, len(validators))
+	for i, validator := range validators {
+		deposit, ok := depositMap[validator]
+		if ok == false {
+			deposit = big.NewInt(0)
+		}
+		weight = common.SafeAddBigInt(weight, deposit)
+		votes[i] = &RoundVote{
+			Validator: validator,
+			Weight:    hexutil.EncodeBig(deposit),
+		}
+	}
+	return votes, weight
+}
+
+func (cph *ConsensusHandler) getRoundState() *RoundState {
+	cph.outerPacketLock.Lock()
+	defer cph.outerPacketLock.Unlock()
+
+	roundState := &RoundState{
+		Validator:         cph.account.Address,
+		Initialized:       cph.initialized,
+		ParentHash:        cph.currentParentHash,
+		State:             getBlockRoundStateName(BLOCK_STATE_UNKNOWN),
+		ProposalAcks:      make([]*RoundVote, 0),
+		Precommits:        make([]*RoundVote, 0),
+		Commits:           make([]*RoundVote, 0),
+		OutOfOrderPackets: make([]*OutOfOrderPacketState, 0),
+	}
+
+	for _, packetMap := range cph.outOfOrderPacketsMap {
+		for _, pkt := range packetMap {
+			roundState.OutOfOrderPackets = append(roundState.OutOfOrderPackets, &OutOfOrderPacketState{
+				ParentHash:   pkt.Packet.ParentHash,
+				PacketHash:   pkt.Packet.Hash(),
+				PacketType:   byte(getPacketType(pkt.Packet)),
+				ReceivedTime: uint64(pkt.ReceivedTime.Unix()),
+			})
+		}
+	}
+	sort.Slice(roundState.OutOfOrderPackets, func(i, j int) bool {
+		return roundState.OutOfOrderPackets[i].ReceivedTime < roundState.OutOfOrderPackets[j].ReceivedTime
+	})
+
+	blockStateDetails, ok := cph.blockStateDetailsMap[cph.currentParentHash]
+	if ok == false || blockStateDetails.currentRound == 0 {
+		return roundState
+	}
+	blockRoundDetails := blockStateDetails.blockRoundMap[blockStateDetails.currentRound]
+
+	roundState.BlockNumber = blockStateDetails.blockNumber
+	roundState.Round = blockRoundDetails.Round
+	roundState.State = getBlockRoundStateName(blockRoundDetails.state)
+	roundState.NewRoundReason = blockRoundDetails.newRoundReason
+	roundState.Proposer = blockRoundDetails.proposer
+	roundState.VoteType = blockRoundDetails.blockVoteType
+	roundState.ProposalHash = blockRoundDetails.proposalHash
+	roundState.PrecommitHash = blockRoundDetails.precommitHash
+	if blockStateDetails.totalBlockDepositValue != nil {
+		roundState.TotalWeight = hexutil.EncodeBig(blockStateDetails.totalBlockDepositValue)
+	}
+	if blockStateDetails.blockMinWeightedProposalsRequired != nil {
+		roundState.RequiredWeight = hexutil.EncodeBig(blockStateDetails.blockMinWeightedProposalsRequired)
+	}
+
+	validators := make([]common.Address, 0, len(blockRoundDetails.validatorProposalAcks))
+	for validator := range blockRoundDetails.validatorProposalAcks {
+		validators = append(validators, validator)
+	}
+	votes, weight := getRoundVotes(validators, blockStateDetails.filteredValidatorsDepositMap)
+	for _, vote := range votes {
+		vote.VoteType = blockRoundDetails.validatorProposalAcks[vote.Validator].ProposalAckVoteType
+	}
+	roundState.ProposalAcks = votes
+	roundState.ProposalAckWeight = hexutil.EncodeBig(weight)
+
+	validators = make([]common.Address, 0, len(blockRoundDetails.validatorPrecommits))
+	for validator := range blockRoundDetails.validatorPrecommits {
+		validators = append(validators, validator)
+	}
+	votes, weight = getRoundVotes(validators, blockStateDetails.filteredValidatorsDepositMap)
+	roundState.Precommits = votes
+	roundState.PrecommitWeight = hexutil.EncodeBig(weight)
+
+	validators = make([]common.Address, 0, len(blockRoundDetails.validatorCommits))
+	for validator := range blockRoundDetails.validatorCommits {
+		validators = append(validators, validator)
+	}
+	votes, weight = getRoundVotes(validators, blockStateDetails.filteredValidatorsDepositMap)
+	roundState.Commits = votes
+	roundState.CommitWeight = hexutil.EncodeBig(weight)
+
+	//Same timeouts as in HandleConsensus
+	proposalTimeoutMs := BLOCK_TIMEOUT_MS
+	if shouldSignFull(blockStateDetails.blockNumber) {
+		proposalTimeoutMs = FULL_BLOCK_TIMEOUT_MS
+	}
+	elapsed := Elapsed(cph.clock, blockRoundDetails.initTime)
+	roundState.ProposalTimeout = getRemainingTime(elapsed, proposalTimeoutMs*int64(blockRoundDetails.Round))
+	roundState.AckTimeout = getRemainingTime(elapsed, int64(ACK_BLOCK_TIMEOUT_MS*int(blockRoundDetails.Round)))
+	if blockRoundDetails.precommitStarted {
+		roundState.PrecommitTimeout = getRemainingTime(Elapsed(cph.clock, blockRoundDetails.precommitInitTime), int64(ACK_BLOCK_TIMEOUT_MS*int(blockRoundDetails.Round)))
+	}
+
+	return roundState
+}
@@ -0,0 +1,81 @@
+package proofofstake
+
+import (
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/common/mclock"
+	"github.com/QuantumCoinProject/qc/eth/protocols/eth"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestGetRoundState(t *testing.T) {
+	parentHash := common.BytesToHash([]byte{1})
+	val1 := common.BytesToAddress([]byte{1})
+	val2 := common.BytesToAddress([]byte{2})
+	val3 := common.BytesToAddress([]byte{3})
+	clock := &mclock.Simulated{}
+
+	cph := &ConsensusHandler{
+		blockStateDetailsMap: make(map[common.Hash]*BlockStateDetails),
+		outOfOrderPacketsMap: make(map[common.Hash]map[common.Hash]*OutOfOrderPacket),
+		currentParentHash:    parentHash,
+		clock:                clock,
+	}
+
+	roundState := cph.getRoundState()
+	if roundState.State != "UNKNOWN" || roundState.Round != 0 || len(roundState.ProposalAcks) != 0 {
+		t.Fatalf("failed")
+	}
+
+	roundDetails := &BlockRoundDetails{
+		Round:                 1,
+		state:                 BLOCK_STATE_WAITING_FOR_PRECOMMITS,
+		proposer:              val2,
+		validatorProposalAcks: make(map[common.Address]*ProposalAckDetails),
+		validatorPrecommits:   make(map[common.Address]*PreCommitDetails),
+		validatorCommits:      make(map[common.Address]*CommitDetails),
+		initTime:              clock.Now(),
+		precommitStarted:      true,
+		precommitInitTime:     clock.Now(),
+	}
+	roundDetails.validatorProposalAcks[val3] = &ProposalAckDetails{ProposalAckVoteType: VOTE_TYPE_NIL}
+	roundDetails.validatorProposalAcks[val1] = &ProposalAckDetails{ProposalAckVoteType: VOTE_TYPE_OK}
+	roundDetails.validatorPrecommits[val1] = &PreCommitDetails{}
+
+	cph.blockStateDetailsMap[parentHash] = &BlockStateDetails{
+		filteredValidatorsDepositMap: map[common.Address]*big.Int{val1: big.NewInt(10), val2: big.NewInt(20), val3: big.NewInt(30)},
+		totalBlockDepositValue:       big.NewInt(60),
+		blockRoundMap:                map[byte]*BlockRoundDetails{1: roundDetails},
+		currentRound:                 1,
+		blockNumber:                  1,
+	}
+
+	packet := &eth.ConsensusPacket{
+		ParentHash:    common.BytesToHash([]byte{2}),
+		ConsensusData: []byte{ConsensusNetworkProtocolVersion, byte(CONSENSUS_PACKET_TYPE_COMMIT_BLOCK), 0xc0},
+	}
+	cph.outOfOrderPacketsMap[packet.ParentHash] = map[common.Hash]*OutOfOrderPacket{
+		packet.Hash(): {ReceivedTime: time.Now(), Packet: packet},
+	}
+
+	clock.Run(500 * time.Millisecond)
+	roundState = cph.getRoundState()
+	if roundState.State != "WAITING_FOR_PRECOMMITS" || roundState.Round != 1 || roundState.Proposer != val2 {
+		t.Fatalf("failed %v", roundState)
+	}
+	if len(roundState.ProposalAcks) != 2 || roundState.ProposalAcks[0].Validator != val1 || roundState.ProposalAcks[0].VoteType != VOTE_TYPE_OK ||
+		roundState.ProposalAcks[1].VoteType != VOTE_TYPE_NIL || roundState.ProposalAckWeight != hexutil.EncodeBig(big.NewInt(40)) {
+		t.Fatalf("failed")
+	}
+	if len(roundState.Precommits) != 1 || roundState.PrecommitWeight != hexutil.EncodeBig(big.NewInt(10)) || len(roundState.Commits) != 0 {
+		t.Fatalf("failed")
+	}
+	if roundState.AckTimeout != int64(ACK_BLOCK_TIMEOUT_MS)-500 || roundState.PrecommitTimeout != int64(ACK_BLOCK_TIMEOUT_MS)-500 {
+		t.Fatalf("failed %d %d", roundState.AckTimeout, roundState.PrecommitTimeout)
+	}
+	if len(roundState.OutOfOrderPackets) != 1 || roundState.OutOfOrderPackets[0].PacketType != byte(CONSENSUS_PACKET_TYPE_COMMIT_BLOCK) {
+		t.Fatalf("failed")
+	}
+}
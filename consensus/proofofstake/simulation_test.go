@@ -0,0 +1,657 @@
+package proofofstake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/accounts"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/mclock"
+	"github.com/QuantumCoinProject/qc/crypto"
+	"github.com/QuantumCoinProject/qc/eth/protocols/eth"
+	"github.com/QuantumCoinProject/qc/params"
+	"github.com/QuantumCoinProject/qc/rlp"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The simulation runs validators in process over a simulated network. The handlers and the network share a
+// simulated clock, packets are delivered by its timers so that faults can delay, drop, duplicate and reorder them,
+// and the round timeouts pass without waiting for them. Whether a packet is dropped and how
+// long it is delayed is derived from the seed, the packet and its receiver, and not from the order in which the
+// goroutines of the handlers run.
+
+var SimulationSafetyErr = errors.New("two different blocks were committed for the same parent")
+var SimulationLivenessErr = errors.New("no block was committed")
+
+type SimulationConfig struct {
+	Validators       int
+	Deposits         []*big.Int    //deposit of each validator, defaults to the minimum deposit
+	Seed             uint64        //seed of the network faults
+	Latency          time.Duration //delay of every packet
+	Jitter           time.Duration //random delay added to the latency, packets get reordered when it is larger than the time between them
+	DropRate         int           //percentage of the packets dropped
+	DuplicateRate    int           //percentage of the packets delivered twice
+	Step             time.Duration //time between two calls to HandleConsensus of a validator
+	MaxStepsPerBlock int           //steps after which a block that is not committed fails the liveness check
+	Transactions     int           //transactions in each block
+	StartBlock       uint64
+}
+
+type SimulationStats struct {
+	Sent       int
+	Dropped    int
+	Duplicated int
+	Delivered  int
+}
+
+type simValidator struct {
+	index        int
+	address      common.Address
+	localPeerId  string
+	handler      *ConsensusHandler
+	sim          *Simulation
+	crashed      bool
+	equivocating bool
+}
+
+type Simulation struct {
+	lock       sync.Mutex
+	t          *testing.T
+	config     SimulationConfig
+	clock      *mclock.Simulated
+	vm         *ValidatorManager
+	validators []*simValidator
+	peerMap    map[string]*simValidator
+	partition  map[int]int //group of each validator, nil when the network is not partitioned
+	attempts   map[common.Hash]uint64
+	commits    map[common.Hash]*BlockConsensusData
+	parents    []common.Hash
+	numbers    map[common.Hash]uint64
+	stats      SimulationStats
+}
+
+func NewSimulation(t *testing.T, config SimulationConfig) *Simulation {
+	if config.Deposits != nil && len(config.Deposits) != config.Validators {
+		t.Fatalf("failed deposits %d validators %d", len(config.Deposits), config.Validators)
+	}
+	if config.Step == 0 {
+		config.Step = 100 * time.Millisecond
+	}
+	if config.MaxStepsPerBlock == 0 {
+		config.MaxStepsPerBlock = 300
+	}
+	if config.StartBlock == 0 {
+		config.StartBlock = TEST_CONSENSUS_BLOCK_NUMBER
+	}
+
+	STARTUP_DELAY_MS = int64(0)
+	BLOCK_TIMEOUT_MS = int64(1000)
+	ACK_BLOCK_TIMEOUT_MS = 3000 //relative to start of block locally
+	BLOCK_CLEANUP_TIME_MS = int64(60000)
+	MAX_ROUND = byte(2)
+	BROADCAST_RESEND_DELAY = int64(100)
+	BROADCAST_CLEANUP_DELAY = int64(1800000)
+	CONSENSUS_DATA_REQUEST_RESEND_DELAY = int64(1000)
+	SKIP_HASH_CHECK = true
+
+	s := &Simulation{
+		t:        t,
+		config:   config,
+		clock:    &mclock.Simulated{},
+		vm:       NewValidatorManager(config.Validators),
+		peerMap:  make(map[string]*simValidator),
+		attempts: make(map[common.Hash]uint64),
+		commits:  make(map[common.Hash]*BlockConsensusData),
+		numbers:  make(map[common.Hash]uint64),
+	}
+
+	//Validators are ordered by address, so that faults can be scripted by index
+	addresses := make([]common.Address, 0, len(s.vm.valMap))
+	for addr := range s.vm.valMap {
+		addresses = append(addresses, addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i].Bytes(), addresses[j].Bytes()) < 0
+	})
+
+	for i, addr := range addresses {
+		if config.Deposits != nil {
+			s.vm.valMap[addr].balance = config.Deposits[i]
+		} else {
+			s.vm.valMap[addr].balance = params.EtherToWei(big.NewInt(500000000000))
+		}
+
+		handler := NewConsensusPacketHandler(WithClock(s.clock))
+		handler.getValidatorsFn = s.vm.GetValidatorsFn
+		handler.listValidatorsFn = s.vm.ListValidatorsAsMap
+		handler.doesFinalizedTransactionExistFn = s.doesFinalizedTransactionExistFn
+		handler.getBlockConsensusContext = getBlockConsensusContext
+		handler.signFn = s.vm.SignData
+		handler.signFnWithContext = s.vm.SignDataWithContext
+		//The sends reach the network before HandleConsensus returns, so that the clock only moves once they are queued
+		handler.goSend = func(send func()) { send() }
+		handler.peerHandler.goSend = handler.goSend
+		handler.account = accounts.Account{
+			Address: addr,
+		}
+
+		v := &simValidator{
+			index:       i,
+			address:     addr,
+			localPeerId: "peer" + strconv.Itoa(i),
+			handler:     handler,
+			sim:         s,
+		}
+		handler.p2pHandler = v
+		s.validators = append(s.validators, v)
+		s.peerMap[v.localPeerId] = v
+	}
+
+	return s
+}
+
+func (s *Simulation) doesFinalizedTransactionExistFn(txnHash common.Hash) (bool, error) {
+	return false, nil
+}
+
+// Now returns the time elapsed since the start of the simulation.
+func (s *Simulation) Now() time.Duration {
+	return time.Duration(s.clock.Now())
+}
+
+// At runs fn when the simulation reaches the given time, to script faults.
+func (s *Simulation) At(at time.Duration, fn func()) {
+	delay := at - s.Now()
+	if delay < 0 {
+		delay = 0
+	}
+	s.clock.AfterFunc(delay, fn)
+}
+
+// Crash stops a validator, it no longer sends or receives packets until it is recovered.
+func (s *Simulation) Crash(index int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.validators[index].crashed = true
+}
+
+func (s *Simulation) Recover(index int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.validators[index].crashed = false
+}
+
+// Equivocate makes a validator send conflicting proposals and proposal acks to half of its peers.
+func (s *Simulation) Equivocate(index int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.validators[index].equivocating = true
+}
+
+// Partition splits the network into groups of validator indexes. Validators that are not in any of the groups are
+// isolated.
+func (s *Simulation) Partition(groups ...[]int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.partition = make(map[int]int)
+	for group, indexes := range groups {
+		for _, index := range indexes {
+			s.partition[index] = group
+		}
+	}
+}
+
+func (s *Simulation) Heal() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.partition = nil
+}
+
+func (s *Simulation) getBlockNumber(parentHash common.Hash) uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.numbers[parentHash]
+}
+
+func (s *Simulation) Stats() SimulationStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stats
+}
+
+func (s *Simulation) isCrashed(v *simValidator) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return v.crashed
+}
+
+func (s *Simulation) isEquivocating(v *simValidator) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return v.equivocating
+}
+
+// canReach should be called with the lock held.
+func (s *Simulation) canReach(from *simValidator, to *simValidator) bool {
+	if from.crashed || to.crashed {
+		return false
+	}
+	if s.partition == nil {
+		return true
+	}
+	fromGroup, ok := s.partition[from.index]
+	if ok == false {
+		return false
+	}
+	toGroup, ok := s.partition[to.index]
+	if ok == false {
+		return false
+	}
+	return fromGroup == toGroup
+}
+
+// random returns the random value of a message to a receiver. Each resend of the same message gets a new value.
+// It should be called with the lock held.
+func (s *Simulation) random(message common.Hash, to *simValidator) uint64 {
+	seed := make([]byte, 8)
+	binary.BigEndian.PutUint64(seed, s.config.Seed)
+	key := crypto.Keccak256Hash(message.Bytes(), to.address.Bytes())
+	attempt := make([]byte, 8)
+	binary.BigEndian.PutUint64(attempt, s.attempts[key])
+	s.attempts[key] = s.attempts[key] + 1
+	return binary.BigEndian.Uint64(crypto.Keccak256(seed, key.Bytes(), attempt))
+}
+
+func (s *Simulation) delay(r uint64) time.Duration {
+	delay := s.config.Latency
+	if s.config.Jitter > 0 {
+		delay = delay + time.Duration(r%uint64(s.config.Jitter))
+	}
+	return delay
+}
+
+// send schedules the delivery of a message, fn is called when it is delivered.
+func (s *Simulation) send(from *simValidator, to *simValidator, message common.Hash, fn func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stats.Sent = s.stats.Sent + 1
+	if s.canReach(from, to) == false {
+		s.stats.Dropped = s.stats.Dropped + 1
+		return
+	}
+	r := s.random(message, to)
+	if int(r%100) < s.config.DropRate {
+		s.stats.Dropped = s.stats.Dropped + 1
+		return
+	}
+
+	deliver := func() {
+		s.lock.Lock()
+		reachable := s.canReach(from, to)
+		if reachable {
+			s.stats.Delivered = s.stats.Delivered + 1
+		}
+		s.lock.Unlock()
+		if reachable {
+			fn()
+		}
+	}
+
+	delay := s.delay(r >> 8)
+	s.clock.AfterFunc(delay, deliver)
+	if int((r>>16)%100) < s.config.DuplicateRate {
+		s.stats.Duplicated = s.stats.Duplicated + 1
+		s.clock.AfterFunc(delay+s.delay(r>>24), deliver)
+	}
+}
+
+func (s *Simulation) sendPacket(from *simValidator, to *simValidator, packet *eth.ConsensusPacket) {
+	pkt := eth.NewConsensusPacket(packet)
+	s.send(from, to, pkt.Hash(), func() {
+		to.handler.HandleConsensusPacket(&pkt, from.localPeerId)
+	})
+}
+
+// conflictingPacket returns a packet signed by the validator that conflicts with its proposal or proposal ack, or
+// nil if there is no conflicting packet.
+func (s *Simulation) conflictingPacket(v *simValidator, packet *eth.ConsensusPacket) *eth.ConsensusPacket {
+	var startIndex int
+	if packet.ConsensusData[0] >= MinConsensusNetworkProtocolVersion {
+		startIndex = 2
+	} else {
+		startIndex = 1
+	}
+
+	var details interface{}
+	packetType := getPacketType(packet)
+	if packetType == CONSENSUS_PACKET_TYPE_PROPOSE_BLOCK {
+		proposalDetails := &ProposalDetails{}
+		if err := rlp.DecodeBytes(packet.ConsensusData[startIndex:], proposalDetails); err != nil {
+			return nil
+		}
+		proposalDetails.Txns = append(proposalDetails.Txns, crypto.Keccak256Hash(packet.ConsensusData))
+		details = proposalDetails
+	} else if packetType == CONSENSUS_PACKET_TYPE_ACK_BLOCK_PROPOSAL {
+		proposalAckDetails := &ProposalAckDetails{}
+		if err := rlp.DecodeBytes(packet.ConsensusData[startIndex:], proposalAckDetails); err != nil {
+			return nil
+		}
+		if proposalAckDetails.ProposalAckVoteType != VOTE_TYPE_OK {
+			return nil
+		}
+		proposalAckDetails.ProposalAckVoteType = VOTE_TYPE_NIL
+		proposalAckDetails.ProposalHash = getNilVoteProposalHash(packet.ParentHash, proposalAckDetails.Round)
+		details = proposalAckDetails
+	} else {
+		return nil
+	}
+
+	encoded, err := rlp.EncodeToBytes(details)
+	if err != nil {
+		return nil
+	}
+	data := make([]byte, startIndex)
+	copy(data, packet.ConsensusData[:startIndex])
+	data = append(data, encoded...)
+
+	account := accounts.Account{
+		Address: v.address,
+	}
+	dataToSign := append(packet.ParentHash.Bytes(), data...)
+	var signature []byte
+	if packetType == CONSENSUS_PACKET_TYPE_PROPOSE_BLOCK && shouldSignFull(s.getBlockNumber(packet.ParentHash)) {
+		signature, err = s.vm.SignDataWithContext(account, accounts.MimetypeProofOfStake, dataToSign, FULL_SIGN_CONTEXT)
+	} else {
+		signature, err = s.vm.SignData(account, accounts.MimetypeProofOfStake, dataToSign)
+	}
+	if err != nil {
+		return nil
+	}
+
+	return &eth.ConsensusPacket{
+		ParentHash:    packet.ParentHash,
+		ConsensusData: data,
+		Signature:     signature,
+	}
+}
+
+func (v *simValidator) SendConsensusPacket(peerList []string, packet *eth.ConsensusPacket) error {
+	for _, peerId := range peerList {
+		peer, ok := v.sim.peerMap[peerId]
+		if ok == false || peer == v {
+			continue
+		}
+		v.sim.sendPacket(v, peer, packet)
+	}
+	return nil
+}
+
+func (v *simValidator) BroadcastConsensusData(packet *eth.ConsensusPacket) error {
+	var conflicting *eth.ConsensusPacket
+	if v.sim.isEquivocating(v) {
+		conflicting = v.sim.conflictingPacket(v, packet)
+	}
+	for _, peer := range v.sim.validators {
+		if peer == v {
+			continue
+		}
+		if conflicting != nil && peer.index%2 == 1 {
+			v.sim.sendPacket(v, peer, conflicting)
+		} else {
+			v.sim.sendPacket(v, peer, packet)
+		}
+	}
+	return nil
+}
+
+// RequestTransactions does nothing, since all the validators are given the transactions of the block.
+func (v *simValidator) RequestTransactions(txns []common.Hash) error {
+	return nil
+}
+
+func (v *simValidator) RequestConsensusData(packet *eth.RequestConsensusDataPacket) error {
+	request := &eth.RequestConsensusDataPacket{
+		ParentHash:  packet.ParentHash,
+		RequestData: common.CopyBytes(packet.RequestData),
+	}
+	message := crypto.Keccak256Hash(request.ParentHash.Bytes(), request.RequestData, v.address.Bytes())
+	for _, peer := range v.sim.validators {
+		if peer == v {
+			continue
+		}
+		peer := peer
+		v.sim.send(v, peer, message, func() {
+			packets, err := peer.handler.HandleRequestConsensusDataPacket(request)
+			if err != nil {
+				return
+			}
+			for _, pkt := range packets {
+				v.sim.sendPacket(peer, v, pkt)
+			}
+		})
+	}
+	return nil
+}
+
+func (v *simValidator) GetLocalPeerId() string {
+	return v.localPeerId
+}
+
+func getSimulationParentHash(seed uint64, blockNumber uint64) common.Hash {
+	return crypto.Keccak256Hash([]byte("simulation"), []byte(strconv.FormatUint(seed, 10)), []byte(strconv.FormatUint(blockNumber, 10)))
+}
+
+func getSimulationTransactions(parentHash common.Hash, count int) []common.Hash {
+	txns := make([]common.Hash, count)
+	for i := 0; i < count; i++ {
+		txns[i] = crypto.Keccak256Hash(parentHash.Bytes(), []byte(strconv.Itoa(i)))
+	}
+	return txns
+}
+
+// checkSafety checks that all the validators that received the commits of a parent committed the same block, and
+// returns whether an honest validator committed it.
+func (s *Simulation) checkSafety(parentHash common.Hash) (bool, error) {
+	committed := false
+	for _, v := range s.validators {
+		state, _, err := v.handler.getBlockState(parentHash)
+		if err != nil || state != BLOCK_STATE_RECEIVED_COMMITS {
+			continue
+		}
+		blockConsensusData, _, err := v.handler.getBlockConsensusData(parentHash)
+		if err != nil {
+			return false, err
+		}
+
+		s.lock.Lock()
+		commit, ok := s.commits[parentHash]
+		if ok == false {
+			s.commits[parentHash] = blockConsensusData
+			commit = blockConsensusData
+		}
+		honest := v.crashed == false && v.equivocating == false
+		s.lock.Unlock()
+
+		if commit.VoteType != blockConsensusData.VoteType || commit.Round != blockConsensusData.Round ||
+			commit.ProposalHash.IsEqualTo(blockConsensusData.ProposalHash) == false ||
+			commit.PrecommitHash.IsEqualTo(blockConsensusData.PrecommitHash) == false {
+			return false, fmt.Errorf("%w: parent %v validator %v precommitHash %v %v", SimulationSafetyErr, parentHash,
+				v.address, commit.PrecommitHash, blockConsensusData.PrecommitHash)
+		}
+		if honest {
+			committed = true
+		}
+	}
+	return committed, nil
+}
+
+// step calls HandleConsensus of the validators that are not crashed and then moves the clock by a step.
+func (s *Simulation) step(parentHash common.Hash, txns []common.Hash, blockNumber uint64) {
+	for _, v := range s.validators {
+		if s.isCrashed(v) {
+			continue
+		}
+		v.handler.HandleConsensus(parentHash, txns, blockNumber)
+	}
+
+	s.clock.Run(s.config.Step)
+}
+
+// Run runs consensus for the given number of blocks. A block is done when an honest validator received its commits,
+// the validators then move on to the next block. It returns the committed blocks, and an error if a block was not
+// committed within MaxStepsPerBlock steps or if the safety check failed.
+func (s *Simulation) Run(blocks int) ([]*BlockConsensusData, error) {
+	results := make([]*BlockConsensusData, 0, blocks)
+	for i := 0; i < blocks; i++ {
+		blockNumber := s.config.StartBlock + uint64(len(s.parents))
+		parentHash := getSimulationParentHash(s.config.Seed, blockNumber)
+		txns := getSimulationTransactions(parentHash, s.config.Transactions)
+		s.lock.Lock()
+		s.parents = append(s.parents, parentHash)
+		s.numbers[parentHash] = blockNumber
+		s.lock.Unlock()
+
+		committed := false
+		for steps := 0; committed == false; steps++ {
+			if steps >= s.config.MaxStepsPerBlock {
+				return results, fmt.Errorf("%w: block %d parent %v after %v", SimulationLivenessErr, blockNumber, parentHash, s.Now())
+			}
+			s.step(parentHash, txns, blockNumber)
+
+			//Validators that are behind can still commit the earlier blocks
+			for _, parent := range s.parents {
+				ok, err := s.checkSafety(parent)
+				if err != nil {
+					return results, err
+				}
+				if parent.IsEqualTo(parentHash) {
+					committed = ok
+				}
+			}
+		}
+
+		s.lock.Lock()
+		results = append(results, s.commits[parentHash])
+		s.lock.Unlock()
+		s.t.Logf("block %d committed at %v voteType %v round %d", blockNumber, s.Now(), results[i].VoteType, results[i].Round)
+	}
+
+	return results, nil
+}
+
+func TestSimulation_basic(t *testing.T) {
+	sim := NewSimulation(t, SimulationConfig{
+		Validators:   4,
+		Latency:      20 * time.Millisecond,
+		Transactions: 2,
+	})
+	results, err := sim.Run(3)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	for _, result := range results {
+		if result.VoteType != VOTE_TYPE_OK || len(result.SelectedTransactions) != 2 {
+			t.Fatalf("failed %v %d", result.VoteType, len(result.SelectedTransactions))
+		}
+	}
+}
+
+func TestSimulation_networkFaults(t *testing.T) {
+	sim := NewSimulation(t, SimulationConfig{
+		Validators:    7,
+		Seed:          1,
+		Latency:       20 * time.Millisecond,
+		Jitter:        300 * time.Millisecond,
+		DropRate:      10,
+		DuplicateRate: 20,
+		Transactions:  2,
+	})
+	_, err := sim.Run(3)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	stats := sim.Stats()
+	if stats.Dropped == 0 || stats.Duplicated == 0 {
+		t.Fatalf("failed %v", stats)
+	}
+}
+
+func TestSimulation_crashedValidator(t *testing.T) {
+	sim := NewSimulation(t, SimulationConfig{
+		Validators: 7,
+		Latency:    20 * time.Millisecond,
+	})
+	sim.Crash(0)
+	_, err := sim.Run(2)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	sim.Recover(0)
+	sim.Crash(1)
+	_, err = sim.Run(2)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+}
+
+func TestSimulation_partition(t *testing.T) {
+	sim := NewSimulation(t, SimulationConfig{
+		Validators: 7,
+		Latency:    20 * time.Millisecond,
+	})
+	healTime := 5 * time.Second
+	sim.Partition([]int{0, 1, 2, 3}, []int{4, 5, 6})
+	sim.At(healTime, sim.Heal)
+
+	_, err := sim.Run(2)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if sim.Now() < healTime {
+		t.Fatalf("failed committed during the partition at %v", sim.Now())
+	}
+}
+
+func TestSimulation_equivocation(t *testing.T) {
+	sim := NewSimulation(t, SimulationConfig{
+		Validators:   7,
+		Latency:      20 * time.Millisecond,
+		Jitter:       100 * time.Millisecond,
+		Transactions: 2,
+	})
+	sim.Equivocate(0)
+	sim.Equivocate(1)
+	_, err := sim.Run(3)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+}
+
+func TestSimulation_liveness(t *testing.T) {
+	deposits := make([]*big.Int, 4)
+	for i := 0; i < len(deposits); i++ {
+		deposits[i] = params.EtherToWei(big.NewInt(500000000000))
+	}
+	deposits[0] = params.EtherToWei(big.NewInt(2000000000000))
+
+	//The crashed validator has more than a third of the deposits
+	sim := NewSimulation(t, SimulationConfig{
+		Validators:       4,
+		Deposits:         deposits,
+		Latency:          20 * time.Millisecond,
+		MaxStepsPerBlock: 100,
+	})
+	sim.Crash(0)
+	_, err := sim.Run(1)
+	if errors.Is(err, SimulationLivenessErr) == false {
+		t.Fatalf("failed %v", err)
+	}
+}
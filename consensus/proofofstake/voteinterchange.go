@@ -0,0 +1,264 @@
+package proofofstake
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/crypto"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/rlp"
+	"os"
+	"sort"
+)
+
+// VOTE_INTERCHANGE_FORMAT_VERSION is the version of the vote interchange file format written by ExportVoteInterchange.
+var VOTE_INTERCHANGE_FORMAT_VERSION = uint64(1)
+
+var InvalidVoteInterchangeErr = errors.New("invalid vote interchange")
+var OverlappingVoteHistoryErr = errors.New("vote interchange overlaps with the existing vote history of this validator")
+
+// VoteInterchange is the JSON interchange format used to move the signed-vote history of a validator key
+// between machines. Example:
+//
+//	{
+//	  "metadata": {
+//	    "interchange_format_version": 1,
+//	    "validator": "0x...",
+//	    "genesis_hash": "0x...",
+//	    "votes_hash": "0x..."
+//	  },
+//	  "votes": [
+//	    {
+//	      "parent_hash": "0x...",
+//	      "block_number": 1234,
+//	      "round": 1,
+//	      "packet_type": 1,
+//	      "vote_hash": "0x...",
+//	      "signed_time": 1700000000
+//	    }
+//	  ]
+//	}
+//
+// packet_type is the consensus packet type (0 propose, 1 ack, 2 precommit, 3 commit). genesis_hash is the zero hash
+// if the chain was not initialized when exporting. votes_hash is the Keccak256 of the rlp encoded list of votes,
+// in the order they appear in the file; it guards against truncated or edited files.
+type VoteInterchange struct {
+	Metadata VoteInterchangeMetadata `json:"metadata"`
+	Votes    []*VoteInterchangeVote  `json:"votes"`
+}
+
+type VoteInterchangeMetadata struct {
+	InterchangeFormatVersion uint64         `json:"interchange_format_version"`
+	Validator                common.Address `json:"validator"`
+	GenesisHash              common.Hash    `json:"genesis_hash"`
+	VotesHash                common.Hash    `json:"votes_hash"`
+}
+
+type VoteInterchangeVote struct {
+	ParentHash  common.Hash `json:"parent_hash"`
+	BlockNumber uint64      `json:"block_number"`
+	Round       byte        `json:"round"`
+	PacketType  byte        `json:"packet_type"`
+	VoteHash    common.Hash `json:"vote_hash"`
+	SignedTime  uint64      `json:"signed_time"`
+}
+
+func (v *VoteInterchangeVote) signedVote() *SignedVote {
+	return &SignedVote{
+		ParentHash:  v.ParentHash,
+		BlockNumber: v.BlockNumber,
+		Round:       v.Round,
+		PacketType:  v.PacketType,
+		VoteHash:    v.VoteHash,
+		SignedTime:  v.SignedTime,
+	}
+}
+
+func sortSignedVotes(votes []*SignedVote) {
+	sort.Slice(votes, func(i, j int) bool {
+		if votes[i].BlockNumber != votes[j].BlockNumber {
+			return votes[i].BlockNumber < votes[j].BlockNumber
+		}
+		if votes[i].ParentHash != votes[j].ParentHash {
+			return bytes.Compare(votes[i].ParentHash.Bytes(), votes[j].ParentHash.Bytes()) < 0
+		}
+		if votes[i].Round != votes[j].Round {
+			return votes[i].Round < votes[j].Round
+		}
+		return votes[i].PacketType < votes[j].PacketType
+	})
+}
+
+func getVotesHash(votes []*SignedVote) (common.Hash, error) {
+	data, err := rlp.EncodeToBytes(votes)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(data), nil
+}
+
+// Verify checks the metadata and integrity of an interchange, and that its votes do not conflict with each other.
+func (v *VoteInterchange) Verify(validator common.Address, genesisHash common.Hash) error {
+	if v.Metadata.InterchangeFormatVersion != VOTE_INTERCHANGE_FORMAT_VERSION {
+		return fmt.Errorf("%w: unsupported interchange_format_version %d", InvalidVoteInterchangeErr, v.Metadata.InterchangeFormatVersion)
+	}
+	if v.Metadata.Validator != validator {
+		return fmt.Errorf("%w: validator %s does not match %s", InvalidVoteInterchangeErr, v.Metadata.Validator.Hex(), validator.Hex())
+	}
+	if v.Metadata.GenesisHash != (common.Hash{}) && genesisHash != (common.Hash{}) && v.Metadata.GenesisHash != genesisHash {
+		return fmt.Errorf("%w: genesis_hash %s does not match %s", InvalidVoteInterchangeErr, v.Metadata.GenesisHash.Hex(), genesisHash.Hex())
+	}
+
+	votes := v.signedVotes()
+	votesHash, err := getVotesHash(votes)
+	if err != nil {
+		return err
+	}
+	if votesHash != v.Metadata.VotesHash {
+		return fmt.Errorf("%w: votes_hash %s does not match the votes in the file (%s)", InvalidVoteInterchangeErr, v.Metadata.VotesHash.Hex(), votesHash.Hex())
+	}
+
+	sortSignedVotes(votes)
+	history := newVoteHistory(validator)
+	for _, vote := range votes {
+		found, err := history.check(vote)
+		if err != nil {
+			return fmt.Errorf("%w: %v", InvalidVoteInterchangeErr, err)
+		}
+		if found == false {
+			history.add(vote)
+		}
+	}
+
+	return nil
+}
+
+func (v *VoteInterchange) signedVotes() []*SignedVote {
+	votes := make([]*SignedVote, len(v.Votes))
+	for i, vote := range v.Votes {
+		votes[i] = vote.signedVote()
+	}
+	return votes
+}
+
+// ExportVoteInterchange returns the signed-vote history of the journal in the interchange format.
+func (j *VoteJournal) ExportVoteInterchange(genesisHash common.Hash) (*VoteInterchange, error) {
+	votes := j.Votes()
+	sortSignedVotes(votes)
+
+	votesHash, err := getVotesHash(votes)
+	if err != nil {
+		return nil, err
+	}
+
+	interchange := &VoteInterchange{
+		Metadata: VoteInterchangeMetadata{
+			InterchangeFormatVersion: VOTE_INTERCHANGE_FORMAT_VERSION,
+			Validator:                j.validator,
+			GenesisHash:              genesisHash,
+			VotesHash:                votesHash,
+		},
+		Votes: make([]*VoteInterchangeVote, len(votes)),
+	}
+	for i, vote := range votes {
+		interchange.Votes[i] = &VoteInterchangeVote{
+			ParentHash:  vote.ParentHash,
+			BlockNumber: vote.BlockNumber,
+			Round:       vote.Round,
+			PacketType:  vote.PacketType,
+			VoteHash:    vote.VoteHash,
+			SignedTime:  vote.SignedTime,
+		}
+	}
+
+	return interchange, nil
+}
+
+// ImportVoteInterchange merges the votes of an interchange into the journal. The import is refused as a whole if the
+// interchange is invalid, conflicts with a vote already in the journal, or if the journal has votes within the block
+// range of the interchange that are not part of it (i.e. the key was active on both machines for the same blocks).
+// Importing the same interchange again is a no-op. Returns the number of votes added.
+func (j *VoteJournal) ImportVoteInterchange(interchange *VoteInterchange, genesisHash common.Hash) (int, error) {
+	if err := interchange.Verify(j.validator, genesisHash); err != nil {
+		return 0, err
+	}
+
+	votes := interchange.signedVotes()
+	if len(votes) == 0 {
+		return 0, nil
+	}
+	sortSignedVotes(votes)
+	minBlock := votes[0].BlockNumber
+	maxBlock := votes[len(votes)-1].BlockNumber
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		return 0, errors.New("vote journal is closed")
+	}
+
+	imported := newVoteHistory(j.validator)
+	for _, vote := range votes {
+		imported.add(vote)
+	}
+	for key, existing := range j.votes {
+		if existing.BlockNumber < minBlock || existing.BlockNumber > maxBlock {
+			continue
+		}
+		vote, ok := imported.votes[key]
+		if ok == false || vote.VoteHash.IsEqualTo(existing.VoteHash) == false {
+			return 0, fmt.Errorf("%w: blockNumber %d, parentHash %s, round %d, packetType %d",
+				OverlappingVoteHistoryErr, existing.BlockNumber, existing.ParentHash.Hex(), existing.Round, existing.PacketType)
+		}
+	}
+
+	added := make([]*SignedVote, 0, len(votes))
+	watermark := j.lowWatermark()
+	for _, vote := range votes {
+		if vote.BlockNumber < watermark {
+			continue
+		}
+		found, err := j.check(vote)
+		if err != nil {
+			return 0, err
+		}
+		if found == false {
+			added = append(added, vote)
+		}
+	}
+
+	for _, vote := range added {
+		j.add(vote)
+	}
+	if err := j.compact(); err != nil {
+		return 0, err
+	}
+
+	log.Info("Imported vote interchange", "validator", j.validator, "votes", len(added), "highestBlock", j.highestBlock)
+	return len(added), nil
+}
+
+// WriteVoteInterchangeFile writes an interchange as indented JSON.
+func WriteVoteInterchangeFile(path string, interchange *VoteInterchange) error {
+	data, err := json.MarshalIndent(interchange, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// ReadVoteInterchangeFile reads an interchange written by WriteVoteInterchangeFile.
+func ReadVoteInterchangeFile(path string) (*VoteInterchange, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	interchange := &VoteInterchange{}
+	if err := json.Unmarshal(data, interchange); err != nil {
+		return nil, fmt.Errorf("%w: %v", InvalidVoteInterchangeErr, err)
+	}
+	return interchange, nil
+}
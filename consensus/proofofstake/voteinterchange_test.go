@@ -0,0 +1,149 @@
+package proofofstake
+
+import (
+	"errors"
+	"github.com/QuantumCoinProject/qc/common"
+	"path/filepath"
+	"testing"
+)
+
+var testGenesisHash = common.BytesToHash([]byte{0x99})
+
+func TestVoteInterchange_roundtrip(t *testing.T) {
+	journal, err := OpenVoteJournal(t.TempDir(), testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer journal.Close()
+
+	parentHash := common.BytesToHash([]byte{1})
+	if err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_NIL)); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if err = journal.CheckAndRecord(parentHash, 10, createTestCommitData(parentHash, 1)); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	interchange, err := journal.ExportVoteInterchange(testGenesisHash)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "interchange.json")
+	if err = WriteVoteInterchangeFile(path, interchange); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	interchange, err = ReadVoteInterchangeFile(path)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	target, err := OpenVoteJournal(t.TempDir(), testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer target.Close()
+
+	count, err := target.ImportVoteInterchange(interchange, testGenesisHash)
+	if err != nil || count != 2 {
+		t.Fatalf("failed %v %d", err, count)
+	}
+
+	//Importing again is a no-op
+	count, err = target.ImportVoteInterchange(interchange, testGenesisHash)
+	if err != nil || count != 0 {
+		t.Fatalf("failed %v %d", err, count)
+	}
+
+	//The imported history protects the new machine
+	err = target.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_OK))
+	if errors.Is(err, ConflictingVoteErr) == false {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	err = target.CheckAndRecord(parentHash, 10, createTestCommitData(parentHash, 2))
+	if errors.Is(err, ConflictingVoteErr) == false {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
+
+func TestVoteInterchange_invalid(t *testing.T) {
+	journal, err := OpenVoteJournal(t.TempDir(), testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer journal.Close()
+
+	parentHash := common.BytesToHash([]byte{1})
+	if err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_NIL)); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	interchange, err := journal.ExportVoteInterchange(testGenesisHash)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	if err = interchange.Verify(common.BytesToAddress([]byte{0x22}), testGenesisHash); errors.Is(err, InvalidVoteInterchangeErr) == false {
+		t.Fatalf("expected invalid validator, got %v", err)
+	}
+	if err = interchange.Verify(testJournalValidator, common.BytesToHash([]byte{0x98})); errors.Is(err, InvalidVoteInterchangeErr) == false {
+		t.Fatalf("expected invalid genesis, got %v", err)
+	}
+	if err = interchange.Verify(testJournalValidator, common.Hash{}); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	interchange.Votes[0].Round = 2
+	if err = interchange.Verify(testJournalValidator, testGenesisHash); errors.Is(err, InvalidVoteInterchangeErr) == false {
+		t.Fatalf("expected invalid votes hash, got %v", err)
+	}
+}
+
+func TestVoteInterchange_overlap(t *testing.T) {
+	parentHash := common.BytesToHash([]byte{1})
+
+	source, err := OpenVoteJournal(t.TempDir(), testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer source.Close()
+	if err = source.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_NIL)); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	otherParentHash := common.BytesToHash([]byte{2})
+	if err = source.CheckAndRecord(otherParentHash, 12, createTestAckData(otherParentHash, 1, VOTE_TYPE_NIL)); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	interchange, err := source.ExportVoteInterchange(testGenesisHash)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	//Conflicting vote on the target
+	target, err := OpenVoteJournal(t.TempDir(), testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer target.Close()
+	if err = target.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_OK)); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if _, err = target.ImportVoteInterchange(interchange, testGenesisHash); errors.Is(err, OverlappingVoteHistoryErr) == false {
+		t.Fatalf("expected overlap, got %v", err)
+	}
+
+	//Non-conflicting vote within the block range of the interchange
+	target2, err := OpenVoteJournal(t.TempDir(), testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer target2.Close()
+	thirdParentHash := common.BytesToHash([]byte{3})
+	if err = target2.CheckAndRecord(thirdParentHash, 11, createTestAckData(thirdParentHash, 1, VOTE_TYPE_NIL)); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if _, err = target2.ImportVoteInterchange(interchange, testGenesisHash); errors.Is(err, OverlappingVoteHistoryErr) == false {
+		t.Fatalf("expected overlap, got %v", err)
+	}
+	if len(target2.Votes()) != 1 {
+		t.Fatalf("failed")
+	}
+}
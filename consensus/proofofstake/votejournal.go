@@ -0,0 +1,382 @@
+package proofofstake
+
+import (
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/crypto"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/rlp"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// VOTE_JOURNAL_DIR is the directory (relative to the node instance directory) holding one vote journal per validator key.
+var VOTE_JOURNAL_DIR = "votejournal"
+
+// VOTE_JOURNAL_RETAIN_BLOCKS is the number of blocks below the highest signed block for which votes are retained.
+// Signing for a block older than this window is refused, since the history needed to detect a conflict is gone.
+var VOTE_JOURNAL_RETAIN_BLOCKS = uint64(1024)
+
+// VOTE_JOURNAL_COMPACT_THRESHOLD is the number of appended records after which the journal file is rewritten without pruned votes.
+var VOTE_JOURNAL_COMPACT_THRESHOLD = 4096
+
+var ConflictingVoteErr = errors.New("refusing to sign conflicting consensus packet")
+var StaleVoteErr = errors.New("refusing to sign consensus packet below the vote journal low watermark")
+
+// SignedVote is a single consensus packet signed by a validator key, as recorded in the vote journal.
+type SignedVote struct {
+	ParentHash  common.Hash
+	BlockNumber uint64
+	Round       byte
+	PacketType  byte
+	VoteHash    common.Hash //Hash of ParentHash + PacketType + packet details, independent of the network protocol version
+	SignedTime  uint64
+}
+
+type voteKey struct {
+	parentHash common.Hash
+	round      byte
+	packetType ConsensusPacketType
+}
+
+// VoteJournal is an append-only, fsync'd journal of every consensus packet signed by a validator key.
+// It is consulted before signing, so that a restarted node (or a second node running the same key)
+// never signs a proposal, ack, precommit or commit that conflicts with one signed earlier.
+type VoteJournal struct {
+	path      string
+	validator common.Address
+	file      *os.File
+	lock      sync.Mutex
+
+	votes           map[voteKey]*SignedVote
+	commits         map[common.Hash]*SignedVote //at most one commit per parentHash, across rounds
+	highestBlock    uint64
+	appendedRecords int
+}
+
+// GetVoteJournalPath returns the journal file path of a validator key inside the given directory.
+func GetVoteJournalPath(dir string, validator common.Address) string {
+	return filepath.Join(dir, validator.Hex()+".rlp")
+}
+
+// OpenVoteJournal opens (or creates) the vote journal of a validator, loading and pruning any existing history.
+func OpenVoteJournal(dir string, validator common.Address) (*VoteJournal, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	journal := newVoteHistory(validator)
+	journal.path = GetVoteJournalPath(dir, validator)
+
+	votes, err := readVoteJournalFile(journal.path)
+	if err != nil {
+		return nil, err
+	}
+	for _, vote := range votes {
+		journal.add(vote)
+	}
+
+	if err := journal.compact(); err != nil {
+		return nil, err
+	}
+
+	log.Info("Opened vote journal", "path", journal.path, "votes", len(journal.votes), "highestBlock", journal.highestBlock)
+	return journal, nil
+}
+
+// readVoteJournalFile reads all complete records of a journal file. A torn record at the end of the file,
+// left by a crash in the middle of a write, is discarded; it was never followed by a signature.
+func readVoteJournalFile(path string) ([]*SignedVote, error) {
+	votes := make([]*SignedVote, 0)
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return votes, nil
+		}
+		return nil, err
+	}
+
+	rest := b
+	for len(rest) > 0 {
+		_, _, next, err := rlp.Split(rest)
+		if err != nil {
+			log.Warn("Discarding incomplete vote journal record", "path", path, "offset", len(b)-len(rest), "err", err)
+			break
+		}
+		vote := &SignedVote{}
+		if err := rlp.DecodeBytes(rest[:len(rest)-len(next)], vote); err != nil {
+			log.Warn("Discarding invalid vote journal record", "path", path, "offset", len(b)-len(rest), "err", err)
+			break
+		}
+		votes = append(votes, vote)
+		rest = next
+	}
+
+	return votes, nil
+}
+
+// newVoteHistory returns an in-memory journal that is not backed by a file.
+func newVoteHistory(validator common.Address) *VoteJournal {
+	return &VoteJournal{
+		validator: validator,
+		votes:     make(map[voteKey]*SignedVote),
+		commits:   make(map[common.Hash]*SignedVote),
+	}
+}
+
+func (j *VoteJournal) add(vote *SignedVote) {
+	j.votes[voteKey{parentHash: vote.ParentHash, round: vote.Round, packetType: ConsensusPacketType(vote.PacketType)}] = vote
+	if ConsensusPacketType(vote.PacketType) == CONSENSUS_PACKET_TYPE_COMMIT_BLOCK {
+		j.commits[vote.ParentHash] = vote
+	}
+	if vote.BlockNumber > j.highestBlock {
+		j.highestBlock = vote.BlockNumber
+	}
+}
+
+func (j *VoteJournal) lowWatermark() uint64 {
+	if j.highestBlock <= VOTE_JOURNAL_RETAIN_BLOCKS {
+		return 0
+	}
+	return j.highestBlock - VOTE_JOURNAL_RETAIN_BLOCKS
+}
+
+// compact drops votes below the low watermark and atomically rewrites the journal file.
+func (j *VoteJournal) compact() error {
+	watermark := j.lowWatermark()
+	for key, vote := range j.votes {
+		if vote.BlockNumber < watermark {
+			delete(j.votes, key)
+		}
+	}
+	for parentHash, vote := range j.commits {
+		if vote.BlockNumber < watermark {
+			delete(j.commits, parentHash)
+		}
+	}
+
+	if j.file != nil {
+		j.file.Close()
+		j.file = nil
+	}
+
+	tmpPath := j.path + ".new"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	for _, vote := range j.votes {
+		if err = rlp.Encode(tmp, vote); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	if err = os.Rename(tmpPath, j.path); err != nil {
+		return err
+	}
+
+	j.file, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	j.appendedRecords = 0
+
+	return nil
+}
+
+// append writes a vote to disk and fsyncs it before returning.
+func (j *VoteJournal) append(vote *SignedVote) error {
+	if j.file == nil {
+		return errors.New("vote journal is closed")
+	}
+	if err := rlp.Encode(j.file, vote); err != nil {
+		return err
+	}
+	if err := j.file.Sync(); err != nil {
+		return err
+	}
+	j.add(vote)
+	j.appendedRecords = j.appendedRecords + 1
+
+	if j.appendedRecords >= VOTE_JOURNAL_COMPACT_THRESHOLD {
+		return j.compact()
+	}
+	return nil
+}
+
+// check returns an error if signing the vote would conflict with the journal. found is true if an identical vote was already signed.
+func (j *VoteJournal) check(vote *SignedVote) (found bool, err error) {
+	if vote.BlockNumber < j.lowWatermark() {
+		return false, fmt.Errorf("%w: blockNumber %d, watermark %d", StaleVoteErr, vote.BlockNumber, j.lowWatermark())
+	}
+
+	existing, ok := j.votes[voteKey{parentHash: vote.ParentHash, round: vote.Round, packetType: ConsensusPacketType(vote.PacketType)}]
+	if ok {
+		if existing.VoteHash.IsEqualTo(vote.VoteHash) {
+			return true, nil
+		}
+		return false, fmt.Errorf("%w: parentHash %s, round %d, packetType %d", ConflictingVoteErr, vote.ParentHash.Hex(), vote.Round, vote.PacketType)
+	}
+
+	if ConsensusPacketType(vote.PacketType) == CONSENSUS_PACKET_TYPE_COMMIT_BLOCK {
+		commit, ok := j.commits[vote.ParentHash]
+		if ok && commit.Round != vote.Round {
+			return false, fmt.Errorf("%w: already committed parentHash %s in round %d", ConflictingVoteErr, vote.ParentHash.Hex(), commit.Round)
+		}
+	}
+
+	return false, nil
+}
+
+// CheckAndRecord must be called before signing a consensus packet. It returns an error if the packet conflicts with
+// one signed earlier by this key; otherwise the vote is durably recorded before the caller is allowed to sign it.
+func (j *VoteJournal) CheckAndRecord(parentHash common.Hash, blockNumber uint64, data []byte) error {
+	vote, err := newSignedVote(parentHash, blockNumber, data)
+	if err != nil {
+		return err
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	found, err := j.check(vote)
+	if err != nil {
+		return err
+	}
+	if found {
+		return nil
+	}
+
+	return j.append(vote)
+}
+
+// RecordObserved records a packet signed by this journal's key that was received from the network.
+// Seeing a packet we did not sign ourselves means the key is active elsewhere; recording it stops us
+// from signing a conflicting packet afterwards.
+func (j *VoteJournal) RecordObserved(parentHash common.Hash, blockNumber uint64, data []byte) error {
+	vote, err := newSignedVote(parentHash, blockNumber, data)
+	if err != nil {
+		return err
+	}
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	found, err := j.check(vote)
+	if found {
+		return nil
+	}
+	if errors.Is(err, StaleVoteErr) {
+		log.Debug("Stale consensus packet signed by this validator key was seen on the network",
+			"validator", j.validator, "parentHash", parentHash, "blockNumber", blockNumber, "err", err)
+		return err
+	}
+	if err != nil {
+		log.Error("Conflicting consensus packet signed by this validator key was seen on the network. Is the key running on another machine?",
+			"validator", j.validator, "parentHash", parentHash, "round", vote.Round, "packetType", vote.PacketType, "err", err)
+		return err
+	}
+
+	log.Error("Consensus packet signed by this validator key was seen on the network, but was not signed by this node. Is the key running on another machine?",
+		"validator", j.validator, "parentHash", parentHash, "round", vote.Round, "packetType", vote.PacketType)
+	return j.append(vote)
+}
+
+// Votes returns a copy of all votes currently in the journal.
+func (j *VoteJournal) Votes() []*SignedVote {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	votes := make([]*SignedVote, 0, len(j.votes))
+	for _, vote := range j.votes {
+		v := *vote
+		votes = append(votes, &v)
+	}
+	return votes
+}
+
+func (j *VoteJournal) Validator() common.Address {
+	return j.validator
+}
+
+func (j *VoteJournal) Close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+func newSignedVote(parentHash common.Hash, blockNumber uint64, data []byte) (*SignedVote, error) {
+	packetType, round, payload, err := parseConsensusData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedVote{
+		ParentHash:  parentHash,
+		BlockNumber: blockNumber,
+		Round:       round,
+		PacketType:  byte(packetType),
+		VoteHash:    crypto.Keccak256Hash(parentHash.Bytes(), []byte{byte(packetType)}, payload),
+		SignedTime:  uint64(time.Now().UTC().Unix()),
+	}, nil
+}
+
+// parseConsensusData returns the packet type, round and rlp payload of unsigned consensus packet data.
+func parseConsensusData(data []byte) (ConsensusPacketType, byte, []byte, error) {
+	if len(data) < 2 {
+		return 0, 0, nil, InvalidPacketErr
+	}
+
+	var startIndex int
+	if data[0] >= MinConsensusNetworkProtocolVersion {
+		startIndex = 2
+	} else {
+		startIndex = 1
+	}
+
+	packetType := ConsensusPacketType(data[startIndex-1])
+	payload := data[startIndex:]
+
+	var round byte
+	var err error
+	switch packetType {
+	case CONSENSUS_PACKET_TYPE_PROPOSE_BLOCK:
+		details := ProposalDetails{}
+		err = rlp.DecodeBytes(payload, &details)
+		round = details.Round
+	case CONSENSUS_PACKET_TYPE_ACK_BLOCK_PROPOSAL:
+		details := ProposalAckDetails{}
+		err = rlp.DecodeBytes(payload, &details)
+		round = details.Round
+	case CONSENSUS_PACKET_TYPE_PRECOMMIT_BLOCK:
+		details := PreCommitDetails{}
+		err = rlp.DecodeBytes(payload, &details)
+		round = details.Round
+	case CONSENSUS_PACKET_TYPE_COMMIT_BLOCK:
+		details := CommitDetails{}
+		err = rlp.DecodeBytes(payload, &details)
+		round = details.Round
+	default:
+		return 0, 0, nil, InvalidPacketErr
+	}
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	return packetType, round, payload, nil
+}
@@ -0,0 +1,226 @@
+package proofofstake
+
+import (
+	"errors"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/rlp"
+	"os"
+	"testing"
+)
+
+var testJournalValidator = common.BytesToAddress([]byte{0x11})
+
+func createTestConsensusData(packetType ConsensusPacketType, details interface{}) []byte {
+	data, err := rlp.EncodeToBytes(details)
+	if err != nil {
+		panic(err)
+	}
+	return append([]byte{ConsensusNetworkProtocolVersion}, append([]byte{byte(packetType)}, data...)...)
+}
+
+func createTestAckData(parentHash common.Hash, round byte, voteType VoteType) []byte {
+	details := &ProposalAckDetails{
+		ProposalAckVoteType: voteType,
+		Round:               round,
+	}
+	if voteType == VOTE_TYPE_NIL {
+		details.ProposalHash.CopyFrom(getNilVoteProposalHash(parentHash, round))
+	} else {
+		details.ProposalHash.CopyFrom(common.BytesToHash([]byte{round, 1}))
+	}
+	return createTestConsensusData(CONSENSUS_PACKET_TYPE_ACK_BLOCK_PROPOSAL, details)
+}
+
+func createTestCommitData(parentHash common.Hash, round byte) []byte {
+	details := &CommitDetails{
+		Round: round,
+	}
+	details.CommitHash.CopyFrom(getCommitHash(getNilVotePreCommitHash(parentHash, round)))
+	return createTestConsensusData(CONSENSUS_PACKET_TYPE_COMMIT_BLOCK, details)
+}
+
+func TestVoteJournal_conflict(t *testing.T) {
+	dir := t.TempDir()
+	parentHash := common.BytesToHash([]byte{1})
+
+	journal, err := OpenVoteJournal(dir, testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_OK))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	//Signing the same packet again is allowed
+	err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_OK))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_NIL))
+	if errors.Is(err, ConflictingVoteErr) == false {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	//A different round is not a conflict
+	err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 2, VOTE_TYPE_NIL))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	if len(journal.Votes()) != 2 {
+		t.Fatalf("failed")
+	}
+	journal.Close()
+
+	//Conflicts are still detected after a restart
+	journal, err = OpenVoteJournal(dir, testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer journal.Close()
+
+	if len(journal.Votes()) != 2 {
+		t.Fatalf("failed")
+	}
+
+	err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_NIL))
+	if errors.Is(err, ConflictingVoteErr) == false {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_OK))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+}
+
+func TestVoteJournal_single_commit_per_parent(t *testing.T) {
+	journal, err := OpenVoteJournal(t.TempDir(), testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer journal.Close()
+
+	parentHash := common.BytesToHash([]byte{1})
+	err = journal.CheckAndRecord(parentHash, 10, createTestCommitData(parentHash, 1))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	err = journal.CheckAndRecord(parentHash, 10, createTestCommitData(parentHash, 2))
+	if errors.Is(err, ConflictingVoteErr) == false {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	otherParentHash := common.BytesToHash([]byte{2})
+	err = journal.CheckAndRecord(otherParentHash, 11, createTestCommitData(otherParentHash, 2))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+}
+
+func TestVoteJournal_observed(t *testing.T) {
+	journal, err := OpenVoteJournal(t.TempDir(), testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer journal.Close()
+
+	parentHash := common.BytesToHash([]byte{1})
+	err = journal.RecordObserved(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_NIL))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_OK))
+	if errors.Is(err, ConflictingVoteErr) == false {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
+
+func TestVoteJournal_torn_record(t *testing.T) {
+	dir := t.TempDir()
+	parentHash := common.BytesToHash([]byte{1})
+
+	journal, err := OpenVoteJournal(dir, testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_OK))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	journal.Close()
+
+	//Simulate a crash in the middle of writing the next record
+	vote, err := newSignedVote(parentHash, 10, createTestCommitData(parentHash, 1))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	data, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	f, err := os.OpenFile(GetVoteJournalPath(dir, testJournalValidator), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	f.Write(data[:len(data)/2])
+	f.Close()
+
+	journal, err = OpenVoteJournal(dir, testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer journal.Close()
+
+	if len(journal.Votes()) != 1 {
+		t.Fatalf("failed")
+	}
+
+	err = journal.CheckAndRecord(parentHash, 10, createTestAckData(parentHash, 1, VOTE_TYPE_NIL))
+	if errors.Is(err, ConflictingVoteErr) == false {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+}
+
+func TestVoteJournal_watermark(t *testing.T) {
+	dir := t.TempDir()
+
+	journal, err := OpenVoteJournal(dir, testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	oldParentHash := common.BytesToHash([]byte{1})
+	err = journal.CheckAndRecord(oldParentHash, 10, createTestAckData(oldParentHash, 1, VOTE_TYPE_OK))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	parentHash := common.BytesToHash([]byte{2})
+	err = journal.CheckAndRecord(parentHash, 10+VOTE_JOURNAL_RETAIN_BLOCKS+1, createTestAckData(parentHash, 1, VOTE_TYPE_OK))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	err = journal.CheckAndRecord(oldParentHash, 10, createTestAckData(oldParentHash, 2, VOTE_TYPE_NIL))
+	if errors.Is(err, StaleVoteErr) == false {
+		t.Fatalf("expected stale vote, got %v", err)
+	}
+	journal.Close()
+
+	//The old vote is pruned on reopen
+	journal, err = OpenVoteJournal(dir, testJournalValidator)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer journal.Close()
+
+	if len(journal.Votes()) != 1 {
+		t.Fatalf("failed")
+	}
+}
@@ -97,8 +97,12 @@ func newTester(t *testing.T, confOverride func(*ethconfig.Config)) *tester {
 	if err != nil {
 		t.Fatalf("failed to create node: %v", err)
 	}
+	genesis, err := core.DeveloperGenesisBlock(15, common.Address{})
+	if err != nil {
+		t.Fatalf("failed to create developer genesis: %v", err)
+	}
 	ethConf := &ethconfig.Config{
-		Genesis: core.DeveloperGenesisBlock(15, common.Address{}),
+		Genesis: genesis,
 		Miner: miner.Config{
 			Etherbase: common.HexToAddress(testAddress),
 		},
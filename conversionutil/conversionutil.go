@@ -1007,18 +1007,18 @@ var FirstPart = []byte{25, 71, 244, 125, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
 var SecondPart = []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 132}
 
 func IsGasExemptTxn(tx *types.Transaction, signer types.Signer) (bool, error) {
-	msg, err := tx.AsMessage(signer)
+	from, err := types.Sender(signer, tx)
 
 	if err != nil {
 		log.Trace("IsGasExemptTxn")
 		return false, err
 	}
 
-	if msg.To().IsEqualTo(conversion.CONVERSION_CONTRACT_ADDRESS) == false {
+	if tx.To().IsEqualTo(conversion.CONVERSION_CONTRACT_ADDRESS) == false {
 		return false, nil
 	}
 
-	ethAddress, err := VerifyDataAndGetEthereumAddress(msg.From(), tx.Data())
+	ethAddress, err := VerifyDataAndGetEthereumAddress(from, tx.Data())
 	if err != nil {
 		log.Trace("IsGasExemptTxn VerifyDataAndGetEthereumAddress failed", "err", err)
 		return false, err
@@ -32,10 +32,16 @@ import (
 	"github.com/QuantumCoinProject/qc/core/rawdb"
 	"github.com/QuantumCoinProject/qc/core/state"
 	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/core/vm"
+	"github.com/QuantumCoinProject/qc/crypto"
 	"github.com/QuantumCoinProject/qc/ethdb"
 	"github.com/QuantumCoinProject/qc/log"
 	"github.com/QuantumCoinProject/qc/params"
 	"github.com/QuantumCoinProject/qc/rlp"
+	"github.com/QuantumCoinProject/qc/systemcontracts/consensuscontext"
+	"github.com/QuantumCoinProject/qc/systemcontracts/conversion"
+	"github.com/QuantumCoinProject/qc/systemcontracts/staking"
+	"github.com/QuantumCoinProject/qc/systemcontracts/staking/stakingv2"
 	"github.com/QuantumCoinProject/qc/trie"
 )
 
@@ -402,17 +408,27 @@ func DefaultCalaverasGenesisBlock() *Genesis {
 	}
 }
 
-// DeveloperGenesisBlock returns the 'geth --dev' genesis block.
-func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
+// DeveloperGenesisBlock returns the 'geth --dev' genesis block. The system contracts are deployed, and the faucet
+// is the depositor of DeveloperValidator, the single validator of the chain. An error is returned if the deposit of
+// the validator cannot be made.
+func DeveloperGenesisBlock(period uint64, faucet common.Address) (*Genesis, error) {
 	// Override the default period to the user requested one
 	config := *params.AllProofOfStakeProtocolChanges
+	// The system contracts are deployed in the genesis, so the consensus rules of the network apply from the first block
 	config.ProofOfStake = &params.ProofOfStakeConfig{
-		Period: period,
-		Epoch:  config.ProofOfStake.Epoch,
+		Period:                period,
+		Epoch:                 config.ProofOfStake.Epoch,
+		Developer:             true,
+		RewardStartBlock:      big.NewInt(1),
+		StakingV2Block:        big.NewInt(0),
+		ConsensusContextBlock: big.NewInt(0),
+		ValidatorNilBlock:     big.NewInt(1),
+		BlockTimeOrigBlock:    big.NewInt(1),
+		TxnFeeBlock:           big.NewInt(1),
 	}
 
 	// Assemble and return the genesis with the precompiles and faucet pre-funded
-	return &Genesis{
+	genesis := &Genesis{
 		Config:     &config,
 		ExtraData:  append(append(make([]byte, 32), faucet[:]...), make([]byte, cryptobase.SigAlg.SignatureWithPublicKeyLength())...),
 		GasLimit:   11500000,
@@ -430,6 +446,71 @@ func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 			faucet:                           {Balance: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(9))},
 		},
 	}
+
+	// Deploy the system contracts
+	genesis.Alloc[conversion.CONVERSION_CONTRACT_ADDRESS] = GenesisAccount{Code: common.FromHex(conversion.CONVERSION_RUNTIME_BIN), Balance: new(big.Int)}
+	genesis.Alloc[consensuscontext.CONSENSUS_CONTEXT_CONTRACT_ADDRESS] = GenesisAccount{Code: common.FromHex(consensuscontext.CONSENSUS_CONTEXT_RUNTIME_BIN), Balance: new(big.Int)}
+	if err := developerDeposit(genesis, faucet, DeveloperValidator(faucet)); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// DeveloperDeposit is the deposit of the validator of a developer chain, the minimum deposit of the staking contract.
+var DeveloperDeposit = params.EtherToWei(big.NewInt(5000000))
+
+// DeveloperValidator returns the validator of a developer chain, which has no key since developer blocks are not
+// signed.
+func DeveloperValidator(faucet common.Address) common.Address {
+	return common.BytesToAddress(crypto.Keccak256([]byte("developer-validator"), faucet.Bytes()))
+}
+
+// developerDeposit deploys the staking contract in the genesis, with the deposit of the validator made by the
+// depositor. The deposit is made by running the contract, so that its storage is the same as after a newDeposit
+// transaction.
+func developerDeposit(genesis *Genesis, depositor common.Address, validator common.Address) error {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		return err
+	}
+	contractAddress := staking.STAKING_CONTRACT_ADDRESS
+	statedb.SetCode(contractAddress, common.FromHex(stakingv2.STAKING_RUNTIME_BIN))
+	statedb.AddBalance(depositor, genesis.Alloc[depositor].Balance)
+
+	abiData, err := staking.GetStakingContractV2_ABI()
+	if err != nil {
+		return err
+	}
+	data, err := abiData.Pack(staking.GetContract_Method_NewDeposit(), validator)
+	if err != nil {
+		return err
+	}
+
+	header := &types.Header{Number: new(big.Int), Difficulty: genesis.Difficulty, GasLimit: genesis.GasLimit}
+	msg := types.NewMessage(depositor, &contractAddress, 0, DeveloperDeposit, genesis.GasLimit, new(big.Int), data, nil, false)
+	evm := vm.NewEVM(NewEVMBlockContext(header, nil, &common.Address{}), NewEVMTxContext(msg), statedb, genesis.Config, vm.Config{})
+	result, err := ApplyMessage(evm, msg, new(GasPool).AddGas(genesis.GasLimit))
+	if err != nil {
+		return err
+	}
+	if result.Failed() {
+		return fmt.Errorf("developer deposit failed: %v", result.Err)
+	}
+	if _, err = statedb.Commit(true); err != nil {
+		return err
+	}
+
+	storage := make(map[common.Hash]common.Hash)
+	err = statedb.ForEachStorage(contractAddress, func(key, value common.Hash) bool {
+		storage[key] = value
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	genesis.Alloc[contractAddress] = GenesisAccount{Code: statedb.GetCode(contractAddress), Storage: storage, Balance: statedb.GetBalance(contractAddress)}
+	genesis.Alloc[depositor] = GenesisAccount{Balance: statedb.GetBalance(depositor)}
+	return nil
 }
 
 func decodePrealloc(data string) GenesisAlloc {
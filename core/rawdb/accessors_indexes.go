@@ -155,6 +155,20 @@ func WriteBloomBits(db ethdb.KeyValueWriter, bit uint, section uint64, head comm
 	}
 }
 
+// ReadValidatorPerformance retrieves the encoded validator performance of the
+// given section.
+func ReadValidatorPerformance(db ethdb.KeyValueReader, section uint64, head common.Hash) ([]byte, error) {
+	return db.Get(validatorPerformanceKey(section, head))
+}
+
+// WriteValidatorPerformance stores the encoded validator performance of the
+// given section.
+func WriteValidatorPerformance(db ethdb.KeyValueWriter, section uint64, head common.Hash, data []byte) {
+	if err := db.Put(validatorPerformanceKey(section, head), data); err != nil {
+		log.Crit("Failed to store validator performance", "err", err)
+	}
+}
+
 // DeleteBloombits removes all compressed bloom bits vector belonging to the
 // given section range and bit index.
 func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
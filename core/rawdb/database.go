@@ -303,6 +303,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		preimages         stat
 		bloomBits         stat
 		proofofstakeSnaps stat
+		validatorPerf     stat
 
 		// Ancient store statistics
 		ancientHeadersSize  common.StorageSize
@@ -360,6 +361,10 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			bloomBits.Add(size)
 		case bytes.HasPrefix(key, BloomBitsIndexPrefix):
 			bloomBits.Add(size)
+		case bytes.HasPrefix(key, validatorPerformancePrefix) && len(key) == (len(validatorPerformancePrefix)+8+common.HashLength):
+			validatorPerf.Add(size)
+		case bytes.HasPrefix(key, ValidatorPerformanceIndexPrefix):
+			validatorPerf.Add(size)
 		case bytes.HasPrefix(key, []byte("proofofstake-")) && len(key) == 7+common.HashLength:
 			proofofstakeSnaps.Add(size)
 		case bytes.HasPrefix(key, []byte("cht-")) ||
@@ -423,6 +428,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
 		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
 		{"Key-Value store", "ProofOfStake snapshots", proofofstakeSnaps.Size(), proofofstakeSnaps.Count()},
+		{"Key-Value store", "Validator performance index", validatorPerf.Size(), validatorPerf.Count()},
 		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
 		{"Ancient store", "Headers", ancientHeadersSize.String(), ancients.String()},
 		{"Ancient store", "Bodies", ancientBodiesSize.String(), ancients.String()},
@@ -90,12 +90,16 @@ var (
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
 
+	validatorPerformancePrefix = []byte("V") // validatorPerformancePrefix + section (uint64 big endian) + hash -> validator performance of the section
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
 
+	ValidatorPerformanceIndexPrefix = []byte("iV") // ValidatorPerformanceIndexPrefix is the data table of the validator performance indexer to track its progress
+
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
 )
@@ -207,6 +211,16 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// validatorPerformanceKey = validatorPerformancePrefix + section (uint64 big endian) + hash
+func validatorPerformanceKey(section uint64, hash common.Hash) []byte {
+	key := make([]byte, len(validatorPerformancePrefix)+8+common.HashLength)
+	copy(key, validatorPerformancePrefix)
+	binary.BigEndian.PutUint64(key[len(validatorPerformancePrefix):], section)
+	copy(key[len(validatorPerformancePrefix)+8:], hash.Bytes())
+
+	return key
+}
+
 // preimageKey = preimagePrefix + hash
 func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
@@ -28,6 +28,15 @@ import (
 
 const TXN_FEE_CUTTOFF_BLOCK = 1607600
 
+// TxnFeeCutoffBlock returns the block from which the transaction fees are split between the block proposer and
+// burning instead of being paid to the coinbase. It is TXN_FEE_CUTTOFF_BLOCK unless the chain config sets it.
+func TxnFeeCutoffBlock(config *params.ChainConfig) uint64 {
+	if config.ProofOfStake != nil && config.ProofOfStake.TxnFeeBlock != nil {
+		return config.ProofOfStake.TxnFeeBlock.Uint64()
+	}
+	return TXN_FEE_CUTTOFF_BLOCK
+}
+
 /*
 The State Transitioning Model
 
@@ -286,7 +295,7 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 		st.refundGas(params.RefundQuotientEIP3529)
 	}
 
-	if st.evm.Context.BlockNumber.Uint64() < TXN_FEE_CUTTOFF_BLOCK {
+	if st.evm.Context.BlockNumber.Uint64() < TxnFeeCutoffBlock(st.evm.ChainConfig()) {
 		st.state.AddBalance(st.evm.Context.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
 	}
 
@@ -408,13 +408,20 @@ func (l *txList) LastElement() *types.Transaction {
 // then the heap is sorted based on the effective tip based on the given base fee.
 // If baseFee is nil then the sorting is based on gasFeeCap.
 type priceHeap struct {
-	list []*types.Transaction
+	gasTier bool // Whether the gas tier fork is active and lower tiers are discarded first
+	list    []*types.Transaction
 }
 
 func (h *priceHeap) Len() int      { return len(h.list) }
 func (h *priceHeap) Swap(i, j int) { h.list[i], h.list[j] = h.list[j], h.list[i] }
 
 func (h *priceHeap) Less(i, j int) bool {
+	//Lower gas tiers are discarded first, once every tier is charged its own price
+	if h.gasTier {
+		if tierI, tierJ := h.list[i].GasTier(), h.list[j].GasTier(); tierI != tierJ {
+			return tierI < tierJ
+		}
+	}
 	return h.list[i].Nonce() > h.list[j].Nonce()
 }
 
@@ -484,6 +491,40 @@ func (l *txPricedList) Removed(count int) {
 	l.Reheap()
 }
 
+// Underpriced checks whether a transaction has a lower gas tier than the lowest
+// priced remote transaction the pool is tracking.
+func (l *txPricedList) Underpriced(tx *types.Transaction) bool {
+	// Note: with two queues, being underpriced is defined as being worse than the worst item
+	// in all non-empty queues if there is any. If both queues are empty then nothing is underpriced.
+	return (l.underpricedFor(&l.urgent, tx) || len(l.urgent.list) == 0) &&
+		(l.underpricedFor(&l.floating, tx) || len(l.floating.list) == 0) &&
+		(len(l.urgent.list) != 0 || len(l.floating.list) != 0)
+}
+
+// underpricedFor checks whether a transaction has a lower gas tier than the
+// lowest priced remote transaction in the given heap. Before the gas tier fork
+// every transaction is charged the same price, so nothing is underpriced.
+func (l *txPricedList) underpricedFor(h *priceHeap, tx *types.Transaction) bool {
+	if h.gasTier == false {
+		return false
+	}
+	// Discard stale price points if found at the heap start
+	for len(h.list) > 0 {
+		head := h.list[0]
+		if l.all.GetRemote(head.Hash()) == nil { // Removed or migrated
+			l.stales--
+			heap.Pop(h)
+			continue
+		}
+		break
+	}
+	// Check if the transaction is underpriced or not
+	if len(h.list) == 0 {
+		return false // There is no remote transaction at all.
+	}
+	return h.list[0].GasTier() > tx.GasTier()
+}
+
 // Discard finds a number of most underpriced transactions, removes them from the
 // priced list and returns them for further removal from the entire pool.
 //
@@ -526,6 +567,17 @@ func (l *txPricedList) Discard(slots int, force bool) (types.Transactions, bool)
 	return drop, true
 }
 
+// SetGasTier updates whether the heaps are sorted by gas tier and re-heaps if the
+// gas tier fork has been activated or rolled back.
+func (l *txPricedList) SetGasTier(gasTier bool) {
+	if l.urgent.gasTier == gasTier {
+		return
+	}
+	l.urgent.gasTier = gasTier
+	l.floating.gasTier = gasTier
+	l.Reheap()
+}
+
 // Reheap forcibly rebuilds the heap based on the current remote transaction set.
 func (l *txPricedList) Reheap() {
 	start := time.Now()
@@ -242,6 +242,7 @@ type TxPool struct {
 	istanbul bool // Fork indicator whether we are in the istanbul stage.
 	eip2718  bool // Fork indicator whether we are using EIP-2718 type transactions.
 	eip1559  bool // Fork indicator whether we are using EIP-1559 type transactions.
+	gasTier  bool // Fork indicator whether gas tiers are priced and validated.
 
 	currentState  *state.StateDB // Current state in the blockchain head
 	pendingNonces *txNoncer      // Pending state tracking virtual nonces
@@ -562,6 +563,9 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if tx.VerifyFields() == false {
 		return InvalidTx
 	}
+	if pool.gasTier && tx.HasValidGasTier() == false {
+		return InvalidTx
+	}
 
 	if time.Now().UTC().Unix() < txnStartAllowedTime {
 		if tx.To().IsEqualTo(conversion.CONVERSION_CONTRACT_ADDRESS) || tx.To().IsEqualTo(staking.STAKING_CONTRACT_ADDRESS) {
@@ -657,6 +661,12 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (replaced bool, err e
 
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
+		// If the new transaction has a lower gas tier than all remote ones, discard it
+		if !isLocal && pool.priced.Underpriced(tx) {
+			log.Trace("Discarding underpriced transaction", "hash", hash, "gasTier", tx.GasTier())
+			underpricedTxMeter.Mark(1)
+			return false, ErrUnderpriced
+		}
 		// New transaction is better than our worse ones, make room for it.
 		// If it's a local transaction, forcibly discard all available transactions.
 		// Otherwise if we can't make enough room for new one, abort the operation.
@@ -1260,6 +1270,8 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.istanbul = pool.chainconfig.IsIstanbul(next)
 	pool.eip2718 = pool.chainconfig.IsBerlin(next)
 	pool.eip1559 = pool.chainconfig.IsLondon(next)
+	pool.gasTier = pool.chainconfig.IsGasTier(next)
+	pool.priced.SetGasTier(pool.gasTier)
 }
 
 // promoteExecutables moves transactions that have become processable from the
@@ -12,8 +12,14 @@ type GasTier uint64
 
 const (
 	GAS_TIER_DEFAULT GasTier = 1
+	GAS_TIER_2X      GasTier = 2
+	GAS_TIER_5X      GasTier = 5
+	GAS_TIER_10X     GasTier = 10
 )
 
+// GAS_TIERS lists the valid gas tiers, in increasing order of price.
+var GAS_TIERS = []GasTier{GAS_TIER_DEFAULT, GAS_TIER_2X, GAS_TIER_5X, GAS_TIER_10X}
+
 type AccessList []AccessTuple
 
 // AccessTuple is the element type of an access list.
@@ -33,6 +39,37 @@ func (al AccessList) StorageKeys() int {
 
 var DEFAULT_PRICE = int64(47619047619047600)
 var GAS_TIER_DEFAULT_PRICE = big.NewInt(DEFAULT_PRICE) // 1000 DP / 21000 in wei (1000/21000 = 0.0476190476190476)
+var GAS_TIER_2x_PRICE = common.SafeMulBigInt(GAS_TIER_DEFAULT_PRICE, big.NewInt(2))
+var GAS_TIER_5x_PRICE = common.SafeMulBigInt(GAS_TIER_DEFAULT_PRICE, big.NewInt(5))
+var GAS_TIER_10x_PRICE = common.SafeMulBigInt(GAS_TIER_DEFAULT_PRICE, big.NewInt(10))
+
+// GetGasTierPrice returns the gas price of a gas tier, or nil if the gas tier is not valid.
+func GetGasTierPrice(tier GasTier) *big.Int {
+	switch tier {
+	case GAS_TIER_DEFAULT:
+		return GAS_TIER_DEFAULT_PRICE
+	case GAS_TIER_2X:
+		return GAS_TIER_2x_PRICE
+	case GAS_TIER_5X:
+		return GAS_TIER_5x_PRICE
+	case GAS_TIER_10X:
+		return GAS_TIER_10x_PRICE
+	}
+	return nil
+}
+
+// GetGasTierFromPrice returns the gas tier whose price is the given gas price.
+func GetGasTierFromPrice(price *big.Int) (GasTier, bool) {
+	if price == nil {
+		return 0, false
+	}
+	for _, tier := range GAS_TIERS {
+		if GetGasTierPrice(tier).Cmp(price) == 0 {
+			return tier, true
+		}
+	}
+	return 0, false
+}
 
 type DefaultFeeTx struct {
 	ChainID    *big.Int
@@ -94,21 +131,19 @@ func (tx *DefaultFeeTx) protected() bool        { return true }
 func (tx *DefaultFeeTx) accessList() AccessList { return tx.AccessList }
 func (tx *DefaultFeeTx) data() []byte           { return tx.Data }
 func (tx *DefaultFeeTx) gas() uint64            { return tx.Gas }
-func (tx *DefaultFeeTx) gasFeeCap() *big.Int    { return GAS_TIER_DEFAULT_PRICE }
+func (tx *DefaultFeeTx) gasFeeCap() *big.Int    { return tx.gasPrice() }
 func (tx *DefaultFeeTx) gasPrice() *big.Int {
-	if tx.MaxGasTier == GAS_TIER_DEFAULT {
+	price := GetGasTierPrice(tx.MaxGasTier)
+	if price == nil {
 		return GAS_TIER_DEFAULT_PRICE
 	}
-	return GAS_TIER_DEFAULT_PRICE
+	return price
 }
 func (tx *DefaultFeeTx) maxGasTier() GasTier { return tx.MaxGasTier }
 func (tx *DefaultFeeTx) value() *big.Int     { return tx.Value }
 func (tx *DefaultFeeTx) nonce() uint64       { return tx.Nonce }
 func (tx *DefaultFeeTx) to() *common.Address { return tx.To }
 func (tx *DefaultFeeTx) verifyFields() bool {
-	if tx.gasPrice() != GAS_TIER_DEFAULT_PRICE {
-		return false
-	}
 	return len(tx.Remarks) <= MAX_REMARKS_LENGTH
 }
 
@@ -2,6 +2,8 @@ package types
 
 import (
 	"fmt"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/params"
 	"math/big"
 	"testing"
 )
@@ -10,3 +12,75 @@ func TestGas(t *testing.T) {
 	a := big.NewInt(47619047619047600)
 	fmt.Println(a)
 }
+
+func TestGasTierPrice(t *testing.T) {
+	for _, tier := range GAS_TIERS {
+		tx := NewDefaultFeeTransaction(big.NewInt(DEFAULT_CHAIN_ID), 0, nil, big.NewInt(0), 21000, tier, nil)
+		if tx.VerifyFields() == false || tx.HasValidGasTier() == false {
+			t.Fatalf("failed %d", tier)
+		}
+		expected := new(big.Int).Mul(GAS_TIER_DEFAULT_PRICE, big.NewInt(int64(tier)))
+		if tx.GasPrice().Cmp(expected) != 0 {
+			t.Fatalf("failed %d %v", tier, tx.GasPrice())
+		}
+		if tx.GasTier() != tier {
+			t.Fatalf("failed %d", tier)
+		}
+		fromPrice, ok := GetGasTierFromPrice(expected)
+		if ok == false || fromPrice != tier {
+			t.Fatalf("failed %d", tier)
+		}
+	}
+
+	tx := NewDefaultFeeTransaction(big.NewInt(DEFAULT_CHAIN_ID), 0, nil, big.NewInt(0), 21000, GasTier(3), nil)
+	if tx.HasValidGasTier() == true {
+		t.Fatalf("failed")
+	}
+
+	//Before the gas tier fork, every transaction is charged the default gas tier price
+	tx = NewDefaultFeeTransaction(big.NewInt(DEFAULT_CHAIN_ID), 0, nil, big.NewInt(0), 21000, GAS_TIER_10X, nil)
+	config := &params.ChainConfig{GasTierBlock: big.NewInt(10)}
+	if price := EffectiveGasPrice(config, big.NewInt(9), tx); price.Cmp(GAS_TIER_DEFAULT_PRICE) != 0 {
+		t.Fatalf("failed %v", price)
+	}
+	if price := EffectiveGasPrice(config, big.NewInt(10), tx); price.Cmp(GAS_TIER_10x_PRICE) != 0 {
+		t.Fatalf("failed %v", price)
+	}
+	if _, ok := GetGasTierFromPrice(big.NewInt(1)); ok == true {
+		t.Fatalf("failed")
+	}
+}
+
+func TestGasTierUnmarshalJSON(t *testing.T) {
+	const txJson = `{"type":"0x0","chainId":"0x1e0f3","nonce":"0x0","gas":"0x5208","maxGasTier":"%s","value":"0x0","input":"0x","vBlob":"AQ==","rBlob":"AQ==","sBlob":"AQ=="}`
+
+	tests := []struct {
+		maxGasTier string
+		tier       GasTier
+		fail       bool
+	}{
+		{maxGasTier: "0x1", tier: GAS_TIER_DEFAULT},
+		{maxGasTier: "0x2", tier: GAS_TIER_2X},
+		{maxGasTier: "0x5", tier: GAS_TIER_5X},
+		{maxGasTier: "0xa", tier: GAS_TIER_10X},
+		{maxGasTier: hexutil.EncodeBig(GAS_TIER_DEFAULT_PRICE), tier: GAS_TIER_DEFAULT},
+		{maxGasTier: hexutil.EncodeBig(GAS_TIER_10x_PRICE), tier: GAS_TIER_10X},
+		{maxGasTier: "0x3", fail: true},
+	}
+	for _, test := range tests {
+		tx := &Transaction{}
+		err := tx.UnmarshalJSON([]byte(fmt.Sprintf(txJson, test.maxGasTier)))
+		if test.fail {
+			if err == nil {
+				t.Fatalf("expected error for %s", test.maxGasTier)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed %s %v", test.maxGasTier, err)
+		}
+		if tx.GasTier() != test.tier {
+			t.Fatalf("failed %s %d", test.maxGasTier, tx.GasTier())
+		}
+	}
+}
@@ -61,9 +61,10 @@ type Receipt struct {
 
 	// Implementation fields: These fields are added by geth when processing a transaction.
 	// They are stored in the chain database.
-	TxHash          common.Hash    `json:"transactionHash" gencodec:"required"`
-	ContractAddress common.Address `json:"contractAddress"`
-	GasUsed         uint64         `json:"gasUsed" gencodec:"required"`
+	TxHash            common.Hash    `json:"transactionHash" gencodec:"required"`
+	ContractAddress   common.Address `json:"contractAddress"`
+	GasUsed           uint64         `json:"gasUsed" gencodec:"required"`
+	EffectiveGasPrice *big.Int       `json:"effectiveGasPrice"` // Gas price charged for the transaction, derived and not stored
 
 	// Inclusion information: These fields provide information about the inclusion of the
 	// transaction corresponding to this receipt.
@@ -78,6 +79,7 @@ type receiptMarshaling struct {
 	Status            hexutil.Uint64
 	CumulativeGasUsed hexutil.Uint64
 	GasUsed           hexutil.Uint64
+	EffectiveGasPrice *hexutil.Big
 	BlockNumber       *hexutil.Big
 	TransactionIndex  hexutil.Uint
 }
@@ -349,6 +351,7 @@ func (r Receipts) DeriveFields(config *params.ChainConfig, hash common.Hash, num
 		// The transaction type and hash can be retrieved from the transaction itself
 		r[i].Type = txs[i].Type()
 		r[i].TxHash = txs[i].Hash()
+		r[i].EffectiveGasPrice = EffectiveGasPrice(config, new(big.Int).SetUint64(number), txs[i])
 
 		// block location fields
 		r[i].BlockHash = hash
@@ -245,6 +245,9 @@ func TestDeriveFields(t *testing.T) {
 		if receipts[i].GasUsed != txs[i].Gas() {
 			t.Errorf("receipts[%d].GasUsed = %d, want %d", i, receipts[i].GasUsed, txs[i].Gas())
 		}
+		if receipts[i].EffectiveGasPrice.Cmp(txs[i].GasPrice()) != 0 {
+			t.Errorf("receipts[%d].EffectiveGasPrice = %v, want %v", i, receipts[i].EffectiveGasPrice, txs[i].GasPrice())
+		}
 		if txs[i].To() != nil && receipts[i].ContractAddress != (common.Address{}) {
 			t.Errorf("receipts[%d].ContractAddress = %s, want %s", i, receipts[i].ContractAddress.String(), (common.Address{}).String())
 		}
@@ -322,6 +325,7 @@ func clearComputedFieldsOnReceipt(t *testing.T, receipt *Receipt) {
 	receipt.TransactionIndex = math.MaxUint32
 	receipt.ContractAddress = common.Address{}
 	receipt.GasUsed = 0
+	receipt.EffectiveGasPrice = nil
 
 	clearComputedFieldsOnLogs(t, receipt.Logs)
 }
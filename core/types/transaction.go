@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/params"
 	"github.com/QuantumCoinProject/qc/rlp"
 )
 
@@ -246,6 +247,21 @@ func (tx *Transaction) GasPrice() *big.Int { return new(big.Int).Set(tx.inner.ga
 
 func (tx *Transaction) MaxGasTier() *big.Int { return new(big.Int).Set(tx.inner.gasPrice()) }
 
+// GasTier returns the gas tier of the transaction.
+func (tx *Transaction) GasTier() GasTier { return tx.inner.maxGasTier() }
+
+// HasValidGasTier returns whether the gas tier of the transaction is one of GAS_TIERS.
+func (tx *Transaction) HasValidGasTier() bool { return GetGasTierPrice(tx.GasTier()) != nil }
+
+// EffectiveGasPrice returns the gas price charged for the transaction in the block with the given number. Before the
+// gas tier fork, every transaction is charged the default gas tier price.
+func EffectiveGasPrice(config *params.ChainConfig, number *big.Int, tx *Transaction) *big.Int {
+	if config.IsGasTier(number) {
+		return tx.GasPrice()
+	}
+	return new(big.Int).Set(GAS_TIER_DEFAULT_PRICE)
+}
+
 // Value returns the ether amount of the transaction.
 func (tx *Transaction) Value() *big.Int { return new(big.Int).Set(tx.inner.value()) }
 
@@ -413,6 +429,74 @@ func (s *TxBySortPrefix) Pop() interface{} {
 	return x
 }
 
+// gasTierHead is the next transaction of an account while ordering transactions by gas tier.
+type gasTierHead struct {
+	address common.Address
+	order   int // Position of the account in the address order
+	index   int // Index of the next transaction of the account
+	tier    GasTier
+}
+
+// txByGasTier implements the heap interface, ordering the account heads by gas tier, then by the number of
+// transactions already taken from the account and then by the address order.
+type txByGasTier []*gasTierHead
+
+func (s txByGasTier) Len() int { return len(s) }
+func (s txByGasTier) Less(i, j int) bool {
+	if s[i].tier != s[j].tier {
+		return s[i].tier > s[j].tier
+	}
+	if s[i].index != s[j].index {
+		return s[i].index < s[j].index
+	}
+	return s[i].order < s[j].order
+}
+func (s txByGasTier) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+func (s *txByGasTier) Push(x interface{}) {
+	*s = append(*s, x.(*gasTierHead))
+}
+
+func (s *txByGasTier) Pop() interface{} {
+	old := *s
+	n := len(old)
+	x := old[n-1]
+	*s = old[0 : n-1]
+	return x
+}
+
+// SortByGasTier orders the transactions by their own gas tier, highest first. The nonce order of the transactions
+// of each account is kept, so a transaction never goes before an earlier transaction of the same account.
+// Transactions of the same gas tier are taken from the accounts in turn, in the given order of addresses.
+func SortByGasTier(txns map[common.Address]Transactions, addresses []common.Address) Transactions {
+	heads := make(txByGasTier, 0, len(addresses))
+	count := 0
+	for i, addr := range addresses {
+		accTxs := txns[addr]
+		if len(accTxs) == 0 {
+			continue
+		}
+		count = count + len(accTxs)
+		heads = append(heads, &gasTierHead{address: addr, order: i, tier: accTxs[0].GasTier()})
+	}
+	heap.Init(&heads)
+
+	sorted := make(Transactions, 0, count)
+	for heads.Len() > 0 {
+		head := heads[0]
+		accTxs := txns[head.address]
+		sorted = append(sorted, accTxs[head.index])
+		head.index = head.index + 1
+		if head.index < len(accTxs) {
+			head.tier = accTxs[head.index].GasTier()
+			heap.Fix(&heads, 0)
+		} else {
+			heap.Pop(&heads)
+		}
+	}
+	return sorted
+}
+
 // TransactionsByNonce represents a set of transactions supporting removing
 // entire batches of transactions for non-executable accounts.
 type TransactionsByNonce struct {
@@ -423,6 +507,9 @@ type TransactionsByNonce struct {
 	orderedAddresses []common.Address
 	addressIndex     int
 	round            int
+	gasTierOrdered   bool         // Whether the cursor follows gasTierTxns, once the gas tier fork is active
+	gasTierTxns      Transactions // Transactions ordered by gas tier
+	gasTierIndex     int
 }
 
 // NewTransactionsByNonce creates a transaction set that can retrieve transactions in a nonce-honouring way.
@@ -524,7 +611,21 @@ func (t *TransactionsByNonce) internalSort() {
 	})
 }
 
+// SortByGasTier makes the cursor return the transactions ordered by their own gas tier, see SortByGasTier. It is
+// used once the gas tier fork is active, and resets the cursor.
+func (t *TransactionsByNonce) SortByGasTier() {
+	t.gasTierOrdered = true
+	t.gasTierTxns = SortByGasTier(t.txns, t.orderedAddresses)
+	t.ResetCursor()
+}
+
 func (t *TransactionsByNonce) PeekCursor() *Transaction {
+	if t.gasTierOrdered {
+		if t.gasTierIndex < 0 || t.gasTierIndex >= len(t.gasTierTxns) {
+			return nil
+		}
+		return t.gasTierTxns[t.gasTierIndex]
+	}
 	if t.addressIndex < 0 || len(t.txns) == 0 {
 		return nil
 	}
@@ -534,9 +635,16 @@ func (t *TransactionsByNonce) PeekCursor() *Transaction {
 func (t *TransactionsByNonce) ResetCursor() {
 	t.round = 0
 	t.addressIndex = -1
+	t.gasTierIndex = -1
 }
 
 func (t *TransactionsByNonce) NextCursor() bool {
+	if t.gasTierOrdered {
+		if t.gasTierIndex < len(t.gasTierTxns) {
+			t.gasTierIndex = t.gasTierIndex + 1
+		}
+		return t.gasTierIndex < len(t.gasTierTxns)
+	}
 	if t.addressIndex == -2 {
 		return false
 	}
@@ -626,12 +734,13 @@ func NewMessage(from common.Address, to *common.Address, nonce uint64, amount *b
 	}
 }
 
-// AsMessage returns the transaction as a core.Message.
-func (tx *Transaction) AsMessage(s Signer) (Message, error) {
+// AsMessage returns the transaction as a core.Message, charged the effective gas price of the block with the given
+// number.
+func (tx *Transaction) AsMessage(s Signer, config *params.ChainConfig, number *big.Int) (Message, error) {
 	msg := Message{
 		nonce:      tx.Nonce(),
 		gasLimit:   tx.Gas(),
-		gasPrice:   new(big.Int).Set(tx.GasPrice()),
+		gasPrice:   EffectiveGasPrice(config, number, tx),
 		to:         tx.To(),
 		amount:     tx.Value(),
 		data:       tx.Data(),
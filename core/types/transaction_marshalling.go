@@ -160,7 +160,7 @@ func (t *Transaction) UnmarshalJSON(input []byte) error {
 		}
 		itx.Data = *dec.Data
 		if dec.Remarks != nil {
-			itx.Remarks = *dec.Data
+			itx.Remarks = *dec.Remarks
 			if len(itx.Remarks) > MAX_REMARKS_LENGTH {
 				return errors.New("verify remarks failed")
 			}
@@ -169,12 +169,13 @@ func (t *Transaction) UnmarshalJSON(input []byte) error {
 		if dec.MaxGasTier == nil {
 			return errors.New("missing required field 'maxGasTier' in transaction") //todo: fill
 		}
-		maxGasTier := int64(*dec.MaxGasTier)
+		maxGasTier := uint64(*dec.MaxGasTier)
 
-		if big.NewInt(maxGasTier).Cmp(GAS_TIER_DEFAULT_PRICE) == 0 {
-			itx.MaxGasTier = GAS_TIER_DEFAULT
-		} else if maxGasTier == int64(GAS_TIER_DEFAULT) {
-			itx.MaxGasTier = GAS_TIER_DEFAULT
+		//maxGasTier is either the gas tier, or the gas price of the gas tier
+		if tier, ok := GetGasTierFromPrice(new(big.Int).SetUint64(maxGasTier)); ok {
+			itx.MaxGasTier = tier
+		} else if GetGasTierPrice(GasTier(maxGasTier)) != nil {
+			itx.MaxGasTier = GasTier(maxGasTier)
 		} else {
 			log.Error("invalid max gas tier", "tier", maxGasTier)
 			return errors.New("invalid max gas tier")
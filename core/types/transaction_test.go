@@ -634,6 +634,7 @@ func TestTransactionCoding(t *testing.T) {
 			MaxGasTier: GAS_TIER_DEFAULT,
 			AccessList: accesses,
 			Data:       []byte("abcdef"),
+			Remarks:    []byte("memo"),
 		}
 
 		tx, err := SignNewTx(key, signer, txdata)
@@ -653,6 +654,9 @@ func TestTransactionCoding(t *testing.T) {
 			t.Fatal(err)
 		}
 		assertEqual(parsedTx, tx)
+		if bytes.Equal(parsedTx.Remarks(), tx.Remarks()) == false {
+			t.Fatalf("invalid remarks, want %x, got %x", tx.Remarks(), parsedTx.Remarks())
+		}
 	}
 }
 
@@ -695,3 +699,62 @@ func assertEqual(orig *Transaction, cpy *Transaction) error {
 	}
 	return nil
 }
+
+func TestTransactionSortGasTier(t *testing.T) {
+	keys := make([]*signaturealgorithm.PrivateKey, len(GAS_TIERS))
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = cryptobase.SigAlg.GenerateKey()
+	}
+	signer := NewLondonSignerDefaultChain()
+
+	//Each account sends a transaction of its own gas tier followed by a 10x one
+	groups := map[common.Address]Transactions{}
+	for i, key := range keys {
+		addr := cryptobase.SigAlg.PublicKeyToAddressNoError(&key.PublicKey)
+		for nonce, tier := range []GasTier{GAS_TIERS[i], GAS_TIER_10X} {
+			tx, err := SignTx(NewDefaultFeeTransaction(big.NewInt(DEFAULT_CHAIN_ID), uint64(nonce), &common.Address{}, big.NewInt(100), 21000, tier, nil), signer, key)
+			if err != nil {
+				t.Fatalf("failed %v", err)
+			}
+			groups[addr] = append(groups[addr], tx)
+		}
+	}
+
+	parentHash := common.BytesToHash([]byte("test parent hash"))
+	txset := NewTransactionsByNonce(signer, groups, parentHash)
+
+	//Before the gas tier fork, the accounts are taken in turn regardless of the gas tier
+	count := 0
+	for txset.NextCursor() {
+		txn := txset.PeekCursor()
+		if (count < len(keys)) != (txn.Nonce() == 0) {
+			t.Fatalf("failed %d %d", count, txn.Nonce())
+		}
+		count = count + 1
+	}
+	if count != len(keys)*2 {
+		t.Fatalf("failed")
+	}
+
+	//After it, each transaction goes after every account head with a higher gas tier
+	txset.SortByGasTier()
+	next := make(map[common.Address]int)
+	count = 0
+	for txset.NextCursor() {
+		txn := txset.PeekCursor()
+		from, _ := Sender(signer, txn)
+		if txn.Nonce() != uint64(next[from]) {
+			t.Fatalf("nonce %d sorted before %d", txn.Nonce(), next[from])
+		}
+		for addr, txns := range groups {
+			if next[addr] < len(txns) && txns[next[addr]].GasTier() > txn.GasTier() {
+				t.Fatalf("gas tier %d sorted before %d", txn.GasTier(), txns[next[addr]].GasTier())
+			}
+		}
+		next[from] = next[from] + 1
+		count = count + 1
+	}
+	if count != len(keys)*2 {
+		t.Fatalf("failed")
+	}
+}
@@ -93,6 +93,21 @@ var PrecompiledContractsBerlin = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{9}): &blake2F{},
 }
 
+// PrecompiledContractsPqSigVerify contains the Berlin set of pre-compiled contracts
+// together with the post-quantum signature verification contract.
+var PrecompiledContractsPqSigVerify = map[common.Address]PrecompiledContract{
+	//common.BytesToAddress([]byte{1}): &ecrecover{},
+	common.BytesToAddress([]byte{2}): &sha256hash{},
+	common.BytesToAddress([]byte{3}): &ripemd160hash{},
+	common.BytesToAddress([]byte{4}): &dataCopy{},
+	common.BytesToAddress([]byte{5}): &bigModExp{eip2565: true},
+	//common.BytesToAddress([]byte{6}): &bn256AddIstanbul{},
+	common.BytesToAddress([]byte{7}):  &bn256ScalarMulIstanbul{},
+	common.BytesToAddress([]byte{8}):  &bn256PairingIstanbul{},
+	common.BytesToAddress([]byte{9}):  &blake2F{},
+	common.BytesToAddress([]byte{19}): &pqSigVerify{},
+}
+
 // PrecompiledContractsBLS contains the set of pre-compiled Ethereum
 // contracts specified in EIP-2537. These are exported for testing purposes.
 var PrecompiledContractsBLS = map[common.Address]PrecompiledContract{
@@ -108,10 +123,11 @@ var PrecompiledContractsBLS = map[common.Address]PrecompiledContract{
 }
 
 var (
-	PrecompiledAddressesBerlin    []common.Address
-	PrecompiledAddressesIstanbul  []common.Address
-	PrecompiledAddressesByzantium []common.Address
-	PrecompiledAddressesHomestead []common.Address
+	PrecompiledAddressesPqSigVerify []common.Address
+	PrecompiledAddressesBerlin      []common.Address
+	PrecompiledAddressesIstanbul    []common.Address
+	PrecompiledAddressesByzantium   []common.Address
+	PrecompiledAddressesHomestead   []common.Address
 )
 
 func init() {
@@ -127,11 +143,16 @@ func init() {
 	for k := range PrecompiledContractsBerlin {
 		PrecompiledAddressesBerlin = append(PrecompiledAddressesBerlin, k)
 	}
+	for k := range PrecompiledContractsPqSigVerify {
+		PrecompiledAddressesPqSigVerify = append(PrecompiledAddressesPqSigVerify, k)
+	}
 }
 
 // ActivePrecompiles returns the precompiles enabled with the current configuration.
 func ActivePrecompiles(rules params.Rules) []common.Address {
 	switch {
+	case rules.IsPqSigVerify:
+		return PrecompiledAddressesPqSigVerify
 	case rules.IsBerlin:
 		return PrecompiledAddressesBerlin
 	case rules.IsIstanbul:
@@ -199,6 +220,58 @@ func (c *ecrecover) Run(input []byte) ([]byte, error) {
 	return common.LeftPadBytes(crypto.Keccak256(pubKey[:])[common.AddressTruncateBytes:], common.HashLength), nil
 }
 
+// pqSigVerify verifies a cryptobase.SigAlg signature, as a replacement for ECRECOVER.
+// The input is either
+//
+//	digest (32 bytes) || combined signature (as returned by SigAlg.Sign)
+//	digest (32 bytes) || signature (SignatureLength bytes) || public key (PublicKeyLength bytes)
+//
+// and the output is the 32 byte address of the signer, or no output if the signature is invalid.
+type pqSigVerify struct{}
+
+const pqSigVerifyDigestLength = 32
+
+func (c *pqSigVerify) RequiredGas(input []byte) uint64 {
+	return params.PqSigVerifyGas
+}
+
+func (c *pqSigVerify) Run(input []byte) ([]byte, error) {
+	if len(input) <= pqSigVerifyDigestLength {
+		return nil, nil
+	}
+	digest := input[:pqSigVerifyDigestLength]
+	sig := input[pqSigVerifyDigestLength:]
+
+	var pubKey, combinedSig []byte
+	switch len(sig) {
+	case cryptobase.SigAlg.SignatureWithPublicKeyLength():
+		_, pubKeyBytes, err := common.ExtractTwoParts(sig)
+		if err != nil {
+			return nil, nil
+		}
+		pubKey = pubKeyBytes
+		combinedSig = sig
+	case cryptobase.SigAlg.SignatureLength() + cryptobase.SigAlg.PublicKeyLength():
+		pubKey = sig[cryptobase.SigAlg.SignatureLength():]
+		combined, err := cryptobase.SigAlg.CombinePublicKeySignature(sig[:cryptobase.SigAlg.SignatureLength()], pubKey)
+		if err != nil {
+			return nil, nil
+		}
+		combinedSig = combined
+	default:
+		return nil, nil
+	}
+
+	if len(pubKey) != cryptobase.SigAlg.PublicKeyLength() {
+		return nil, nil
+	}
+	if cryptobase.SigAlg.Verify(pubKey, digest, combinedSig) == false {
+		return nil, nil
+	}
+
+	return common.LeftPadBytes(crypto.PublicKeyBytesToAddress(pubKey).Bytes(), common.HashLength), nil
+}
+
 // SHA256 implemented as a native contract.
 type sha256hash struct{}
 
@@ -21,10 +21,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/crypto"
+	"github.com/QuantumCoinProject/qc/crypto/cryptobase"
+	"github.com/QuantumCoinProject/qc/crypto/secp256k1"
+	"github.com/QuantumCoinProject/qc/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -65,6 +70,7 @@ var allPrecompiles = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{16}):   &bls12381Pairing{},
 	common.BytesToAddress([]byte{17}):   &bls12381MapG1{},
 	common.BytesToAddress([]byte{18}):   &bls12381MapG2{},
+	common.BytesToAddress([]byte{19}):   &pqSigVerify{},
 }
 
 // EIP-152 test vectors
@@ -202,6 +208,37 @@ func BenchmarkPrecompiledEcrecover(bench *testing.B) {
 	benchmarkPrecompiled("01", t, bench)
 }
 
+// Benchmarks the PQ signature verification precompile. The "ecrecover-gas/op" metric is the gas of a verification at
+// the gas per second of a secp256k1 public key recovery priced at EcrecoverGas, which PqSigVerifyGas is derived from.
+func BenchmarkPrecompiledPqSigVerify(bench *testing.B) {
+	tests := pqSigVerifyTests(bench)
+	for _, test := range tests {
+		benchmarkPrecompiled("13", test, bench)
+	}
+
+	digest := crypto.Keccak256([]byte("ecrecover"))
+	ecSig, err := secp256k1.Sign(digest, crypto.Keccak256([]byte("ecrecover key")))
+	if err != nil {
+		bench.Fatal(err)
+	}
+	p := allPrecompiles[common.HexToAddress("13")]
+	in := common.Hex2Bytes(tests[0].Input)
+	bench.Run("derived", func(bench *testing.B) {
+		start := time.Now()
+		for i := 0; i < bench.N; i++ {
+			secp256k1.RecoverPubkey(digest, ecSig)
+		}
+		ecrecoverElapsed := time.Since(start)
+
+		start = time.Now()
+		for i := 0; i < bench.N; i++ {
+			RunPrecompiledContract(p, in, params.PqSigVerifyGas)
+		}
+		elapsed := time.Since(start)
+		bench.ReportMetric(float64(params.EcrecoverGas)*float64(elapsed)/float64(ecrecoverElapsed), "ecrecover-gas/op")
+	})
+}
+
 // Benchmarks the sample inputs from the SHA256 precompile.
 func BenchmarkPrecompiledSha256(bench *testing.B) {
 	t := precompiledTest{
@@ -275,6 +312,111 @@ func TestPrecompiledEcrecover(t *testing.T) {
 	//testJson("ecRecover", "01", t)
 }
 
+// pqSigVerifyTests signs a digest with a new key and returns inputs in both the combined and the separate format.
+func pqSigVerifyTests(t testing.TB) []precompiledTest {
+	key, err := cryptobase.SigAlg.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := crypto.Keccak256([]byte("pqSigVerify"))
+	combinedSig, err := cryptobase.SigAlg.Sign(digest, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, pubKey, err := common.ExtractTwoParts(combinedSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	address, err := cryptobase.SigAlg.PublicKeyToAddress(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := common.Bytes2Hex(common.LeftPadBytes(address.Bytes(), common.HashLength))
+
+	otherDigest := crypto.Keccak256([]byte("other"))
+
+	return []precompiledTest{
+		{
+			Input:    common.Bytes2Hex(append(common.CopyBytes(digest), combinedSig...)),
+			Expected: expected,
+			Gas:      params.PqSigVerifyGas,
+			Name:     "combined",
+		},
+		{
+			Input:    common.Bytes2Hex(append(append(common.CopyBytes(digest), sig...), pubKey...)),
+			Expected: expected,
+			Gas:      params.PqSigVerifyGas,
+			Name:     "separate",
+		},
+		{
+			Input:       common.Bytes2Hex(append(common.CopyBytes(otherDigest), combinedSig...)),
+			Expected:    "",
+			Gas:         params.PqSigVerifyGas,
+			Name:        "wrong-digest",
+			NoBenchmark: true,
+		},
+	}
+}
+
+func TestPrecompiledPqSigVerify(t *testing.T) {
+	for _, test := range pqSigVerifyTests(t) {
+		testPrecompiled("13", test, t)
+	}
+}
+
+func TestPrecompiledPqSigVerifyMalformedInput(t *testing.T) {
+	tests := []precompiledTest{
+		{
+			Input: "",
+			Name:  "empty",
+		},
+		{
+			Input: common.Bytes2Hex(make([]byte, 32)),
+			Name:  "digest only",
+		},
+		{
+			Input: common.Bytes2Hex(make([]byte, 32+cryptobase.SigAlg.SignatureWithPublicKeyLength()-1)),
+			Name:  "short",
+		},
+		{
+			Input: common.Bytes2Hex(make([]byte, 32+cryptobase.SigAlg.SignatureWithPublicKeyLength())),
+			Name:  "zero combined",
+		},
+		{
+			Input: common.Bytes2Hex(make([]byte, 32+cryptobase.SigAlg.SignatureLength()+cryptobase.SigAlg.PublicKeyLength())),
+			Name:  "zero separate",
+		},
+	}
+	for _, test := range tests {
+		test.Gas = params.PqSigVerifyGas
+		testPrecompiled("13", test, t)
+	}
+}
+
+func TestPrecompiledPqSigVerifyActivation(t *testing.T) {
+	config := *params.TestChainConfig
+	config.PqSigVerifyBlock = big.NewInt(10)
+	address := common.BytesToAddress([]byte{19})
+
+	for _, test := range []struct {
+		number int64
+		active bool
+	}{{0, false}, {9, false}, {10, true}, {11, true}} {
+		rules := config.Rules(big.NewInt(test.number))
+		active := false
+		for _, addr := range ActivePrecompiles(rules) {
+			if addr == address {
+				active = true
+			}
+		}
+		evm := &EVM{chainRules: rules}
+		_, ok := evm.precompile(address)
+		if active != test.active || ok != test.active {
+			t.Errorf("block %d: active %v precompile %v, want %v", test.number, active, ok, test.active)
+		}
+	}
+}
+
 func testJson(name, addr string, t *testing.T) {
 	tests, err := loadJson(name)
 	if err != nil {
@@ -193,5 +193,7 @@ type Config struct {
 func CreateConsensusEngine(stack *node.Node, chainConfig *params.ChainConfig, notify []string, noverify bool, db ethdb.Database,
 	ethApi *ethapi.PublicBlockChainAPI, genesisHash common.Hash) consensus.Engine {
 
-	return proofofstake.New(chainConfig, db, ethApi, genesisHash)
+	engine := proofofstake.New(chainConfig, db, ethApi, genesisHash)
+	engine.SetVoteJournalDir(stack.ResolvePath(proofofstake.VOTE_JOURNAL_DIR))
+	return engine
 }
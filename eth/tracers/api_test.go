@@ -0,0 +1,169 @@
+package tracers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/consensus"
+	"github.com/QuantumCoinProject/qc/consensus/mockconsensus"
+	"github.com/QuantumCoinProject/qc/core"
+	"github.com/QuantumCoinProject/qc/core/rawdb"
+	"github.com/QuantumCoinProject/qc/core/state"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/core/vm"
+	"github.com/QuantumCoinProject/qc/crypto/cryptobase"
+	"github.com/QuantumCoinProject/qc/ethdb"
+	"github.com/QuantumCoinProject/qc/internal/ethapi"
+	"github.com/QuantumCoinProject/qc/params"
+	"github.com/QuantumCoinProject/qc/rpc"
+)
+
+var errBlockNotFound = errors.New("block not found")
+
+// testBackend serves the blocks and the states of a generated chain to the tracing API.
+type testBackend struct {
+	chainConfig *params.ChainConfig
+	engine      consensus.Engine
+	chaindb     ethdb.Database
+	blocks      []*types.Block
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	block, err := b.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	block, err := b.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return block.Header(), nil
+}
+
+func (b *testBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, errBlockNotFound
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return b.blocks[len(b.blocks)-1], nil
+	}
+	if number < 0 || int(number) >= len(b.blocks) {
+		return nil, errBlockNotFound
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) GetTransaction(ctx context.Context, txHash common.Hash) (*types.Transaction, common.Hash, uint64, uint64, error) {
+	tx, hash, blockNumber, index := rawdb.ReadTransaction(b.chaindb, txHash)
+	return tx, hash, blockNumber, index, nil
+}
+
+func (b *testBackend) RPCGasCap() uint64 {
+	return 25000000
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return b.chainConfig
+}
+
+func (b *testBackend) Engine() consensus.Engine {
+	return b.engine
+}
+
+func (b *testBackend) ChainDb() ethdb.Database {
+	return b.chaindb
+}
+
+func (b *testBackend) StateAtBlock(ctx context.Context, block *types.Block, reexec uint64, base *state.StateDB, checkLive bool) (*state.StateDB, error) {
+	return state.New(block.Root(), state.NewDatabase(b.chaindb), nil)
+}
+
+func (b *testBackend) StateAtTransaction(ctx context.Context, block *types.Block, txIndex int, reexec uint64) (core.Message, vm.BlockContext, *state.StateDB, error) {
+	return nil, vm.BlockContext{}, nil, errors.New("not supported")
+}
+
+// TestTraceBlockBeforeGasTier checks that a block traced before the gas tier fork
+// sees the gas price the block was executed with, rather than the gas tier price
+// of its transactions.
+func TestTraceBlockBeforeGasTier(t *testing.T) {
+	key, err := cryptobase.SigAlg.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	var (
+		config   = *params.TestChainConfig
+		db       = rawdb.NewMemoryDatabase()
+		engine   = mockconsensus.NewMockConsensus()
+		from     = cryptobase.SigAlg.PublicKeyToAddressNoError(&key.PublicKey)
+		contract = common.BytesToAddress([]byte("gasprice"))
+	)
+	config.GasTierBlock = big.NewInt(100)
+
+	//GASPRICE DUP1 PUSH1 0 SSTORE PUSH1 0 MSTORE PUSH1 32 PUSH1 0 RETURN
+	code := common.FromHex("0x3a8060005560005260206000f3")
+	gspec := &core.Genesis{
+		Config: &config,
+		Alloc: core.GenesisAlloc{
+			from:     {Balance: new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(1000000))},
+			contract: {Code: code, Balance: big.NewInt(0)},
+		},
+	}
+	genesis := gspec.MustCommit(db)
+
+	signer := types.MakeSigner(&config, big.NewInt(1))
+	blocks, _ := core.GenerateChain(&config, genesis, engine, db, 1, func(i int, gen *core.BlockGen) {
+		tx, err := types.SignTx(types.NewDefaultFeeTransaction(config.ChainID, gen.TxNonce(from), &contract, big.NewInt(0), 100000, types.GAS_TIER_10X, nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	block := blocks[0]
+	if config.IsGasTier(block.Number()) {
+		t.Fatalf("failed %v", block.Number())
+	}
+
+	statedb, err := state.New(block.Root(), state.NewDatabase(db), nil)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	executed := statedb.GetState(contract, common.Hash{})
+	if executed.Big().Cmp(types.GAS_TIER_DEFAULT_PRICE) != 0 {
+		t.Fatalf("failed %v", executed.Big())
+	}
+
+	api := NewAPI(&testBackend{
+		chainConfig: &config,
+		engine:      engine,
+		chaindb:     db,
+		blocks:      append([]*types.Block{genesis}, blocks...),
+	})
+	results, err := api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(block.NumberU64()), nil)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if len(results) != 1 || results[0].Error != "" {
+		t.Fatalf("failed %v", results)
+	}
+	result, ok := results[0].Result.(*ethapi.ExecutionResult)
+	if ok == false {
+		t.Fatalf("failed %T", results[0].Result)
+	}
+	if result.ReturnValue != fmt.Sprintf("%x", executed.Bytes()) {
+		t.Fatalf("failed %v %x", result.ReturnValue, executed.Bytes())
+	}
+}
@@ -83,6 +83,19 @@ func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount hexutil.U
 	return results, nil
 }
 
+// GasPrice returns the gas price of the given gas tier, or of the default gas tier if none is given.
+func (s *PublicEthereumAPI) GasPrice(ctx context.Context, maxGasTier *hexutil.Uint64) (*hexutil.Big, error) {
+	tier := types.GAS_TIER_DEFAULT
+	if maxGasTier != nil {
+		tier = types.GasTier(*maxGasTier)
+	}
+	price := types.GetGasTierPrice(tier)
+	if price == nil {
+		return nil, fmt.Errorf("invalid maxGasTier %d", tier)
+	}
+	return (*hexutil.Big)(new(big.Int).Set(price)), nil
+}
+
 // Syncing returns false in case the node is currently not syncing with the network. It can be up to date or has not
 // yet received the latest block headers from its pears. In case it is synchronizing:
 // - startingBlock: block number this node started to synchronise from
@@ -440,7 +453,7 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args Transactio
 	if args.Gas == nil {
 		return nil, fmt.Errorf("gas not specified")
 	}
-	if args.GasPrice == nil {
+	if args.GasPrice == nil && args.MaxGasTier == nil {
 		return nil, fmt.Errorf("missing GasPrice")
 	}
 	if args.Nonce == nil {
@@ -902,7 +915,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		hi = block.GasLimit()
 	}
 	// Recap the highest gas limit with account's available balance.
-	if args.GasPrice != nil && args.GasPrice.ToInt().BitLen() != 0 {
+	if gasPrice := args.gasPrice(); gasPrice != nil && gasPrice.BitLen() != 0 {
 		state, _, err := b.StateAndHeaderByNumberOrHash(ctx, blockNrOrHash)
 		if err != nil {
 			return 0, err
@@ -916,7 +929,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 			}
 			available.Sub(available, args.Value.ToInt())
 		}
-		allowance := new(big.Int).Div(available, args.GasPrice.ToInt())
+		allowance := new(big.Int).Div(available, gasPrice)
 
 		// If the allowance is larger than maximum uint64, skip checking
 		if allowance.IsUint64() && hi > allowance.Uint64() {
@@ -925,7 +938,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 				transfer = new(hexutil.Big)
 			}
 			log.Warn("Gas estimation capped by limited funds", "original", hi, "balance", balance,
-				"sent", transfer.ToInt(), "gasprice", args.GasPrice.ToInt(), "fundable", allowance)
+				"sent", transfer.ToInt(), "gasprice", gasPrice, "fundable", allowance)
 			hi = allowance.Uint64()
 		}
 	}
@@ -1141,6 +1154,7 @@ type RPCTransaction struct {
 	GasFeeCap        *hexutil.Big      `json:"maxFeePerGas,omitempty"`
 	Hash             common.Hash       `json:"hash"`
 	Input            hexutil.Bytes     `json:"input"`
+	Remarks          hexutil.Bytes     `json:"remarks,omitempty"`
 	Nonce            hexutil.Uint64    `json:"nonce"`
 	To               *common.Address   `json:"to"`
 	TransactionIndex *hexutil.Uint64   `json:"transactionIndex"`
@@ -1175,6 +1189,7 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		GasPrice: (*hexutil.Big)(tx.GasPrice()),
 		Hash:     tx.Hash(),
 		Input:    hexutil.Bytes(tx.Data()),
+		Remarks:  hexutil.Bytes(tx.Remarks()),
 		Nonce:    hexutil.Uint64(tx.Nonce()),
 		To:       tx.To(),
 		Value:    (*hexutil.Big)(tx.Value()),
@@ -1490,17 +1505,42 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if len(receipts) <= int(index) {
 		return nil, nil
 	}
-	receipt := receipts[index]
+	signer := types.MakeSigner(s.b.ChainConfig(), new(big.Int).SetUint64(blockNumber))
+	return marshalReceipt(receipts[index], blockHash, blockNumber, signer, s.b.ChainConfig(), tx, index), nil
+}
+
+// GetBlockReceipts returns the receipts of all the transactions in the given block, in transaction order.
+func (s *PublicTransactionPoolAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	block, err := s.b.BlockByNumberOrHash(ctx, blockNrOrHash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	receipts, err := s.b.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return nil, fmt.Errorf("receipts length mismatch: %d vs %d", len(receipts), len(txs))
+	}
+	signer := types.MakeSigner(s.b.ChainConfig(), block.Number())
 
+	result := make([]map[string]interface{}, len(receipts))
+	for i, receipt := range receipts {
+		result[i] = marshalReceipt(receipt, block.Hash(), block.NumberU64(), signer, s.b.ChainConfig(), txs[i], uint64(i))
+	}
+	return result, nil
+}
+
+// marshalReceipt converts a receipt into the RPC representation.
+func marshalReceipt(receipt *types.Receipt, blockHash common.Hash, blockNumber uint64, signer types.Signer, config *params.ChainConfig, tx *types.Transaction, index uint64) map[string]interface{} {
 	// Derive the sender.
-	bigblock := new(big.Int).SetUint64(blockNumber)
-	signer := types.MakeSigner(s.b.ChainConfig(), bigblock)
 	from, _ := types.Sender(signer, tx)
 
 	fields := map[string]interface{}{
 		"blockHash":         blockHash,
 		"blockNumber":       hexutil.Uint64(blockNumber),
-		"transactionHash":   hash,
+		"transactionHash":   tx.Hash(),
 		"transactionIndex":  hexutil.Uint64(index),
 		"from":              from,
 		"to":                tx.To(),
@@ -1512,7 +1552,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 		"type":              hexutil.Uint(tx.Type()),
 	}
 	// Assign the effective gas price paid
-	fields["effectiveGasPrice"] = hexutil.Uint64(tx.GasPrice().Uint64())
+	fields["effectiveGasPrice"] = (*hexutil.Big)(types.EffectiveGasPrice(config, new(big.Int).SetUint64(blockNumber), tx))
 	// Assign receipt status or post state.
 	if len(receipt.PostState) > 0 {
 		fields["root"] = hexutil.Bytes(receipt.PostState)
@@ -1526,7 +1566,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
-	return fields, nil
+	return fields
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
@@ -1594,7 +1634,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Tra
 		defer s.nonceLock.UnlockAddr(args.from())
 	}
 
-	if args.GasPrice == nil {
+	if args.GasPrice == nil && args.MaxGasTier == nil {
 		log.Info("GasPrice is nil. Setting GasPrice to GAS_TIER_DEFAULT_PRICE")
 		args.GasPrice = (*hexutil.Big)(types.GAS_TIER_DEFAULT_PRICE)
 	}
@@ -1677,7 +1717,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Tra
 	if args.Gas == nil {
 		return nil, fmt.Errorf("gas not specified")
 	}
-	if args.GasPrice == nil {
+	if args.GasPrice == nil && args.MaxGasTier == nil {
 		return nil, fmt.Errorf("missing GasPrice")
 	}
 	if args.Nonce == nil {
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/QuantumCoinProject/qc/common"
 	"github.com/QuantumCoinProject/qc/common/hexutil"
 	"github.com/QuantumCoinProject/qc/common/math"
@@ -39,6 +40,10 @@ type TransactionArgs struct {
 	Value    *hexutil.Big    `json:"value"`
 	Nonce    *hexutil.Uint64 `json:"nonce"`
 
+	// MaxGasTier is one of types.GAS_TIERS. If not set, the gas tier is derived from
+	// GasPrice, falling back to the default gas tier.
+	MaxGasTier *hexutil.Uint64 `json:"maxGasTier"`
+
 	// We accept "data" and "input" for backwards-compatibility reasons.
 	// "input" is the newer name and should be preferred by clients.
 	// Issue detail: https://github.com/ethereum/go-ethereum/issues/15628
@@ -77,11 +82,44 @@ func (arg *TransactionArgs) context() []byte {
 	return nil
 }
 
+// gasTier returns the gas tier of the arguments.
+func (args *TransactionArgs) gasTier() (types.GasTier, error) {
+	if args.MaxGasTier != nil {
+		tier := types.GasTier(*args.MaxGasTier)
+		if types.GetGasTierPrice(tier) == nil {
+			return 0, fmt.Errorf("invalid maxGasTier %d", tier)
+		}
+		return tier, nil
+	}
+	if args.GasPrice != nil {
+		if tier, ok := types.GetGasTierFromPrice(args.GasPrice.ToInt()); ok {
+			return tier, nil
+		}
+	}
+	return types.GAS_TIER_DEFAULT, nil
+}
+
+// gasPrice returns the gas price of the arguments. If MaxGasTier is set, this is the price of the gas tier.
+func (args *TransactionArgs) gasPrice() *big.Int {
+	if args.MaxGasTier != nil {
+		if price := types.GetGasTierPrice(types.GasTier(*args.MaxGasTier)); price != nil {
+			return price
+		}
+	}
+	if args.GasPrice != nil {
+		return args.GasPrice.ToInt()
+	}
+	return nil
+}
+
 // setDefaults fills in default values for unspecified tx fields.
 func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
-	if args.GasPrice == nil {
+	if args.GasPrice == nil && args.MaxGasTier == nil {
 		return errors.New("GasPrice is nil")
 	}
+	if _, err := args.gasTier(); err != nil {
+		return err
+	}
 	// After london, default to 1559 unless gasPrice is set
 	//head := b.CurrentHeader()
 	if args.Value == nil {
@@ -108,6 +146,7 @@ func (args *TransactionArgs) setDefaults(ctx context.Context, b Backend) error {
 			From:       args.From,
 			To:         args.To,
 			GasPrice:   args.GasPrice,
+			MaxGasTier: args.MaxGasTier,
 			Value:      args.Value,
 			Data:       args.Data,
 			AccessList: args.AccessList,
@@ -151,8 +190,8 @@ func (args *TransactionArgs) ToMessage(globalGasCap uint64) (types.Message, erro
 	)
 
 	gasPrice = new(big.Int)
-	if args.GasPrice != nil {
-		gasPrice = args.GasPrice.ToInt()
+	if price := args.gasPrice(); price != nil {
+		gasPrice = price
 	}
 
 	value := new(big.Int)
@@ -171,6 +210,10 @@ func (args *TransactionArgs) ToMessage(globalGasCap uint64) (types.Message, erro
 // toTransaction converts the arguments to a transaction.
 // This assumes that setDefaults has been called.
 func (args *TransactionArgs) toTransaction() *types.Transaction {
+	tier, err := args.gasTier()
+	if err != nil {
+		tier = types.GAS_TIER_DEFAULT
+	}
 	var data types.TxData
 	switch {
 	case args.AccessList != nil:
@@ -179,7 +222,7 @@ func (args *TransactionArgs) toTransaction() *types.Transaction {
 			ChainID:    (*big.Int)(args.ChainID),
 			Nonce:      uint64(*args.Nonce),
 			Gas:        uint64(*args.Gas),
-			MaxGasTier: types.GAS_TIER_DEFAULT,
+			MaxGasTier: tier,
 			Value:      (*big.Int)(args.Value),
 			Data:       args.data(),
 			Remarks:    args.context(),
@@ -191,7 +234,7 @@ func (args *TransactionArgs) toTransaction() *types.Transaction {
 			ChainID:    (*big.Int)(args.ChainID),
 			Nonce:      uint64(*args.Nonce),
 			Gas:        uint64(*args.Gas),
-			MaxGasTier: types.GAS_TIER_DEFAULT,
+			MaxGasTier: tier,
 			Value:      (*big.Int)(args.Value),
 			Data:       args.data(),
 			Remarks:    args.context(),
@@ -75,6 +75,16 @@ web3._extend({
 			call: 'proofofstake_getBlockConsensusContext',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getRoundState',
+			call: 'proofofstake_getRoundState',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getValidatorPerformance',
+			call: 'proofofstake_getValidatorPerformance',
+			params: 3
+		}),
 	]
 });
 `
@@ -489,6 +499,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, function (val) { return !!val; }]
 		}),
+		new web3._extend.Method({
+			name: 'getBlockReceipts',
+			call: 'eth_getBlockReceipts',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getRawTransaction',
 			call: 'eth_getRawTransactionByHash',
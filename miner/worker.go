@@ -884,6 +884,9 @@ func (w *worker) proposePhase(interrupt *int32, timestamp int64) error {
 
 	//log.Trace("pendingTxns txn address count", len(pendingTxns), "block", header.Number.Uint64())
 	txsByNonce := types.NewTransactionsByNonce(w.current.signer, selectedTxns, w.current.header.ParentHash)
+	if w.chainConfig.IsGasTier(w.current.header.Number) {
+		txsByNonce.SortByGasTier()
+	}
 
 	w.selectedTransactions = txsByNonce
 
@@ -54,6 +54,10 @@ var CheckpointOracles = map[common.Hash]*CheckpointOracleConfig{
 
 var (
 	// MainnetChainConfig is the chain parameters to run a node on the main network.
+	//
+	// The PqSigVerifyBlock and GasTierBlock forks are deliberately not scheduled in this config or in the test network
+	// configs below, so the forks stay inactive until a block is chosen for each network. A network activates them
+	// through the chain config of its genesis file.
 	MainnetChainConfig = &ChainConfig{
 		ChainID:             big.NewInt(1),
 		HomesteadBlock:      big.NewInt(1_150_000),
@@ -251,6 +255,8 @@ var (
 		big.NewInt(0),
 		big.NewInt(0),
 		nil,
+		big.NewInt(0),
+		big.NewInt(0),
 		new(EthashConfig),
 		nil}
 
@@ -275,6 +281,8 @@ var (
 		big.NewInt(0),
 		big.NewInt(0),
 		nil,
+		big.NewInt(0),
+		big.NewInt(0),
 		nil,
 		&ProofOfStakeConfig{Period: 0, Epoch: 30000}}
 
@@ -294,6 +302,8 @@ var (
 		big.NewInt(0),
 		big.NewInt(0),
 		nil,
+		big.NewInt(0),
+		big.NewInt(0),
 		new(EthashConfig),
 		nil}
 	TestRules = TestChainConfig.Rules(new(big.Int))
@@ -377,6 +387,9 @@ type ChainConfig struct {
 
 	CatalystBlock *big.Int `json:"catalystBlock,omitempty"` // Catalyst switch block (nil = no fork, 0 = already on catalyst)
 
+	PqSigVerifyBlock *big.Int `json:"pqSigVerifyBlock,omitempty"` // PQ signature verification precompile switch block (nil = no fork, 0 = already activated)
+	GasTierBlock     *big.Int `json:"gasTierBlock,omitempty"`     // Gas tier pricing and ordering switch block (nil = no fork, 0 = already activated)
+
 	// Various consensus engines
 	Ethash       *EthashConfig       `json:"ethash,omitempty"`
 	ProofOfStake *ProofOfStakeConfig `json:"proofofstake,omitempty"`
@@ -392,8 +405,18 @@ func (c *EthashConfig) String() string {
 
 // ProofOfStakeConfig is the consensus engine configs for proof-of-stake based sealing.
 type ProofOfStakeConfig struct {
-	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
-	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
+	Period    uint64 `json:"period"`              // Number of seconds between blocks to enforce
+	Epoch     uint64 `json:"epoch"`               // Epoch length to reset votes and checkpoint
+	Developer bool   `json:"developer,omitempty"` // Seal blocks locally without consensus rounds, for developer chains only
+
+	// Consensus rule switch blocks of chains other than the mainnet (nil = mainnet block)
+	RewardStartBlock      *big.Int `json:"rewardStartBlock,omitempty"`      // Block rewards start
+	StakingV2Block        *big.Int `json:"stakingV2Block,omitempty"`        // Staking contract v2 switch block
+	ConsensusContextBlock *big.Int `json:"consensusContextBlock,omitempty"` // Consensus context contract switch block
+	ValidatorNilBlock     *big.Int `json:"validatorNilBlock,omitempty"`     // Nil blocks of the validators are counted
+	BlockTimeOrigBlock    *big.Int `json:"blockTimeOrigBlock,omitempty"`    // Block time is the time proposed in the consensus data
+	TxnFeeBlock           *big.Int `json:"txnFeeBlock,omitempty"`           // Transaction fees are split between the proposer and burning
+	SlashStartBlock       *big.Int `json:"slashStartBlock,omitempty"`       // Block proposers that miss their round are slashed
 }
 
 // String implements the stringer interface, returning the consensus engine details.
@@ -401,6 +424,77 @@ func (c *ProofOfStakeConfig) String() string {
 	return "proofofstake"
 }
 
+// MainnetProofOfStakeForks are the consensus rule switch blocks of the mainnet, which a chain uses for the blocks its
+// proof-of-stake config does not set.
+var MainnetProofOfStakeForks = ProofOfStakeConfig{
+	RewardStartBlock:      big.NewInt(277204),
+	StakingV2Block:        big.NewInt(421888),
+	ConsensusContextBlock: big.NewInt(421888),
+	ValidatorNilBlock:     big.NewInt(421889),
+	BlockTimeOrigBlock:    big.NewInt(536001),
+	TxnFeeBlock:           big.NewInt(1607600),
+	SlashStartBlock:       big.NewInt(1497600),
+}
+
+// Forks returns the consensus rule switch blocks of the chain: the blocks set by the config, and the mainnet blocks
+// for the others. The config can be nil.
+func (c *ProofOfStakeConfig) Forks() ProofOfStakeConfig {
+	forks := MainnetProofOfStakeForks
+	if c == nil {
+		return forks
+	}
+	if c.RewardStartBlock != nil {
+		forks.RewardStartBlock = c.RewardStartBlock
+	}
+	if c.StakingV2Block != nil {
+		forks.StakingV2Block = c.StakingV2Block
+	}
+	if c.ConsensusContextBlock != nil {
+		forks.ConsensusContextBlock = c.ConsensusContextBlock
+	}
+	if c.ValidatorNilBlock != nil {
+		forks.ValidatorNilBlock = c.ValidatorNilBlock
+	}
+	if c.BlockTimeOrigBlock != nil {
+		forks.BlockTimeOrigBlock = c.BlockTimeOrigBlock
+	}
+	if c.TxnFeeBlock != nil {
+		forks.TxnFeeBlock = c.TxnFeeBlock
+	}
+	if c.SlashStartBlock != nil {
+		forks.SlashStartBlock = c.SlashStartBlock
+	}
+	return forks
+}
+
+// checkCompatible checks whether the consensus rule switch blocks can be changed to those of newcfg at the given
+// head. Either config can be nil, in which case the mainnet blocks are used.
+func (c *ProofOfStakeConfig) checkCompatible(newcfg *ProofOfStakeConfig, head *big.Int) *ConfigCompatError {
+	stored, next := c.Forks(), newcfg.Forks()
+	if isForkIncompatible(stored.RewardStartBlock, next.RewardStartBlock, head) {
+		return newCompatError("Proof-of-stake reward start block", stored.RewardStartBlock, next.RewardStartBlock)
+	}
+	if isForkIncompatible(stored.StakingV2Block, next.StakingV2Block, head) {
+		return newCompatError("Proof-of-stake staking v2 block", stored.StakingV2Block, next.StakingV2Block)
+	}
+	if isForkIncompatible(stored.ConsensusContextBlock, next.ConsensusContextBlock, head) {
+		return newCompatError("Proof-of-stake consensus context block", stored.ConsensusContextBlock, next.ConsensusContextBlock)
+	}
+	if isForkIncompatible(stored.ValidatorNilBlock, next.ValidatorNilBlock, head) {
+		return newCompatError("Proof-of-stake validator nil block", stored.ValidatorNilBlock, next.ValidatorNilBlock)
+	}
+	if isForkIncompatible(stored.BlockTimeOrigBlock, next.BlockTimeOrigBlock, head) {
+		return newCompatError("Proof-of-stake block time orig block", stored.BlockTimeOrigBlock, next.BlockTimeOrigBlock)
+	}
+	if isForkIncompatible(stored.TxnFeeBlock, next.TxnFeeBlock, head) {
+		return newCompatError("Proof-of-stake transaction fee block", stored.TxnFeeBlock, next.TxnFeeBlock)
+	}
+	if isForkIncompatible(stored.SlashStartBlock, next.SlashStartBlock, head) {
+		return newCompatError("Proof-of-stake slash start block", stored.SlashStartBlock, next.SlashStartBlock)
+	}
+	return nil
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -496,6 +590,16 @@ func (c *ChainConfig) IsCatalyst(num *big.Int) bool {
 	return isForked(c.CatalystBlock, num)
 }
 
+// IsPqSigVerify returns whether num is either equal to the PQ signature verification precompile fork block or greater.
+func (c *ChainConfig) IsPqSigVerify(num *big.Int) bool {
+	return isForked(c.PqSigVerifyBlock, num)
+}
+
+// IsGasTier returns whether num is either equal to the gas tier fork block or greater.
+func (c *ChainConfig) IsGasTier(num *big.Int) bool {
+	return isForked(c.GasTierBlock, num)
+}
+
 // CheckCompatible checks whether scheduled fork transitions have been imported
 // with a mismatching chain configuration.
 func (c *ChainConfig) CheckCompatible(newcfg *ChainConfig, height uint64) *ConfigCompatError {
@@ -605,6 +709,15 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.LondonBlock, newcfg.LondonBlock, head) {
 		return newCompatError("London fork block", c.LondonBlock, newcfg.LondonBlock)
 	}
+	if isForkIncompatible(c.PqSigVerifyBlock, newcfg.PqSigVerifyBlock, head) {
+		return newCompatError("PQ signature verification fork block", c.PqSigVerifyBlock, newcfg.PqSigVerifyBlock)
+	}
+	if isForkIncompatible(c.GasTierBlock, newcfg.GasTierBlock, head) {
+		return newCompatError("Gas tier fork block", c.GasTierBlock, newcfg.GasTierBlock)
+	}
+	if err := c.ProofOfStake.checkCompatible(newcfg.ProofOfStake, head); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -673,6 +786,7 @@ type Rules struct {
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158               bool
 	IsByzantium, IsConstantinople, IsPetersburg, IsIstanbul bool
 	IsBerlin, IsLondon, IsCatalyst                          bool
+	IsPqSigVerify, IsGasTier                                bool
 }
 
 // Rules ensures c's ChainID is not nil.
@@ -694,6 +808,8 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 		IsBerlin:         c.IsBerlin(num),
 		IsLondon:         c.IsLondon(num),
 		IsCatalyst:       c.IsCatalyst(num),
+		IsPqSigVerify:    c.IsPqSigVerify(num),
+		IsGasTier:        c.IsGasTier(num),
 	}
 
 	return r
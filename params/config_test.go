@@ -87,6 +87,51 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     30,
 			},
 		},
+		{
+			stored:  &ChainConfig{ProofOfStake: &ProofOfStakeConfig{StakingV2Block: big.NewInt(10)}},
+			new:     &ChainConfig{ProofOfStake: &ProofOfStakeConfig{StakingV2Block: big.NewInt(20)}},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{ProofOfStake: &ProofOfStakeConfig{StakingV2Block: big.NewInt(10)}},
+			new:    &ChainConfig{ProofOfStake: &ProofOfStakeConfig{StakingV2Block: big.NewInt(20)}},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "Proof-of-stake staking v2 block",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored:  &ChainConfig{ProofOfStake: &ProofOfStakeConfig{Period: 1}},
+			new:     &ChainConfig{ProofOfStake: &ProofOfStakeConfig{Period: 1, TxnFeeBlock: big.NewInt(100)}},
+			head:    50,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{ProofOfStake: &ProofOfStakeConfig{Period: 1}},
+			new:    &ChainConfig{ProofOfStake: &ProofOfStakeConfig{Period: 1, RewardStartBlock: big.NewInt(10)}},
+			head:   50,
+			wantErr: &ConfigCompatError{
+				What:         "Proof-of-stake reward start block",
+				StoredConfig: MainnetProofOfStakeForks.RewardStartBlock,
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
+		{
+			stored: &ChainConfig{ProofOfStake: &ProofOfStakeConfig{SlashStartBlock: big.NewInt(30)}},
+			new:    &ChainConfig{ProofOfStake: &ProofOfStakeConfig{SlashStartBlock: big.NewInt(20)}},
+			head:   25,
+			wantErr: &ConfigCompatError{
+				What:         "Proof-of-stake slash start block",
+				StoredConfig: big.NewInt(30),
+				NewConfig:    big.NewInt(20),
+				RewindTo:     19,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -133,6 +133,8 @@ const (
 	IdentityBaseGas     uint64 = 15   // Base price for a data copy operation
 	IdentityPerWordGas  uint64 = 3    // Per-work price for a data copy operation
 
+	PqSigVerifyGas uint64 = 15000 // Hybrid ed25519-dilithium signature verification gas price, at the gas per second of an ecrecover (ecrecover-gas/op of BenchmarkPrecompiledPqSigVerify)
+
 	Bn256AddGasByzantium             uint64 = 500    // Byzantium gas needed for an elliptic curve addition
 	Bn256AddGasIstanbul              uint64 = 150    // Gas needed for an elliptic curve addition
 	Bn256ScalarMulGasByzantium       uint64 = 40000  // Byzantium gas needed for an elliptic curve scalar multiplication
@@ -0,0 +1,310 @@
+package relay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/log"
+	"golang.org/x/time/rate"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ApiKeyScope is an api that an api key can be allowed to use.
+type ApiKeyScope string
+
+const (
+	API_KEY_SCOPE_READ ApiKeyScope = "read"
+	API_KEY_SCOPE_SEND ApiKeyScope = "send"
+)
+
+// UsageFlushInterval is how often the usage counters are written to the usage file.
+var UsageFlushInterval = 10 * time.Second
+
+const usageDayLayout = "2006-01-02"
+
+var (
+	ErrUnauthorized   = errors.New("Unauthorized")
+	ErrForbiddenScope = errors.New("the api key is not allowed to use this api")
+	ErrRateLimited    = errors.New("rate limit exceeded")
+	ErrQuotaExceeded  = errors.New("daily quota exceeded")
+	ErrBurstExceeded  = errors.New("request exceeds the burst of the api key")
+)
+
+// ApiKeyConfig is the configuration of an api key in the relay config. Example:
+//
+//	{"key": "...", "scopes": ["read"], "requestsPerSecond": 5, "burst": 10, "dailyQuota": 100000}
+//
+// The keys of the comma separated apiKeys value have all the scopes and no limits.
+type ApiKeyConfig struct {
+	Key               string   `json:"key"`
+	Scopes            []string `json:"scopes"`            //read, send; all scopes if empty
+	RequestsPerSecond float64  `json:"requestsPerSecond"` //0 for no rate limit
+	Burst             int      `json:"burst"`             //defaults to requestsPerSecond, rounded up
+	DailyQuota        uint64   `json:"dailyQuota"`        //requests per UTC day, 0 for no quota
+}
+
+// ApiKeyUsage is the usage of an api key, persisted in the usage file.
+type ApiKeyUsage struct {
+	Day        string `json:"day"` //UTC day of DayCount
+	DayCount   uint64 `json:"dayCount"`
+	TotalCount uint64 `json:"totalCount"`
+	Throttled  uint64 `json:"throttled"` //requests refused by the rate limit or quota
+}
+
+type apiKey struct {
+	scopes     map[ApiKeyScope]bool
+	limiter    *rate.Limiter
+	dailyQuota uint64
+	usage      *ApiKeyUsage
+}
+
+// ApiKeyManager authorizes requests by api key, enforces the rate limit and daily quota of each key, and counts
+// the requests of each key. The counters are persisted in the usage file, if one is configured.
+type ApiKeyManager struct {
+	lock       sync.Mutex
+	enableAuth bool
+	keys       map[string]*apiKey
+	usagePath  string
+	dirty      bool
+	quit       chan struct{}
+	wg         sync.WaitGroup
+	now        func() time.Time
+}
+
+// NewApiKeyManager creates an api key manager for the keys of a relay config.
+func NewApiKeyManager(enableAuth bool, apiKeys string, configs []ApiKeyConfig, usagePath string) (*ApiKeyManager, error) {
+	m := &ApiKeyManager{
+		enableAuth: enableAuth,
+		keys:       make(map[string]*apiKey),
+		usagePath:  usagePath,
+		quit:       make(chan struct{}),
+		now:        time.Now,
+	}
+
+	allScopes := map[ApiKeyScope]bool{API_KEY_SCOPE_READ: true, API_KEY_SCOPE_SEND: true}
+	for _, key := range strings.Split(apiKeys, ",") {
+		if len(key) == 0 {
+			continue
+		}
+		m.keys[key] = &apiKey{scopes: allScopes, usage: &ApiKeyUsage{}}
+	}
+
+	for _, config := range configs {
+		if len(config.Key) == 0 {
+			return nil, errors.New("empty api key in apiKeyConfigs")
+		}
+		if _, ok := m.keys[config.Key]; ok {
+			return nil, errors.New("duplicate api key in apiKeyConfigs")
+		}
+		if config.RequestsPerSecond < 0 || config.Burst < 0 {
+			return nil, errors.New("negative api key rate limit")
+		}
+
+		key := &apiKey{
+			scopes:     allScopes,
+			dailyQuota: config.DailyQuota,
+			usage:      &ApiKeyUsage{},
+		}
+		if len(config.Scopes) > 0 {
+			key.scopes = make(map[ApiKeyScope]bool)
+			for _, scope := range config.Scopes {
+				if allScopes[ApiKeyScope(scope)] == false {
+					return nil, fmt.Errorf("unknown api key scope %s", scope)
+				}
+				key.scopes[ApiKeyScope(scope)] = true
+			}
+		}
+		if config.RequestsPerSecond > 0 {
+			burst := config.Burst
+			if burst == 0 {
+				burst = int(math.Ceil(config.RequestsPerSecond))
+			}
+			key.limiter = rate.NewLimiter(rate.Limit(config.RequestsPerSecond), burst)
+		}
+		m.keys[config.Key] = key
+	}
+
+	if len(usagePath) > 0 {
+		if err := m.loadUsage(); err != nil {
+			return nil, err
+		}
+		m.wg.Add(1)
+		go m.flushLoop()
+	}
+
+	return m, nil
+}
+
+// Authorize checks that the api key can use the api, and counts the request as weight requests against the rate
+// limit and daily quota, for example one per transaction of a batch. If the request is throttled, returns how long
+// the client should wait before retrying.
+func (m *ApiKeyManager) Authorize(key string, scope ApiKeyScope, weight int) (time.Duration, error) {
+	if m.enableAuth == false {
+		return 0, nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	k, ok := m.keys[key]
+	if len(key) == 0 || ok == false {
+		return 0, ErrUnauthorized
+	}
+	if k.scopes[scope] == false {
+		return 0, ErrForbiddenScope
+	}
+
+	if weight < 1 {
+		weight = 1
+	}
+
+	now := m.now()
+	day := now.UTC().Format(usageDayLayout)
+	if k.usage.Day != day {
+		k.usage.Day = day
+		k.usage.DayCount = 0
+	}
+	if k.dailyQuota > 0 && k.usage.DayCount+uint64(weight) > k.dailyQuota {
+		k.usage.Throttled++
+		m.dirty = true
+		year, month, date := now.UTC().Date()
+		return time.Date(year, month, date+1, 0, 0, 0, 0, time.UTC).Sub(now), ErrQuotaExceeded
+	}
+	if k.limiter != nil {
+		reservation := k.limiter.ReserveN(now, weight)
+		if reservation.OK() == false {
+			k.usage.Throttled++
+			m.dirty = true
+			return 0, ErrBurstExceeded
+		}
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			k.usage.Throttled++
+			m.dirty = true
+			return delay, ErrRateLimited
+		}
+	}
+
+	k.usage.DayCount += uint64(weight)
+	k.usage.TotalCount += uint64(weight)
+	m.dirty = true
+	return 0, nil
+}
+
+// AuthorizeRequest authorizes a request with Authorize. If the request is not authorized, returns the status code of
+// the response; the Retry-After header is set for throttled requests.
+func (m *ApiKeyManager) AuthorizeRequest(w http.ResponseWriter, key string, scope ApiKeyScope, weight int) (int, error) {
+	retryAfter, err := m.Authorize(key, scope, weight)
+	switch {
+	case err == nil:
+		return http.StatusOK, nil
+	case errors.Is(err, ErrRateLimited) || errors.Is(err, ErrQuotaExceeded):
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+		return http.StatusTooManyRequests, err
+	case errors.Is(err, ErrBurstExceeded):
+		return http.StatusBadRequest, err
+	case errors.Is(err, ErrForbiddenScope):
+		return http.StatusForbidden, err
+	default:
+		return http.StatusUnauthorized, err
+	}
+}
+
+// Usage returns the usage of the api key.
+func (m *ApiKeyManager) Usage(key string) (ApiKeyUsage, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	k, ok := m.keys[key]
+	if ok == false {
+		return ApiKeyUsage{}, false
+	}
+	return *k.usage, true
+}
+
+// Close stops the manager and writes the usage counters.
+func (m *ApiKeyManager) Close() error {
+	if len(m.usagePath) == 0 {
+		return nil
+	}
+	close(m.quit)
+	m.wg.Wait()
+	return m.flush()
+}
+
+func (m *ApiKeyManager) flushLoop() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(UsageFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.flush(); err != nil {
+				log.Error("ApiKeyManager flush", "error", err, "path", m.usagePath)
+			}
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// loadUsage reads the usage counters of the configured keys from the usage file.
+func (m *ApiKeyManager) loadUsage() error {
+	data, err := os.ReadFile(m.usagePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var usages map[string]*ApiKeyUsage
+	if err := json.Unmarshal(data, &usages); err != nil {
+		return fmt.Errorf("invalid usage file %s: %v", m.usagePath, err)
+	}
+	for key, usage := range usages {
+		if k, ok := m.keys[key]; ok && usage != nil {
+			k.usage = usage
+		}
+	}
+	return nil
+}
+
+// flush writes the usage counters to the usage file, if they changed.
+func (m *ApiKeyManager) flush() error {
+	m.lock.Lock()
+	if m.dirty == false {
+		m.lock.Unlock()
+		return nil
+	}
+	usages := make(map[string]ApiKeyUsage, len(m.keys))
+	for key, k := range m.keys {
+		usages[key] = *k.usage
+	}
+	m.dirty = false
+	m.lock.Unlock()
+
+	data, err := json.MarshalIndent(usages, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := filepath.Join(filepath.Dir(m.usagePath), "."+filepath.Base(m.usagePath)+".tmp")
+	err = os.WriteFile(tmpPath, data, 0600)
+	if err == nil {
+		err = os.Rename(tmpPath, m.usagePath)
+	}
+	if err != nil {
+		m.lock.Lock()
+		m.dirty = true
+		m.lock.Unlock()
+	}
+	return err
+}
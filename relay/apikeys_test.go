@@ -0,0 +1,180 @@
+package relay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApiKeyManager_scopes(t *testing.T) {
+	m, err := NewApiKeyManager(true, "legacy", []ApiKeyConfig{
+		{Key: "reader", Scopes: []string{"read"}},
+		{Key: "sender", Scopes: []string{"send"}},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	tests := []struct {
+		key   string
+		scope ApiKeyScope
+		err   error
+	}{
+		{"legacy", API_KEY_SCOPE_READ, nil},
+		{"legacy", API_KEY_SCOPE_SEND, nil},
+		{"reader", API_KEY_SCOPE_READ, nil},
+		{"reader", API_KEY_SCOPE_SEND, ErrForbiddenScope},
+		{"sender", API_KEY_SCOPE_READ, ErrForbiddenScope},
+		{"sender", API_KEY_SCOPE_SEND, nil},
+		{"unknown", API_KEY_SCOPE_READ, ErrUnauthorized},
+		{"", API_KEY_SCOPE_READ, ErrUnauthorized},
+	}
+	for _, test := range tests {
+		if _, err := m.Authorize(test.key, test.scope, 1); err != test.err {
+			t.Fatalf("failed %s %s %v", test.key, test.scope, err)
+		}
+	}
+
+	m, err = NewApiKeyManager(false, "", nil, "")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if _, err := m.Authorize("", API_KEY_SCOPE_SEND, 1); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	_, err = NewApiKeyManager(true, "", []ApiKeyConfig{{Key: "key", Scopes: []string{"write"}}}, "")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	_, err = NewApiKeyManager(true, "key", []ApiKeyConfig{{Key: "key"}}, "")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestApiKeyManager_limits(t *testing.T) {
+	m, err := NewApiKeyManager(true, "", []ApiKeyConfig{
+		{Key: "limited", RequestsPerSecond: 2, Burst: 2},
+		{Key: "quota", DailyQuota: 3},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.Authorize("limited", API_KEY_SCOPE_READ, 1); err != nil {
+			t.Fatalf("failed %v", err)
+		}
+	}
+	retryAfter, err := m.Authorize("limited", API_KEY_SCOPE_READ, 1)
+	if err != ErrRateLimited || retryAfter != 500*time.Millisecond {
+		t.Fatalf("failed %v %v", err, retryAfter)
+	}
+	now = now.Add(retryAfter)
+	if _, err := m.Authorize("limited", API_KEY_SCOPE_READ, 1); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := m.Authorize("quota", API_KEY_SCOPE_READ, 1); err != nil {
+			t.Fatalf("failed %v", err)
+		}
+	}
+	w := httptest.NewRecorder()
+	code, err := m.AuthorizeRequest(w, "quota", API_KEY_SCOPE_READ, 1)
+	if err != ErrQuotaExceeded || code != http.StatusTooManyRequests {
+		t.Fatalf("failed %v %d", err, code)
+	}
+	//the quota resets at midnight UTC
+	if w.Header().Get("Retry-After") != "3600" {
+		t.Fatalf("failed %s", w.Header().Get("Retry-After"))
+	}
+	now = now.Add(time.Hour)
+	if _, err := m.Authorize("quota", API_KEY_SCOPE_READ, 1); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	usage, ok := m.Usage("quota")
+	if ok == false || usage.Day != "2024-05-02" || usage.DayCount != 1 || usage.TotalCount != 4 || usage.Throttled != 1 {
+		t.Fatalf("failed %v", usage)
+	}
+}
+
+func TestApiKeyManager_weight(t *testing.T) {
+	m, err := NewApiKeyManager(true, "", []ApiKeyConfig{
+		{Key: "limited", RequestsPerSecond: 2, Burst: 4},
+		{Key: "quota", DailyQuota: 5},
+	}, "")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	if _, err := m.Authorize("limited", API_KEY_SCOPE_SEND, 3); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	retryAfter, err := m.Authorize("limited", API_KEY_SCOPE_SEND, 2)
+	if err != ErrRateLimited || retryAfter != 500*time.Millisecond {
+		t.Fatalf("failed %v %v", err, retryAfter)
+	}
+	w := httptest.NewRecorder()
+	code, err := m.AuthorizeRequest(w, "limited", API_KEY_SCOPE_SEND, 5)
+	if err != ErrBurstExceeded || code != http.StatusBadRequest {
+		t.Fatalf("failed %v %d", err, code)
+	}
+
+	if _, err := m.Authorize("quota", API_KEY_SCOPE_SEND, 4); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if _, err := m.Authorize("quota", API_KEY_SCOPE_SEND, 2); err != ErrQuotaExceeded {
+		t.Fatalf("failed %v", err)
+	}
+	if _, err := m.Authorize("quota", API_KEY_SCOPE_SEND, 1); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	usage, ok := m.Usage("quota")
+	if ok == false || usage.DayCount != 5 || usage.TotalCount != 5 || usage.Throttled != 1 {
+		t.Fatalf("failed %v", usage)
+	}
+}
+
+func TestApiKeyManager_usageFile(t *testing.T) {
+	usagePath := filepath.Join(t.TempDir(), "usage.json")
+	configs := []ApiKeyConfig{{Key: "key1"}, {Key: "key2", DailyQuota: 2}}
+
+	m, err := NewApiKeyManager(true, "", configs, usagePath)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		m.Authorize("key2", API_KEY_SCOPE_SEND, 1)
+	}
+	if err = m.Close(); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	//The counters, including the daily count, are restored
+	m, err = NewApiKeyManager(true, "", configs, usagePath)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer m.Close()
+	if _, err := m.Authorize("key2", API_KEY_SCOPE_SEND, 1); err != ErrQuotaExceeded {
+		t.Fatalf("failed %v", err)
+	}
+	usage, _ := m.Usage("key2")
+	if usage.DayCount != 2 || usage.TotalCount != 2 || usage.Throttled != 2 {
+		t.Fatalf("failed %v", usage)
+	}
+	usage, _ = m.Usage("key1")
+	if usage.TotalCount != 0 {
+		t.Fatalf("failed %v", usage)
+	}
+}
@@ -3,13 +3,25 @@ package relay
 import "errors"
 
 var (
-	InfoTitleLatestBlockDetails      = "Get latest block details"
-	InfoTitleAccountDetails          = "Get account details"
-	InfoTitleTransaction             = "Get Transaction"
-	InfoTitleSendTransaction         = "Send Transaction"
-	InfoTitleListAccountTransactions = "List Account Transactions"
-	InfoTitleGetBlockchainDetails    = "Get Blockchain details"
-	InfoTitleQueryDetails            = "Query details"
+	InfoTitleLatestBlockDetails        = "Get latest block details"
+	InfoTitleAccountDetails            = "Get account details"
+	InfoTitleTransaction               = "Get Transaction"
+	InfoTitleSendTransaction           = "Send Transaction"
+	InfoTitleSendTransactions          = "Send Transactions"
+	InfoTitleListAccountTransactions   = "List Account Transactions"
+	InfoTitleGetBlockchainDetails      = "Get Blockchain details"
+	InfoTitleQueryDetails              = "Query details"
+	InfoTitleListAccountTokens         = "List Account Tokens"
+	InfoTitleGetTokenDetails           = "Get Token details"
+	InfoTitleListTokenTransfers        = "List Token Transfers"
+	InfoTitleTransactionStatus         = "Get Transaction Status"
+	InfoTitleGetBlockDetails           = "Get Block details"
+	InfoTitleListBlocks                = "List Blocks"
+	InfoTitleListBlockTransactions     = "List Block Transactions"
+	InfoTitleListValidators            = "List Validators"
+	InfoTitleGetValidatorDetails       = "Get Validator details"
+	InfoTitleListStakingHistory        = "List Staking History"
+	InfoTitleListTransactionsByRemarks = "List Transactions By Remarks"
 )
 
 var (
@@ -27,6 +39,7 @@ var (
 	MsgTimeDuration       = "Time Duration"
 	MsgStatus             = "Status"
 	MsgError              = "Error"
+	MsgValidator          = "Validator"
 )
 
 var (
@@ -38,15 +51,22 @@ var (
 )
 
 type RelayConfig struct {
-	Api                string `json:"api"`
-	Ip                 string `json:"ip"`
-	Port               string `json:"port"`
-	NodeUrl            string `json:"nodeUrl"`
-	CorsAllowedOrigins string `json:"corsAllowedOrigins"`
-	EnableAuth         bool   `json:"enableAuth"`
-	ApiKeys            string `json:"apiKeys"`
-	CachePath          string `json:"cachePath"`
-	EnableExtendedApis bool   `json:"enableExtendedApis"`
-	GenesisFilePath    string `json:"genesisFilePath"`
-	MaxSupply          string `json:"maxSupply"`
+	Api                string         `json:"api"`
+	Ip                 string         `json:"ip"`
+	Port               string         `json:"port"`
+	NodeUrl            string         `json:"nodeUrl"`
+	NodeUrls           []string       `json:"nodeUrls"` //additional nodes, used with nodeUrl
+	CorsAllowedOrigins string         `json:"corsAllowedOrigins"`
+	EnableAuth         bool           `json:"enableAuth"`
+	ApiKeys            string         `json:"apiKeys"`
+	CachePath          string         `json:"cachePath"`
+	EnableExtendedApis bool           `json:"enableExtendedApis"`
+	GenesisFilePath    string         `json:"genesisFilePath"`
+	MaxSupply          string         `json:"maxSupply"`
+	MetricsAddr        string         `json:"metricsAddr"`
+	ApiKeyConfigs      []ApiKeyConfig `json:"apiKeyConfigs"`
+	UsageFilePath      string         `json:"usageFilePath"`
+	TrackerPath        string         `json:"trackerPath"`   //write api: directory of the sent transactions, tracking is disabled if empty
+	WebhookUrl         string         `json:"webhookUrl"`    //write api: status changes of the sent transactions are posted here
+	WebhookSecret      string         `json:"webhookSecret"` //write api: the webhook requests are signed with HMAC-SHA256 if set
 }
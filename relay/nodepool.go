@@ -0,0 +1,363 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/rpc"
+	"sort"
+	"sync"
+	"time"
+)
+
+// NodeProbeInterval is how often the nodes of a pool are probed.
+var NodeProbeInterval = 2 * time.Second
+
+// NodeProbeTimeout is the timeout of a probe of a node.
+var NodeProbeTimeout = 2 * time.Second
+
+// MaxNodeBlockLag is the number of blocks a node can be behind the highest node of the pool and still be in sync.
+var MaxNodeBlockLag uint64 = 2
+
+// SendTransactionFanout is the number of nodes a transaction is sent to.
+var SendTransactionFanout = 3
+
+var ErrNoHealthyNode = errors.New("no healthy node available")
+
+// ErrNullTransactionHash is returned when a node returns no hash, and no error, for a sent transaction.
+var ErrNullTransactionHash = errors.New("node returned no transaction hash")
+
+// poolNode is an upstream node of a pool.
+type poolNode struct {
+	url         string
+	client      *rpc.Client
+	healthy     bool
+	blockNumber uint64
+	latency     time.Duration
+}
+
+// NodeStatus is the state of a node of a pool, as of its last probe.
+type NodeStatus struct {
+	Url         string
+	Healthy     bool
+	InSync      bool
+	BlockNumber uint64
+	Latency     time.Duration
+}
+
+// NodePool keeps a connection to each upstream node of the relay, and probes the nodes for liveness and their latest
+// block. Calls are sent to the in-sync node with the lowest latency, and fail over to the next node when a node cannot
+// be reached.
+type NodePool struct {
+	lock  sync.RWMutex
+	nodes []*poolNode
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewNodePool creates a pool for the node urls, and probes the nodes once before returning.
+func NewNodePool(urls []string) (*NodePool, error) {
+	p := &NodePool{
+		quit: make(chan struct{}),
+	}
+
+	added := make(map[string]bool)
+	for _, url := range urls {
+		if len(url) == 0 || added[url] {
+			continue
+		}
+		added[url] = true
+		p.nodes = append(p.nodes, &poolNode{url: url})
+	}
+	if len(p.nodes) == 0 {
+		return nil, errors.New("no node url")
+	}
+
+	p.probe()
+	p.wg.Add(1)
+	go p.probeLoop()
+
+	return p, nil
+}
+
+// Close stops probing the nodes and closes the connections.
+func (p *NodePool) Close() {
+	close(p.quit)
+	p.wg.Wait()
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, node := range p.nodes {
+		if node.client != nil {
+			node.client.Close()
+			node.client = nil
+		}
+	}
+}
+
+func (p *NodePool) probeLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(NodeProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.probe()
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// probe updates the block number and latency of all the nodes concurrently.
+func (p *NodePool) probe() {
+	p.lock.RLock()
+	nodes := make([]*poolNode, len(p.nodes))
+	copy(nodes, p.nodes)
+	p.lock.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *poolNode) {
+			defer wg.Done()
+			p.probeNode(node)
+		}(node)
+	}
+	wg.Wait()
+}
+
+func (p *NodePool) probeNode(node *poolNode) {
+	ctx, cancel := context.WithTimeout(context.Background(), NodeProbeTimeout)
+	defer cancel()
+
+	p.lock.RLock()
+	client := node.client
+	p.lock.RUnlock()
+
+	var err error
+	if client == nil {
+		client, err = rpc.DialContext(ctx, node.url)
+		if err == nil {
+			p.lock.Lock()
+			node.client = client
+			p.lock.Unlock()
+		}
+	}
+
+	var blockNumber hexutil.Uint64
+	startTime := time.Now()
+	if err == nil {
+		err = client.CallContext(ctx, &blockNumber, "eth_blockNumber")
+	}
+	latency := time.Since(startTime)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if err != nil {
+		if node.healthy {
+			log.Warn("Node is unavailable", "url", node.url, "error", err)
+		}
+		node.healthy = false
+		return
+	}
+	if node.healthy == false {
+		log.Info("Node is available", "url", node.url, "blockNumber", uint64(blockNumber))
+	}
+	node.healthy = true
+	node.blockNumber = uint64(blockNumber)
+	node.latency = latency
+}
+
+// candidates returns the healthy nodes that are in sync, by latency. Nodes that are more than MaxNodeBlockLag blocks
+// behind the highest healthy node are not used until they catch up.
+func (p *NodePool) candidates() []*poolNode {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	highest := p.highestBlock()
+	nodes := make([]*poolNode, 0, len(p.nodes))
+	for _, node := range p.nodes {
+		if node.healthy && node.client != nil && node.blockNumber+MaxNodeBlockLag >= highest {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].latency < nodes[j].latency
+	})
+	return nodes
+}
+
+// highestBlock returns the highest block of the healthy nodes. Must be called with the lock held.
+func (p *NodePool) highestBlock() uint64 {
+	var highest uint64
+	for _, node := range p.nodes {
+		if node.healthy && node.blockNumber > highest {
+			highest = node.blockNumber
+		}
+	}
+	return highest
+}
+
+// markUnhealthy takes a node out of rotation until its next successful probe.
+func (p *NodePool) markUnhealthy(node *poolNode, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if node.healthy {
+		log.Warn("Node is unavailable", "url", node.url, "error", err)
+	}
+	node.healthy = false
+}
+
+// isNodeFailure returns whether the error is caused by the node being unreachable, rather than by the call.
+func isNodeFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr) == false
+}
+
+// Client returns the client of the preferred node.
+func (p *NodePool) Client() (*rpc.Client, error) {
+	nodes := p.candidates()
+	if len(nodes) == 0 {
+		return nil, ErrNoHealthyNode
+	}
+	return nodes[0].client, nil
+}
+
+// CallContext performs a JSON-RPC call on the preferred node. If the node cannot be reached, the call is retried on
+// the next node. The candidates are taken again after each failure, since a node that was behind the failed node may
+// now be in sync.
+func (p *NodePool) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return p.call(ctx, func(client *rpc.Client) error {
+		return client.CallContext(ctx, result, method, args...)
+	})
+}
+
+// BatchCallContext sends a batch of JSON-RPC calls to the preferred node, failing over like CallContext. The errors of
+// the individual calls are set on the batch elements and do not cause a failover.
+func (p *NodePool) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return p.call(ctx, func(client *rpc.Client) error {
+		return client.BatchCallContext(ctx, b)
+	})
+}
+
+// call runs the call on the preferred node, and on the next node each time a node cannot be reached.
+func (p *NodePool) call(ctx context.Context, call func(client *rpc.Client) error) error {
+	err := ErrNoHealthyNode
+	tried := make(map[*poolNode]bool)
+	for {
+		var node *poolNode
+		for _, candidate := range p.candidates() {
+			if tried[candidate] == false {
+				node = candidate
+				break
+			}
+		}
+		if node == nil {
+			return err
+		}
+		tried[node] = true
+
+		err = call(node.client)
+		if isNodeFailure(ctx, err) == false {
+			return err
+		}
+		p.markUnhealthy(node, err)
+	}
+}
+
+// SendRawTransaction sends the transaction to up to SendTransactionFanout nodes concurrently. The transaction is
+// sent if any node accepts it. If no node accepts it, and no node returns an error for the transaction itself, it is
+// sent to the next nodes; otherwise the error of the preferred node is returned.
+func (p *NodePool) SendRawTransaction(ctx context.Context, rawTxHex string) (*common.Hash, error) {
+	err := ErrNoHealthyNode
+	tried := make(map[*poolNode]bool)
+	for {
+		var nodes []*poolNode
+		for _, candidate := range p.candidates() {
+			if tried[candidate] == false && len(nodes) < SendTransactionFanout {
+				nodes = append(nodes, candidate)
+				tried[candidate] = true
+			}
+		}
+		if len(nodes) == 0 {
+			return nil, err
+		}
+
+		var hash *common.Hash
+		hash, err = p.sendRawTransaction(ctx, nodes, rawTxHex)
+		if hash != nil {
+			return hash, nil
+		}
+		//A node returning no hash counts as a node failure, so the transaction is sent to the next nodes
+		if isNodeFailure(ctx, err) == false {
+			return nil, err
+		}
+	}
+}
+
+// sendRawTransaction sends the transaction to the nodes concurrently, and returns the hash of the first node that
+// accepts it. Otherwise it returns the error of the preferred node, preferring the error returned by a node for the
+// transaction, such as an invalid nonce, to a node returning no hash, and both to a connection error.
+func (p *NodePool) sendRawTransaction(ctx context.Context, nodes []*poolNode, rawTxHex string) (*common.Hash, error) {
+	hashes := make([]*common.Hash, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *poolNode) {
+			defer wg.Done()
+			errs[i] = node.client.CallContext(ctx, &hashes[i], "eth_sendRawTransaction", rawTxHex)
+			if errs[i] == nil && hashes[i] == nil {
+				log.Warn("Node returned no transaction hash", "url", node.url)
+				errs[i] = ErrNullTransactionHash
+			}
+			if isNodeFailure(ctx, errs[i]) && errs[i] != ErrNullTransactionHash {
+				p.markUnhealthy(node, errs[i])
+			}
+		}(i, node)
+	}
+	wg.Wait()
+
+	for i := range nodes {
+		if errs[i] == nil {
+			return hashes[i], nil
+		}
+	}
+	for i := range nodes {
+		if isNodeFailure(ctx, errs[i]) == false {
+			return nil, errs[i]
+		}
+	}
+	for i := range nodes {
+		if errs[i] == ErrNullTransactionHash {
+			return nil, errs[i]
+		}
+	}
+	return nil, errs[0]
+}
+
+// Status returns the state of the nodes, as of their last probe.
+func (p *NodePool) Status() []NodeStatus {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	highest := p.highestBlock()
+	status := make([]NodeStatus, len(p.nodes))
+	for i, node := range p.nodes {
+		status[i] = NodeStatus{
+			Url:         node.url,
+			Healthy:     node.healthy,
+			InSync:      node.healthy && node.blockNumber+MaxNodeBlockLag >= highest,
+			BlockNumber: node.blockNumber,
+			Latency:     node.latency,
+		}
+	}
+	return status
+}
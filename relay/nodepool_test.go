@@ -0,0 +1,189 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/rpc"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type testNodeService struct {
+	blockNumber uint64
+	sendErr     error
+	sendNull    bool
+	sent        int32
+}
+
+func (s *testNodeService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(atomic.LoadUint64(&s.blockNumber))
+}
+
+func (s *testNodeService) SendRawTransaction(rawTxHex string) (*common.Hash, error) {
+	atomic.AddInt32(&s.sent, 1)
+	if s.sendErr != nil || s.sendNull {
+		return nil, s.sendErr
+	}
+	txHash := common.BytesToHash([]byte(rawTxHex))
+	return &txHash, nil
+}
+
+func newTestNode(t *testing.T, blockNumber uint64) (*testNodeService, *httptest.Server) {
+	service := &testNodeService{blockNumber: blockNumber}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return service, httpServer
+}
+
+func TestNodePool_failover(t *testing.T) {
+	_, behind := newTestNode(t, 90)
+	_, down := newTestNode(t, 100)
+	_, synced := newTestNode(t, 100)
+	down.Close()
+
+	pool, err := NewNodePool([]string{behind.URL, down.URL, synced.URL, synced.URL, ""})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer pool.Close()
+
+	status := pool.Status()
+	if len(status) != 3 {
+		t.Fatalf("failed %v", status)
+	}
+	if status[0].Healthy == false || status[0].InSync || status[1].Healthy || status[2].InSync == false {
+		t.Fatalf("failed %v", status)
+	}
+
+	var blockNumber hexutil.Uint64
+	if err = pool.CallContext(context.Background(), &blockNumber, "eth_blockNumber"); err != nil || blockNumber != 100 {
+		t.Fatalf("failed %v %d", err, blockNumber)
+	}
+
+	//The call fails over to the node that is behind
+	synced.Close()
+	if err = pool.CallContext(context.Background(), &blockNumber, "eth_blockNumber"); err != nil || blockNumber != 90 {
+		t.Fatalf("failed %v %d", err, blockNumber)
+	}
+	status = pool.Status()
+	if status[2].Healthy || status[0].InSync == false {
+		t.Fatalf("failed %v", status)
+	}
+
+	behind.Close()
+	if err = pool.CallContext(context.Background(), &blockNumber, "eth_blockNumber"); err == nil {
+		t.Fatalf("expected error")
+	}
+	if _, err = pool.Client(); err != ErrNoHealthyNode {
+		t.Fatalf("failed %v", err)
+	}
+}
+
+func TestNodePool_batchFailover(t *testing.T) {
+	_, down := newTestNode(t, 100)
+	_, synced := newTestNode(t, 100)
+
+	pool, err := NewNodePool([]string{down.URL, synced.URL})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer pool.Close()
+
+	//The batch fails over to the other node when a node goes down after its probe
+	down.Close()
+	var blockNumber hexutil.Uint64
+	batch := []rpc.BatchElem{{Method: "eth_blockNumber", Result: &blockNumber}}
+	if err = pool.BatchCallContext(context.Background(), batch); err != nil || batch[0].Error != nil || blockNumber != 100 {
+		t.Fatalf("failed %v %v %d", err, batch[0].Error, blockNumber)
+	}
+
+	//The error of a call in the batch does not cause a failover
+	batch = []rpc.BatchElem{{Method: "eth_unknown", Result: &blockNumber}}
+	if err = pool.BatchCallContext(context.Background(), batch); err != nil || batch[0].Error == nil {
+		t.Fatalf("failed %v %v", err, batch[0].Error)
+	}
+	if status := pool.Status(); status[1].Healthy == false {
+		t.Fatalf("failed %v", status)
+	}
+}
+
+func TestNodePool_sendRawTransaction(t *testing.T) {
+	rejecting, rejectingServer := newTestNode(t, 100)
+	rejecting.sendErr = errors.New("nonce too low")
+	accepting, acceptingServer := newTestNode(t, 100)
+	behind, behindServer := newTestNode(t, 90)
+
+	pool, err := NewNodePool([]string{rejectingServer.URL, acceptingServer.URL, behindServer.URL})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer pool.Close()
+
+	txHash, err := pool.SendRawTransaction(context.Background(), "0x01")
+	if err != nil || *txHash != common.BytesToHash([]byte("0x01")) {
+		t.Fatalf("failed %v %v", err, txHash)
+	}
+	if atomic.LoadInt32(&rejecting.sent) != 1 || atomic.LoadInt32(&accepting.sent) != 1 {
+		t.Fatalf("failed %d %d", rejecting.sent, accepting.sent)
+	}
+	//The node that is behind is not used while other nodes are in sync
+	if atomic.LoadInt32(&behind.sent) != 0 {
+		t.Fatalf("failed %d", behind.sent)
+	}
+
+	//The error of a node is returned rather than the connection error of another node
+	acceptingServer.Close()
+	_, err = pool.SendRawTransaction(context.Background(), "0x01")
+	if err == nil || err.Error() != "nonce too low" {
+		t.Fatalf("failed %v", err)
+	}
+}
+
+func TestNodePool_sendRawTransactionNull(t *testing.T) {
+	defer func(fanout int) { SendTransactionFanout = fanout }(SendTransactionFanout)
+	SendTransactionFanout = 1
+
+	null, nullServer := newTestNode(t, 100)
+	null.sendNull = true
+	accepting, acceptingServer := newTestNode(t, 100)
+
+	pool, err := NewNodePool([]string{nullServer.URL, acceptingServer.URL})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer pool.Close()
+
+	//The transaction is sent to the next node when a node returns no hash
+	txHash, err := pool.SendRawTransaction(context.Background(), "0x01")
+	if err != nil || txHash == nil || *txHash != common.BytesToHash([]byte("0x01")) {
+		t.Fatalf("failed %v %v", err, txHash)
+	}
+	if atomic.LoadInt32(&accepting.sent) != 1 {
+		t.Fatalf("failed %d", accepting.sent)
+	}
+
+	accepting.sendNull = true
+	txHash, err = pool.SendRawTransaction(context.Background(), "0x01")
+	if err != ErrNullTransactionHash || txHash != nil {
+		t.Fatalf("failed %v %v", err, txHash)
+	}
+	if atomic.LoadInt32(&accepting.sent) != 2 {
+		t.Fatalf("failed %d", accepting.sent)
+	}
+	//A node returning no hash stays in rotation
+	for _, status := range pool.Status() {
+		if status.Healthy == false {
+			t.Fatalf("failed %v", status)
+		}
+	}
+}
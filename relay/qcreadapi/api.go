@@ -12,6 +12,7 @@ package qcreadapi
 
 import (
 	"context"
+	"github.com/QuantumCoinProject/qc/cachemanager"
 	"net/http"
 )
 
@@ -27,6 +28,17 @@ type ReadApiAPIRouter interface {
 	ListAccountTransactions(http.ResponseWriter, *http.Request)
 	GetBlockchainDetails(http.ResponseWriter, *http.Request)
 	QueryDetails(http.ResponseWriter, *http.Request)
+	ListAccountTokens(http.ResponseWriter, *http.Request)
+	GetTokenDetails(http.ResponseWriter, *http.Request)
+	ListTokenTransfers(http.ResponseWriter, *http.Request)
+	GetBlockByNumber(http.ResponseWriter, *http.Request)
+	GetBlockByHash(http.ResponseWriter, *http.Request)
+	ListBlocks(http.ResponseWriter, *http.Request)
+	ListBlockTransactions(http.ResponseWriter, *http.Request)
+	ListValidators(http.ResponseWriter, *http.Request)
+	GetValidatorDetails(http.ResponseWriter, *http.Request)
+	ListStakingHistory(http.ResponseWriter, *http.Request)
+	ListTransactionsByRemarks(http.ResponseWriter, *http.Request)
 }
 
 
@@ -39,6 +51,18 @@ type ReadApiAPIServicer interface {
 	GetAccountDetails(context.Context, string) (ImplResponse, error)
 	GetTransactionDetails(context.Context, string) (ImplResponse, error)
 	ListAccountTransactions(context.Context, string, int64) (ImplResponse, error)
+	QueryAccountTransactions(context.Context, string, cachemanager.AccountTransactionQuery) (ImplResponse, error)
 	GetBlockchainDetails(context.Context) (ImplResponse, error)
 	QueryDetails(context.Context, string) (ImplResponse, error)
+	ListAccountTokens(context.Context, string) (ImplResponse, error)
+	GetTokenDetails(context.Context, string) (ImplResponse, error)
+	ListTokenTransfers(context.Context, string, int64) (ImplResponse, error)
+	GetBlockByNumber(context.Context, uint64) (ImplResponse, error)
+	GetBlockByHash(context.Context, string) (ImplResponse, error)
+	ListBlocks(context.Context, int64) (ImplResponse, error)
+	ListBlockTransactions(context.Context, uint64, int64) (ImplResponse, error)
+	ListValidators(context.Context) (ImplResponse, error)
+	GetValidatorDetails(context.Context, string) (ImplResponse, error)
+	ListStakingHistory(context.Context, string, int64) (ImplResponse, error)
+	ListTransactionsByRemarks(context.Context, string, string, int64) (ImplResponse, error)
 }
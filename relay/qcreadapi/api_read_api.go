@@ -12,12 +12,15 @@ package qcreadapi
 
 import (
 	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/cachemanager"
 	"github.com/QuantumCoinProject/qc/common"
 	"github.com/QuantumCoinProject/qc/log"
 	"github.com/QuantumCoinProject/qc/relay"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -30,8 +33,8 @@ type ReadApiAPIController struct {
 	service ReadApiAPIServicer
 	errorHandler ErrorHandler
 	corsAllowedOrigins string
-	enableAuth bool
-	apiKeysMap map[string]bool
+	apiKeyManager *relay.ApiKeyManager
+	streamHub *StreamHub
 }
 
 // ReadApiAPIOption for how the controller is set up.
@@ -44,24 +47,20 @@ func WithReadApiAPIErrorHandler(h ErrorHandler) ReadApiAPIOption {
 	}
 }
 
+// WithReadApiAPIStreamHub enables the streaming endpoints, which send the events of the hub
+func WithReadApiAPIStreamHub(hub *StreamHub) ReadApiAPIOption {
+	return func(c *ReadApiAPIController) {
+		c.streamHub = hub
+	}
+}
+
 // NewReadApiAPIController creates a default api controller
-func NewReadApiAPIController(s ReadApiAPIServicer, corsAllowedOrigins string, enableAuth bool, apiKeys string, opts ...ReadApiAPIOption) *ReadApiAPIController {
+func NewReadApiAPIController(s ReadApiAPIServicer, corsAllowedOrigins string, apiKeyManager *relay.ApiKeyManager, opts ...ReadApiAPIOption) *ReadApiAPIController {
 	controller := &ReadApiAPIController{
 		service:      s,
 		errorHandler: DefaultErrorHandler,
 		corsAllowedOrigins: corsAllowedOrigins,
-		enableAuth: enableAuth,
-		apiKeysMap: make(map[string]bool),
-	}
-
-	if len(apiKeys) > 0 {
-		apiKeyList := strings.Split(apiKeys, ",")
-		for _, key := range apiKeyList {
-			if len(key) == 0 {
-				continue
-			}
-			controller.apiKeysMap[key] = true
-		}
+		apiKeyManager: apiKeyManager,
 	}
 
 	for _, opt := range opts {
@@ -94,6 +93,11 @@ func (c *ReadApiAPIController) Routes() Routes {
 			"/account/{address}/transactions/{pageNumber}",
 			c.ListAccountTransactions,
 		},
+		"QueryAccountTransactions": Route{
+			strings.ToUpper("Get"),
+			"/account/{address}/transactions",
+			c.ListAccountTransactions,
+		},
 		"GetBlockchainDetailsResponse": Route{
 			strings.ToUpper("Get"),
 			"/blockchaindetails",
@@ -104,6 +108,71 @@ func (c *ReadApiAPIController) Routes() Routes {
 			"/api",
 			c.QueryDetails,
 		},
+		"ListAccountTokens": Route{
+			strings.ToUpper("Get"),
+			"/account/{address}/tokens",
+			c.ListAccountTokens,
+		},
+		"GetTokenDetails": Route{
+			strings.ToUpper("Get"),
+			"/token/{address}",
+			c.GetTokenDetails,
+		},
+		"ListTokenTransfers": Route{
+			strings.ToUpper("Get"),
+			"/token/{address}/transfers/{pageNumber}",
+			c.ListTokenTransfers,
+		},
+		"GetBlockByNumber": Route{
+			strings.ToUpper("Get"),
+			"/block/{number}",
+			c.GetBlockByNumber,
+		},
+		"GetBlockByHash": Route{
+			strings.ToUpper("Get"),
+			"/block/hash/{hash}",
+			c.GetBlockByHash,
+		},
+		"ListBlocks": Route{
+			strings.ToUpper("Get"),
+			"/blocks/{pageNumber}",
+			c.ListBlocks,
+		},
+		"ListBlockTransactions": Route{
+			strings.ToUpper("Get"),
+			"/block/{number}/transactions/{pageNumber}",
+			c.ListBlockTransactions,
+		},
+		"ListValidators": Route{
+			strings.ToUpper("Get"),
+			"/validators",
+			c.ListValidators,
+		},
+		"GetValidatorDetails": Route{
+			strings.ToUpper("Get"),
+			"/validator/{address}",
+			c.GetValidatorDetails,
+		},
+		"ListStakingHistory": Route{
+			strings.ToUpper("Get"),
+			"/account/{address}/staking/{pageNumber}",
+			c.ListStakingHistory,
+		},
+		"ListTransactionsByRemarks": Route{
+			strings.ToUpper("Get"),
+			"/account/{address}/remarks/{remarks}/transactions/{pageNumber}",
+			c.ListTransactionsByRemarks,
+		},
+		"StreamEvents": Route{
+			strings.ToUpper("Get"),
+			"/stream/events",
+			c.StreamEvents,
+		},
+		"StreamEventsWebSocket": Route{
+			strings.ToUpper("Get"),
+			"/stream/ws",
+			c.StreamEventsWebSocket,
+		},
 	}
 }
 
@@ -114,26 +183,14 @@ func (c *ReadApiAPIController) setupCORS(w *http.ResponseWriter, req *http.Reque
 	(*w).Header().Set("Access-Control-Allow-Headers", "*")
 }
 
-func (c *ReadApiAPIController) authorize(req *http.Request) bool {
-	if c.enableAuth == false {
-		return  true
-	}
-
-	if req.Header == nil {
-		return  false
-	}
-
-	apiKey := req.Header.Get(API_KEY_HEADER_NAME)
-
-	if len(apiKey) == 0 {
-		return false
-	}
-
-	if c.apiKeysMap[apiKey] == true {
-		return true
+// authorize checks the api key of the request. Returns the status code of the response if it is not authorized.
+func (c *ReadApiAPIController) authorize(w http.ResponseWriter, req *http.Request) (int, error) {
+	apiKey := ""
+	if req.Header != nil {
+		apiKey = req.Header.Get(API_KEY_HEADER_NAME)
 	}
 
-	return false
+	return c.apiKeyManager.AuthorizeRequest(w, apiKey, relay.API_KEY_SCOPE_READ, 1)
 }
 
 // GetLatestBlockDetails - Get latest block details
@@ -153,13 +210,10 @@ func (c *ReadApiAPIController) GetLatestBlockDetails(w http.ResponseWriter, r *h
 		return
 	}
 
-	if c.authorize(r) == false {
-		result := Response(http.StatusUnauthorized, nil)
-		// If no error, encode the body and the result code
-		_ = EncodeJSONResponse(result.Body, &result.Code, w)
-
-		log.Error("GetLatestBlockDetails", "requestId", requestId, "error", "Unauthorized");
-		c.errorHandler(w, r, errors.New("Unauthorized"), &result)
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("GetLatestBlockDetails", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
 		return
 	}
 
@@ -192,14 +246,10 @@ func (c *ReadApiAPIController) GetAccountDetails(w http.ResponseWriter, r *http.
 		return
 	}
 
-	if c.authorize(r) == false {
-		result := Response(http.StatusUnauthorized, nil)
-		// If no error, encode the body and the result code
-		_ = EncodeJSONResponse(result.Body, &result.Code, w)
-
-		log.Error("GetAccountDetails", "requestId", requestId, "error", "Unauthorized");
-
-		c.errorHandler(w, r, errors.New("Unauthorized"), &result)
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("GetAccountDetails", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
 		return
 	}
 
@@ -247,13 +297,10 @@ func (c *ReadApiAPIController) GetTransactionDetails(w http.ResponseWriter, r *h
 		return
 	}
 
-	if c.authorize(r) == false {
-		result := Response(http.StatusUnauthorized, nil)
-		// If no error, encode the body and the result code
-		_ = EncodeJSONResponse(result.Body, &result.Code, w)
-
-		log.Error("GetTransactionDetails OPTIONS", "requestId", requestId, "error", "Unauthorized")
-		c.errorHandler(w, r, errors.New("Unauthorized"), &result)
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("GetTransactionDetails", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
 		return
 	}
 
@@ -299,13 +346,10 @@ func (c *ReadApiAPIController) GetBlockchainDetails(w http.ResponseWriter, r *ht
 		return
 	}
 
-	if c.authorize(r) == false {
-		result := Response(http.StatusUnauthorized, nil)
-		// If no error, encode the body and the result code
-		_ = EncodeJSONResponse(result.Body, &result.Code, w)
-
-		log.Error("GetBlockchainDetails", "requestId", requestId, "error", "Unauthorized")
-		c.errorHandler(w, r, errors.New("Unauthorized"), &result)
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("GetBlockchainDetails", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
 		return
 	}
 
@@ -338,13 +382,10 @@ func (c *ReadApiAPIController) ListAccountTransactions(w http.ResponseWriter, r
 		return
 	}
 
-	if c.authorize(r) == false {
-		result := Response(http.StatusUnauthorized, nil)
-		// If no error, encode the body and the result code
-		_ = EncodeJSONResponse(result.Body, &result.Code, w)
-
-		log.Error("ListAccountTransactions", "requestId", requestId, "error", "Unauthorized")
-		c.errorHandler(w, r, errors.New("Unauthorized"), &result)
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("ListAccountTransactions", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
 		return
 	}
 
@@ -362,9 +403,33 @@ func (c *ReadApiAPIController) ListAccountTransactions(w http.ResponseWriter, r
 		return
 	}
 
-	pageNumber := int64(-1)
-	pageNumberParam := params["pageNumber"]
+	var result ImplResponse
 	var err error
+	pageNumberParam, ok := params["pageNumber"]
+	if ok == false {
+		//queried by cursor
+		query, err := parseAccountTransactionQuery(r)
+		if err != nil {
+			c.errorHandler(w, r, err, nil)
+			log.Error("ListAccountTransactions", "requestId", requestId, "error", err)
+			return
+		}
+		result, err = c.service.QueryAccountTransactions(r.Context(), addressParam, query)
+		// If an error occurred, encode the error with the status code
+		if err != nil {
+			c.errorHandler(w, r, err, &result)
+			log.Error("ListAccountTransactions", "requestId", requestId, "error", err)
+			return
+		}
+
+		// If no error, encode the body and the result code
+		_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+		log.Info("ListAccountTransactions ok", "requestId", requestId)
+		return
+	}
+
+	pageNumber := int64(-1)
 	if len(pageNumberParam) > 0 {
 		pageNumber, err = strconv.ParseInt(pageNumberParam, 10, 64)
 		if err != nil {
@@ -377,7 +442,7 @@ func (c *ReadApiAPIController) ListAccountTransactions(w http.ResponseWriter, r
 		}
 	}
 
-	result, err := c.service.ListAccountTransactions(r.Context(), addressParam, pageNumber)
+	result, err = c.service.ListAccountTransactions(r.Context(), addressParam, pageNumber)
 	// If an error occurred, encode the error with the status code
 	if err != nil {
 		c.errorHandler(w, r, err, &result)
@@ -391,6 +456,52 @@ func (c *ReadApiAPIController) ListAccountTransactions(w http.ResponseWriter, r
 	log.Info("ListAccountTransactions ok", "requestId", requestId)
 }
 
+// parseAccountTransactionQuery parses the query parameters of a cursor based account transactions query.
+func parseAccountTransactionQuery(r *http.Request) (cachemanager.AccountTransactionQuery, error) {
+	values := r.URL.Query()
+	query := cachemanager.AccountTransactionQuery{
+		Cursor: values.Get("cursor"),
+		Order: cachemanager.QueryOrder(values.Get("order")),
+		Direction: cachemanager.QueryDirection(values.Get("direction")),
+		TransactionType: values.Get("type"),
+		Status: values.Get("status"),
+	}
+
+	var err error
+	if limit := values.Get("limit"); len(limit) > 0 {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > cachemanager.MaxQueryLimit {
+			return query, &ParsingError{"limit", fmt.Errorf("must be between 1 and %d", cachemanager.MaxQueryLimit)}
+		}
+	}
+	if fromBlock := values.Get("fromBlock"); len(fromBlock) > 0 {
+		query.FromBlock, err = strconv.ParseUint(fromBlock, 10, 64)
+		if err != nil {
+			return query, &ParsingError{"fromBlock", err}
+		}
+	}
+	if toBlock := values.Get("toBlock"); len(toBlock) > 0 {
+		query.ToBlock, err = strconv.ParseUint(toBlock, 10, 64)
+		if err != nil {
+			return query, &ParsingError{"toBlock", err}
+		}
+	}
+	if fromTime := values.Get("fromTime"); len(fromTime) > 0 {
+		query.FromTime, err = time.Parse(time.RFC3339, fromTime)
+		if err != nil {
+			return query, &ParsingError{"fromTime", err}
+		}
+	}
+	if toTime := values.Get("toTime"); len(toTime) > 0 {
+		query.ToTime, err = time.Parse(time.RFC3339, toTime)
+		if err != nil {
+			return query, &ParsingError{"toTime", err}
+		}
+	}
+
+	return query, nil
+}
+
 
 func (c *ReadApiAPIController) QueryDetails(w http.ResponseWriter, r *http.Request) {
 	requestId := ""
@@ -406,13 +517,10 @@ func (c *ReadApiAPIController) QueryDetails(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if c.authorize(r) == false {
-		result := Response(http.StatusUnauthorized, nil)
-		// If no error, encode the body and the result code
-		_ = EncodeJSONResponse(result.Body, &result.Code, w)
-
-		log.Error("QueryDetails", "requestId", requestId, "error", "Unauthorized")
-		c.errorHandler(w, r, errors.New("Unauthorized"), &result)
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("QueryDetails", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
 		return
 	}
 
@@ -431,4 +539,609 @@ func (c *ReadApiAPIController) QueryDetails(w http.ResponseWriter, r *http.Reque
 	_ = EncodeTextResponse(result.Body, &result.Code, w)
 
 	log.Info("QueryDetails ok", "requestId", requestId)
-}
\ No newline at end of file
+}
+// ListAccountTokens - List the tokens held by an account
+func (c *ReadApiAPIController) ListAccountTokens(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("ListAccountTokens", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("ListAccountTokens", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	addressParam := params["address"]
+	if addressParam == "" {
+		c.errorHandler(w, r, &RequiredError{"address"}, nil)
+		log.Error("ListAccountTokens address is empty", "requestId", requestId)
+		return
+	}
+
+	if !common.IsHexAddressDeep(addressParam) {
+		log.Error(relay.MsgAddress, relay.MsgAddress, addressParam, relay.MsgError, relay.ErrInvalidAddress, relay.MsgStatus, http.StatusBadRequest, "requestId", requestId)
+		c.errorHandler(w, r, &ParsingError{"address", errors.New("Invalid address")}, nil)
+		return
+	}
+
+	result, err := c.service.ListAccountTokens(r.Context(), addressParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("ListAccountTokens", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("ListAccountTokens ok", "requestId", requestId)
+}
+
+// GetTokenDetails - Get token details
+func (c *ReadApiAPIController) GetTokenDetails(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("GetTokenDetails", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("GetTokenDetails", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	addressParam := params["address"]
+	if addressParam == "" {
+		c.errorHandler(w, r, &RequiredError{"address"}, nil)
+		log.Error("GetTokenDetails address is empty", "requestId", requestId)
+		return
+	}
+
+	if !common.IsHexAddressDeep(addressParam) {
+		log.Error(relay.MsgAddress, relay.MsgAddress, addressParam, relay.MsgError, relay.ErrInvalidAddress, relay.MsgStatus, http.StatusBadRequest, "requestId", requestId)
+		c.errorHandler(w, r, &ParsingError{"address", errors.New("Invalid address")}, nil)
+		return
+	}
+
+	result, err := c.service.GetTokenDetails(r.Context(), addressParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("GetTokenDetails", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("GetTokenDetails ok", "requestId", requestId)
+}
+
+// ListTokenTransfers - List the transfers of a token
+func (c *ReadApiAPIController) ListTokenTransfers(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("ListTokenTransfers", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("ListTokenTransfers", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	addressParam := params["address"]
+	if addressParam == "" {
+		c.errorHandler(w, r, &RequiredError{"address"}, nil)
+		log.Error("ListTokenTransfers address is empty", "requestId", requestId)
+		return
+	}
+
+	if !common.IsHexAddressDeep(addressParam) {
+		log.Error(relay.MsgAddress, relay.MsgAddress, addressParam, relay.MsgError, relay.ErrInvalidAddress, relay.MsgStatus, http.StatusBadRequest, "requestId", requestId)
+		c.errorHandler(w, r, &ParsingError{"address", errors.New("Invalid address")}, nil)
+		return
+	}
+
+	pageNumber := int64(-1)
+	pageNumberParam := params["pageNumber"]
+	var err error
+	if len(pageNumberParam) > 0 {
+		pageNumber, err = strconv.ParseInt(pageNumberParam, 10, 64)
+		if err != nil {
+			c.errorHandler(w, r, &ParsingError{"pageNumber", err}, nil)
+			log.Error("ListTokenTransfers", "requestId", requestId, "error", "invalid pageNumber")
+			return
+		}
+		if pageNumber <= 0 {
+			pageNumber = -1
+		}
+	}
+
+	result, err := c.service.ListTokenTransfers(r.Context(), addressParam, pageNumber)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("ListTokenTransfers", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("ListTokenTransfers ok", "requestId", requestId)
+}
+
+// GetBlockByNumber - Get block details by block number
+func (c *ReadApiAPIController) GetBlockByNumber(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("GetBlockByNumber", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("GetBlockByNumber", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	numberParam := params["number"]
+	if numberParam == "" {
+		c.errorHandler(w, r, &RequiredError{"number"}, nil)
+		log.Error("GetBlockByNumber number is empty", "requestId", requestId)
+		return
+	}
+
+	number, err := strconv.ParseUint(numberParam, 10, 64)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{"number", err}, nil)
+		log.Error("GetBlockByNumber", "requestId", requestId, "error", "invalid number")
+		return
+	}
+
+	result, err := c.service.GetBlockByNumber(r.Context(), number)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("GetBlockByNumber", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("GetBlockByNumber ok", "requestId", requestId)
+}
+
+// GetBlockByHash - Get block details by block hash
+func (c *ReadApiAPIController) GetBlockByHash(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("GetBlockByHash", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("GetBlockByHash", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	hashParam := params["hash"]
+	if hashParam == "" {
+		c.errorHandler(w, r, &RequiredError{"hash"}, nil)
+		log.Error("GetBlockByHash hashParam is empty", "requestId", requestId)
+		return
+	}
+
+	if !common.IsHexAddressDeep(hashParam) {
+		log.Error(relay.MsgHash, relay.MsgHash, hashParam, relay.MsgError, relay.ErrInvalidHash, relay.MsgStatus, http.StatusBadRequest, "requestId", requestId)
+		c.errorHandler(w, r, &ParsingError{"hash", errors.New("Invalid hash")}, nil)
+		return
+	}
+
+	result, err := c.service.GetBlockByHash(r.Context(), hashParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("GetBlockByHash", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("GetBlockByHash ok", "requestId", requestId)
+}
+
+// ListBlocks - List blocks
+func (c *ReadApiAPIController) ListBlocks(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("ListBlocks", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("ListBlocks", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	pageNumber := int64(-1)
+	pageNumberParam := params["pageNumber"]
+	var err error
+	if len(pageNumberParam) > 0 {
+		pageNumber, err = strconv.ParseInt(pageNumberParam, 10, 64)
+		if err != nil {
+			c.errorHandler(w, r, &ParsingError{"pageNumber", err}, nil)
+			log.Error("ListBlocks", "requestId", requestId, "error", "invalid pageNumber")
+			return
+		}
+		if pageNumber <= 0 {
+			pageNumber = -1
+		}
+	}
+
+	result, err := c.service.ListBlocks(r.Context(), pageNumber)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("ListBlocks", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("ListBlocks ok", "requestId", requestId)
+}
+
+// ListBlockTransactions - List the transactions in a block
+func (c *ReadApiAPIController) ListBlockTransactions(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("ListBlockTransactions", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("ListBlockTransactions", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	numberParam := params["number"]
+	if numberParam == "" {
+		c.errorHandler(w, r, &RequiredError{"number"}, nil)
+		log.Error("ListBlockTransactions number is empty", "requestId", requestId)
+		return
+	}
+
+	number, err := strconv.ParseUint(numberParam, 10, 64)
+	if err != nil {
+		c.errorHandler(w, r, &ParsingError{"number", err}, nil)
+		log.Error("ListBlockTransactions", "requestId", requestId, "error", "invalid number")
+		return
+	}
+
+	pageNumber := int64(-1)
+	pageNumberParam := params["pageNumber"]
+	if len(pageNumberParam) > 0 {
+		pageNumber, err = strconv.ParseInt(pageNumberParam, 10, 64)
+		if err != nil {
+			c.errorHandler(w, r, &ParsingError{"pageNumber", err}, nil)
+			log.Error("ListBlockTransactions", "requestId", requestId, "error", "invalid pageNumber")
+			return
+		}
+		if pageNumber <= 0 {
+			pageNumber = -1
+		}
+	}
+
+	result, err := c.service.ListBlockTransactions(r.Context(), number, pageNumber)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("ListBlockTransactions", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("ListBlockTransactions ok", "requestId", requestId)
+}
+
+// ListValidators - List the validators
+func (c *ReadApiAPIController) ListValidators(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("ListValidators", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("ListValidators", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	result, err := c.service.ListValidators(r.Context())
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("ListValidators", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("ListValidators ok", "requestId", requestId)
+}
+
+// GetValidatorDetails - Get the staking details of a validator
+func (c *ReadApiAPIController) GetValidatorDetails(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("GetValidatorDetails", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("GetValidatorDetails", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	addressParam := params["address"]
+	if addressParam == "" {
+		c.errorHandler(w, r, &RequiredError{"address"}, nil)
+		log.Error("GetValidatorDetails address is empty", "requestId", requestId)
+		return
+	}
+
+	if !common.IsHexAddressDeep(addressParam) {
+		log.Error(relay.MsgAddress, relay.MsgAddress, addressParam, relay.MsgError, relay.ErrInvalidAddress, relay.MsgStatus, http.StatusBadRequest, "requestId", requestId)
+		c.errorHandler(w, r, &ParsingError{"address", errors.New("Invalid address")}, nil)
+		return
+	}
+
+	result, err := c.service.GetValidatorDetails(r.Context(), addressParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("GetValidatorDetails", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("GetValidatorDetails ok", "requestId", requestId)
+}
+
+// ListStakingHistory - List the block rewards and slashings of a depositor
+func (c *ReadApiAPIController) ListStakingHistory(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("ListStakingHistory", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("ListStakingHistory", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	addressParam := params["address"]
+	if addressParam == "" {
+		c.errorHandler(w, r, &RequiredError{"address"}, nil)
+		log.Error("ListStakingHistory address is empty", "requestId", requestId)
+		return
+	}
+
+	if !common.IsHexAddressDeep(addressParam) {
+		log.Error(relay.MsgAddress, relay.MsgAddress, addressParam, relay.MsgError, relay.ErrInvalidAddress, relay.MsgStatus, http.StatusBadRequest, "requestId", requestId)
+		c.errorHandler(w, r, &ParsingError{"address", errors.New("Invalid address")}, nil)
+		return
+	}
+
+	pageNumber := int64(-1)
+	pageNumberParam := params["pageNumber"]
+	var err error
+	if len(pageNumberParam) > 0 {
+		pageNumber, err = strconv.ParseInt(pageNumberParam, 10, 64)
+		if err != nil {
+			c.errorHandler(w, r, &ParsingError{"pageNumber", err}, nil)
+			log.Error("ListStakingHistory", "requestId", requestId, "error", "invalid pageNumber")
+			return
+		}
+		if pageNumber <= 0 {
+			pageNumber = -1
+		}
+	}
+
+	result, err := c.service.ListStakingHistory(r.Context(), addressParam, pageNumber)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("ListStakingHistory", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("ListStakingHistory ok", "requestId", requestId)
+}
+
+// ListTransactionsByRemarks - List the transactions received by an account with the given remarks
+func (c *ReadApiAPIController) ListTransactionsByRemarks(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("ListTransactionsByRemarks", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error("ListTransactionsByRemarks", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	addressParam := params["address"]
+	if addressParam == "" {
+		c.errorHandler(w, r, &RequiredError{"address"}, nil)
+		log.Error("ListTransactionsByRemarks address is empty", "requestId", requestId)
+		return
+	}
+
+	if !common.IsHexAddressDeep(addressParam) {
+		log.Error(relay.MsgAddress, relay.MsgAddress, addressParam, relay.MsgError, relay.ErrInvalidAddress, relay.MsgStatus, http.StatusBadRequest, "requestId", requestId)
+		c.errorHandler(w, r, &ParsingError{"address", errors.New("Invalid address")}, nil)
+		return
+	}
+
+	remarksParam := params["remarks"]
+	if remarksParam == "" {
+		c.errorHandler(w, r, &RequiredError{"remarks"}, nil)
+		log.Error("ListTransactionsByRemarks remarks is empty", "requestId", requestId)
+		return
+	}
+
+	pageNumber := int64(-1)
+	pageNumberParam := params["pageNumber"]
+	var err error
+	if len(pageNumberParam) > 0 {
+		pageNumber, err = strconv.ParseInt(pageNumberParam, 10, 64)
+		if err != nil {
+			c.errorHandler(w, r, &ParsingError{"pageNumber", err}, nil)
+			log.Error("ListTransactionsByRemarks", "requestId", requestId, "error", "invalid pageNumber")
+			return
+		}
+		if pageNumber <= 0 {
+			pageNumber = -1
+		}
+	}
+
+	result, err := c.service.ListTransactionsByRemarks(r.Context(), addressParam, remarksParam, pageNumber)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("ListTransactionsByRemarks", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("ListTransactionsByRemarks ok", "requestId", requestId)
+}
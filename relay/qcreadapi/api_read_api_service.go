@@ -19,8 +19,8 @@ import (
 	"github.com/QuantumCoinProject/qc/relay"
 	"github.com/QuantumCoinProject/qc/common"
 	"github.com/QuantumCoinProject/qc/common/hexutil"
-	"github.com/QuantumCoinProject/qc/rpc"
 	"github.com/QuantumCoinProject/qc/cachemanager"
+	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
 	"net/http"
 	"errors"
 	"github.com/mattn/go-colorable"
@@ -33,7 +33,7 @@ import (
 // This service should implement the business logic for every endpoint for the ReadApiAPI API.
 // Include any external packages or services that will be required by this service.
 type ReadApiAPIService struct {
-  DpUrl string
+  nodePool *relay.NodePool
   cacheManager *cachemanager.CacheManager
 	enableExtendedApis bool
 }
@@ -47,6 +47,7 @@ type RPCTransaction struct {
 	GasFeeCap        *hexutil.Big      `json:"maxFeePerGas,omitempty"`
 	Hash             common.Hash       `json:"hash"`
 	Input            hexutil.Bytes     `json:"input"`
+	Remarks          hexutil.Bytes     `json:"remarks"`
 	Nonce            hexutil.Uint64    `json:"nonce"`
 	To               *common.Address   `json:"to"`
 	TransactionIndex *hexutil.Uint64   `json:"transactionIndex"`
@@ -55,10 +56,10 @@ type RPCTransaction struct {
 }
 
 // NewReadApiAPIService creates a default api service
-func NewReadApiAPIService(dpUrl string, cacheManager *cachemanager.CacheManager,enableExtendedApis bool) (*ReadApiAPIService, error) {
+func NewReadApiAPIService(nodePool *relay.NodePool, cacheManager *cachemanager.CacheManager,enableExtendedApis bool) (*ReadApiAPIService, error) {
 	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(3), log.StreamHandler(colorable.NewColorableStderr(), log.TerminalFormat(true))))
 	return &ReadApiAPIService{
-		DpUrl: dpUrl,
+		nodePool: nodePool,
 		cacheManager: cacheManager,
 		enableExtendedApis: enableExtendedApis,
 	}, nil
@@ -69,17 +70,12 @@ func (s *ReadApiAPIService) GetLatestBlockDetails(ctx context.Context) (ImplResp
 
 	startTime := time.Now()
 
-	log.Info(relay.InfoTitleLatestBlockDetails, relay.MsgDial, s.DpUrl)
+	log.Info(relay.InfoTitleLatestBlockDetails)
 
-	client, err := rpc.Dial(s.DpUrl)
-	if err != nil {
-		log.Error(relay.MsgDial, relay.MsgError, "errors.New(err.Error())", relay.MsgStatus, http.StatusInternalServerError)
-		return Response(http.StatusInternalServerError, nil), errors.New(err.Error())
-	}
-	defer client.Close()
+	client := s.nodePool
 
 	var blockNumber *hexutil.Uint64
-	err = client.CallContext(ctx, &blockNumber, "eth_blockNumber")
+	err := client.CallContext(ctx, &blockNumber, "eth_blockNumber")
 	if err != nil {
 		log.Error(relay.MsgBlockNumber, relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusInternalServerError)
 		return Response(http.StatusInternalServerError, nil), errors.New(err.Error())
@@ -103,14 +99,9 @@ func (s *ReadApiAPIService) GetAccountDetails(ctx context.Context, address strin
 
 	startTime := time.Now()
 
-	log.Info(relay.InfoTitleAccountDetails, relay.MsgDial, s.DpUrl)
+	log.Info(relay.InfoTitleAccountDetails)
 
-	client, err := rpc.Dial(s.DpUrl)
-	if err != nil {
-		log.Error(relay.MsgDial, relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusInternalServerError)
-		return Response(http.StatusInternalServerError, nil), errors.New(err.Error())
-	}
-	defer client.Close()
+	client := s.nodePool
 
 	if !common.IsHexAddressDeep(address) {
 		log.Error(relay.MsgAddress, relay.MsgAddress, address, relay.MsgError, relay.ErrInvalidAddress, relay.MsgStatus, http.StatusBadRequest)
@@ -118,7 +109,7 @@ func (s *ReadApiAPIService) GetAccountDetails(ctx context.Context, address strin
 	}
 
 	var balance *hexutil.Big
-	err = client.CallContext(ctx, &balance, "eth_getBalance", common.HexToAddress(address), "latest")
+	err := client.CallContext(ctx, &balance, "eth_getBalance", common.HexToAddress(address), "latest")
 	if err != nil {
 		log.Error(relay.MsgBalance, relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusInternalServerError)
 		return Response(http.StatusInternalServerError, nil), errors.New(err.Error())
@@ -174,14 +165,9 @@ func (s *ReadApiAPIService) GetTransactionDetails(ctx context.Context, hash stri
 	startTime := time.Now()
 	isDiscarded := false
 	discardReason := ""
-	log.Info(relay.InfoTitleTransaction, relay.MsgDial, s.DpUrl)
+	log.Info(relay.InfoTitleTransaction)
 
-	client, err := rpc.Dial(s.DpUrl)
-	if err != nil {
-		log.Error(relay.MsgDial,relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusInternalServerError)
-		return Response(http.StatusInternalServerError, nil), errors.New(err.Error())
-	}
-	defer client.Close()
+	client := s.nodePool
 
 	if !common.IsHexAddressDeep(hash)  {
 		log.Error(relay.MsgHash, relay.MsgHash, hash, relay.MsgError, relay.ErrInvalidHash, relay.MsgStatus, http.StatusBadRequest)
@@ -189,7 +175,7 @@ func (s *ReadApiAPIService) GetTransactionDetails(ctx context.Context, hash stri
 	}
 
 	var raw json.RawMessage
-	err =  client.CallContext(ctx, &raw, "eth_getTransactionByHash", common.HexToHash(hash))
+	err :=  client.CallContext(ctx, &raw, "eth_getTransactionByHash", common.HexToHash(hash))
 	if err != nil {
 		log.Error(relay.MsgTransaction, relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusInternalServerError)
 		return  Response(http.StatusInternalServerError, nil), errors.New(err.Error())
@@ -223,6 +209,12 @@ func (s *ReadApiAPIService) GetTransactionDetails(ctx context.Context, hash stri
 		txnHash = rpcTxn.Hash.String()
 		input = rpcTxn.Input.String()
 
+		var remarks *string
+		if len(rpcTxn.Remarks) > 0 {
+			r := rpcTxn.Remarks.String()
+			remarks = &r
+		}
+
 		if rpcTxn.To != nil {
 			to = rpcTxn.To.String()
 		}
@@ -263,7 +255,7 @@ func (s *ReadApiAPIService) GetTransactionDetails(ctx context.Context, hash stri
 
 			txnDetails := TransactionDetails{
 				&blochHash, &blockNumber, from,gas, gasPrice, txnHash,
-				input, &isDiscarded, &discardReason, nonce , &to,value,
+				input, remarks, &isDiscarded, &discardReason, nonce , &to,value,
 				transactionReceipt}
 
 			Dump(txnDetails)
@@ -276,7 +268,7 @@ func (s *ReadApiAPIService) GetTransactionDetails(ctx context.Context, hash stri
 
 		txnDetails := TransactionDetails{
 			&blochHash, &blockNumber, from,gas, gasPrice, txnHash,
-			input, &isDiscarded, &discardReason, nonce , &to,value,
+			input, remarks, &isDiscarded, &discardReason, nonce , &to,value,
 			transactionReceipt}
 
 		Dump(txnDetails)
@@ -338,6 +330,328 @@ func (s *ReadApiAPIService) ListAccountTransactions(ctx context.Context, address
 	return Response(http.StatusOK,listResponse),	nil
 }
 
+// QueryAccountTransactions - List account transactions by cursor
+func (s *ReadApiAPIService) QueryAccountTransactions(ctx context.Context, address string, query cachemanager.AccountTransactionQuery) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleListAccountTransactions)
+
+	if common.IsHexAddressDeep(address) == false {
+		return Response(http.StatusBadRequest, nil), relay.ErrInvalidAddress
+	}
+
+	queryResponse, err := s.cacheManager.QueryAccountTransactions(common.HexToAddress(address), query)
+	if err != nil {
+		if errors.Is(err, cachemanager.InvalidQueryErr) || errors.Is(err, cachemanager.InvalidCursorErr) {
+			return Response(http.StatusBadRequest, nil), err
+		}
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleListAccountTransactions, relay.MsgAddress, address, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,queryResponse),	nil
+}
+
+// ListAccountTokens - List the tokens held by an account
+func (s *ReadApiAPIService) ListAccountTokens(ctx context.Context, address string) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleListAccountTokens)
+
+	if common.IsHexAddressDeep(address) == false {
+		return Response(http.StatusBadRequest, nil), relay.ErrInvalidAddress
+	}
+
+	listResponse, err := s.cacheManager.ListAccountTokens(common.HexToAddress(address))
+	if err != nil {
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleListAccountTokens, relay.MsgAddress, address, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,listResponse),	nil
+}
+
+// GetTokenDetails - Get token details
+func (s *ReadApiAPIService) GetTokenDetails(ctx context.Context, address string) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleGetTokenDetails)
+
+	if common.IsHexAddressDeep(address) == false {
+		return Response(http.StatusBadRequest, nil), relay.ErrInvalidAddress
+	}
+
+	getResponse, err := s.cacheManager.GetTokenDetails(common.HexToAddress(address))
+	if err != nil {
+		if errors.Is(err, cachemanager.TokenNotFoundErr) {
+			return Response(http.StatusNotFound, nil), errors.New("Not Found")
+		}
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleGetTokenDetails, relay.MsgAddress, address, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,getResponse),	nil
+}
+
+// ListTokenTransfers - List the transfers of a token
+func (s *ReadApiAPIService) ListTokenTransfers(ctx context.Context, address string, pageNumber int64) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleListTokenTransfers)
+
+	if common.IsHexAddressDeep(address) == false {
+		return Response(http.StatusBadRequest, nil), relay.ErrInvalidAddress
+	}
+
+	listResponse, err := s.cacheManager.ListTokenTransfers(common.HexToAddress(address), pageNumber)
+	if err != nil {
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleListTokenTransfers, relay.MsgAddress, address, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,listResponse),	nil
+}
+
+// GetBlockByNumber - Get block details by block number
+func (s *ReadApiAPIService) GetBlockByNumber(ctx context.Context, number uint64) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleGetBlockDetails)
+
+	getResponse, err := s.cacheManager.GetBlockByNumber(number)
+	if err != nil {
+		if errors.Is(err, cachemanager.BlockNotFoundErr) {
+			return Response(http.StatusNotFound, nil), errors.New("Not Found")
+		}
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleGetBlockDetails, relay.MsgBlockNumber, number, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,getResponse),	nil
+}
+
+// GetBlockByHash - Get block details by block hash
+func (s *ReadApiAPIService) GetBlockByHash(ctx context.Context, hash string) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleGetBlockDetails)
+
+	if common.IsHexAddressDeep(hash) == false {
+		return Response(http.StatusBadRequest, nil), relay.ErrInvalidHash
+	}
+
+	getResponse, err := s.cacheManager.GetBlockByHash(common.HexToHash(hash))
+	if err != nil {
+		if errors.Is(err, cachemanager.BlockNotFoundErr) {
+			return Response(http.StatusNotFound, nil), errors.New("Not Found")
+		}
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleGetBlockDetails, relay.MsgHash, hash, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,getResponse),	nil
+}
+
+// ListBlocks - List blocks
+func (s *ReadApiAPIService) ListBlocks(ctx context.Context, pageNumber int64) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleListBlocks)
+
+	listResponse, err := s.cacheManager.ListBlocks(pageNumber)
+	if err != nil {
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleListBlocks, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,listResponse),	nil
+}
+
+// ListBlockTransactions - List the transactions in a block
+func (s *ReadApiAPIService) ListBlockTransactions(ctx context.Context, number uint64, pageNumber int64) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleListBlockTransactions)
+
+	listResponse, err := s.cacheManager.ListBlockTransactions(number, pageNumber)
+	if err != nil {
+		if errors.Is(err, cachemanager.BlockNotFoundErr) {
+			return Response(http.StatusNotFound, nil), errors.New("Not Found")
+		}
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleListBlockTransactions, relay.MsgBlockNumber, number, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,listResponse),	nil
+}
+
+// ListValidators - List the validators
+func (s *ReadApiAPIService) ListValidators(ctx context.Context) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleListValidators)
+
+	client := s.nodePool
+
+	var blockNumber hexutil.Uint64
+	err := client.CallContext(ctx, &blockNumber, "eth_blockNumber")
+	if err != nil {
+		log.Error(relay.MsgBlockNumber, relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusInternalServerError)
+		return Response(http.StatusInternalServerError, nil), errors.New(err.Error())
+	}
+
+	var validators []*proofofstake.ValidatorDetails
+	err = client.CallContext(ctx, &validators, "proofofstake_listValidators", blockNumber.String())
+	if err != nil {
+		log.Error(relay.MsgValidator, relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusInternalServerError)
+		return Response(http.StatusInternalServerError, nil), errors.New(err.Error())
+	}
+
+	listResponse := ListValidatorsResponse{
+		BlockNumber: int64(blockNumber),
+		Items: make([]ValidatorDetails, 0, len(validators)),
+	}
+	for _, validator := range validators {
+		listResponse.Items = append(listResponse.Items, newValidatorDetails(validator))
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleListValidators, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,listResponse),	nil
+}
+
+// GetValidatorDetails - Get the staking details of a validator
+func (s *ReadApiAPIService) GetValidatorDetails(ctx context.Context, address string) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleGetValidatorDetails)
+
+	if common.IsHexAddressDeep(address) == false {
+		return Response(http.StatusBadRequest, nil), relay.ErrInvalidAddress
+	}
+
+	var validator *proofofstake.ValidatorDetails
+	err := s.nodePool.CallContext(ctx, &validator, "proofofstake_getStakingDetailsByValidatorAddress", common.HexToAddress(address), "")
+	if err != nil {
+		log.Error(relay.MsgValidator, relay.MsgAddress, address, relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusInternalServerError)
+		return Response(http.StatusInternalServerError, nil), errors.New(err.Error())
+	}
+	if validator == nil || validator.Depositor.IsEqualTo(common.ZERO_ADDRESS) {
+		return Response(http.StatusNotFound, nil), errors.New("Not Found")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleGetValidatorDetails, relay.MsgAddress, address, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,ValidatorDetailsResponse{newValidatorDetails(validator)}),	nil
+}
+
+// ListStakingHistory - List the block rewards and slashings of a depositor
+func (s *ReadApiAPIService) ListStakingHistory(ctx context.Context, address string, pageNumber int64) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleListStakingHistory)
+
+	if s.enableExtendedApis == false {
+		return Response(http.StatusNotFound, nil), errors.New("Not Found")
+	}
+
+	if common.IsHexAddressDeep(address) == false {
+		return Response(http.StatusBadRequest, nil), relay.ErrInvalidAddress
+	}
+
+	listResponse, err := s.cacheManager.ListStakingHistory(common.HexToAddress(address), pageNumber)
+	if err != nil {
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleListStakingHistory, relay.MsgAddress, address, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,listResponse),	nil
+}
+
+// ListTransactionsByRemarks - List the transactions received by an account with the given remarks
+func (s *ReadApiAPIService) ListTransactionsByRemarks(ctx context.Context, address string, remarks string, pageNumber int64) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleListTransactionsByRemarks)
+
+	if common.IsHexAddressDeep(address) == false {
+		return Response(http.StatusBadRequest, nil), relay.ErrInvalidAddress
+	}
+
+	listResponse, err := s.cacheManager.ListTransactionsByRemarks(common.HexToAddress(address), remarks, pageNumber)
+	if err != nil {
+		if errors.Is(err, cachemanager.InvalidRemarksErr) {
+			return Response(http.StatusBadRequest, nil), err
+		}
+		return Response(http.StatusInternalServerError, nil), errors.New("Internal Server Error")
+	}
+
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleListTransactionsByRemarks, relay.MsgAddress, address, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK,listResponse),	nil
+}
+
+func newValidatorDetails(validator *proofofstake.ValidatorDetails) ValidatorDetails {
+	return ValidatorDetails{
+		Depositor: strings.ToLower(validator.Depositor.Hex()),
+		Validator: strings.ToLower(validator.Validator.Hex()),
+		Balance: validator.Balance,
+		NetBalance: validator.NetBalance,
+		BlockRewards: validator.BlockRewards,
+		Slashings: validator.Slashings,
+		IsValidationPaused: validator.IsValidationPaused,
+		WithdrawalBlock: validator.WithdrawalBlock,
+		WithdrawalAmount: validator.WithdrawalAmount,
+		LastNilBlock: validator.LastNiLBlock,
+		NilBlockCount: validator.NilBlockCount,
+	}
+}
+
 // QueryDetails - Query details
 func (s *ReadApiAPIService) QueryDetails(ctx context.Context, queryTerm string) (ImplResponse, error) {
 	queryTerm = strings.ToLower(queryTerm)
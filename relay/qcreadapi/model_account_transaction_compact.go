@@ -38,6 +38,20 @@ type AccountTransactionCompact struct {
 	TransactionType TransactionType `json:"transactionType,omitempty"`
 
 	ErrorReason *string `json:"errorReason,omitempty"`
+
+	TokenAddress *string `json:"tokenAddress,omitempty"`
+
+	TokenSymbol *string `json:"tokenSymbol,omitempty"`
+
+	TokenDecimals *int32 `json:"tokenDecimals,omitempty"`
+
+	TokenAmount *string `json:"tokenAmount,omitempty"`
+
+	// Set for coins transferred by a contract called by the transaction.
+	Internal *bool `json:"internal,omitempty"`
+
+	// The remarks of the transaction, such as a deposit memo.
+	Remarks *string `json:"remarks,omitempty"`
 }
 
 // AssertAccountTransactionCompactRequired checks if the required fields are not zero-ed
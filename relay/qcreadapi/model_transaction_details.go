@@ -31,6 +31,9 @@ type TransactionDetails struct {
 
 	Input string `json:"input,omitempty"`
 
+	// The remarks of the transaction, such as a deposit memo. Null if the transaction has no remarks.
+	Remarks *string `json:"remarks,omitempty"`
+
 	IsDiscarded *bool `json:"isDiscarded,omitempty"`
 
 	DiscardReason *string `json:"discardReason,omitempty"`
@@ -0,0 +1,491 @@
+package qcreadapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/cachemanager"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/event"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamClientBufferSize is the number of events queued for a streaming client. Clients that fall further behind
+// are disconnected.
+const StreamClientBufferSize = 256
+
+// MaxStreamFilterEntries is the maximum number of accounts and transaction hashes a streaming client can subscribe to.
+const MaxStreamFilterEntries = 1000
+
+// StreamKeepAliveInterval is how often an idle stream is pinged, so that proxies do not close it.
+const StreamKeepAliveInterval = 30 * time.Second
+
+const streamWriteTimeout = 10 * time.Second
+
+const (
+	STREAM_EVENT_BLOCK       = "block"
+	STREAM_EVENT_ROLLBACK    = "rollback"
+	STREAM_EVENT_TRANSACTION = "transaction"
+	STREAM_EVENT_SUBSCRIBED  = "subscribed"
+	STREAM_EVENT_ERROR       = "error"
+)
+
+var StreamFilterTooLargeErr = fmt.Errorf("too many accounts and transactions, the maximum is %d", MaxStreamFilterEntries)
+
+// StreamEvent is a message sent to the clients of the streaming endpoints. Rollback events are sent to all clients;
+// the transactions of a rolled back block are no longer part of the chain.
+type StreamEvent struct {
+	Type        string                                  `json:"type"`
+	Block       *cachemanager.BlockEvent                `json:"block,omitempty"`
+	Transaction *cachemanager.AccountTransactionCompact `json:"transaction,omitempty"`
+	Filter      *StreamFilter                           `json:"filter,omitempty"`
+	Error       string                                  `json:"error,omitempty"`
+}
+
+// StreamFilter selects the events sent to a streaming client.
+type StreamFilter struct {
+	Blocks       bool     `json:"blocks"`
+	Accounts     []string `json:"accounts,omitempty"`
+	Transactions []string `json:"transactions,omitempty"`
+}
+
+// StreamRequest is a message sent by a WebSocket client to change its subscriptions.
+//
+//	{"action": "subscribe", "blocks": true, "accounts": ["0x..."], "transactions": ["0x..."]}
+//
+// action is subscribe or unsubscribe. Subscribing to blocks sends an event for each indexed block.
+type StreamRequest struct {
+	Action string `json:"action"`
+	StreamFilter
+}
+
+type indexEventSource interface {
+	SubscribeIndexEvents(ch chan<- cachemanager.IndexEvent) event.Subscription
+}
+
+// StreamHub fans out the blocks indexed by the cache manager to the clients of the streaming endpoints.
+type StreamHub struct {
+	lock    sync.RWMutex
+	clients map[*streamClient]struct{}
+	sub     event.Subscription
+}
+
+type streamClient struct {
+	lock         sync.RWMutex
+	blocks       bool
+	accounts     map[string]bool
+	transactions map[string]bool
+
+	events  chan *StreamEvent
+	dropped chan struct{} //closed when the client fell behind and was removed
+}
+
+// NewStreamHub creates a hub that receives the index events of the cache manager.
+func NewStreamHub(source indexEventSource) *StreamHub {
+	hub := &StreamHub{
+		clients: make(map[*streamClient]struct{}),
+	}
+	events := make(chan cachemanager.IndexEvent, StreamClientBufferSize)
+	hub.sub = source.SubscribeIndexEvents(events)
+	go hub.loop(events)
+
+	return hub
+}
+
+// Close stops the hub and disconnects all the clients.
+func (hub *StreamHub) Close() {
+	hub.sub.Unsubscribe()
+}
+
+func (hub *StreamHub) loop(events chan cachemanager.IndexEvent) {
+	for {
+		select {
+		case indexEvent := <-events:
+			hub.broadcast(&indexEvent)
+		case <-hub.sub.Err():
+			hub.lock.Lock()
+			for client := range hub.clients {
+				hub.drop(client)
+			}
+			hub.lock.Unlock()
+			return
+		}
+	}
+}
+
+func (hub *StreamHub) broadcast(indexEvent *cachemanager.IndexEvent) {
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+
+	for client := range hub.clients {
+		for _, streamEvent := range client.match(indexEvent) {
+			select {
+			case client.events <- streamEvent:
+			default:
+				log.Warn("Stream client is too slow, disconnecting", "Block number", indexEvent.Block.BlockNumber)
+				hub.drop(client)
+			}
+			if _, ok := hub.clients[client]; ok == false {
+				break
+			}
+		}
+	}
+}
+
+// drop removes the client. Must be called with the lock held.
+func (hub *StreamHub) drop(client *streamClient) {
+	if _, ok := hub.clients[client]; ok == false {
+		return
+	}
+	delete(hub.clients, client)
+	close(client.dropped)
+}
+
+func (hub *StreamHub) subscribe(filter *StreamFilter) (*streamClient, error) {
+	client := &streamClient{
+		accounts:     make(map[string]bool),
+		transactions: make(map[string]bool),
+		events:       make(chan *StreamEvent, StreamClientBufferSize),
+		dropped:      make(chan struct{}),
+	}
+	if err := client.update(filter, true); err != nil {
+		return nil, err
+	}
+
+	hub.lock.Lock()
+	hub.clients[client] = struct{}{}
+	hub.lock.Unlock()
+
+	return client, nil
+}
+
+func (hub *StreamHub) unsubscribe(client *streamClient) {
+	hub.lock.Lock()
+	hub.drop(client)
+	hub.lock.Unlock()
+}
+
+// update adds the entries of the filter to the subscriptions of the client, or removes them.
+// isHexHash returns whether a string is a 0x prefixed hex encoded hash.
+func isHexHash(s string) bool {
+	if len(s) != 2+2*common.HashLength || (strings.HasPrefix(s, "0x") == false && strings.HasPrefix(s, "0X") == false) {
+		return false
+	}
+	return common.IsHex(s[2:])
+}
+
+func (client *streamClient) update(filter *StreamFilter, add bool) error {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	if add && len(client.accounts)+len(client.transactions)+len(filter.Accounts)+len(filter.Transactions) > MaxStreamFilterEntries {
+		return StreamFilterTooLargeErr
+	}
+	for _, account := range filter.Accounts {
+		if common.IsHexAddressDeep(account) == false {
+			return &ParsingError{"accounts", errors.New("Invalid address " + account)}
+		}
+	}
+	for _, hash := range filter.Transactions {
+		if isHexHash(hash) == false {
+			return &ParsingError{"transactions", errors.New("Invalid hash " + hash)}
+		}
+	}
+
+	if filter.Blocks {
+		client.blocks = add
+	}
+	for _, account := range filter.Accounts {
+		if add {
+			client.accounts[strings.ToLower(account)] = true
+		} else {
+			delete(client.accounts, strings.ToLower(account))
+		}
+	}
+	for _, hash := range filter.Transactions {
+		if add {
+			client.transactions[strings.ToLower(hash)] = true
+		} else {
+			delete(client.transactions, strings.ToLower(hash))
+		}
+	}
+
+	return nil
+}
+
+func (client *streamClient) filter() *StreamFilter {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	filter := &StreamFilter{
+		Blocks:       client.blocks,
+		Accounts:     make([]string, 0, len(client.accounts)),
+		Transactions: make([]string, 0, len(client.transactions)),
+	}
+	for account := range client.accounts {
+		filter.Accounts = append(filter.Accounts, account)
+	}
+	for hash := range client.transactions {
+		filter.Transactions = append(filter.Transactions, hash)
+	}
+	return filter
+}
+
+// match returns the events of the indexed block the client is subscribed to.
+func (client *streamClient) match(indexEvent *cachemanager.IndexEvent) []*StreamEvent {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	block := indexEvent.Block
+	if indexEvent.RolledBack {
+		return []*StreamEvent{{Type: STREAM_EVENT_ROLLBACK, Block: &block}}
+	}
+
+	streamEvents := make([]*StreamEvent, 0)
+	if client.blocks {
+		streamEvents = append(streamEvents, &StreamEvent{Type: STREAM_EVENT_BLOCK, Block: &block})
+	}
+	for i := range indexEvent.Transactions {
+		indexed := &indexEvent.Transactions[i]
+		found := client.transactions[strings.ToLower(indexed.Transaction.Hash)]
+		for _, account := range indexed.Accounts {
+			if found {
+				break
+			}
+			found = client.accounts[account]
+		}
+		if found {
+			streamEvents = append(streamEvents, &StreamEvent{Type: STREAM_EVENT_TRANSACTION, Transaction: &indexed.Transaction})
+		}
+	}
+	return streamEvents
+}
+
+// parseStreamFilter reads the filter of a streaming request from the query string, e.g.
+// ?blocks=true&accounts=0x...,0x...&transactions=0x...
+func parseStreamFilter(query url.Values) *StreamFilter {
+	filter := &StreamFilter{
+		Blocks:       strings.EqualFold(query.Get("blocks"), "true"),
+		Accounts:     make([]string, 0),
+		Transactions: make([]string, 0),
+	}
+	for _, value := range query["accounts"] {
+		for _, account := range strings.Split(value, ",") {
+			if len(account) > 0 {
+				filter.Accounts = append(filter.Accounts, account)
+			}
+		}
+	}
+	for _, value := range query["transactions"] {
+		for _, hash := range strings.Split(value, ",") {
+			if len(hash) > 0 {
+				filter.Transactions = append(filter.Transactions, hash)
+			}
+		}
+	}
+	return filter
+}
+
+// StreamEvents - Stream the events selected by the query string as Server-Sent Events
+func (c *ReadApiAPIController) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+
+	client, ok := c.subscribeStream(w, r, "StreamEvents", requestId)
+	if ok == false {
+		return
+	}
+	defer c.streamHub.unsubscribe(client)
+
+	flusher, ok := w.(http.Flusher)
+	if ok == false {
+		result := Response(http.StatusInternalServerError, nil)
+		c.errorHandler(w, r, errors.New("streaming is not supported"), &result)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	write := func(streamEvent *StreamEvent) bool {
+		data, err := json.Marshal(streamEvent)
+		if err != nil {
+			log.Error("StreamEvents json.Marshal", "requestId", requestId, "error", err)
+			return false
+		}
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", streamEvent.Type, data)
+		if err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+	if write(&StreamEvent{Type: STREAM_EVENT_SUBSCRIBED, Filter: client.filter()}) == false {
+		return
+	}
+
+	keepAlive := time.NewTicker(StreamKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case streamEvent := <-client.events:
+			if write(streamEvent) == false {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-client.dropped:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// StreamEventsWebSocket - Stream events over a WebSocket. The initial subscriptions are read from the query string,
+// and can be changed by sending StreamRequest messages.
+func (c *ReadApiAPIController) StreamEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+
+	client, ok := c.subscribeStream(w, r, "StreamEventsWebSocket", requestId)
+	if ok == false {
+		return
+	}
+	defer c.streamHub.unsubscribe(client)
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin: c.checkStreamOrigin,
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		//the upgrader has already replied
+		log.Error("StreamEventsWebSocket Upgrade", "requestId", requestId, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	var writeLock sync.Mutex
+	write := func(streamEvent *StreamEvent) error {
+		writeLock.Lock()
+		defer writeLock.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		return conn.WriteJSON(streamEvent)
+	}
+	if err := write(&StreamEvent{Type: STREAM_EVENT_SUBSCRIBED, Filter: client.filter()}); err != nil {
+		return
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			var request StreamRequest
+			if err := conn.ReadJSON(&request); err != nil {
+				return
+			}
+
+			switch request.Action {
+			case "subscribe", "unsubscribe":
+				err = client.update(&request.StreamFilter, request.Action == "subscribe")
+			default:
+				err = errors.New("unknown action " + request.Action)
+			}
+			if err != nil {
+				err = write(&StreamEvent{Type: STREAM_EVENT_ERROR, Error: err.Error()})
+			} else {
+				err = write(&StreamEvent{Type: STREAM_EVENT_SUBSCRIBED, Filter: client.filter()})
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(StreamKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case streamEvent := <-client.events:
+			if err := write(streamEvent); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			writeLock.Lock()
+			err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+			writeLock.Unlock()
+			if err != nil {
+				return
+			}
+		case <-client.dropped:
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
+// subscribeStream authorizes a streaming request and subscribes it to the events selected by its query string.
+// The response is written if the request is not subscribed.
+func (c *ReadApiAPIController) subscribeStream(w http.ResponseWriter, r *http.Request, name string, requestId string) (*streamClient, bool) {
+	if len(requestId) > 0 {
+		log.Info(name, "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		return nil, false
+	}
+
+	if code, err := c.authorize(w, r); err != nil {
+		result := Response(code, nil)
+		log.Error(name, "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return nil, false
+	}
+
+	if c.streamHub == nil {
+		result := Response(http.StatusServiceUnavailable, nil)
+		log.Error(name, "requestId", requestId, "error", "Streaming is not enabled")
+		c.errorHandler(w, r, errors.New("Streaming is not enabled"), &result)
+		return nil, false
+	}
+
+	client, err := c.streamHub.subscribe(parseStreamFilter(r.URL.Query()))
+	if err != nil {
+		log.Error(name, "requestId", requestId, "error", err)
+		result := Response(http.StatusBadRequest, nil)
+		c.errorHandler(w, r, &ParsingError{"", err}, &result)
+		return nil, false
+	}
+
+	return client, true
+}
+
+func (c *ReadApiAPIController) checkStreamOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if len(origin) == 0 || c.corsAllowedOrigins == "*" {
+		return true
+	}
+	for _, allowed := range strings.Split(c.corsAllowedOrigins, ",") {
+		if strings.EqualFold(strings.TrimSpace(allowed), origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package qcreadapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/QuantumCoinProject/qc/cachemanager"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/event"
+	"github.com/QuantumCoinProject/qc/relay"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testIndexEventSource struct {
+	feed event.Feed
+}
+
+func (source *testIndexEventSource) SubscribeIndexEvents(ch chan<- cachemanager.IndexEvent) event.Subscription {
+	return source.feed.Subscribe(ch)
+}
+
+var (
+	testStreamAlice = strings.ToLower(common.BytesToAddress([]byte{0xa1}).Hex())
+	testStreamBob   = strings.ToLower(common.BytesToAddress([]byte{0xb0}).Hex())
+	testStreamHash  = strings.ToLower(common.BytesToHash([]byte{0x01}).Hex())
+)
+
+func newTestIndexEvent(blockNumber uint64) cachemanager.IndexEvent {
+	return cachemanager.IndexEvent{
+		Block: cachemanager.BlockEvent{BlockNumber: blockNumber, TransactionCount: 2},
+		Transactions: []cachemanager.IndexedTransaction{
+			{
+				Transaction: cachemanager.AccountTransactionCompact{Hash: testStreamHash, BlockNumber: blockNumber, From: testStreamAlice, To: testStreamBob},
+				Accounts:    []string{testStreamAlice, testStreamBob},
+			},
+			{
+				Transaction: cachemanager.AccountTransactionCompact{Hash: strings.ToLower(common.BytesToHash([]byte{0x02}).Hex()), BlockNumber: blockNumber},
+				Accounts:    []string{testStreamBob},
+			},
+		},
+	}
+}
+
+func newTestStreamServer(t *testing.T) (*testIndexEventSource, *StreamHub, *httptest.Server) {
+	source := &testIndexEventSource{}
+	hub := NewStreamHub(source)
+	t.Cleanup(hub.Close)
+
+	apiKeyManager, err := relay.NewApiKeyManager(true, "key1,key2", nil, "")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	controller := NewReadApiAPIController(nil, "*", apiKeyManager, WithReadApiAPIStreamHub(hub))
+	server := httptest.NewServer(NewRouter(controller))
+	t.Cleanup(server.Close)
+
+	return source, hub, server
+}
+
+// waitTestStreamClients waits until the hub has the number of clients.
+func waitTestStreamClients(t *testing.T, hub *StreamHub, count int) {
+	for i := 0; i < 100; i++ {
+		hub.lock.RLock()
+		clients := len(hub.clients)
+		hub.lock.RUnlock()
+		if clients == count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("failed, expected %d clients", count)
+}
+
+func TestStreamClient_match(t *testing.T) {
+	hub := &StreamHub{clients: make(map[*streamClient]struct{})}
+
+	client, err := hub.subscribe(&StreamFilter{Accounts: []string{strings.ToUpper(testStreamAlice[2:])}})
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	client, err = hub.subscribe(&StreamFilter{Accounts: []string{"0x" + strings.ToUpper(testStreamAlice[2:])}})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	indexEvent := newTestIndexEvent(5)
+	streamEvents := client.match(&indexEvent)
+	if len(streamEvents) != 1 || streamEvents[0].Type != STREAM_EVENT_TRANSACTION || streamEvents[0].Transaction.Hash != testStreamHash {
+		t.Fatalf("failed %v", streamEvents)
+	}
+
+	for _, hash := range []string{testStreamHash[2:], testStreamHash[:64], testStreamHash[:64] + "zz"} {
+		if err = client.update(&StreamFilter{Transactions: []string{hash}}, true); err == nil {
+			t.Fatalf("expected error %s", hash)
+		}
+	}
+	err = client.update(&StreamFilter{Blocks: true, Accounts: []string{testStreamBob}}, true)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	streamEvents = client.match(&indexEvent)
+	if len(streamEvents) != 3 || streamEvents[0].Type != STREAM_EVENT_BLOCK || streamEvents[0].Block.BlockNumber != 5 {
+		t.Fatalf("failed %v", streamEvents)
+	}
+
+	err = client.update(&StreamFilter{Blocks: true, Accounts: []string{testStreamAlice, testStreamBob}}, false)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if streamEvents = client.match(&indexEvent); len(streamEvents) != 0 {
+		t.Fatalf("failed %v", streamEvents)
+	}
+
+	//Rollbacks are sent to every client
+	streamEvents = client.match(&cachemanager.IndexEvent{Block: cachemanager.BlockEvent{BlockNumber: 5}, RolledBack: true})
+	if len(streamEvents) != 1 || streamEvents[0].Type != STREAM_EVENT_ROLLBACK {
+		t.Fatalf("failed %v", streamEvents)
+	}
+
+	accounts := make([]string, MaxStreamFilterEntries+1)
+	for i := range accounts {
+		accounts[i] = testStreamAlice
+	}
+	if err = client.update(&StreamFilter{Accounts: accounts}, true); err != StreamFilterTooLargeErr {
+		t.Fatalf("failed %v", err)
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	source, hub, server := newTestStreamServer(t)
+
+	response, err := http.Get(server.URL + "/stream/events?transactions=" + testStreamHash)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("failed %d", response.StatusCode)
+	}
+
+	request, _ := http.NewRequest("GET", server.URL+"/stream/events?blocks=true&transactions="+testStreamHash, nil)
+	request.Header.Set(API_KEY_HEADER_NAME, "key2")
+	response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("failed %d %s", response.StatusCode, response.Header.Get("Content-Type"))
+	}
+
+	waitTestStreamClients(t, hub, 1)
+	source.feed.Send(newTestIndexEvent(7))
+
+	reader := bufio.NewReader(response.Body)
+	for _, eventType := range []string{STREAM_EVENT_SUBSCRIBED, STREAM_EVENT_BLOCK, STREAM_EVENT_TRANSACTION} {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		if line != "event: "+eventType+"\n" {
+			t.Fatalf("failed %s", line)
+		}
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		var streamEvent StreamEvent
+		if err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &streamEvent); err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		if streamEvent.Type != eventType {
+			t.Fatalf("failed %s", streamEvent.Type)
+		}
+		if eventType == STREAM_EVENT_TRANSACTION && streamEvent.Transaction.Hash != testStreamHash {
+			t.Fatalf("failed %s", streamEvent.Transaction.Hash)
+		}
+		if _, err = reader.ReadString('\n'); err != nil {
+			t.Fatalf("failed %v", err)
+		}
+	}
+}
+
+func TestStreamEventsWebSocket(t *testing.T) {
+	source, hub, server := newTestStreamServer(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/stream/ws"
+
+	_, response, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected error")
+	}
+
+	header := http.Header{}
+	header.Set(API_KEY_HEADER_NAME, "key1")
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer conn.Close()
+
+	var streamEvent StreamEvent
+	if err = conn.ReadJSON(&streamEvent); err != nil || streamEvent.Type != STREAM_EVENT_SUBSCRIBED {
+		t.Fatalf("failed %v %s", err, streamEvent.Type)
+	}
+
+	err = conn.WriteJSON(&StreamRequest{Action: "subscribe", StreamFilter: StreamFilter{Accounts: []string{"0x1"}}})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if err = conn.ReadJSON(&streamEvent); err != nil || streamEvent.Type != STREAM_EVENT_ERROR {
+		t.Fatalf("failed %v %s", err, streamEvent.Type)
+	}
+
+	err = conn.WriteJSON(&StreamRequest{Action: "subscribe", StreamFilter: StreamFilter{Accounts: []string{testStreamBob}}})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	streamEvent = StreamEvent{}
+	if err = conn.ReadJSON(&streamEvent); err != nil || streamEvent.Type != STREAM_EVENT_SUBSCRIBED {
+		t.Fatalf("failed %v %s", err, streamEvent.Type)
+	}
+	if len(streamEvent.Filter.Accounts) != 1 || streamEvent.Filter.Accounts[0] != testStreamBob {
+		t.Fatalf("failed %v", streamEvent.Filter)
+	}
+
+	waitTestStreamClients(t, hub, 1)
+	source.feed.Send(newTestIndexEvent(8))
+	for i := 0; i < 2; i++ {
+		streamEvent = StreamEvent{}
+		if err = conn.ReadJSON(&streamEvent); err != nil || streamEvent.Type != STREAM_EVENT_TRANSACTION {
+			t.Fatalf("failed %v %s", err, streamEvent.Type)
+		}
+		if streamEvent.Transaction.BlockNumber != 8 {
+			t.Fatalf("failed %d", streamEvent.Transaction.BlockNumber)
+		}
+	}
+
+	conn.Close()
+	waitTestStreamClients(t, hub, 0)
+}
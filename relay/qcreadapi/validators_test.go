@@ -0,0 +1,120 @@
+package qcreadapi
+
+import (
+	"encoding/json"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/consensus/proofofstake"
+	"github.com/QuantumCoinProject/qc/relay"
+	"github.com/QuantumCoinProject/qc/rpc"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	testValidator = common.BytesToAddress([]byte{0x0a})
+	testDepositor = common.BytesToAddress([]byte{0x0d})
+)
+
+type testEthService struct{}
+
+func (s *testEthService) BlockNumber() hexutil.Uint64 {
+	return 100
+}
+
+type testProofOfStakeService struct {
+	blockNumbers []string
+}
+
+func (s *testProofOfStakeService) ListValidators(blockNumberHex string) []*proofofstake.ValidatorDetails {
+	s.blockNumbers = append(s.blockNumbers, blockNumberHex)
+	return []*proofofstake.ValidatorDetails{{Depositor: testDepositor, Validator: testValidator, Balance: "0x64", LastNiLBlock: "0x5"}}
+}
+
+func (s *testProofOfStakeService) GetStakingDetailsByValidatorAddress(validator common.Address, blockNumberHex string) *proofofstake.ValidatorDetails {
+	if validator != testValidator {
+		return &proofofstake.ValidatorDetails{}
+	}
+	return &proofofstake.ValidatorDetails{Depositor: testDepositor, Validator: testValidator, Balance: "0x64"}
+}
+
+func newTestValidatorsServer(t *testing.T, service *testProofOfStakeService) *httptest.Server {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &testEthService{}); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if err := server.RegisterName("proofofstake", service); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	nodeServer := httptest.NewServer(server)
+	t.Cleanup(nodeServer.Close)
+
+	nodePool, err := relay.NewNodePool([]string{nodeServer.URL})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	t.Cleanup(nodePool.Close)
+	apiKeyManager, err := relay.NewApiKeyManager(false, "", nil, "")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	readService, err := NewReadApiAPIService(nodePool, nil, false)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	readServer := httptest.NewServer(NewRouter(NewReadApiAPIController(readService, "*", apiKeyManager)))
+	t.Cleanup(readServer.Close)
+	return readServer
+}
+
+func getTestJson(t *testing.T, url string, result interface{}) int {
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusOK {
+		if err = json.NewDecoder(response.Body).Decode(result); err != nil {
+			t.Fatalf("failed %v", err)
+		}
+	}
+	return response.StatusCode
+}
+
+func TestValidators(t *testing.T) {
+	service := &testProofOfStakeService{}
+	server := newTestValidatorsServer(t, service)
+
+	var listResponse ListValidatorsResponse
+	if code := getTestJson(t, server.URL+"/validators", &listResponse); code != http.StatusOK {
+		t.Fatalf("failed %d", code)
+	}
+	if listResponse.BlockNumber != 100 || len(listResponse.Items) != 1 || service.blockNumbers[0] != "0x64" {
+		t.Fatalf("failed %v %v", listResponse, service.blockNumbers)
+	}
+	validator := listResponse.Items[0]
+	if validator.Validator != strings.ToLower(testValidator.Hex()) || validator.Depositor != strings.ToLower(testDepositor.Hex()) || validator.LastNilBlock != "0x5" {
+		t.Fatalf("failed %v", validator)
+	}
+
+	var detailsResponse ValidatorDetailsResponse
+	if code := getTestJson(t, server.URL+"/validator/"+testValidator.Hex(), &detailsResponse); code != http.StatusOK {
+		t.Fatalf("failed %d", code)
+	}
+	if detailsResponse.Result.Balance != "0x64" {
+		t.Fatalf("failed %v", detailsResponse.Result)
+	}
+	if code := getTestJson(t, server.URL+"/validator/"+testDepositor.Hex(), &detailsResponse); code != http.StatusNotFound {
+		t.Fatalf("failed %d", code)
+	}
+	if code := getTestJson(t, server.URL+"/validator/0x01", &detailsResponse); code != http.StatusBadRequest {
+		t.Fatalf("failed %d", code)
+	}
+
+	//The staking history is only indexed when the extended apis are enabled
+	if code := getTestJson(t, server.URL+"/account/"+testDepositor.Hex()+"/staking/1", &listResponse); code != http.StatusNotFound {
+		t.Fatalf("failed %d", code)
+	}
+}
@@ -22,6 +22,8 @@ import (
 // pass the data to a WriteApiAPIServicer to perform the required actions, then write the service results to the http response.
 type WriteApiAPIRouter interface { 
 	SendTransaction(http.ResponseWriter, *http.Request)
+	SendTransactions(http.ResponseWriter, *http.Request)
+	GetTransactionStatus(http.ResponseWriter, *http.Request)
 }
 
 
@@ -31,4 +33,6 @@ type WriteApiAPIRouter interface {
 // and updated with the logic required for the API.
 type WriteApiAPIServicer interface { 
 	SendTransaction(context.Context, SendTransactionRequest) (ImplResponse, error)
+	SendTransactions(context.Context, SendTransactionsRequest) (ImplResponse, error)
+	GetTransactionStatus(context.Context, string) (ImplResponse, error)
 }
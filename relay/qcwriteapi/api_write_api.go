@@ -12,7 +12,10 @@ package qcwriteapi
 
 import (
 	"encoding/json"
+	"github.com/QuantumCoinProject/qc/common"
 	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/relay"
+	"github.com/gorilla/mux"
 	"io"
 	"net/http"
 	"strings"
@@ -27,8 +30,7 @@ type WriteApiAPIController struct {
 	service WriteApiAPIServicer
 	errorHandler ErrorHandler
 	corsAllowedOrigins string
-	enableAuth bool
-	apiKeysMap map[string]bool
+	apiKeyManager *relay.ApiKeyManager
 }
 
 // WriteApiAPIOption for how the controller is set up.
@@ -42,23 +44,12 @@ func WithWriteApiAPIErrorHandler(h ErrorHandler) WriteApiAPIOption {
 }
 
 // NewWriteApiAPIController creates a default api controller
-func NewWriteApiAPIController(s WriteApiAPIServicer, corsAllowedOrigins string, enableAuth bool, apiKeys string, opts ...WriteApiAPIOption) *WriteApiAPIController {
+func NewWriteApiAPIController(s WriteApiAPIServicer, corsAllowedOrigins string, apiKeyManager *relay.ApiKeyManager, opts ...WriteApiAPIOption) *WriteApiAPIController {
 	controller := &WriteApiAPIController{
 		service:      s,
 		errorHandler: DefaultErrorHandler,
 		corsAllowedOrigins: corsAllowedOrigins,
-		enableAuth: enableAuth,
-		apiKeysMap: make(map[string]bool),
-	}
-
-	if len(apiKeys) > 0 {
-		apiKeyList := strings.Split(apiKeys, ",")
-		for _, key := range apiKeyList {
-			if len(key) == 0 {
-				continue
-			}
-			controller.apiKeysMap[key] = true
-		}
+		apiKeyManager: apiKeyManager,
 	}
 
 	for _, opt := range opts {
@@ -76,6 +67,16 @@ func (c *WriteApiAPIController) Routes() Routes {
 			"/transactions",
 			c.SendTransaction,
 		},
+		"SendTransactions": Route{
+			strings.ToUpper("Post"),
+			"/transactions/batch",
+			c.SendTransactions,
+		},
+		"GetTransactionStatus": Route{
+			strings.ToUpper("Get"),
+			"/transactions/{hash}",
+			c.GetTransactionStatus,
+		},
 	}
 }
 
@@ -85,26 +86,15 @@ func (c *WriteApiAPIController) setupCORS(w *http.ResponseWriter, req *http.Requ
 	(*w).Header().Set("Access-Control-Allow-Headers", "*")
 }
 
-func (c *WriteApiAPIController) authorize(w http.ResponseWriter, req *http.Request) bool {
-	if c.enableAuth == false {
-		return  true
-	}
-
-	if req.Header == nil {
-		return  false
-	}
-
-	apiKey := req.Header.Get(API_KEY_HEADER_NAME)
-
-	if len(apiKey) == 0 {
-		return false
+// authorize checks the api key of the request, and counts it as weight requests. Returns the status code of the
+// response if it is not authorized.
+func (c *WriteApiAPIController) authorize(w http.ResponseWriter, req *http.Request, weight int) (int, error) {
+	apiKey := ""
+	if req.Header != nil {
+		apiKey = req.Header.Get(API_KEY_HEADER_NAME)
 	}
 
-	if c.apiKeysMap[apiKey] == true {
-		return true
-	}
-
-	return false
+	return c.apiKeyManager.AuthorizeRequest(w, apiKey, relay.API_KEY_SCOPE_SEND, weight)
 }
 
 // SendTransaction - Send Transaction
@@ -123,12 +113,10 @@ func (c *WriteApiAPIController) SendTransaction(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	if c.authorize(w, r) == false {
-		result := Response(http.StatusUnauthorized, nil)
-		// If no error, encode the body and the result code
-		_ = EncodeJSONResponse(result.Body, &result.Code, w)
-		log.Error("SendTransaction", "requestId", requestId, "error", "Unauthorized")
-		c.errorHandler(w, r, errors.New("Unauthorized"), &result)
+	if code, err := c.authorize(w, r, 1); err != nil {
+		result := Response(code, nil)
+		log.Error("SendTransaction", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
 		return
 	}
 
@@ -165,3 +153,112 @@ func (c *WriteApiAPIController) SendTransaction(w http.ResponseWriter, r *http.R
 
 	log.Info("SendTransaction ok", "requestId", requestId)
 }
+
+// SendTransactions - Send a batch of transactions
+func (c *WriteApiAPIController) SendTransactions(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("SendTransactions", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		log.Info("SendTransactions OPTIONS", "requestId", requestId)
+		return
+	}
+
+	sendTransactionsRequestParam := SendTransactionsRequest{}
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&sendTransactionsRequestParam); err != nil && !errors.Is(err, io.EOF) {
+		c.errorHandler(w, r, &ParsingError{Err: err, Param: "txnDataList"}, nil)
+		log.Error("SendTransactions", "requestId", requestId, "error", "invalid txnDataList")
+		return
+	}
+	if err := AssertSendTransactionsRequestRequired(sendTransactionsRequestParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		log.Error("SendTransactions", "requestId", requestId, "error", "err fields")
+		return
+	}
+	if err := AssertSendTransactionsRequestConstraints(sendTransactionsRequestParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		log.Error("SendTransactions", "requestId", requestId, "error", "err constraints")
+		return
+	}
+
+	// each transaction of the batch is counted against the rate limit and quota of the api key
+	if code, err := c.authorize(w, r, len(sendTransactionsRequestParam.TxnDataList)); err != nil {
+		result := Response(code, nil)
+		log.Error("SendTransactions", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	result, err := c.service.SendTransactions(r.Context(), sendTransactionsRequestParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		if result.Code == http.StatusMethodNotAllowed {
+			result = Response(http.StatusBadRequest, result.Body)
+		}
+		c.errorHandler(w, r, err, &result)
+		log.Error("SendTransactions", "requestId", requestId, "error", err)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("SendTransactions ok", "requestId", requestId)
+}
+
+// GetTransactionStatus - Get the status of a transaction sent by the relay
+func (c *WriteApiAPIController) GetTransactionStatus(w http.ResponseWriter, r *http.Request) {
+	requestId := ""
+	if r.Header != nil {
+		requestId = r.Header.Get(REQUEST_ID_HEADER_NAME)
+	}
+	if len(requestId) > 0 {
+		log.Info("GetTransactionStatus", "requestId", requestId)
+	}
+
+	c.setupCORS(&w, r)
+	if (*r).Method == "OPTIONS" {
+		log.Info("GetTransactionStatus OPTIONS", "requestId", requestId)
+		return
+	}
+
+	if code, err := c.authorize(w, r, 1); err != nil {
+		result := Response(code, nil)
+		log.Error("GetTransactionStatus", "requestId", requestId, "error", err)
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+
+	params := mux.Vars(r)
+	hashParam := params["hash"]
+	if hashParam == "" {
+		c.errorHandler(w, r, &RequiredError{"hash"}, nil)
+		log.Error("GetTransactionStatus hashParam is empty", "requestId", requestId)
+		return
+	}
+
+	if !common.IsHexAddressDeep(hashParam) {
+		log.Error(relay.MsgHash, relay.MsgHash, hashParam, relay.MsgError, relay.ErrInvalidHash, relay.MsgStatus, http.StatusBadRequest, "requestId", requestId)
+		c.errorHandler(w, r, &ParsingError{"hash", errors.New("Invalid hash")}, nil)
+		return
+	}
+
+	result, err := c.service.GetTransactionStatus(r.Context(), hashParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		log.Error("GetTransactionStatus", "requestId", requestId, "error", err)
+		return
+	}
+
+	// If no error, encode the body and the result code
+	_ = EncodeJSONResponse(result.Body, &result.Code, w)
+
+	log.Info("GetTransactionStatus ok", "requestId", requestId)
+}
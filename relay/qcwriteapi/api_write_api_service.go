@@ -13,13 +13,15 @@ package qcwriteapi
 import (
 	"context"
 	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/core/types"
 	"github.com/QuantumCoinProject/qc/log"
 	"github.com/QuantumCoinProject/qc/relay"
-	"github.com/QuantumCoinProject/qc/rpc"
 	"net/http"
 	"errors"
 	"github.com/mattn/go-colorable"
+	"math/big"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,13 +29,16 @@ import (
 // This service should implement the business logic for every endpoint for the WriteApiAPI API.
 // Include any external packages or services that will be required by this service.
 type WriteApiAPIService struct {
-	DpUrl string
+	nodePool *relay.NodePool
+	tracker *TransactionTracker //nil if tracking is not enabled
+	chainIDLock sync.Mutex
+	chainID *big.Int //chain id of the nodes, read on the first transaction
 }
 
 // NewWriteApiAPIService creates a default api service
-func NewWriteApiAPIService(dpUrl string) *WriteApiAPIService {
+func NewWriteApiAPIService(nodePool *relay.NodePool, tracker *TransactionTracker) *WriteApiAPIService {
 	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(3), log.StreamHandler(colorable.NewColorableStderr(), log.TerminalFormat(true))))
-	return &WriteApiAPIService{DpUrl: dpUrl}
+	return &WriteApiAPIService{nodePool: nodePool, tracker: tracker}
 }
 
 // SendTransaction - Send Transaction
@@ -41,14 +46,7 @@ func (s *WriteApiAPIService) SendTransaction(ctx context.Context, sendTransactio
 
 	startTime := time.Now()
 
-	log.Info(relay.InfoTitleSendTransaction, relay.MsgDial, s.DpUrl)
-
-	client, err := rpc.Dial(s.DpUrl)
-	if err != nil {
-		log.Error(relay.MsgDial, relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusInternalServerError)
-		return Response(http.StatusInternalServerError, nil), errors.New(err.Error())
-	}
-	defer client.Close()
+	log.Info(relay.InfoTitleSendTransaction)
 
 	rawTxHex := sendTransactionRequest.TxnData
 
@@ -57,12 +55,19 @@ func (s *WriteApiAPIService) SendTransaction(ctx context.Context, sendTransactio
 		return  Response(http.StatusBadRequest, nil), relay.ErrEmptyRawTxHex
 	}
 
-	var txHash *common.Hash
-	err = client.CallContext(ctx, &txHash, "eth_sendRawTransaction", rawTxHex)
+	tx, err := decodeRawTransaction(rawTxHex)
+	if err != nil {
+		return transactionErrorResponse(relay.MsgRawTxData, err)
+	}
 
+	from, signer, err := s.transactionSender(ctx, tx)
 	if err != nil {
-		log.Error(relay.MsgSend + " " + relay.MsgTransaction, relay.MsgError, errors.New(err.Error()), relay.MsgStatus, http.StatusBadRequest)
-		return Response(http.StatusBadRequest, nil), errors.New(err.Error())
+		return transactionErrorResponse(relay.MsgSend + " " + relay.MsgTransaction, err)
+	}
+
+	txHash, err := s.sendTransaction(ctx, tx, rawTxHex, from, signer)
+	if err != nil {
+		return transactionErrorResponse(relay.MsgSend + " " + relay.MsgTransaction, err)
 	}
 
 	duration := time.Now().Sub(startTime)
@@ -71,3 +76,93 @@ func (s *WriteApiAPIService) SendTransaction(ctx context.Context, sendTransactio
 
 	return Response(http.StatusOK, txHash.String()), nil
 }
+
+// SendTransactions - Send a batch of transactions
+func (s *WriteApiAPIService) SendTransactions(ctx context.Context, sendTransactionsRequest SendTransactionsRequest) (ImplResponse, error) {
+
+	startTime := time.Now()
+
+	log.Info(relay.InfoTitleSendTransactions, "count", len(sendTransactionsRequest.TxnDataList))
+
+	err := checkBatch(sendTransactionsRequest.TxnDataList)
+	if err != nil {
+		log.Error(relay.InfoTitleSendTransactions, relay.MsgError, err, relay.MsgStatus, http.StatusBadRequest)
+		return Response(http.StatusBadRequest, nil), err
+	}
+
+	results := s.sendBatch(ctx, sendTransactionsRequest.TxnDataList)
+
+	sent := 0
+	for _, result := range results {
+		if result.Hash != nil {
+			sent++
+		}
+	}
+	duration := time.Now().Sub(startTime)
+
+	log.Info(relay.InfoTitleSendTransactions, "count", len(results), "sent", sent, relay.MsgTimeDuration, duration, relay.MsgStatus, http.StatusOK)
+
+	return Response(http.StatusOK, SendTransactionsResponse{Results: results}), nil
+}
+
+// sendTransaction validates a decoded transaction from the sender returned by transactionSender, sends it to the
+// nodes and tracks it.
+func (s *WriteApiAPIService) sendTransaction(ctx context.Context, tx *types.Transaction, rawTxHex string, from common.Address, signer types.Signer) (*common.Hash, error) {
+	err := s.validateTransaction(ctx, tx, from, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, err := s.nodePool.SendRawTransaction(ctx, rawTxHex)
+	if err == nil && txHash == nil {
+		err = relay.ErrNullTransactionHash
+	}
+	if errors.Is(err, relay.ErrNullTransactionHash) {
+		//The nodes returned no hash and no error, so the transaction is not known to be sent
+		return nil, newTransactionError(TRANSACTION_ERROR_REJECTED, err)
+	}
+	if err != nil {
+		return nil, nodeTransactionError(err)
+	}
+
+	if s.tracker != nil {
+		err = s.tracker.Track(tx, from, rawTxHex)
+		if err != nil {
+			log.Error("Track " + relay.MsgTransaction, relay.MsgHash, txHash.String(), relay.MsgError, err)
+		}
+	}
+	return txHash, nil
+}
+
+// GetTransactionStatus - Get the status of a transaction sent by the relay
+func (s *WriteApiAPIService) GetTransactionStatus(ctx context.Context, hash string) (ImplResponse, error) {
+	if s.tracker == nil {
+		log.Error(relay.InfoTitleTransactionStatus, relay.MsgError, ErrTrackingDisabled, relay.MsgStatus, http.StatusNotFound)
+		return Response(http.StatusNotFound, nil), ErrTrackingDisabled
+	}
+
+	status, err := s.tracker.Status(hash)
+	if err != nil {
+		if errors.Is(err, ErrTransactionNotTracked) {
+			log.Error(relay.InfoTitleTransactionStatus, relay.MsgHash, hash, relay.MsgError, err, relay.MsgStatus, http.StatusNotFound)
+			return Response(http.StatusNotFound, nil), err
+		}
+		log.Error(relay.InfoTitleTransactionStatus, relay.MsgHash, hash, relay.MsgError, err, relay.MsgStatus, http.StatusInternalServerError)
+		return Response(http.StatusInternalServerError, nil), err
+	}
+
+	log.Info(relay.InfoTitleTransactionStatus, relay.MsgHash, hash, relay.MsgStatus, http.StatusOK)
+	return Response(http.StatusOK, TransactionStatusResponse{Result: *status}), nil
+}
+
+// transactionErrorResponse returns the response of a transaction that was not sent: 400 if the transaction is not
+// valid or was rejected by the node, 503 if the node could not be reached.
+func transactionErrorResponse(msg string, err error) (ImplResponse, error) {
+	var transactionErr *TransactionError
+	if errors.As(err, &transactionErr) {
+		log.Error(msg, relay.MsgError, err, "code", transactionErr.Code, relay.MsgStatus, http.StatusBadRequest)
+		return Response(http.StatusBadRequest, nil), err
+	}
+	log.Error(msg, relay.MsgError, err, relay.MsgStatus, http.StatusServiceUnavailable)
+	return Response(http.StatusServiceUnavailable, nil), err
+}
@@ -0,0 +1,120 @@
+package qcwriteapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MaxBatchTransactions is the maximum number of transactions in a batch.
+var MaxBatchTransactions = 1000
+
+// BatchSendConcurrency is the number of senders of a batch whose transactions are sent at the same time.
+var BatchSendConcurrency = 8
+
+var (
+	ErrEmptyBatch    = errors.New("empty transaction batch")
+	ErrBatchTooLarge = errors.New("too many transactions in the batch")
+)
+
+// batchTransaction is a transaction of a batch that was decoded and has a valid signature.
+type batchTransaction struct {
+	index    int
+	rawTxHex string
+	tx       *types.Transaction
+	from     common.Address
+	signer   types.Signer
+}
+
+// transactionErrorModel returns the error of a transaction of a batch.
+func transactionErrorModel(err error) *ErrorResponseModel {
+	message := err.Error()
+	var transactionErr *TransactionError
+	if errors.As(err, &transactionErr) {
+		return &ErrorResponseModel{Message: &message, Status: http.StatusBadRequest, Code: &transactionErr.Code}
+	}
+	return &ErrorResponseModel{Message: &message, Status: http.StatusServiceUnavailable}
+}
+
+// checkBatch checks the size of a batch.
+func checkBatch(rawTxHexes []string) error {
+	if len(rawTxHexes) == 0 {
+		return ErrEmptyBatch
+	}
+	if len(rawTxHexes) > MaxBatchTransactions {
+		return fmt.Errorf("%w: %d, the maximum is %d", ErrBatchTooLarge, len(rawTxHexes), MaxBatchTransactions)
+	}
+	return nil
+}
+
+// sendBatch sends the transactions of a batch, and returns the result of each transaction in the order of the batch.
+// The transactions of a sender are sent one at a time in the order of their nonce; once one of them fails, the
+// following ones are skipped, since the node would not be able to include them.
+func (s *WriteApiAPIService) sendBatch(ctx context.Context, rawTxHexes []string) []SendTransactionResult {
+	results := make([]SendTransactionResult, len(rawTxHexes))
+	senders := make(map[common.Address][]*batchTransaction)
+	for i, rawTxHex := range rawTxHexes {
+		results[i].Index = int32(i)
+
+		if len(strings.TrimSpace(rawTxHex)) == 0 {
+			results[i].Error = transactionErrorModel(newTransactionError(TRANSACTION_ERROR_INVALID_ENCODING, errors.New("empty raw tx")))
+			continue
+		}
+		tx, err := decodeRawTransaction(rawTxHex)
+		if err != nil {
+			results[i].Error = transactionErrorModel(err)
+			continue
+		}
+		from, signer, err := s.transactionSender(ctx, tx)
+		if err != nil {
+			results[i].Error = transactionErrorModel(err)
+			continue
+		}
+		senders[from] = append(senders[from], &batchTransaction{index: i, rawTxHex: rawTxHex, tx: tx, from: from, signer: signer})
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, BatchSendConcurrency)
+	for _, transactions := range senders {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(transactions []*batchTransaction) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			sort.SliceStable(transactions, func(i, j int) bool {
+				return transactions[i].tx.Nonce() < transactions[j].tx.Nonce()
+			})
+			var failed *batchTransaction
+			for _, transaction := range transactions {
+				result := &results[transaction.index]
+				if failed != nil {
+					result.Error = transactionErrorModel(newTransactionError(TRANSACTION_ERROR_SKIPPED,
+						fmt.Errorf("not sent, the transaction at index %d with nonce %d failed", failed.index, failed.tx.Nonce())))
+					continue
+				}
+
+				txHash, err := s.sendTransaction(ctx, transaction.tx, transaction.rawTxHex, transaction.from, transaction.signer)
+				if err != nil {
+					result.Error = transactionErrorModel(err)
+					var transactionErr *TransactionError
+					if errors.As(err, &transactionErr) == false || transactionErr.Code != TRANSACTION_ERROR_ALREADY_KNOWN {
+						failed = transaction
+					}
+					continue
+				}
+				hash := txHash.String()
+				result.Hash = &hash
+			}
+		}(transactions)
+	}
+	wg.Wait()
+
+	return results
+}
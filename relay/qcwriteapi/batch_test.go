@@ -0,0 +1,81 @@
+package qcwriteapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"math/big"
+	"net/http"
+	"testing"
+)
+
+func TestSendTransactions(t *testing.T) {
+	//The balance of the senders only covers the gas of transactions with a zero value
+	node := &testNodeService{nonce: 5, pendingNonce: 5, balance: new(big.Int).Mul(big.NewInt(21000), big.NewInt(types.DEFAULT_PRICE))}
+	server := newTestWriteServer(t, node)
+
+	newTransaction := func(sender int64, nonce uint64) string {
+		return newTestRawTransaction(t, func(tx *types.DefaultFeeTx) {
+			tx.S = big.NewInt(sender)
+			tx.Nonce = nonce
+			tx.Value = big.NewInt(0)
+		})
+	}
+	txnDataList := []string{
+		newTransaction(1, 7),
+		newTransaction(2, 4),
+		"0x01",
+		newTransaction(1, 5),
+		newTransaction(2, 5),
+		newTransaction(1, 6),
+	}
+	body, _ := json.Marshal(SendTransactionsRequest{TxnDataList: txnDataList})
+	response, err := http.Post(server.URL+"/transactions/batch", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("failed %d", response.StatusCode)
+	}
+	var batchResponse SendTransactionsResponse
+	if err = json.NewDecoder(response.Body).Decode(&batchResponse); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	expected := []string{"", TRANSACTION_ERROR_NONCE_TOO_LOW, TRANSACTION_ERROR_UNSUPPORTED_TYPE, "", TRANSACTION_ERROR_SKIPPED, ""}
+	if len(batchResponse.Results) != len(expected) {
+		t.Fatalf("failed %v", batchResponse.Results)
+	}
+	for i, result := range batchResponse.Results {
+		if result.Index != int32(i) {
+			t.Fatalf("failed %d %d", i, result.Index)
+		}
+		if len(expected[i]) == 0 {
+			if result.Hash == nil || result.Error != nil {
+				t.Fatalf("failed %d %v", i, result.Error)
+			}
+			continue
+		}
+		if result.Hash != nil || result.Error == nil || *result.Error.Code != expected[i] {
+			t.Fatalf("failed %d %v", i, result)
+		}
+	}
+
+	//The transactions of a sender are sent in the order of their nonce
+	if len(node.sent) != 3 || node.sent[0] != txnDataList[3] || node.sent[1] != txnDataList[5] || node.sent[2] != txnDataList[0] {
+		t.Fatalf("failed %v", node.sent)
+	}
+
+	for _, txnDataList := range [][]string{nil, make([]string, MaxBatchTransactions+1)} {
+		body, _ = json.Marshal(SendTransactionsRequest{TxnDataList: txnDataList})
+		response, err = http.Post(server.URL+"/transactions/batch", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed %v", err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest {
+			t.Fatalf("failed %d", response.StatusCode)
+		}
+	}
+}
@@ -69,6 +69,14 @@ func DefaultErrorHandler(w http.ResponseWriter, _ *http.Request, err error, resu
 		return
 	} 
 
+	var transactionErr *TransactionError
+	if ok := errors.As(err, &transactionErr); ok {
+		// Handle transaction errors
+		message := transactionErr.Error()
+		_ = EncodeJSONResponse(ErrorResponseModel{Message: &message, Status: int32(result.Code), Code: &transactionErr.Code}, &result.Code, w)
+		return
+	}
+
 	// Handle all other errors
 	_ = EncodeJSONResponse(err.Error(), &result.Code, w)
 }
@@ -18,6 +18,8 @@ type ErrorResponseModel struct {
 	Message *string `json:"message,omitempty"`
 
 	Status int32 `json:"status,omitempty"`
+
+	Code *string `json:"code,omitempty"`
 }
 
 // AssertErrorResponseModelRequired checks if the required fields are not zero-ed
@@ -0,0 +1,567 @@
+package qcwriteapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/core/rawdb"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/ethdb"
+	"github.com/QuantumCoinProject/qc/log"
+	"github.com/QuantumCoinProject/qc/relay"
+	"github.com/QuantumCoinProject/qc/rpc"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	TRANSACTION_STATUS_PENDING  = "pending"
+	TRANSACTION_STATUS_INCLUDED = "included"
+	TRANSACTION_STATUS_DROPPED  = "dropped"
+	TRANSACTION_STATUS_REPLACED = "replaced"
+)
+
+const WEBHOOK_SIGNATURE_HEADER_NAME = "X-Webhook-Signature"
+
+// TrackerCheckInterval is how often the pending transactions are checked.
+var TrackerCheckInterval = 15 * time.Second
+
+// RebroadcastInterval is how long a transaction that is not in the pool of the node waits before it is sent again.
+var RebroadcastInterval = time.Minute
+
+// MaxRebroadcasts is the number of times a transaction is sent again before it is dropped.
+var MaxRebroadcasts = 10
+
+// TrackedTransactionRetention is how long transactions are kept after they are included, dropped or replaced.
+var TrackedTransactionRetention = 7 * 24 * time.Hour
+
+// MaxWebhookAttempts is the number of times a status change is posted to the webhook before it is given up.
+var MaxWebhookAttempts = 5
+
+// WebhookTimeout is the timeout of a webhook request.
+var WebhookTimeout = 10 * time.Second
+
+// MaxConcurrentWebhooks is the number of webhook requests that are in flight at once. Status changes that do not get a
+// slot are posted on a later check.
+var MaxConcurrentWebhooks = 8
+
+// TrackerConfirmations is the number of blocks on top of the block of an included transaction after which it is no
+// longer checked for reorgs.
+var TrackerConfirmations = uint64(6)
+
+const trackedTransactionPrefix = "tracked-tx-"
+
+var TrackedTransactionKey = trackedTransactionPrefix + "%s" //%s is transaction hash
+
+var (
+	ErrTrackingDisabled      = errors.New("transaction tracking is not enabled")
+	ErrTransactionNotTracked = errors.New("transaction not found")
+)
+
+// trackedTransaction is a transaction sent by the relay, as persisted by the tracker.
+type trackedTransaction struct {
+	Hash            string    `json:"hash"`
+	From            string    `json:"from"`
+	Nonce           uint64    `json:"nonce"`
+	RawTx           string    `json:"rawTx"`
+	Status          string    `json:"status"`
+	BlockNumber     *uint64   `json:"blockNumber,omitempty"`
+	BlockHash       string    `json:"blockHash,omitempty"`
+	ReceiptStatus   string    `json:"receiptStatus,omitempty"`
+	SubmittedAt     time.Time `json:"submittedAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	BroadcastAt     time.Time `json:"broadcastAt"`
+	Rebroadcasts    int       `json:"rebroadcasts"`
+	Final           bool      `json:"final,omitempty"`         //included and confirmed by TrackerConfirmations blocks
+	StatusChanges   int       `json:"statusChanges,omitempty"` //tells the webhook requests of successive statuses apart
+	WebhookPending  bool      `json:"webhookPending,omitempty"`
+	WebhookAttempts int       `json:"webhookAttempts,omitempty"`
+}
+
+// isActive returns whether the transaction is still checked, either because it is pending or because its block could
+// still be reorged out.
+func (tracked *trackedTransaction) isActive() bool {
+	return tracked.Status == TRANSACTION_STATUS_PENDING || (tracked.Status == TRANSACTION_STATUS_INCLUDED && tracked.Final == false)
+}
+
+// trackedReceipt is the part of the receipt of a transaction that is tracked.
+type trackedReceipt struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Status      hexutil.Uint64 `json:"status"`
+}
+
+// setReceipt sets the block and the receipt status of the transaction from its receipt.
+func (tracked *trackedTransaction) setReceipt(receipt *trackedReceipt) {
+	blockNumber := uint64(receipt.BlockNumber)
+	tracked.BlockNumber = &blockNumber
+	tracked.BlockHash = strings.ToLower(receipt.BlockHash.Hex())
+	tracked.ReceiptStatus = receipt.Status.String()
+}
+
+func (tracked *trackedTransaction) transactionStatus() TransactionStatus {
+	status := TransactionStatus{
+		Hash:         tracked.Hash,
+		From:         tracked.From,
+		Nonce:        int64(tracked.Nonce),
+		Status:       tracked.Status,
+		SubmittedAt:  tracked.SubmittedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:    tracked.UpdatedAt.UTC().Format(time.RFC3339),
+		Rebroadcasts: int32(tracked.Rebroadcasts),
+	}
+	if tracked.BlockNumber != nil {
+		blockNumber := int64(*tracked.BlockNumber)
+		status.BlockNumber = &blockNumber
+	}
+	if len(tracked.BlockHash) > 0 {
+		status.BlockHash = &tracked.BlockHash
+	}
+	if len(tracked.ReceiptStatus) > 0 {
+		status.ReceiptStatus = &tracked.ReceiptStatus
+	}
+	return status
+}
+
+// TransactionTracker persists the transactions sent by the write relay, and checks whether they were included.
+// Transactions that drop out of the pool of the node are sent again, and included transactions are checked until they
+// are confirmed, in case they are reorged out. Status changes are posted to the webhook, if one is configured.
+type TransactionTracker struct {
+	lock          sync.Mutex
+	db            ethdb.Database
+	nodePool      *relay.NodePool
+	webhookUrl    string
+	webhookSecret string
+	httpClient    *http.Client
+	quit          chan struct{}
+	wg            sync.WaitGroup
+	now           func() time.Time
+
+	webhookCtx      context.Context
+	webhookCancel   context.CancelFunc
+	webhookSlots    chan struct{}   // Bounds the webhook requests in flight
+	webhookInFlight map[string]bool // Transactions whose status is being posted, guarded by lock
+	webhookWg       sync.WaitGroup
+}
+
+// NewTransactionTracker creates a tracker that keeps its transactions in the tracker directory.
+func NewTransactionTracker(trackerDir string, nodePool *relay.NodePool, webhookUrl string, webhookSecret string) (*TransactionTracker, error) {
+	db, err := rawdb.NewLevelDBDatabase(filepath.Join(trackerDir, "tracker.db"), 16, 0, "", false)
+	if err != nil {
+		return nil, err
+	}
+	t := &TransactionTracker{
+		db:              db,
+		nodePool:        nodePool,
+		webhookUrl:      webhookUrl,
+		webhookSecret:   webhookSecret,
+		httpClient:      &http.Client{Timeout: WebhookTimeout},
+		quit:            make(chan struct{}),
+		now:             time.Now,
+		webhookSlots:    make(chan struct{}, MaxConcurrentWebhooks),
+		webhookInFlight: make(map[string]bool),
+	}
+	t.webhookCtx, t.webhookCancel = context.WithCancel(context.Background())
+	t.wg.Add(1)
+	go t.loop()
+	return t, nil
+}
+
+// Close stops checking the transactions, cancels the webhook requests in flight and closes the database.
+func (t *TransactionTracker) Close() error {
+	close(t.quit)
+	t.wg.Wait()
+	t.webhookCancel()
+	t.webhookWg.Wait()
+	return t.db.Close()
+}
+
+func (t *TransactionTracker) loop() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(TrackerCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.check()
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// Track starts tracking a transaction that was sent. A transaction that is already tracked is not changed, unless it
+// was dropped.
+func (t *TransactionTracker) Track(tx *types.Transaction, from common.Address, rawTxHex string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	hash := strings.ToLower(tx.Hash().Hex())
+	existing, err := t.get(hash)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Status != TRANSACTION_STATUS_DROPPED {
+		return nil
+	}
+
+	now := t.now()
+	return t.put(&trackedTransaction{
+		Hash:        hash,
+		From:        strings.ToLower(from.Hex()),
+		Nonce:       tx.Nonce(),
+		RawTx:       rawTxHex,
+		Status:      TRANSACTION_STATUS_PENDING,
+		SubmittedAt: now,
+		UpdatedAt:   now,
+		BroadcastAt: now,
+	})
+}
+
+// Status returns the status of a tracked transaction.
+func (t *TransactionTracker) Status(hash string) (*TransactionStatus, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	tracked, err := t.get(strings.ToLower(hash))
+	if err != nil {
+		return nil, err
+	}
+	if tracked == nil {
+		return nil, ErrTransactionNotTracked
+	}
+	status := tracked.transactionStatus()
+	return &status, nil
+}
+
+func (t *TransactionTracker) get(hash string) (*trackedTransaction, error) {
+	data, err := t.db.Get([]byte(fmt.Sprintf(TrackedTransactionKey, hash)))
+	if err != nil {
+		if err.Error() == "leveldb: not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var tracked trackedTransaction
+	if err = json.Unmarshal(data, &tracked); err != nil {
+		return nil, err
+	}
+	return &tracked, nil
+}
+
+func (t *TransactionTracker) put(tracked *trackedTransaction) error {
+	data, err := json.Marshal(tracked)
+	if err != nil {
+		return err
+	}
+	return t.db.Put([]byte(fmt.Sprintf(TrackedTransactionKey, tracked.Hash)), data)
+}
+
+// list returns all the tracked transactions.
+func (t *TransactionTracker) list() ([]*trackedTransaction, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	it := t.db.NewIterator([]byte(trackedTransactionPrefix), nil)
+	defer it.Release()
+
+	var list []*trackedTransaction
+	for it.Next() {
+		var tracked trackedTransaction
+		if err := json.Unmarshal(it.Value(), &tracked); err != nil {
+			log.Error("TransactionTracker unmarshal", "key", string(it.Key()), "error", err)
+			continue
+		}
+		list = append(list, &tracked)
+	}
+	return list, it.Error()
+}
+
+// update saves the new state of a transaction, unless it was tracked again in the meantime. If the status did not
+// change, the webhook state is kept, since a webhook request may have completed in the meantime.
+func (t *TransactionTracker) update(tracked *trackedTransaction, submittedAt time.Time) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	existing, err := t.get(tracked.Hash)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.SubmittedAt.Equal(submittedAt) == false {
+		return nil
+	}
+	if existing.StatusChanges == tracked.StatusChanges {
+		tracked.WebhookPending = existing.WebhookPending
+		tracked.WebhookAttempts = existing.WebhookAttempts
+	}
+	return t.put(tracked)
+}
+
+// check updates the pending and included transactions, posts the status changes to the webhook and deletes the
+// expired transactions.
+func (t *TransactionTracker) check() {
+	list, err := t.list()
+	if err != nil {
+		log.Error("TransactionTracker list", "error", err)
+		return
+	}
+
+	for _, tracked := range list {
+		submittedAt := tracked.SubmittedAt
+		if tracked.isActive() {
+			var changed bool
+			if tracked.Status == TRANSACTION_STATUS_PENDING {
+				changed, err = t.checkPending(tracked)
+			} else {
+				changed, err = t.checkIncluded(tracked)
+			}
+			if err != nil {
+				log.Warn("TransactionTracker check", "hash", tracked.Hash, "error", err)
+				continue
+			}
+			if changed {
+				tracked.UpdatedAt = t.now()
+				tracked.StatusChanges++
+				tracked.WebhookPending = len(t.webhookUrl) > 0
+				tracked.WebhookAttempts = 0
+				log.Info("TransactionTracker status", "hash", tracked.Hash, "status", tracked.Status)
+			}
+			if err = t.update(tracked, submittedAt); err != nil {
+				log.Error("TransactionTracker update", "hash", tracked.Hash, "error", err)
+				continue
+			}
+		} else if tracked.WebhookPending == false && t.now().Sub(tracked.UpdatedAt) > TrackedTransactionRetention {
+			t.lock.Lock()
+			err = t.db.Delete([]byte(fmt.Sprintf(TrackedTransactionKey, tracked.Hash)))
+			t.lock.Unlock()
+			if err != nil {
+				log.Error("TransactionTracker delete", "hash", tracked.Hash, "error", err)
+			}
+			continue
+		}
+
+		if tracked.WebhookPending {
+			t.startWebhook(tracked)
+		}
+	}
+}
+
+// checkPending checks whether a pending transaction was included, dropped or replaced, and sends it again if the node
+// no longer has it. Returns whether the status changed.
+func (t *TransactionTracker) checkPending(tracked *trackedTransaction) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), TrackerCheckInterval)
+	defer cancel()
+
+	var receipt *trackedReceipt
+	if err := t.nodePool.CallContext(ctx, &receipt, "eth_getTransactionReceipt", common.HexToHash(tracked.Hash)); err != nil {
+		return false, err
+	}
+	if receipt != nil {
+		tracked.Status = TRANSACTION_STATUS_INCLUDED
+		tracked.setReceipt(receipt)
+		return true, nil
+	}
+
+	var raw json.RawMessage
+	if err := t.nodePool.CallContext(ctx, &raw, "eth_getTransactionByHash", common.HexToHash(tracked.Hash)); err != nil {
+		return false, err
+	}
+	if len(raw) > 0 && string(raw) != "null" {
+		return false, nil
+	}
+
+	//The transaction is not in the pool; it was replaced if its nonce was used by another transaction. The calls above
+	//may have gone to a node that is behind, so the nonce is read in one batch with the receipt, from the same node
+	var nonce hexutil.Uint64
+	batch := []rpc.BatchElem{
+		{Method: "eth_getTransactionCount", Args: []interface{}{common.HexToAddress(tracked.From), "latest"}, Result: &nonce},
+		{Method: "eth_getTransactionReceipt", Args: []interface{}{common.HexToHash(tracked.Hash)}, Result: &receipt},
+	}
+	if err := t.nodePool.BatchCallContext(ctx, batch); err != nil {
+		return false, err
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return false, elem.Error
+		}
+	}
+	if receipt != nil {
+		tracked.Status = TRANSACTION_STATUS_INCLUDED
+		tracked.setReceipt(receipt)
+		return true, nil
+	}
+	if uint64(nonce) > tracked.Nonce {
+		tracked.Status = TRANSACTION_STATUS_REPLACED
+		return true, nil
+	}
+
+	if t.now().Sub(tracked.BroadcastAt) < RebroadcastInterval {
+		return false, nil
+	}
+	if tracked.Rebroadcasts >= MaxRebroadcasts {
+		tracked.Status = TRANSACTION_STATUS_DROPPED
+		return true, nil
+	}
+
+	tracked.Rebroadcasts++
+	tracked.BroadcastAt = t.now()
+	_, err := t.nodePool.SendRawTransaction(ctx, tracked.RawTx)
+	if err == nil {
+		log.Info("TransactionTracker rebroadcast", "hash", tracked.Hash, "rebroadcasts", tracked.Rebroadcasts)
+		return false, nil
+	}
+	var transactionErr *TransactionError
+	if errors.As(nodeTransactionError(err), &transactionErr) == false {
+		return false, err
+	}
+	switch transactionErr.Code {
+	case TRANSACTION_ERROR_ALREADY_KNOWN, TRANSACTION_ERROR_NONCE_TOO_LOW:
+		//A node whose nonce is ahead may have included the transaction, which the next check reads from a single node
+		return false, nil
+	default:
+		log.Warn("TransactionTracker rebroadcast rejected", "hash", tracked.Hash, "error", err)
+		tracked.Status = TRANSACTION_STATUS_DROPPED
+	}
+	return true, nil
+}
+
+// checkIncluded checks whether an included transaction is still in the block it was included in. A transaction that
+// was reorged out is pending again, and one that was included in another block gets the new block. Once the block has
+// TrackerConfirmations blocks on top of it, the transaction is final. Returns whether the status changed.
+func (t *TransactionTracker) checkIncluded(tracked *trackedTransaction) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), TrackerCheckInterval)
+	defer cancel()
+
+	var receipt *trackedReceipt
+	if err := t.nodePool.CallContext(ctx, &receipt, "eth_getTransactionReceipt", common.HexToHash(tracked.Hash)); err != nil {
+		return false, err
+	}
+	if receipt == nil {
+		log.Warn("TransactionTracker reorged out", "hash", tracked.Hash, "blockHash", tracked.BlockHash)
+		tracked.Status = TRANSACTION_STATUS_PENDING
+		tracked.BlockNumber = nil
+		tracked.BlockHash = ""
+		tracked.ReceiptStatus = ""
+		tracked.BroadcastAt = t.now()
+		return true, nil
+	}
+	blockHash := strings.ToLower(receipt.BlockHash.Hex())
+	if blockHash != tracked.BlockHash {
+		log.Warn("TransactionTracker included in another block", "hash", tracked.Hash, "blockHash", blockHash)
+		tracked.setReceipt(receipt)
+		return true, nil
+	}
+
+	var head hexutil.Uint64
+	if err := t.nodePool.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+		return false, err
+	}
+	if uint64(head) >= *tracked.BlockNumber+TrackerConfirmations {
+		tracked.Final = true
+	}
+	return false, nil
+}
+
+// startWebhook posts the status of a transaction to the webhook in the background, so that an unreachable webhook
+// does not hold up the checks. The status is posted on a later check if all the webhook slots are in use, or if its
+// previous request is still in flight.
+func (t *TransactionTracker) startWebhook(tracked *trackedTransaction) {
+	t.lock.Lock()
+	if t.webhookInFlight[tracked.Hash] {
+		t.lock.Unlock()
+		return
+	}
+	existing, err := t.get(tracked.Hash)
+	if err != nil || existing == nil || existing.WebhookPending == false ||
+		existing.StatusChanges != tracked.StatusChanges {
+		t.lock.Unlock()
+		return
+	}
+	select {
+	case t.webhookSlots <- struct{}{}:
+	default:
+		t.lock.Unlock()
+		return
+	}
+	t.webhookInFlight[tracked.Hash] = true
+	t.lock.Unlock()
+
+	t.webhookWg.Add(1)
+	go func() {
+		defer t.webhookWg.Done()
+		err := t.postWebhook(tracked)
+
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		delete(t.webhookInFlight, tracked.Hash)
+		<-t.webhookSlots
+		if err = t.webhookDone(tracked, err); err != nil {
+			log.Error("TransactionTracker update", "hash", tracked.Hash, "error", err)
+		}
+	}()
+}
+
+// webhookDone records the result of posting the status of a transaction, unless the status changed or the transaction
+// was tracked again in the meantime. Must be called with the lock held.
+func (t *TransactionTracker) webhookDone(tracked *trackedTransaction, err error) error {
+	existing, getErr := t.get(tracked.Hash)
+	if getErr != nil {
+		return getErr
+	}
+	if existing == nil || existing.SubmittedAt.Equal(tracked.SubmittedAt) == false ||
+		existing.StatusChanges != tracked.StatusChanges || existing.WebhookPending == false {
+		return nil
+	}
+
+	if err == nil {
+		existing.WebhookPending = false
+		return t.put(existing)
+	}
+	existing.WebhookAttempts++
+	if existing.WebhookAttempts >= MaxWebhookAttempts {
+		log.Error("TransactionTracker webhook failed, giving up", "hash", existing.Hash, "attempts", existing.WebhookAttempts, "error", err)
+		existing.WebhookPending = false
+		return t.put(existing)
+	}
+	log.Warn("TransactionTracker webhook failed", "hash", existing.Hash, "attempts", existing.WebhookAttempts, "error", err)
+	return t.put(existing)
+}
+
+// postWebhook posts the status of a transaction to the webhook. The request is signed with the webhook secret, if one
+// is configured.
+func (t *TransactionTracker) postWebhook(tracked *trackedTransaction) error {
+	body, err := json.Marshal(tracked.transactionStatus())
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(t.webhookCtx, http.MethodPost, t.webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if len(t.webhookSecret) > 0 {
+		mac := hmac.New(sha256.New, []byte(t.webhookSecret))
+		mac.Write(body)
+		request.Header.Set(WEBHOOK_SIGNATURE_HEADER_NAME, hex.EncodeToString(mac.Sum(nil)))
+	}
+	response, err := t.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook status %d", response.StatusCode)
+	}
+	return nil
+}
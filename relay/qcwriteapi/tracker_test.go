@@ -0,0 +1,309 @@
+package qcwriteapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/relay"
+	"github.com/QuantumCoinProject/qc/rpc"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testWebhook struct {
+	lock     sync.Mutex
+	statuses []TransactionStatus
+	failures int
+}
+
+func (webhook *testWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	webhook.lock.Lock()
+	defer webhook.lock.Unlock()
+
+	if webhook.failures > 0 {
+		webhook.failures--
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if r.Header.Get(WEBHOOK_SIGNATURE_HEADER_NAME) != hex.EncodeToString(mac.Sum(nil)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var status TransactionStatus
+	json.Unmarshal(body, &status)
+	webhook.statuses = append(webhook.statuses, status)
+}
+
+func newTestTracker(t *testing.T, node *testNodeService, webhook *testWebhook) (*TransactionTracker, *time.Time) {
+	checkInterval := TrackerCheckInterval
+	TrackerCheckInterval = time.Hour
+	t.Cleanup(func() { TrackerCheckInterval = checkInterval })
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	nodeServer := httptest.NewServer(server)
+	t.Cleanup(nodeServer.Close)
+	nodePool, err := relay.NewNodePool([]string{nodeServer.URL})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	t.Cleanup(nodePool.Close)
+
+	webhookServer := httptest.NewServer(webhook)
+	t.Cleanup(webhookServer.Close)
+
+	tracker, err := NewTransactionTracker(t.TempDir(), nodePool, webhookServer.URL, "secret")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	t.Cleanup(func() { tracker.Close() })
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+	return tracker, &now
+}
+
+func trackTestTransaction(t *testing.T, tracker *TransactionTracker, nonce uint64) common.Hash {
+	rawTxHex := newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.Nonce = nonce })
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(hexutil.MustDecode(rawTxHex)); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if err := tracker.Track(tx, testSenderAddress, rawTxHex); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	return tx.Hash()
+}
+
+func checkTestTransactionStatus(t *testing.T, tracker *TransactionTracker, hash common.Hash, expected string) *TransactionStatus {
+	status, err := tracker.Status(hash.Hex())
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	if status.Status != expected {
+		t.Fatalf("failed %s, expected %s", status.Status, expected)
+	}
+	return status
+}
+
+func TestTransactionTracker(t *testing.T) {
+	node := &testNodeService{
+		nonce:    5,
+		receipts: make(map[common.Hash]map[string]interface{}),
+		pool:     make(map[common.Hash]bool),
+	}
+	webhook := &testWebhook{failures: 1}
+	tracker, now := newTestTracker(t, node, webhook)
+
+	included := trackTestTransaction(t, tracker, 5)
+	replaced := trackTestTransaction(t, tracker, 6)
+	dropped := trackTestTransaction(t, tracker, 7)
+	node.pool[included] = true
+
+	//Only the transactions that are not in the pool are sent again, once per RebroadcastInterval
+	*now = now.Add(RebroadcastInterval)
+	tracker.check()
+	tracker.check()
+	if len(node.sent) != 2 {
+		t.Fatalf("failed %d", len(node.sent))
+	}
+	status := checkTestTransactionStatus(t, tracker, dropped, TRANSACTION_STATUS_PENDING)
+	if status.Rebroadcasts != 1 {
+		t.Fatalf("failed %d", status.Rebroadcasts)
+	}
+
+	node.receipts[included] = map[string]interface{}{"blockNumber": "0xa", "blockHash": common.BytesToHash([]byte{0x0a}), "status": "0x1"}
+	node.nonce = 7
+	node.sendErr = errors.New("insufficient funds for gas * price + value")
+	*now = now.Add(RebroadcastInterval)
+	tracker.check()
+	tracker.webhookWg.Wait()
+
+	status = checkTestTransactionStatus(t, tracker, included, TRANSACTION_STATUS_INCLUDED)
+	if *status.BlockNumber != 10 || *status.ReceiptStatus != "0x1" {
+		t.Fatalf("failed %v", status)
+	}
+	checkTestTransactionStatus(t, tracker, replaced, TRANSACTION_STATUS_REPLACED)
+	checkTestTransactionStatus(t, tracker, dropped, TRANSACTION_STATUS_DROPPED)
+
+	//The first webhook request fails, and is sent again on the next check
+	webhook.lock.Lock()
+	if len(webhook.statuses) != 2 {
+		t.Fatalf("failed %v", webhook.statuses)
+	}
+	webhook.lock.Unlock()
+	tracker.check()
+	tracker.webhookWg.Wait()
+	webhook.lock.Lock()
+	if len(webhook.statuses) != 3 {
+		t.Fatalf("failed %v", webhook.statuses)
+	}
+	webhook.lock.Unlock()
+
+	*now = now.Add(TrackedTransactionRetention + time.Second)
+	tracker.check()
+	if _, err := tracker.Status(included.Hex()); err != ErrTransactionNotTracked {
+		t.Fatalf("failed %v", err)
+	}
+}
+
+func TestTransactionTracker_maxRebroadcasts(t *testing.T) {
+	node := &testNodeService{nonce: 5}
+	tracker, now := newTestTracker(t, node, &testWebhook{})
+
+	hash := trackTestTransaction(t, tracker, 5)
+	for i := 0; i < MaxRebroadcasts; i++ {
+		*now = now.Add(RebroadcastInterval)
+		tracker.check()
+	}
+	checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_PENDING)
+	*now = now.Add(RebroadcastInterval)
+	tracker.check()
+	checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_DROPPED)
+	if len(node.sent) != MaxRebroadcasts {
+		t.Fatalf("failed %d", len(node.sent))
+	}
+
+	//A dropped transaction can be tracked again
+	trackTestTransaction(t, tracker, 5)
+	checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_PENDING)
+}
+
+func TestTransactionTracker_receiptBehindNonce(t *testing.T) {
+	node := &testNodeService{
+		nonce:       6,
+		lagReceipts: 1,
+		receipts:    make(map[common.Hash]map[string]interface{}),
+	}
+	tracker, _ := newTestTracker(t, node, &testWebhook{})
+
+	//The first receipt call is answered as by a node that is behind, the transaction is not replaced by the nonce it used
+	hash := trackTestTransaction(t, tracker, 5)
+	node.receipts[hash] = map[string]interface{}{"blockNumber": "0xa", "blockHash": common.BytesToHash([]byte{0x0a}), "status": "0x1"}
+	tracker.check()
+	status := checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_INCLUDED)
+	if *status.BlockNumber != 10 {
+		t.Fatalf("failed %v", status)
+	}
+}
+
+func TestTransactionTracker_reorg(t *testing.T) {
+	node := &testNodeService{
+		blockNumber: 12,
+		nonce:       5,
+		receipts:    make(map[common.Hash]map[string]interface{}),
+		pool:        make(map[common.Hash]bool),
+	}
+	webhook := &testWebhook{}
+	tracker, _ := newTestTracker(t, node, webhook)
+	checkAndPost := func() {
+		tracker.check()
+		tracker.webhookWg.Wait()
+	}
+
+	hash := trackTestTransaction(t, tracker, 5)
+	node.pool[hash] = true
+	node.receipts[hash] = map[string]interface{}{"blockNumber": "0xa", "blockHash": common.BytesToHash([]byte{0x0a}), "status": "0x1"}
+	checkAndPost()
+	checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_INCLUDED)
+
+	//The transaction is reorged out before it is confirmed
+	delete(node.receipts, hash)
+	checkAndPost()
+	status := checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_PENDING)
+	if status.BlockNumber != nil || status.BlockHash != nil {
+		t.Fatalf("failed %v", status)
+	}
+
+	//It is included in another block, which is then confirmed
+	node.receipts[hash] = map[string]interface{}{"blockNumber": "0xb", "blockHash": common.BytesToHash([]byte{0x0b}), "status": "0x1"}
+	checkAndPost()
+	checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_INCLUDED)
+	node.receipts[hash]["blockHash"] = common.BytesToHash([]byte{0x0c})
+	checkAndPost()
+	status = checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_INCLUDED)
+	if *status.BlockHash != strings.ToLower(common.BytesToHash([]byte{0x0c}).Hex()) {
+		t.Fatalf("failed %v", *status.BlockHash)
+	}
+	node.blockNumber = 11 + TrackerConfirmations
+	checkAndPost()
+
+	//A final transaction is no longer checked
+	delete(node.receipts, hash)
+	checkAndPost()
+	checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_INCLUDED)
+
+	webhook.lock.Lock()
+	defer webhook.lock.Unlock()
+	if len(webhook.statuses) != 4 {
+		t.Fatalf("failed %v", webhook.statuses)
+	}
+}
+
+func TestTransactionTracker_slowWebhook(t *testing.T) {
+	node := &testNodeService{
+		nonce:    5,
+		receipts: make(map[common.Hash]map[string]interface{}),
+		pool:     make(map[common.Hash]bool),
+	}
+	release := make(chan struct{})
+	webhook := &testWebhook{}
+	blocked := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		webhook.ServeHTTP(w, r)
+	})
+	tracker, _ := newTestTracker(t, node, webhook)
+	webhookServer := httptest.NewServer(blocked)
+	t.Cleanup(webhookServer.Close)
+	tracker.webhookUrl = webhookServer.URL
+
+	//The checks go on while the webhook requests are held up, with at most MaxConcurrentWebhooks in flight
+	hashes := make([]common.Hash, MaxConcurrentWebhooks+2)
+	for i := range hashes {
+		hashes[i] = trackTestTransaction(t, tracker, 5+uint64(i))
+		node.receipts[hashes[i]] = map[string]interface{}{"blockNumber": "0xa", "blockHash": common.BytesToHash([]byte{0x0a}), "status": "0x1"}
+	}
+	done := make(chan struct{})
+	go func() {
+		tracker.check()
+		tracker.check()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("check held up by the webhook")
+	}
+	for _, hash := range hashes {
+		checkTestTransactionStatus(t, tracker, hash, TRANSACTION_STATUS_INCLUDED)
+	}
+	if len(tracker.webhookSlots) != MaxConcurrentWebhooks {
+		t.Fatalf("failed %d", len(tracker.webhookSlots))
+	}
+
+	//The remaining statuses are posted once slots are free
+	close(release)
+	tracker.webhookWg.Wait()
+	tracker.check()
+	tracker.webhookWg.Wait()
+	webhook.lock.Lock()
+	defer webhook.lock.Unlock()
+	if len(webhook.statuses) != len(hashes) {
+		t.Fatalf("failed %d", len(webhook.statuses))
+	}
+}
@@ -0,0 +1,203 @@
+package qcwriteapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/conversionutil"
+	"github.com/QuantumCoinProject/qc/core"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/rpc"
+	"math/big"
+	"strings"
+)
+
+// Codes of the errors of transactions that are not valid, or that are rejected by the node.
+const (
+	TRANSACTION_ERROR_INVALID_ENCODING      = "INVALID_ENCODING"
+	TRANSACTION_ERROR_UNSUPPORTED_TYPE      = "UNSUPPORTED_TRANSACTION_TYPE"
+	TRANSACTION_ERROR_INVALID_CHAIN_ID      = "INVALID_CHAIN_ID"
+	TRANSACTION_ERROR_INVALID_SIGNATURE     = "INVALID_SIGNATURE"
+	TRANSACTION_ERROR_REMARKS_TOO_LONG      = "REMARKS_TOO_LONG"
+	TRANSACTION_ERROR_INVALID_GAS_TIER      = "INVALID_GAS_TIER"
+	TRANSACTION_ERROR_INTRINSIC_GAS_TOO_LOW = "INTRINSIC_GAS_TOO_LOW"
+	TRANSACTION_ERROR_GAS_LIMIT_EXCEEDED    = "GAS_LIMIT_EXCEEDED"
+	TRANSACTION_ERROR_OVERSIZED_DATA        = "OVERSIZED_DATA"
+	TRANSACTION_ERROR_NONCE_TOO_LOW         = "NONCE_TOO_LOW"
+	TRANSACTION_ERROR_NONCE_TOO_HIGH        = "NONCE_TOO_HIGH"
+	TRANSACTION_ERROR_INSUFFICIENT_FUNDS    = "INSUFFICIENT_FUNDS"
+	TRANSACTION_ERROR_ALREADY_KNOWN         = "ALREADY_KNOWN"
+	TRANSACTION_ERROR_UNDERPRICED           = "UNDERPRICED"
+	TRANSACTION_ERROR_TXPOOL_FULL           = "TXPOOL_FULL"
+	TRANSACTION_ERROR_REJECTED              = "REJECTED"
+	TRANSACTION_ERROR_SKIPPED               = "SKIPPED" //batch: a transaction of the sender with a lower nonce failed
+)
+
+// MaxNonceGap is how far the nonce of a transaction can be ahead of the pending nonce of the sender. The node keeps
+// transactions with a nonce gap in its queue, up to 64 per account by default.
+var MaxNonceGap uint64 = 64
+
+// newSigner returns the signer used to check the signature of transactions.
+var newSigner = types.NewLondonSigner
+
+// nodeTransactionErrors maps the errors returned by the node for a transaction to error codes.
+var nodeTransactionErrors = []struct {
+	err  error
+	code string
+}{
+	{core.ErrNonceTooLow, TRANSACTION_ERROR_NONCE_TOO_LOW},
+	{core.ErrNonceTooHigh, TRANSACTION_ERROR_NONCE_TOO_HIGH},
+	{core.ErrInsufficientFundsForTransfer, TRANSACTION_ERROR_INSUFFICIENT_FUNDS},
+	{core.ErrInsufficientFunds, TRANSACTION_ERROR_INSUFFICIENT_FUNDS},
+	{core.ErrIntrinsicGas, TRANSACTION_ERROR_INTRINSIC_GAS_TOO_LOW},
+	{core.ErrInvalidSender, TRANSACTION_ERROR_INVALID_SIGNATURE},
+	{core.ErrAlreadyKnown, TRANSACTION_ERROR_ALREADY_KNOWN},
+	{core.ErrReplaceUnderpriced, TRANSACTION_ERROR_UNDERPRICED},
+	{core.ErrUnderpriced, TRANSACTION_ERROR_UNDERPRICED},
+	{core.ErrTxPoolOverflow, TRANSACTION_ERROR_TXPOOL_FULL},
+	{core.ErrGasLimit, TRANSACTION_ERROR_GAS_LIMIT_EXCEEDED},
+	{core.ErrOversizedData, TRANSACTION_ERROR_OVERSIZED_DATA},
+	{types.ErrInvalidChainId, TRANSACTION_ERROR_INVALID_CHAIN_ID},
+}
+
+// TransactionError is the error of a transaction that is not valid, or that was rejected by the node. The code is
+// returned in the error response, so that clients can tell the errors apart.
+type TransactionError struct {
+	Code string
+	Err  error
+}
+
+func (e *TransactionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+func newTransactionError(code string, err error) *TransactionError {
+	return &TransactionError{Code: code, Err: err}
+}
+
+// nodeTransactionError returns the error of a transaction rejected by the node as a TransactionError. Other errors,
+// such as the node being unavailable, are returned unchanged.
+func nodeTransactionError(err error) error {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) == false {
+		return err
+	}
+	for _, nodeErr := range nodeTransactionErrors {
+		if strings.Contains(err.Error(), nodeErr.err.Error()) {
+			return newTransactionError(nodeErr.code, err)
+		}
+	}
+	return newTransactionError(TRANSACTION_ERROR_REJECTED, err)
+}
+
+// decodeRawTransaction decodes a raw transaction, and checks the fields that do not depend on the node.
+func decodeRawTransaction(rawTxHex string) (*types.Transaction, error) {
+	data, err := hexutil.Decode(rawTxHex)
+	if err != nil {
+		return nil, newTransactionError(TRANSACTION_ERROR_INVALID_ENCODING, err)
+	}
+	tx := new(types.Transaction)
+	if err = tx.UnmarshalBinary(data); err != nil {
+		if errors.Is(err, types.ErrTxTypeNotSupported) || (len(data) > 0 && data[0] > 0x7f) {
+			return nil, newTransactionError(TRANSACTION_ERROR_UNSUPPORTED_TYPE, err)
+		}
+		return nil, newTransactionError(TRANSACTION_ERROR_INVALID_ENCODING, err)
+	}
+
+	if len(tx.Remarks()) > types.MAX_REMARKS_LENGTH {
+		return nil, newTransactionError(TRANSACTION_ERROR_REMARKS_TOO_LONG,
+			fmt.Errorf("remarks length %d is more than %d", len(tx.Remarks()), types.MAX_REMARKS_LENGTH))
+	}
+	if types.GetGasTierPrice(tx.GasTier()) == nil {
+		return nil, newTransactionError(TRANSACTION_ERROR_INVALID_GAS_TIER, fmt.Errorf("invalid gas tier %d", tx.GasTier()))
+	}
+	if tx.Value().Sign() < 0 {
+		return nil, newTransactionError(TRANSACTION_ERROR_INVALID_ENCODING, core.ErrNegativeValue)
+	}
+	intrinsicGas, err := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, true, true)
+	if err != nil {
+		return nil, newTransactionError(TRANSACTION_ERROR_INTRINSIC_GAS_TOO_LOW, err)
+	}
+	if tx.Gas() < intrinsicGas {
+		return nil, newTransactionError(TRANSACTION_ERROR_INTRINSIC_GAS_TOO_LOW,
+			fmt.Errorf("%v: have %d, want %d", core.ErrIntrinsicGas, tx.Gas(), intrinsicGas))
+	}
+	return tx, nil
+}
+
+// transactionSender checks the chain id and the signature of a transaction, and returns its sender.
+func (s *WriteApiAPIService) transactionSender(ctx context.Context, tx *types.Transaction) (common.Address, types.Signer, error) {
+	chainID, err := s.getChainID(ctx)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	if tx.ChainId() == nil || tx.ChainId().Cmp(chainID) != 0 {
+		return common.Address{}, nil, newTransactionError(TRANSACTION_ERROR_INVALID_CHAIN_ID,
+			fmt.Errorf("invalid chain id %v, expected %v", tx.ChainId(), chainID))
+	}
+
+	signer := newSigner(chainID)
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return common.Address{}, nil, newTransactionError(TRANSACTION_ERROR_INVALID_SIGNATURE, fmt.Errorf("%v: %v", core.ErrInvalidSender, err))
+	}
+	return from, signer, nil
+}
+
+// validateTransaction checks a decoded transaction against the nonce and balance of its sender on the node, so that
+// the transactions the node would reject are refused with a distinct error code. The sender and signer are the ones
+// returned by transactionSender, so that the signature is only verified once.
+func (s *WriteApiAPIService) validateTransaction(ctx context.Context, tx *types.Transaction, from common.Address, signer types.Signer) error {
+	var nonce hexutil.Uint64
+	if err := s.nodePool.CallContext(ctx, &nonce, "eth_getTransactionCount", from, "latest"); err != nil {
+		return err
+	}
+	if tx.Nonce() < uint64(nonce) {
+		return newTransactionError(TRANSACTION_ERROR_NONCE_TOO_LOW,
+			fmt.Errorf("%v: address %v, tx: %d state: %d", core.ErrNonceTooLow, from.Hex(), tx.Nonce(), uint64(nonce)))
+	}
+	var pendingNonce hexutil.Uint64
+	if err := s.nodePool.CallContext(ctx, &pendingNonce, "eth_getTransactionCount", from, "pending"); err != nil {
+		return err
+	}
+	if tx.Nonce() > uint64(pendingNonce)+MaxNonceGap {
+		return newTransactionError(TRANSACTION_ERROR_NONCE_TOO_HIGH,
+			fmt.Errorf("%v: address %v, tx: %d pending: %d", core.ErrNonceTooHigh, from.Hex(), tx.Nonce(), uint64(pendingNonce)))
+	}
+
+	var balance hexutil.Big
+	if err := s.nodePool.CallContext(ctx, &balance, "eth_getBalance", from, "latest"); err != nil {
+		return err
+	}
+	if balance.ToInt().Cmp(tx.Cost()) < 0 {
+		isGasExempt, err := conversionutil.IsGasExemptTxn(tx, signer)
+		if err != nil || isGasExempt == false {
+			return newTransactionError(TRANSACTION_ERROR_INSUFFICIENT_FUNDS,
+				fmt.Errorf("%v: address %v have %v want %v", core.ErrInsufficientFunds, from.Hex(), balance.ToInt(), tx.Cost()))
+		}
+	}
+
+	return nil
+}
+
+// getChainID returns the chain id of the node, which is read once.
+func (s *WriteApiAPIService) getChainID(ctx context.Context) (*big.Int, error) {
+	s.chainIDLock.Lock()
+	defer s.chainIDLock.Unlock()
+
+	if s.chainID != nil {
+		return s.chainID, nil
+	}
+	var chainID hexutil.Big
+	if err := s.nodePool.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	s.chainID = chainID.ToInt()
+	return s.chainID, nil
+}
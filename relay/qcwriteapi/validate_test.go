@@ -0,0 +1,236 @@
+package qcwriteapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/QuantumCoinProject/qc/common"
+	"github.com/QuantumCoinProject/qc/common/hexutil"
+	"github.com/QuantumCoinProject/qc/core/types"
+	"github.com/QuantumCoinProject/qc/relay"
+	"github.com/QuantumCoinProject/qc/rpc"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+var testSenderAddress = common.BytesToAddress([]byte{0x01})
+
+// testSigner returns the S value of the signature of a transaction as its sender, testSenderAddress by default.
+type testSigner struct {
+	chainID *big.Int
+}
+
+func (signer *testSigner) Sender(tx *types.Transaction) (common.Address, error) {
+	_, r, s := tx.RawSignatureValues()
+	if r == nil || r.Sign() == 0 {
+		return common.Address{}, types.ErrInvalidSig
+	}
+	return common.BytesToAddress(s.Bytes()), nil
+}
+
+func (signer *testSigner) SignatureValues(tx *types.Transaction, sig []byte) (r, s, v *big.Int, err error) {
+	return nil, nil, nil, errors.New("not supported")
+}
+
+func (signer *testSigner) ChainID() *big.Int {
+	return signer.chainID
+}
+
+func (signer *testSigner) Hash(tx *types.Transaction) (common.Hash, error) {
+	return tx.Hash(), nil
+}
+
+func (signer *testSigner) Equal(other types.Signer) bool {
+	s, ok := other.(*testSigner)
+	return ok && s.chainID.Cmp(signer.chainID) == 0
+}
+
+type testNodeService struct {
+	blockNumber  uint64 //100 if not set
+	nonce        uint64
+	pendingNonce uint64
+	balance      *big.Int
+	sendErr      error
+	sendNull     bool
+	lagReceipts  int //number of receipt calls answered with no receipt, as by a node that is behind
+	lock         sync.Mutex
+	sent         []string
+	receipts     map[common.Hash]map[string]interface{}
+	pool         map[common.Hash]bool
+}
+
+func (s *testNodeService) BlockNumber() hexutil.Uint64 {
+	if s.blockNumber == 0 {
+		return 100
+	}
+	return hexutil.Uint64(s.blockNumber)
+}
+
+func (s *testNodeService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(types.DEFAULT_CHAIN_ID))
+}
+
+func (s *testNodeService) GetTransactionCount(address common.Address, blockNumber string) hexutil.Uint64 {
+	if blockNumber == "pending" {
+		return hexutil.Uint64(s.pendingNonce)
+	}
+	return hexutil.Uint64(s.nonce)
+}
+
+func (s *testNodeService) GetBalance(address common.Address, blockNumber string) *hexutil.Big {
+	return (*hexutil.Big)(s.balance)
+}
+
+func (s *testNodeService) GetTransactionReceipt(hash common.Hash) map[string]interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.lagReceipts > 0 {
+		s.lagReceipts--
+		return nil
+	}
+	return s.receipts[hash]
+}
+
+func (s *testNodeService) GetTransactionByHash(hash common.Hash) map[string]interface{} {
+	if s.pool[hash] {
+		return map[string]interface{}{"hash": hash}
+	}
+	return nil
+}
+
+func (s *testNodeService) SendRawTransaction(rawTxHex string) (*common.Hash, error) {
+	s.lock.Lock()
+	s.sent = append(s.sent, rawTxHex)
+	sendNull := s.sendNull
+	s.lock.Unlock()
+	if s.sendErr != nil || sendNull {
+		return nil, s.sendErr
+	}
+	txHash := common.BytesToHash([]byte{0x01})
+	return &txHash, nil
+}
+
+func newTestWriteServer(t *testing.T, node *testNodeService) *httptest.Server {
+	newSigner = func(chainID *big.Int) types.Signer { return &testSigner{chainID: chainID} }
+	t.Cleanup(func() { newSigner = types.NewLondonSigner })
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	nodeServer := httptest.NewServer(server)
+	t.Cleanup(nodeServer.Close)
+
+	nodePool, err := relay.NewNodePool([]string{nodeServer.URL})
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	t.Cleanup(nodePool.Close)
+	apiKeyManager, err := relay.NewApiKeyManager(false, "", nil, "")
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	controller := NewWriteApiAPIController(NewWriteApiAPIService(nodePool, nil), "*", apiKeyManager)
+	writeServer := httptest.NewServer(NewRouter(controller))
+	t.Cleanup(writeServer.Close)
+	return writeServer
+}
+
+func newTestRawTransaction(t *testing.T, modify func(tx *types.DefaultFeeTx)) string {
+	to := common.BytesToAddress([]byte{0x70})
+	inner := &types.DefaultFeeTx{
+		ChainID:    big.NewInt(types.DEFAULT_CHAIN_ID),
+		Nonce:      5,
+		Gas:        21000,
+		MaxGasTier: types.GAS_TIER_DEFAULT,
+		To:         &to,
+		Value:      big.NewInt(1000),
+		V:          big.NewInt(28),
+		R:          big.NewInt(1),
+		S:          big.NewInt(1),
+	}
+	if modify != nil {
+		modify(inner)
+	}
+	data, err := types.NewTx(inner).MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	return hexutil.Encode(data)
+}
+
+func sendTestTransaction(t *testing.T, server *httptest.Server, rawTxHex string) (int, *ErrorResponseModel) {
+	body, _ := json.Marshal(SendTransactionRequest{TxnData: rawTxHex})
+	response, err := http.Post(server.URL+"/transactions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusOK {
+		return response.StatusCode, nil
+	}
+	var errorResponse ErrorResponseModel
+	json.NewDecoder(response.Body).Decode(&errorResponse)
+	return response.StatusCode, &errorResponse
+}
+
+func TestSendTransaction_validation(t *testing.T) {
+	node := &testNodeService{nonce: 5, pendingNonce: 6, balance: new(big.Int).Mul(big.NewInt(21000), big.NewInt(types.DEFAULT_PRICE))}
+	server := newTestWriteServer(t, node)
+
+	tests := []struct {
+		name     string
+		rawTxHex string
+		code     string
+	}{
+		{"encoding", "0xzz", TRANSACTION_ERROR_INVALID_ENCODING},
+		{"type", "0x02c0", TRANSACTION_ERROR_UNSUPPORTED_TYPE},
+		{"chainId", newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.ChainID = big.NewInt(1) }), TRANSACTION_ERROR_INVALID_CHAIN_ID},
+		{"remarks", newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.Remarks = make([]byte, types.MAX_REMARKS_LENGTH+1) }), TRANSACTION_ERROR_REMARKS_TOO_LONG},
+		{"gasTier", newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.MaxGasTier = 3 }), TRANSACTION_ERROR_INVALID_GAS_TIER},
+		{"intrinsicGas", newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.Gas = 20000 }), TRANSACTION_ERROR_INTRINSIC_GAS_TOO_LOW},
+		{"signature", newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.R = big.NewInt(0) }), TRANSACTION_ERROR_INVALID_SIGNATURE},
+		{"nonceTooLow", newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.Nonce = 4 }), TRANSACTION_ERROR_NONCE_TOO_LOW},
+		{"nonceGap", newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.Nonce = 6 + MaxNonceGap + 1 }), TRANSACTION_ERROR_NONCE_TOO_HIGH},
+		{"funds", newTestRawTransaction(t, nil), TRANSACTION_ERROR_INSUFFICIENT_FUNDS},
+	}
+	for _, test := range tests {
+		code, errorResponse := sendTestTransaction(t, server, test.rawTxHex)
+		if code != http.StatusBadRequest || errorResponse.Code == nil || *errorResponse.Code != test.code {
+			t.Fatalf("failed %s %d %v", test.name, code, errorResponse)
+		}
+		if errorResponse.Status != http.StatusBadRequest || errorResponse.Message == nil {
+			t.Fatalf("failed %s %v", test.name, errorResponse)
+		}
+	}
+
+	code, _ := sendTestTransaction(t, server, newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.Value = big.NewInt(0) }))
+	if code != http.StatusOK {
+		t.Fatalf("failed %d", code)
+	}
+
+	//Errors of the node are returned with their code
+	node.sendErr = errors.New("already known")
+	code, errorResponse := sendTestTransaction(t, server, newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.Value = big.NewInt(0) }))
+	if code != http.StatusBadRequest || *errorResponse.Code != TRANSACTION_ERROR_ALREADY_KNOWN {
+		t.Fatalf("failed %d %v", code, errorResponse)
+	}
+	node.sendErr = errors.New("some other error")
+	code, errorResponse = sendTestTransaction(t, server, newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.Value = big.NewInt(0) }))
+	if code != http.StatusBadRequest || *errorResponse.Code != TRANSACTION_ERROR_REJECTED {
+		t.Fatalf("failed %d %v", code, errorResponse)
+	}
+
+	//A node returning no hash rejects the transaction
+	node.sendErr = nil
+	node.lock.Lock()
+	node.sendNull = true
+	node.lock.Unlock()
+	code, errorResponse = sendTestTransaction(t, server, newTestRawTransaction(t, func(tx *types.DefaultFeeTx) { tx.Value = big.NewInt(0) }))
+	if code != http.StatusBadRequest || *errorResponse.Code != TRANSACTION_ERROR_REJECTED {
+		t.Fatalf("failed %d %v", code, errorResponse)
+	}
+}